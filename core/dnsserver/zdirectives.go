@@ -32,9 +32,12 @@ var Directives = []string{
 	"local",
 	"dns64",
 	"acl",
+	"ecs",
 	"any",
 	"chaos",
 	"loadbalance",
+	"geosteer",
+	"gslb",
 	"tsig",
 	"cache",
 	"rewrite",
@@ -49,12 +52,15 @@ var Directives = []string{
 	"azure",
 	"clouddns",
 	"k8s_external",
+	"k8s_records",
 	"kubernetes",
 	"file",
 	"auto",
 	"secondary",
 	"etcd",
+	"consul",
 	"loop",
+	"validate",
 	"forward",
 	"grpc",
 	"erratic",
@@ -0,0 +1,40 @@
+package coremain
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file"
+)
+
+// checkZone parses the zone in filename for origin as the file plugin would and reports any problems it finds to w.
+// It returns the exit code: 0 if the zone is valid, 1 otherwise. Warnings don't fail the check.
+func checkZone(w io.Writer, filename, origin string, now time.Time) int {
+	f, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		fmt.Fprintf(w, "%s: %s\n", filename, err)
+		return 1
+	}
+	defer f.Close()
+
+	z, err := file.Parse(f, origin, filename, -1)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %s\n", filename, err)
+		return 1
+	}
+
+	code := 0
+	for _, p := range z.Check(now) {
+		fmt.Fprintf(w, "%s: %s\n", filename, p)
+		if !p.Warning {
+			code = 1
+		}
+	}
+	if code == 0 {
+		fmt.Fprintf(w, "%s: zone %s with SOA serial %d is OK\n", filename, z.Apex.SOA.Header().Name, z.Apex.SOA.Serial)
+	}
+	return code
+}
@@ -0,0 +1,53 @@
+package coremain
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckZone(t *testing.T) {
+	tests := []struct {
+		zone   string
+		code   int
+		expect string
+	}{
+		{
+			zone: `$ORIGIN example.org.
+@ 3600 IN SOA ns.example.org. hostmaster.example.org. 1 7200 3600 1209600 3600
+@ 3600 IN NS ns
+ns 3600 IN A 127.0.0.1
+`,
+			code:   0,
+			expect: "zone example.org. with SOA serial 1 is OK",
+		},
+		{
+			zone: `$ORIGIN example.org.
+@ 3600 IN SOA ns.example.org. hostmaster.example.org. 1 7200 3600 1209600 3600
+@ 3600 IN NS ns
+ns 3600 IN A 127.0.0.1
+www IN A not-an-ip
+`,
+			code:   1,
+			expect: "bad A A",
+		},
+	}
+
+	for i, tc := range tests {
+		name := filepath.Join(t.TempDir(), "db.example.org")
+		if err := os.WriteFile(name, []byte(tc.zone), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		if code := checkZone(&out, name, "example.org.", time.Now()); code != tc.code {
+			t.Errorf("Test %d: expected exit code %d, got %d: %s", i, tc.code, code, out.String())
+		}
+		if !strings.Contains(out.String(), tc.expect) {
+			t.Errorf("Test %d: expected output to contain %q, got %q", i, tc.expect, out.String())
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
@@ -23,6 +24,7 @@ func init() {
 	flag.BoolVar(&plugins, "plugins", false, "List installed plugins")
 	flag.StringVar(&caddy.PidFile, "pidfile", "", "Path to write pid file")
 	flag.BoolVar(&version, "version", false, "Show version")
+	flag.BoolVar(&check, "check-zone", false, "Check the zone FILE for ORIGIN given as arguments and quit")
 	flag.BoolVar(&dnsserver.Quiet, "quiet", false, "Quiet mode (no initialization output)")
 
 	caddy.RegisterCaddyfileLoader("flag", caddy.LoaderFunc(confLoader))
@@ -40,6 +42,13 @@ func Run() {
 	caddy.TrapSignals()
 	flag.Parse()
 
+	if check {
+		if len(flag.Args()) != 2 {
+			mustLogFatal(fmt.Errorf("-check-zone needs a zone file and an origin as arguments, got: %s", flag.Args()))
+		}
+		os.Exit(checkZone(os.Stdout, flag.Arg(0), flag.Arg(1), time.Now().UTC()))
+	}
+
 	if len(flag.Args()) > 0 {
 		mustLogFatal(fmt.Errorf("extra command line arguments: %s", flag.Args()))
 	}
@@ -169,6 +178,7 @@ var (
 	conf    string
 	version bool
 	plugins bool
+	check   bool
 
 	// LogFlags are initially set to 0 for no extra output
 	LogFlags int
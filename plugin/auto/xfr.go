@@ -18,6 +18,22 @@ func (a Auto) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
 	return z.Transfer(serial)
 }
 
+// SOA implements the transfer.SOAer interface.
+func (a Auto) SOA(zone string) (*dns.SOA, error) {
+	a.Zones.RLock()
+	z, ok := a.Zones.Z[zone]
+	a.Zones.RUnlock()
+
+	if !ok || z == nil {
+		return nil, transfer.ErrNotAuthoritative
+	}
+	apex, err := z.ApexIfDefined()
+	if err != nil {
+		return nil, err
+	}
+	return apex[0].(*dns.SOA), nil
+}
+
 // Notify sends notifies for all zones with secondaries configured with the transfer plugin
 func (a Auto) Notify() error {
 	var err error
@@ -33,9 +33,12 @@ package autopath
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 	"github.com/coredns/coredns/plugin/pkg/nonwriter"
 	"github.com/coredns/coredns/request"
@@ -62,6 +65,8 @@ type AutoPath struct {
 	// Search always includes "" as the last element, so we try the base query with out any search paths added as well.
 	search     []string
 	searchFunc Func
+	clients    *clientsFile // Search paths per client network, may be nil.
+	learner    *learner     // Learns search paths of clients, may be nil.
 }
 
 // ServeDNS implements the plugin.Handle interface.
@@ -73,14 +78,17 @@ func (a *AutoPath) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		return plugin.NextOrFailure(a.Name(), a.Next, ctx, w, r)
 	}
 
-	// Check if autopath should be done, searchFunc takes precedence over the local configured search path.
-	var err error
-	searchpath := a.search
-
-	if a.searchFunc != nil {
-		searchpath = a.searchFunc(state)
+	if a.learner != nil {
+		rec := dnstest.NewRecorder(w)
+		w = rec
+		defer func() {
+			a.learner.observe(state.IP(), state.Name(), state.QType(), rec.Rcode, time.Now())
+		}()
 	}
 
+	var err error
+	searchpath := a.searchPath(state)
+
 	if len(searchpath) == 0 {
 		return plugin.NextOrFailure(a.Name(), a.Next, ctx, w, r)
 	}
@@ -145,6 +153,27 @@ func (a *AutoPath) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 // Name implements the Handler interface.
 func (a *AutoPath) Name() string { return "autopath" }
 
+// searchPath returns the search path of the client of state. The search path of the client's network takes
+// precedence over searchFunc, which takes precedence over a learned search path and the local configured one.
+func (a *AutoPath) searchPath(state request.Request) []string {
+	if a.clients != nil {
+		if search := a.clients.lookup(net.ParseIP(state.IP())); search != nil {
+			return search
+		}
+	}
+	if a.searchFunc != nil {
+		if search := a.searchFunc(state); len(search) > 0 || a.learner == nil {
+			return search
+		}
+	}
+	if a.learner != nil {
+		if search := a.learner.lookup(state.IP(), time.Now()); search != nil {
+			return search
+		}
+	}
+	return a.search
+}
+
 // firstInSearchPath checks if name is equal to are a sibling of the first element in the search path.
 func firstInSearchPath(name string, searchpath []string) bool {
 	if name == searchpath[0] {
@@ -2,7 +2,9 @@ package autopath
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
@@ -164,3 +166,49 @@ func TestInSearchPath(t *testing.T) {
 		}
 	}
 }
+
+func TestAutoPathClients(t *testing.T) {
+	clients, err := parseClients(strings.NewReader("10.240.0.0/24 site1.example.org example.org\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ap := new(AutoPath)
+	ap.Zones = []string{"."}
+	ap.clients = &clientsFile{clients: clients}
+	ap.learner = newLearner(time.Hour)
+	ap.Next = nextHandler(map[string]int{
+		"web.site1.example.org.": dns.RcodeNameError,
+		"web.example.org.":       dns.RcodeSuccess,
+		"db.site2.example.org.":  dns.RcodeNameError,
+		"db.example.org.":        dns.RcodeSuccess,
+	})
+
+	// The client's network has a search path.
+	m := new(dns.Msg)
+	m.SetQuestion("web.site1.example.org.", dns.TypeA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: "10.240.0.1"})
+	if _, err := ap.ServeDNS(context.TODO(), rec, m); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rec.Msg.Answer) != 2 || rec.Msg.Answer[0].Header().Rrtype != dns.TypeCNAME {
+		t.Errorf("Expected a CNAME and an A record, got %v", rec.Msg.Answer)
+	}
+
+	// Other clients walk the search path themselves, until it's learned.
+	for i := 0; i <= confirmations; i++ {
+		for _, name := range []string{"db.site2.example.org.", "db.example.org."} {
+			m := new(dns.Msg)
+			m.SetQuestion(name, dns.TypeA)
+			rec = dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: "10.0.0.1"})
+			if _, err := ap.ServeDNS(context.TODO(), rec, m); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if i == confirmations {
+				break
+			}
+		}
+	}
+	if len(rec.Msg.Answer) != 2 || rec.Msg.Answer[1].Header().Name != "db.example.org." {
+		t.Errorf("Expected the learned search path to be used, got %v", rec.Msg.Answer)
+	}
+}
@@ -0,0 +1,113 @@
+package autopath
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+)
+
+// clientSearch is the search path of the clients in network.
+type clientSearch struct {
+	network *net.IPNet
+	search  []string
+}
+
+// clientsFile holds the search paths per client network that are read from a file. Each line of the file holds a
+// network followed by the search path of its clients, i.e. `10.1.0.0/16 site1.example.org example.org`.
+type clientsFile struct {
+	path string
+
+	sync.RWMutex
+	clients []clientSearch // Ordered from the most to the least specific network.
+	mtime   time.Time
+	size    int64
+}
+
+// lookup returns the search path of the most specific network ip is in, or nil.
+func (c *clientsFile) lookup(ip net.IP) []string {
+	c.RLock()
+	defer c.RUnlock()
+	for _, cs := range c.clients {
+		if cs.network.Contains(ip) {
+			return cs.search
+		}
+	}
+	return nil
+}
+
+// readFile reads the file if it changed since it was last read.
+func (c *clientsFile) readFile() error {
+	file, err := os.Open(c.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	c.RLock()
+	unchanged := c.mtime.Equal(stat.ModTime()) && c.size == stat.Size()
+	c.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	clients, err := parseClients(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse %q: %v", c.path, err)
+	}
+	c.Lock()
+	c.clients = clients
+	c.mtime = stat.ModTime()
+	c.size = stat.Size()
+	c.Unlock()
+	return nil
+}
+
+// parseClients parses the lines of r, empty lines and comments starting with a '#' are skipped.
+func parseClients(r io.Reader) ([]clientSearch, error) {
+	var clients []clientSearch
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := scanner.Text()
+		if j := strings.IndexByte(line, '#'); j >= 0 {
+			line = line[:j]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: no search path for %s", i, fields[0])
+		}
+		_, network, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+		search := make([]string, 0, len(fields))
+		for _, s := range fields[1:] {
+			search = append(search, plugin.Name(s).Normalize())
+		}
+		clients = append(clients, clientSearch{network: network, search: append(search, "")}) // sentinel value as demanded.
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(clients, func(i, j int) bool {
+		a, _ := clients[i].network.Mask.Size()
+		b, _ := clients[j].network.Mask.Size()
+		return a > b
+	})
+	return clients, nil
+}
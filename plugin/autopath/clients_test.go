@@ -0,0 +1,71 @@
+package autopath
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const clientsExample = `# site networks
+10.0.0.0/8        example.org
+10.1.0.0/16       site1.example.org example.org
+2001:db8:1::/48   site1.example.org example.org
+`
+
+func TestParseClients(t *testing.T) {
+	clients, err := parseClients(strings.NewReader(clientsExample))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	c := &clientsFile{clients: clients}
+
+	tests := []struct {
+		client   string
+		expected []string
+	}{
+		{"10.1.2.3", []string{"site1.example.org.", "example.org.", ""}},
+		{"10.2.2.3", []string{"example.org.", ""}},
+		{"2001:db8:1::1", []string{"site1.example.org.", "example.org.", ""}},
+		{"192.0.2.1", nil},
+	}
+	for i, tc := range tests {
+		if search := c.lookup(net.ParseIP(tc.client)); !reflect.DeepEqual(search, tc.expected) {
+			t.Errorf("Test %d: expected %v for %s, got %v", i, tc.expected, tc.client, search)
+		}
+	}
+
+	for _, input := range []string{"10.0.0.0/8", "10.0.0.0/33 example.org", "example.org example.org"} {
+		if _, err := parseClients(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for %q, got none", input)
+		}
+	}
+}
+
+func TestClientsFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clients")
+	if err := os.WriteFile(path, []byte("10.0.0.0/8 example.org\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := &clientsFile{path: path}
+	if err := c.readFile(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("10.0.0.0/8 example.net\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the modification time changes, even on file systems with a coarse resolution.
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.readFile(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if search := c.lookup(net.ParseIP("10.0.0.1")); len(search) == 0 || search[0] != "example.net." {
+		t.Errorf("Expected the reloaded search path, got %v", search)
+	}
+}
@@ -0,0 +1,175 @@
+package autopath
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/cache"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// walkWindow is the maximum time between two queries of a client walking its search path.
+	walkWindow = 2 * time.Second
+	// confirmations is the number of walks that must show the same search path before it is used.
+	confirmations = 3
+	// learnSize is the number of clients search paths are learned for.
+	learnSize = 10000
+)
+
+// learner learns the search paths of clients from the queries they send when they walk their search path: a
+// client that gets NXDOMAIN for web.site1.example.org. and then asks for web.example.org. likely has the search
+// path site1.example.org. example.org.
+type learner struct {
+	ttl     time.Duration // How long a learned search path is used.
+	walks   *cache.Cache  // The search path walks in progress per client and query type.
+	learned *cache.Cache  // The search paths per client.
+}
+
+// walk is a search path walk of a client, base is the name the client searches for. A walk is never changed
+// once it's added to the cache.
+type walk struct {
+	name     string
+	base     string
+	suffixes []string
+	seen     time.Time
+}
+
+// learnedSearch is a learned search path, it's only used after it has been confirmed.
+type learnedSearch struct {
+	search    []string
+	confirmed int
+	expires   time.Time
+}
+
+func newLearner(ttl time.Duration) *learner {
+	return &learner{ttl: ttl, walks: cache.New(learnSize), learned: cache.New(learnSize)}
+}
+
+// lookup returns the learned search path of client, or nil.
+func (l *learner) lookup(client string, now time.Time) []string {
+	v, ok := l.learned.Get(hashClient(client))
+	if !ok {
+		return nil
+	}
+	ls := v.(*learnedSearch)
+	if ls.confirmed < confirmations || now.After(ls.expires) {
+		return nil
+	}
+	return ls.search
+}
+
+// observe records the query for qname of client and the rcode of its reply.
+func (l *learner) observe(client, qname string, qtype uint16, rcode int, now time.Time) {
+	key := cache.Hash([]byte(client + "/" + strconv.Itoa(int(qtype))))
+
+	w := &walk{name: qname, seen: now}
+	if v, ok := l.walks.Get(key); ok {
+		if prev := v.(*walk); now.Sub(prev.seen) <= walkWindow && prev.name != qname {
+			w = prev.next(qname, now)
+		}
+	}
+
+	// The walk ends with the first reply that isn't NXDOMAIN, or when the base itself is asked for.
+	if rcode == dns.RcodeNameError && (len(w.suffixes) == 0 || w.suffixes[len(w.suffixes)-1] != "") {
+		l.walks.Add(key, w)
+		return
+	}
+	l.walks.Remove(key)
+	if len(w.suffixes) >= 2 {
+		l.learn(client, w.suffixes, now)
+	}
+}
+
+// learn records search as the search path of client. The search path is confirmed if an earlier walk showed the
+// same search path; walks that stop early only show its first elements. A learned search path always ends with
+// the bare name "", like the search path from resolv.conf, also when the walk stopped before it.
+func (l *learner) learn(client string, search []string, now time.Time) {
+	key := hashClient(client)
+	ls := &learnedSearch{search: withBare(search), confirmed: 1, expires: now.Add(l.ttl)}
+	if v, ok := l.learned.Get(key); ok {
+		prev := v.(*learnedSearch)
+		switch {
+		case isPrefix(search, prev.search):
+			ls.search = prev.search
+			ls.confirmed = prev.confirmed + 1
+		case isPrefix(prev.search[:len(prev.search)-1], search):
+			ls.confirmed = prev.confirmed + 1
+		}
+	}
+	l.learned.Add(key, ls)
+}
+
+// withBare returns search with "" appended, unless it already ends with it.
+func withBare(search []string) []string {
+	if search[len(search)-1] == "" {
+		return search
+	}
+	return append(search[:len(search):len(search)], "")
+}
+
+func hashClient(client string) uint64 { return cache.Hash([]byte(client)) }
+
+// next returns the walk after the client asked for qname, or a new walk if qname doesn't continue w.
+func (w *walk) next(qname string, now time.Time) *walk {
+	n := &walk{name: qname, seen: now}
+	if w.base == "" {
+		base := commonBase(w.name, qname)
+		if base == "" {
+			return n
+		}
+		n.base = base
+		n.suffixes = []string{suffix(w.name, base), suffix(qname, base)}
+		return n
+	}
+
+	if !strings.HasPrefix(qname, w.base) {
+		return n
+	}
+	s := suffix(qname, w.base)
+	for _, x := range w.suffixes {
+		if x == s {
+			return n
+		}
+	}
+	n.base = w.base
+	n.suffixes = append(append(make([]string, 0, len(w.suffixes)+1), w.suffixes...), s)
+	return n
+}
+
+// commonBase returns the leading labels a and b have in common, as a name with a trailing dot. The result is
+// empty if a has no labels left after the base, as a then isn't a name with a search path element.
+func commonBase(a, b string) string {
+	la, lb := dns.SplitDomainName(a), dns.SplitDomainName(b)
+	i := 0
+	for i < len(la) && i < len(lb) && la[i] == lb[i] {
+		i++
+	}
+	if i == 0 || i == len(la) {
+		return ""
+	}
+	return strings.Join(la[:i], ".") + "."
+}
+
+// suffix returns the search path element of name, which starts with base. The element of base itself is "".
+func suffix(name, base string) string {
+	if len(name) <= len(base) {
+		return ""
+	}
+	return name[len(base):]
+}
+
+// isPrefix returns true if a is a prefix of b.
+func isPrefix(a, b []string) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package autopath
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestLearner(t *testing.T) {
+	l := newLearner(time.Hour)
+	now := time.Now()
+
+	// walkSearch replays a client walking its search path for name, all but the last query get NXDOMAIN.
+	walkSearch := func(client string, names ...string) {
+		for i, name := range names {
+			rcode := dns.RcodeNameError
+			if i == len(names)-1 {
+				rcode = dns.RcodeSuccess
+			}
+			l.observe(client, name, dns.TypeA, rcode, now)
+			now = now.Add(10 * time.Millisecond)
+		}
+	}
+
+	for i := 0; i < confirmations; i++ {
+		if search := l.lookup("10.0.0.1", now); search != nil {
+			t.Fatalf("Expected no search path before %d confirmations, got %v", confirmations, search)
+		}
+		walkSearch("10.0.0.1", "web.site1.example.org.", "web.example.org.")
+	}
+	// The walks ended before the bare name, it's still the last element of the search path.
+	expected := []string{"site1.example.org.", "example.org.", ""}
+	if search := l.lookup("10.0.0.1", now); !reflect.DeepEqual(search, expected) {
+		t.Fatalf("Expected search path %v, got %v", expected, search)
+	}
+
+	// A walk that reaches the bare name confirms it.
+	walkSearch("10.0.0.1", "db.site1.example.org.", "db.example.org.", "db.")
+	if search := l.lookup("10.0.0.1", now); !reflect.DeepEqual(search, expected) {
+		t.Errorf("Expected search path %v, got %v", expected, search)
+	}
+
+	// A longer walk extends the learned search path.
+	walkSearch("10.0.0.1", "mail.site1.example.org.", "mail.example.org.", "mail.example.net.")
+	expected = []string{"site1.example.org.", "example.org.", "example.net.", ""}
+	if search := l.lookup("10.0.0.1", now); !reflect.DeepEqual(search, expected) {
+		t.Errorf("Expected search path %v, got %v", expected, search)
+	}
+	if search := l.lookup("10.0.0.2", now); search != nil {
+		t.Errorf("Expected no search path for another client, got %v", search)
+	}
+	if search := l.lookup("10.0.0.1", now.Add(2*time.Hour)); search != nil {
+		t.Errorf("Expected an expired search path, got %v", search)
+	}
+
+	// Queries that are too far apart aren't a walk.
+	l.observe("10.0.0.3", "web.site1.example.org.", dns.TypeA, dns.RcodeNameError, now)
+	l.observe("10.0.0.3", "web.example.org.", dns.TypeA, dns.RcodeSuccess, now.Add(time.Minute))
+	if v, ok := l.learned.Get(hashClient("10.0.0.3")); ok {
+		t.Errorf("Expected nothing learned, got %v", v)
+	}
+}
+
+func TestCommonBase(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"web.site1.example.org.", "web.example.org.", "web."},
+		{"db.prod.site1.example.org.", "db.prod.", "db.prod."},
+		{"web.example.org.", "mail.example.org.", ""},
+		// The first name must have a search path element.
+		{"web.", "web.example.org.", ""},
+	}
+	for i, tc := range tests {
+		if base := commonBase(tc.a, tc.b); base != tc.expected {
+			t.Errorf("Test %d: expected %q, got %q", i, tc.expected, base)
+		}
+	}
+}
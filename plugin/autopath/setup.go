@@ -2,15 +2,28 @@ package autopath
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
 
 	"github.com/miekg/dns"
 )
 
+var log = clog.NewWithPlugin("autopath")
+
+const (
+	// clientsReload is the interval the clients file is checked for changes.
+	clientsReload = 30 * time.Second
+	// defaultLearnTTL is how long a learned search path is used by default.
+	defaultLearnTTL = time.Hour
+)
+
 func init() { plugin.Register("autopath", setup) }
 
 func setup(c *caddy.Controller) error {
@@ -19,6 +32,31 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("autopath", err)
 	}
 
+	if ap.clients != nil {
+		stop := make(chan struct{})
+		c.OnStartup(func() error {
+			go func() {
+				ticker := time.NewTicker(clientsReload)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+						if err := ap.clients.readFile(); err != nil {
+							log.Warningf("Failed to reload clients file: %v", err)
+						}
+					}
+				}
+			}()
+			return nil
+		})
+		c.OnShutdown(func() error {
+			close(stop)
+			return nil
+		})
+	}
+
 	// Do this in OnStartup, so all plugin has been initialized.
 	c.OnStartup(func() error {
 		m := dnsserver.GetConfig(c).Handler(mw)
@@ -47,24 +85,69 @@ func autoPathParse(c *caddy.Controller) (*AutoPath, string, error) {
 
 	for c.Next() {
 		zoneAndresolv := c.RemainingArgs()
-		if len(zoneAndresolv) < 1 {
-			return ap, "", fmt.Errorf("no resolv-conf specified")
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "clients":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return ap, "", c.ArgErr()
+				}
+				path := args[0]
+				if root := dnsserver.GetConfig(c).Root; !filepath.IsAbs(path) && root != "" {
+					path = filepath.Join(root, path)
+				}
+				ap.clients = &clientsFile{path: path}
+				if err := ap.clients.readFile(); err != nil {
+					return ap, "", err
+				}
+			case "learn":
+				args := c.RemainingArgs()
+				if len(args) > 1 {
+					return ap, "", c.ArgErr()
+				}
+				ttl := defaultLearnTTL
+				if len(args) == 1 {
+					d, err := time.ParseDuration(args[0])
+					if err != nil || d <= 0 {
+						return ap, "", c.Errf("invalid duration for learn '%s'", args[0])
+					}
+					ttl = d
+				}
+				ap.learner = newLearner(ttl)
+			default:
+				return ap, "", c.Errf("unknown property '%s'", c.Val())
+			}
 		}
-		resolv := zoneAndresolv[len(zoneAndresolv)-1]
-		if strings.HasPrefix(resolv, "@") {
-			mw = resolv[1:]
-		} else {
-			// assume file on disk
-			rc, err := dns.ClientConfigFromFile(resolv)
-			if err != nil {
-				return ap, "", fmt.Errorf("failed to parse %q: %v", resolv, err)
+
+		// The last argument is the resolv-conf, or a plugin if it starts with @. With clients or learn the
+		// resolv-conf is optional, and the last argument is a zone if there is no such file.
+		if len(zoneAndresolv) >= 1 {
+			resolv := zoneAndresolv[len(zoneAndresolv)-1]
+			_, statErr := os.Stat(resolv)
+			switch {
+			case strings.HasPrefix(resolv, "@"):
+				mw = resolv[1:]
+				zoneAndresolv = zoneAndresolv[:len(zoneAndresolv)-1]
+			case statErr != nil && (ap.clients != nil || ap.learner != nil):
+			default:
+				// assume file on disk
+				rc, err := dns.ClientConfigFromFile(resolv)
+				if err != nil {
+					return ap, "", fmt.Errorf("failed to parse %q: %v", resolv, err)
+				}
+				ap.search = rc.Search
+				plugin.Zones(ap.search).Normalize()
+				ap.search = append(ap.search, "") // sentinel value as demanded.
+				zoneAndresolv = zoneAndresolv[:len(zoneAndresolv)-1]
 			}
-			ap.search = rc.Search
-			plugin.Zones(ap.search).Normalize()
-			ap.search = append(ap.search, "") // sentinel value as demanded.
 		}
-		zones := zoneAndresolv[:len(zoneAndresolv)-1]
-		ap.Zones = plugin.OriginsFromArgsOrServerBlock(zones, c.ServerBlockKeys)
+		ap.Zones = plugin.OriginsFromArgsOrServerBlock(zoneAndresolv, c.ServerBlockKeys)
+
+		// Without a resolv-conf the search paths must come from the clients file or be learned.
+		if mw == "" && ap.search == nil && ap.clients == nil && ap.learner == nil {
+			return ap, "", fmt.Errorf("no resolv-conf specified")
+		}
 	}
 	return ap, mw, nil
 }
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/plugin/test"
@@ -75,3 +76,104 @@ domain foo.com
 search bar.com baz.com
 options ndots:5
 `
+
+func TestSetupAutoPathOptions(t *testing.T) {
+	clients, rm, err := test.TempFile(os.TempDir(), "10.0.0.0/8 example.org\n")
+	if err != nil {
+		t.Fatalf("Could not create clients test file: %s", err)
+	}
+	defer rm()
+
+	tests := []struct {
+		input       string
+		shouldErr   bool
+		withClients bool
+		learnTTL    time.Duration
+	}{
+		{`autopath {
+			clients ` + clients + `
+		}`, false, true, 0},
+		{`autopath @kubernetes {
+			learn
+		}`, false, false, defaultLearnTTL},
+		{`autopath {
+			clients ` + clients + `
+			learn 10m
+		}`, false, true, 10 * time.Minute},
+		{`autopath {
+			learn 0s
+		}`, true, false, 0},
+		{`autopath {
+			clients
+		}`, true, false, 0},
+		{`autopath {
+			clients /highly/unlikely/to/exist
+		}`, true, false, 0},
+		{`autopath {
+			foo
+		}`, true, false, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		ap, _, err := autoPathParse(c)
+		if (err != nil) != test.shouldErr {
+			t.Fatalf("Test %d: expected error %v, got %v for input %s", i, test.shouldErr, err, test.input)
+		}
+		if err != nil {
+			continue
+		}
+		if (ap.clients != nil) != test.withClients {
+			t.Errorf("Test %d: expected clients %v, got %v", i, test.withClients, ap.clients != nil)
+		}
+		if ap.learner == nil && test.learnTTL != 0 || ap.learner != nil && ap.learner.ttl != test.learnTTL {
+			t.Errorf("Test %d: expected learning for %s", i, test.learnTTL)
+		}
+	}
+}
+
+func TestSetupAutoPathZonesWithoutResolvConf(t *testing.T) {
+	clients, rm, err := test.TempFile(os.TempDir(), "10.0.0.0/8 example.org\n")
+	if err != nil {
+		t.Fatalf("Could not create clients test file: %s", err)
+	}
+	defer rm()
+	resolv, rm, err := test.TempFile(os.TempDir(), resolvConf)
+	if err != nil {
+		t.Fatalf("Could not create resolv.conf test file %s: %s", resolvConf, err)
+	}
+	defer rm()
+
+	tests := []struct {
+		input          string
+		expectedZones  []string
+		expectedSearch []string
+	}{
+		{`autopath example.org {
+			learn
+		}`, []string{"example.org."}, nil},
+		{`autopath example.org example.net {
+			clients ` + clients + `
+		}`, []string{"example.org.", "example.net."}, nil},
+		{`autopath example.org ` + resolv + ` {
+			learn
+		}`, []string{"example.org."}, []string{"bar.com.", "baz.com.", ""}},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		ap, mw, err := autoPathParse(c)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %v for input %s", i, err, tc.input)
+		}
+		if mw != "" {
+			t.Errorf("Test %d: expected no plugin, got %s", i, mw)
+		}
+		if !reflect.DeepEqual(ap.Zones, tc.expectedZones) {
+			t.Errorf("Test %d: expected zones %v, got %v", i, tc.expectedZones, ap.Zones)
+		}
+		if !reflect.DeepEqual(ap.search, tc.expectedSearch) {
+			t.Errorf("Test %d: expected search path %v, got %v", i, tc.expectedSearch, ap.search)
+		}
+	}
+}
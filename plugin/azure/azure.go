@@ -10,6 +10,7 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/throttle"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/request"
 
@@ -23,6 +24,9 @@ type zone struct {
 	z       *file.Zone
 	zone    string
 	private bool
+
+	version string    // the version of the zone when it was last listed
+	synced  time.Time // the time the zone was last listed
 }
 
 type zones map[string][]*zone
@@ -35,6 +39,14 @@ type Azure struct {
 	upstream      *upstream.Upstream
 	zMu           sync.RWMutex
 	zones         zones
+	recordSets    recordSetWriter
+	zoneVersions  zoneVersioner
+	// fullRefresh is the longest time between two listings of a zone. If it's longer than refresh, a zone is only
+	// listed when its version changed.
+	fullRefresh time.Duration
+
+	updates bool         // accept dynamic updates
+	updater file.Updater // its TSIG secrets are set on startup
 
 	Next plugin.Handler
 	Fall fall.F
@@ -75,6 +87,8 @@ func New(ctx context.Context, publicClient publicdns.RecordSetsClient, privateCl
 		zones:         zones,
 		zoneNames:     names,
 		upstream:      upstream.New(),
+		recordSets:    azureRecordSets{public: publicClient, private: privateClient},
+		fullRefresh:   refresh,
 	}, nil
 }
 
@@ -84,7 +98,7 @@ func (h *Azure) Run(ctx context.Context) error {
 		return err
 	}
 	go func() {
-		delay := 1 * time.Minute
+		delay := refresh
 		timer := time.NewTimer(delay)
 		defer timer.Stop()
 		for {
@@ -94,7 +108,9 @@ func (h *Azure) Run(ctx context.Context) error {
 				log.Debugf("Breaking out of Azure update loop for %v: %v", h.zoneNames, ctx.Err())
 				return
 			case <-timer.C:
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil {
+				err := h.updateZones(ctx)
+				delay = throttle.Backoff(delay, refresh, err)
+				if err != nil && ctx.Err() == nil {
 					log.Errorf("Failed to update zones %v: %v", h.zoneNames, err)
 				}
 			}
@@ -104,38 +120,78 @@ func (h *Azure) Run(ctx context.Context) error {
 }
 
 func (h *Azure) updateZones(ctx context.Context) error {
-	var err error
-	var publicSet publicdns.RecordSetListResultPage
-	var privateSet privatedns.RecordSetListResultPage
 	errs := make([]string, 0)
+	throttled := false
 	for zName, z := range h.zones {
 		for i, hostedZone := range z {
-			newZ := file.NewZone(zName, "")
-			if hostedZone.private {
-				for privateSet, err = h.privateClient.List(ctx, hostedZone.id, hostedZone.zone, nil, ""); privateSet.NotDone(); err = privateSet.NextWithContext(ctx) {
-					updateZoneFromPrivateResourceSet(privateSet, newZ)
-				}
-			} else {
-				for publicSet, err = h.publicClient.ListByDNSZone(ctx, hostedZone.id, hostedZone.zone, nil, ""); publicSet.NotDone(); err = publicSet.NextWithContext(ctx) {
-					updateZoneFromPublicResourceSet(publicSet, newZ)
-				}
-			}
+			newZ, err := h.syncZone(ctx, zName, hostedZone)
 			if err != nil {
+				zoneSyncErrors.WithLabelValues(zName, hostedZone.id+":"+hostedZone.zone).Inc()
+				throttled = throttled || isThrottled(err)
 				errs = append(errs, fmt.Sprintf("failed to list resource records for %v from azure: %v", hostedZone.zone, err))
+				continue
+			}
+			zoneLastSync.WithLabelValues(zName, hostedZone.id+":"+hostedZone.zone).SetToCurrentTime()
+			if newZ == nil {
+				continue
 			}
-			newZ.Upstream = h.upstream
 			h.zMu.Lock()
 			(*z[i]).z = newZ
 			h.zMu.Unlock()
 		}
 	}
 
+	if throttled {
+		return fmt.Errorf("errors updating zones (%w): %v", throttle.ErrThrottled, errs)
+	}
 	if len(errs) != 0 {
 		return fmt.Errorf("errors updating zones: %v", errs)
 	}
 	return nil
 }
 
+// syncZone lists the record sets of hostedZone and returns them as a new zone. It returns nil if the version of
+// hostedZone didn't change since it was last listed, and it isn't due for a full refresh.
+func (h *Azure) syncZone(ctx context.Context, zName string, hostedZone *zone) (*file.Zone, error) {
+	version, ok, err := h.upToDate(ctx, hostedZone)
+	if err != nil || ok {
+		return nil, err
+	}
+
+	var publicSet publicdns.RecordSetListResultPage
+	var privateSet privatedns.RecordSetListResultPage
+	newZ := file.NewZone(zName, "")
+	if hostedZone.private {
+		for privateSet, err = h.privateClient.List(ctx, hostedZone.id, hostedZone.zone, nil, ""); privateSet.NotDone(); err = privateSet.NextWithContext(ctx) {
+			updateZoneFromPrivateResourceSet(privateSet, newZ)
+		}
+	} else {
+		for publicSet, err = h.publicClient.ListByDNSZone(ctx, hostedZone.id, hostedZone.zone, nil, ""); publicSet.NotDone(); err = publicSet.NextWithContext(ctx) {
+			updateZoneFromPublicResourceSet(publicSet, newZ)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	newZ.Upstream = h.upstream
+	hostedZone.version, hostedZone.synced = version, time.Now()
+	return newZ, nil
+}
+
+// upToDate returns true if hostedZone doesn't need to be listed, because its version didn't change since it was last
+// listed and it isn't due for a full refresh. It also returns the current version of hostedZone, which is empty if
+// every refresh is a full refresh.
+func (h *Azure) upToDate(ctx context.Context, hostedZone *zone) (string, bool, error) {
+	if h.fullRefresh <= refresh {
+		return "", false, nil
+	}
+	version, err := h.zoneVersions.version(ctx, hostedZone)
+	if err != nil {
+		return "", false, err
+	}
+	return version, version == hostedZone.version && time.Since(hostedZone.synced) < h.fullRefresh, nil
+}
+
 func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage, newZ *file.Zone) {
 	for _, result := range *(recordSet.Response().Value) {
 		resultFqdn := *(result.RecordSetProperties.Fqdn)
@@ -313,6 +369,9 @@ func (h *Azure) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 	if !ok || zones == nil {
 		return dns.RcodeServerFailure, nil
 	}
+	if r.Opcode == dns.OpcodeUpdate && h.updates {
+		return h.serveUpdate(ctx, w, r, zone, zones)
+	}
 
 	m := new(dns.Msg)
 	m.SetReply(r)
@@ -2,8 +2,10 @@ package azure
 
 import (
 	"context"
+	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
@@ -11,6 +13,7 @@ import (
 	"github.com/coredns/coredns/plugin/test"
 	"github.com/coredns/coredns/request"
 
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/miekg/dns"
 )
 
@@ -178,3 +181,42 @@ func TestAzure(t *testing.T) {
 		}
 	}
 }
+
+type fakeZoneVersions struct {
+	current string
+	err     error
+}
+
+func (f *fakeZoneVersions) version(context.Context, *zone) (string, error) { return f.current, f.err }
+
+func TestUpToDate(t *testing.T) {
+	versions := &fakeZoneVersions{current: "10 etag-1"}
+	h := &Azure{zoneVersions: versions, fullRefresh: time.Hour}
+	z := &zone{id: "resource_group_foo", zone: "example.org"}
+
+	if _, ok, _ := h.upToDate(context.Background(), z); ok {
+		t.Fatal("Expected a zone that was never listed not to be up to date")
+	}
+	z.version, z.synced = "10 etag-1", time.Now()
+	if _, ok, _ := h.upToDate(context.Background(), z); !ok {
+		t.Error("Expected an unchanged zone to be up to date")
+	}
+	versions.current = "11 etag-2"
+	if version, ok, _ := h.upToDate(context.Background(), z); ok || version != "11 etag-2" {
+		t.Errorf("Expected a changed zone with version 11 etag-2 not to be up to date, got version %q", version)
+	}
+	z.version, z.synced = "11 etag-2", time.Now().Add(-2*time.Hour)
+	if _, ok, _ := h.upToDate(context.Background(), z); ok {
+		t.Error("Expected a zone that is due for a full refresh not to be up to date")
+	}
+	versions.err = autorest.DetailedError{StatusCode: http.StatusTooManyRequests}
+	if _, _, err := h.upToDate(context.Background(), z); !isThrottled(err) {
+		t.Errorf("Expected throttled error, got %v", err)
+	}
+
+	// Without full refreshes, the version isn't checked at all.
+	h = &Azure{fullRefresh: refresh}
+	if _, ok, err := h.upToDate(context.Background(), z); ok || err != nil {
+		t.Errorf("Expected zone not to be up to date without error, got %t, %v", ok, err)
+	}
+}
@@ -0,0 +1,26 @@
+package azure
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// zoneLastSync is the time a zone was last synced successfully.
+	zoneLastSync = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "azure",
+		Name:      "zone_last_sync_timestamp_seconds",
+		Help:      "Gauge of the time of the last successful sync of each Azure zone.",
+	}, []string{"zone", "hosted_zone"})
+
+	// zoneSyncErrors is the number of failed syncs per zone.
+	zoneSyncErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "azure",
+		Name:      "zone_sync_errors_total",
+		Help:      "Counter of failed syncs of each Azure zone.",
+	}, []string{"zone", "hosted_zone"})
+)
@@ -3,6 +3,7 @@ package azure
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
@@ -21,7 +22,7 @@ var log = clog.NewWithPlugin("azure")
 func init() { plugin.Register("azure", setup) }
 
 func setup(c *caddy.Controller) error {
-	env, keys, accessMap, fall, err := parse(c)
+	env, keys, accessMap, opts, err := parse(c)
 	if err != nil {
 		return plugin.Error("azure", err)
 	}
@@ -39,12 +40,20 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("azure", err)
 	}
 
+	publicZonesClient := publicAzureDNS.NewZonesClient(env.Values[auth.SubscriptionID])
+	publicZonesClient.Authorizer = publicDNSClient.Authorizer
+	privateZonesClient := privateAzureDNS.NewPrivateZonesClient(env.Values[auth.SubscriptionID])
+	privateZonesClient.Authorizer = privateDNSClient.Authorizer
+
 	h, err := New(ctx, publicDNSClient, privateDNSClient, keys, accessMap)
 	if err != nil {
 		cancel()
 		return plugin.Error("azure", err)
 	}
-	h.Fall = fall
+	h.Fall = opts.fall
+	h.updates, h.updater.Keys = opts.updates, opts.updateKeys
+	h.fullRefresh = opts.fullRefresh
+	h.zoneVersions = azureZones{public: publicZonesClient, private: privateZonesClient}
 	if err := h.Run(ctx); err != nil {
 		cancel()
 		return plugin.Error("azure", err)
@@ -55,17 +64,26 @@ func setup(c *caddy.Controller) error {
 		return h
 	})
 	c.OnShutdown(func() error { cancel(); return nil })
+	c.OnStartup(func() error { h.updater.Secrets = dnsserver.GetConfig(c).TsigSecret; return nil })
 	return nil
 }
 
-func parse(c *caddy.Controller) (auth.EnvironmentSettings, map[string][]string, map[string]string, fall.F, error) {
+// options holds the properties of the plugin that don't concern the access to Azure.
+type options struct {
+	fall        fall.F
+	updates     bool
+	updateKeys  []string
+	fullRefresh time.Duration
+}
+
+func parse(c *caddy.Controller) (auth.EnvironmentSettings, map[string][]string, map[string]string, options, error) {
 	resourceGroupMapping := map[string][]string{}
 	accessMap := map[string]string{}
 	resourceGroupSet := map[string]struct{}{}
 	azureEnv := azurerest.PublicCloud
 	env := auth.EnvironmentSettings{Values: map[string]string{}}
 
-	var fall fall.F
+	opts := options{fullRefresh: refresh}
 	var access string
 	var resourceGroup string
 	var zoneName string
@@ -76,14 +94,14 @@ func parse(c *caddy.Controller) (auth.EnvironmentSettings, map[string][]string,
 		for i := 0; i < len(args); i++ {
 			parts := strings.SplitN(args[i], ":", 2)
 			if len(parts) != 2 {
-				return env, resourceGroupMapping, accessMap, fall, c.Errf("invalid resource group/zone: %q", args[i])
+				return env, resourceGroupMapping, accessMap, opts, c.Errf("invalid resource group/zone: %q", args[i])
 			}
 			resourceGroup, zoneName = parts[0], parts[1]
 			if resourceGroup == "" || zoneName == "" {
-				return env, resourceGroupMapping, accessMap, fall, c.Errf("invalid resource group/zone: %q", args[i])
+				return env, resourceGroupMapping, accessMap, opts, c.Errf("invalid resource group/zone: %q", args[i])
 			}
 			if _, ok := resourceGroupSet[resourceGroup+zoneName]; ok {
-				return env, resourceGroupMapping, accessMap, fall, c.Errf("conflicting zone: %q", args[i])
+				return env, resourceGroupMapping, accessMap, opts, c.Errf("conflicting zone: %q", args[i])
 			}
 
 			resourceGroupSet[resourceGroup+zoneName] = struct{}{}
@@ -95,50 +113,66 @@ func parse(c *caddy.Controller) (auth.EnvironmentSettings, map[string][]string,
 			switch c.Val() {
 			case "subscription":
 				if !c.NextArg() {
-					return env, resourceGroupMapping, accessMap, fall, c.ArgErr()
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
 				}
 				env.Values[auth.SubscriptionID] = c.Val()
 			case "tenant":
 				if !c.NextArg() {
-					return env, resourceGroupMapping, accessMap, fall, c.ArgErr()
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
 				}
 				env.Values[auth.TenantID] = c.Val()
 			case "client":
 				if !c.NextArg() {
-					return env, resourceGroupMapping, accessMap, fall, c.ArgErr()
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
 				}
 				env.Values[auth.ClientID] = c.Val()
 			case "secret":
 				if !c.NextArg() {
-					return env, resourceGroupMapping, accessMap, fall, c.ArgErr()
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
 				}
 				env.Values[auth.ClientSecret] = c.Val()
 			case "environment":
 				if !c.NextArg() {
-					return env, resourceGroupMapping, accessMap, fall, c.ArgErr()
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
 				}
 				var err error
 				if azureEnv, err = azurerest.EnvironmentFromName(c.Val()); err != nil {
-					return env, resourceGroupMapping, accessMap, fall, c.Errf("cannot set azure environment: %q", err.Error())
+					return env, resourceGroupMapping, accessMap, opts, c.Errf("cannot set azure environment: %q", err.Error())
 				}
 			case "fallthrough":
-				fall.SetZonesFromArgs(c.RemainingArgs())
+				opts.fall.SetZonesFromArgs(c.RemainingArgs())
 			case "access":
 				if !c.NextArg() {
-					return env, resourceGroupMapping, accessMap, fall, c.ArgErr()
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
 				}
 				access = c.Val()
 				if access != "public" && access != "private" {
-					return env, resourceGroupMapping, accessMap, fall, c.Errf("invalid access value: can be public/private, found: %s", access)
+					return env, resourceGroupMapping, accessMap, opts, c.Errf("invalid access value: can be public/private, found: %s", access)
 				}
 				accessMap[resourceGroup+zoneName] = access
+			case "update":
+				opts.updates = true
+				for _, k := range c.RemainingArgs() {
+					opts.updateKeys = append(opts.updateKeys, plugin.Name(k).Normalize())
+				}
+			case "full_refresh":
+				if !c.NextArg() {
+					return env, resourceGroupMapping, accessMap, opts, c.ArgErr()
+				}
+				var err error
+				if opts.fullRefresh, err = time.ParseDuration(c.Val()); err != nil {
+					return env, resourceGroupMapping, accessMap, opts, c.Errf("invalid full refresh interval: %v", err)
+				}
+				if opts.fullRefresh < refresh {
+					return env, resourceGroupMapping, accessMap, opts, c.Errf("full refresh interval must not be shorter than %v: %q", refresh, c.Val())
+				}
 			default:
-				return env, resourceGroupMapping, accessMap, fall, c.Errf("unknown property: %q", c.Val())
+				return env, resourceGroupMapping, accessMap, opts, c.Errf("unknown property: %q", c.Val())
 			}
 		}
 	}
 
 	env.Values[auth.Resource] = azureEnv.ResourceManagerEndpoint
 	env.Environment = azureEnv
-	return env, resourceGroupMapping, accessMap, fall, nil
+	return env, resourceGroupMapping, accessMap, opts, nil
 }
@@ -48,6 +48,21 @@ func TestSetup(t *testing.T) {
 		{`azure resource_set:zone {
 		environment AZUREPUBLICCLOUD
 	}`, false},
+		{`azure resource_set:zone {
+    update
+}`, false},
+		{`azure resource_set:zone {
+    update key.example.org. other.example.org.
+}`, false},
+		{`azure resource_set:zone {
+    full_refresh 1h
+}`, false},
+		{`azure resource_set:zone {
+    full_refresh
+}`, true},
+		{`azure resource_set:zone {
+    full_refresh 10s
+}`, true},
 		{`azure resource_set:zone resource_set:zone {
 			fallthrough
 		}`, true},
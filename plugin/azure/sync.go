@@ -0,0 +1,56 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	publicdns "github.com/Azure/azure-sdk-for-go/profiles/latest/dns/mgmt/dns"
+	privatedns "github.com/Azure/azure-sdk-for-go/profiles/latest/privatedns/mgmt/privatedns"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// refresh is the time between two refreshes of the zones.
+const refresh = time.Minute
+
+// zoneVersioner returns the version of an Azure DNS zone, which changes when the zone changes.
+type zoneVersioner interface {
+	version(ctx context.Context, z *zone) (string, error)
+}
+
+// azureZones returns the version of a zone with the Azure zones clients. The version is made up of the number of
+// record sets in the zone and its etag, so changes that neither add nor remove record sets don't change it.
+type azureZones struct {
+	public  publicdns.ZonesClient
+	private privatedns.PrivateZonesClient
+}
+
+func (a azureZones) version(ctx context.Context, z *zone) (string, error) {
+	if z.private {
+		pz, err := a.private.Get(ctx, z.id, z.zone)
+		if err != nil {
+			return "", err
+		}
+		if pz.PrivateZoneProperties == nil {
+			return to.String(pz.Etag), nil
+		}
+		return strconv.FormatInt(to.Int64(pz.NumberOfRecordSets), 10) + " " + to.String(pz.Etag), nil
+	}
+	pz, err := a.public.Get(ctx, z.id, z.zone)
+	if err != nil {
+		return "", err
+	}
+	if pz.ZoneProperties == nil {
+		return to.String(pz.Etag), nil
+	}
+	return strconv.FormatInt(to.Int64(pz.NumberOfRecordSets), 10) + " " + to.String(pz.Etag), nil
+}
+
+// isThrottled returns true if err is the response to a throttled request.
+func isThrottled(err error) bool {
+	var derr autorest.DetailedError
+	return errors.As(err, &derr) && derr.StatusCode == http.StatusTooManyRequests
+}
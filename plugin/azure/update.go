@@ -0,0 +1,165 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+
+	publicdns "github.com/Azure/azure-sdk-for-go/profiles/latest/dns/mgmt/dns"
+	privatedns "github.com/Azure/azure-sdk-for-go/profiles/latest/privatedns/mgmt/privatedns"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/miekg/dns"
+)
+
+// recordSetWriter writes record sets to an Azure DNS zone, name is the name of the record set relative to the zone.
+type recordSetWriter interface {
+	createOrUpdate(ctx context.Context, z *zone, name string, t uint16, rrs []dns.RR) error
+	delete(ctx context.Context, z *zone, name string, t uint16) error
+}
+
+// serveUpdate handles the dynamic update r of zName, see RFC 2136. The update is applied to the first Azure zone
+// in z, the Azure zones of zName.
+func (h *Azure) serveUpdate(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, zName string, z []*zone) (int, error) {
+	m := new(dns.Msg)
+	m.SetRcode(r, h.updater.Update(w, r, z[0].z, &h.zMu, func(changes []file.Change) (int, int) {
+		return h.commit(ctx, zName, z[0], changes)
+	}))
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// commit writes changes to z. Azure has no change batches, every record set is written on its own, so if a write
+// fails the record sets written so far are still reported as written.
+func (h *Azure) commit(ctx context.Context, zName string, z *zone, changes []file.Change) (int, int) {
+	for _, c := range changes {
+		if !supported(c.Type, z.private) {
+			log.Debugf("Refusing update of %s: record type %s is not supported", zName, dns.TypeToString[c.Type])
+			return 0, dns.RcodeNotImplemented
+		}
+	}
+
+	for i, c := range changes {
+		name := "@"
+		if c.Name != zName {
+			name, _ = dnsutil.TrimZone(c.Name, zName)
+		}
+		var err error
+		if len(c.New) == 0 {
+			err = h.recordSets.delete(ctx, z, name, c.Type)
+		} else {
+			err = h.recordSets.createOrUpdate(ctx, z, name, c.Type, c.New)
+		}
+		if err != nil {
+			log.Errorf("Failed to update %s %s in %v:%v: %v", c.Name, dns.TypeToString[c.Type], z.id, z.zone, err)
+			return i, dns.RcodeServerFailure
+		}
+	}
+	return len(changes), dns.RcodeSuccess
+}
+
+// supported returns true if record sets of type t can be written to a public or private zone.
+func supported(t uint16, private bool) bool {
+	switch t {
+	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeMX, dns.TypePTR, dns.TypeSRV, dns.TypeTXT:
+		return true
+	case dns.TypeNS:
+		return !private
+	}
+	return false
+}
+
+// azureRecordSets writes record sets with the Azure record sets clients.
+type azureRecordSets struct {
+	public  publicdns.RecordSetsClient
+	private privatedns.RecordSetsClient
+}
+
+func (a azureRecordSets) createOrUpdate(ctx context.Context, z *zone, name string, t uint16, rrs []dns.RR) error {
+	rtype := dns.TypeToString[t]
+	if z.private {
+		_, err := a.private.CreateOrUpdate(ctx, z.id, z.zone, privatedns.RecordType(rtype), name, privateRecordSet(rrs), "", "")
+		return err
+	}
+	_, err := a.public.CreateOrUpdate(ctx, z.id, z.zone, name, publicdns.RecordType(rtype), publicRecordSet(rrs), "", "")
+	return err
+}
+
+func (a azureRecordSets) delete(ctx context.Context, z *zone, name string, t uint16) error {
+	rtype := dns.TypeToString[t]
+	if z.private {
+		_, err := a.private.Delete(ctx, z.id, z.zone, privatedns.RecordType(rtype), name, "")
+		return err
+	}
+	_, err := a.public.Delete(ctx, z.id, z.zone, name, publicdns.RecordType(rtype), "")
+	return err
+}
+
+// publicRecordSet returns the record set of a public zone holding rrs.
+func publicRecordSet(rrs []dns.RR) publicdns.RecordSet {
+	p := &publicdns.RecordSetProperties{TTL: to.Int64Ptr(int64(rrs[0].Header().Ttl))}
+	for _, rr := range rrs {
+		switch x := rr.(type) {
+		case *dns.A:
+			p.ARecords = appendTo(p.ARecords, publicdns.ARecord{Ipv4Address: to.StringPtr(x.A.String())})
+		case *dns.AAAA:
+			p.AaaaRecords = appendTo(p.AaaaRecords, publicdns.AaaaRecord{Ipv6Address: to.StringPtr(x.AAAA.String())})
+		case *dns.CNAME:
+			p.CnameRecord = &publicdns.CnameRecord{Cname: to.StringPtr(x.Target)}
+		case *dns.MX:
+			p.MxRecords = appendTo(p.MxRecords, publicdns.MxRecord{Preference: to.Int32Ptr(int32(x.Preference)), Exchange: to.StringPtr(x.Mx)})
+		case *dns.NS:
+			p.NsRecords = appendTo(p.NsRecords, publicdns.NsRecord{Nsdname: to.StringPtr(x.Ns)})
+		case *dns.PTR:
+			p.PtrRecords = appendTo(p.PtrRecords, publicdns.PtrRecord{Ptrdname: to.StringPtr(x.Ptr)})
+		case *dns.SRV:
+			p.SrvRecords = appendTo(p.SrvRecords, publicdns.SrvRecord{
+				Priority: to.Int32Ptr(int32(x.Priority)),
+				Weight:   to.Int32Ptr(int32(x.Weight)),
+				Port:     to.Int32Ptr(int32(x.Port)),
+				Target:   to.StringPtr(x.Target),
+			})
+		case *dns.TXT:
+			p.TxtRecords = appendTo(p.TxtRecords, publicdns.TxtRecord{Value: to.StringSlicePtr(x.Txt)})
+		}
+	}
+	return publicdns.RecordSet{RecordSetProperties: p}
+}
+
+// privateRecordSet returns the record set of a private zone holding rrs.
+func privateRecordSet(rrs []dns.RR) privatedns.RecordSet {
+	p := &privatedns.RecordSetProperties{TTL: to.Int64Ptr(int64(rrs[0].Header().Ttl))}
+	for _, rr := range rrs {
+		switch x := rr.(type) {
+		case *dns.A:
+			p.ARecords = appendTo(p.ARecords, privatedns.ARecord{Ipv4Address: to.StringPtr(x.A.String())})
+		case *dns.AAAA:
+			p.AaaaRecords = appendTo(p.AaaaRecords, privatedns.AaaaRecord{Ipv6Address: to.StringPtr(x.AAAA.String())})
+		case *dns.CNAME:
+			p.CnameRecord = &privatedns.CnameRecord{Cname: to.StringPtr(x.Target)}
+		case *dns.MX:
+			p.MxRecords = appendTo(p.MxRecords, privatedns.MxRecord{Preference: to.Int32Ptr(int32(x.Preference)), Exchange: to.StringPtr(x.Mx)})
+		case *dns.PTR:
+			p.PtrRecords = appendTo(p.PtrRecords, privatedns.PtrRecord{Ptrdname: to.StringPtr(x.Ptr)})
+		case *dns.SRV:
+			p.SrvRecords = appendTo(p.SrvRecords, privatedns.SrvRecord{
+				Priority: to.Int32Ptr(int32(x.Priority)),
+				Weight:   to.Int32Ptr(int32(x.Weight)),
+				Port:     to.Int32Ptr(int32(x.Port)),
+				Target:   to.StringPtr(x.Target),
+			})
+		case *dns.TXT:
+			p.TxtRecords = appendTo(p.TxtRecords, privatedns.TxtRecord{Value: to.StringSlicePtr(x.Txt)})
+		}
+	}
+	return privatedns.RecordSet{RecordSetProperties: p}
+}
+
+// appendTo appends r to the slice s points to, s may be nil.
+func appendTo[T any](s *[]T, r T) *[]T {
+	if s == nil {
+		return &[]T{r}
+	}
+	*s = append(*s, r)
+	return s
+}
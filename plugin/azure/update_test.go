@@ -0,0 +1,193 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/miekg/dns"
+)
+
+// fakeRecordSets records the writes as strings.
+type fakeRecordSets struct {
+	writes []string
+	err    error
+}
+
+func (f *fakeRecordSets) createOrUpdate(_ context.Context, z *zone, name string, t uint16, rrs []dns.RR) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.writes = append(f.writes, fmt.Sprintf("%s %s %s %d %d", z.zone, name, dns.TypeToString[t], rrs[0].Header().Ttl, len(rrs)))
+	return nil
+}
+
+func (f *fakeRecordSets) delete(_ context.Context, z *zone, name string, t uint16) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.writes = append(f.writes, fmt.Sprintf("%s %s %s deleted", z.zone, name, dns.TypeToString[t]))
+	return nil
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		key           string
+		keys          []string
+		private       bool
+		update        func(m *dns.Msg)
+		err           error
+		expectedRcode int
+		expected      []string
+	}{
+		// Unsigned updates are refused.
+		{
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeRefused,
+		},
+		// Signed with a key that isn't allowed.
+		{
+			key:           "other.key.",
+			keys:          []string{"update.key."},
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeRefused,
+		},
+		{
+			key:           "update.key.",
+			keys:          []string{"update.key."},
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("example.org. 60 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected:      []string{"example.org. @ A 60 2"},
+		},
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.MX("mail.example.org. 300 IN MX 20 backup.example.com.")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected:      []string{"example.org. mail MX 300 2"},
+		},
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.RemoveRRset([]dns.RR{test.TXT("txt.example.org. 0 IN TXT \"\"")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected:      []string{"example.org. txt TXT deleted"},
+		},
+		// Private zones have no NS records.
+		{
+			key:           "update.key.",
+			private:       true,
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.NS("sub.example.org. 300 IN NS ns.example.com.")}) },
+			expectedRcode: dns.RcodeNotImplemented,
+		},
+		// Failed prerequisite.
+		{
+			key: "update.key.",
+			update: func(m *dns.Msg) {
+				m.NameNotUsed([]dns.RR{test.A("www.example.org. 0 IN A 0.0.0.0")})
+				m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")})
+			},
+			expectedRcode: dns.RcodeYXDomain,
+		},
+		// Failed write.
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			err:           errors.New("throttled"),
+			expectedRcode: dns.RcodeServerFailure,
+		},
+	}
+
+	for i, tc := range tests {
+		recordSets := &fakeRecordSets{err: tc.err}
+		zones := testZones()
+		zones["example.org."][0].private = tc.private
+		h := &Azure{zoneNames: []string{"example.org."}, zones: zones, recordSets: recordSets, updates: true, updater: file.Updater{Secrets: testSecrets, Keys: tc.keys}}
+
+		m := new(dns.Msg)
+		m.SetUpdate("example.org.")
+		tc.update(m)
+		if tc.key != "" {
+			m = signed(t, m, tc.key)
+		}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := h.ServeDNS(context.Background(), rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if rec.Msg.Rcode != tc.expectedRcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.expectedRcode], dns.RcodeToString[rec.Msg.Rcode])
+		}
+		if !reflect.DeepEqual(recordSets.writes, tc.expected) {
+			t.Errorf("Test %d: expected writes %v, got %v", i, tc.expected, recordSets.writes)
+		}
+	}
+}
+
+func TestUpdateVisible(t *testing.T) {
+	h := &Azure{zoneNames: []string{"example.org."}, zones: testZones(), recordSets: &fakeRecordSets{}, updates: true, updater: file.Updater{Secrets: testSecrets}}
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.org.")
+	m.Insert([]dns.RR{test.AAAA("new.example.org. 60 IN AAAA 2001:db8::1")})
+	m = signed(t, m, "update.key.")
+	if _, err := h.ServeDNS(context.Background(), dnstest.NewRecorder(&test.ResponseWriter{}), m); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("new.example.org.", dns.TypeAAAA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	if _, err := h.ServeDNS(context.Background(), rec, req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].String() != "new.example.org.\t60\tIN\tAAAA\t2001:db8::1" {
+		t.Errorf("Expected the updated record, got %v", rec.Msg.Answer)
+	}
+}
+
+func TestRecordSet(t *testing.T) {
+	rrs := []dns.RR{
+		test.SRV("_sip._tcp.example.org. 120 IN SRV 10 20 5060 sip1.example.org."),
+		test.SRV("_sip._tcp.example.org. 120 IN SRV 10 30 5060 sip2.example.org."),
+	}
+
+	public := publicRecordSet(rrs)
+	if ttl := to.Int64(public.TTL); ttl != 120 {
+		t.Errorf("Expected TTL 120, got %d", ttl)
+	}
+	if public.SrvRecords == nil || len(*public.SrvRecords) != 2 {
+		t.Fatalf("Expected 2 SRV records, got %v", public.SrvRecords)
+	}
+	if srv := (*public.SrvRecords)[1]; to.Int32(srv.Weight) != 30 || to.String(srv.Target) != "sip2.example.org." {
+		t.Errorf("Expected weight 30 and target sip2.example.org., got %d and %s", to.Int32(srv.Weight), to.String(srv.Target))
+	}
+
+	private := privateRecordSet([]dns.RR{test.TXT("txt.example.org. 300 IN TXT \"a\" \"b\"")})
+	if private.TxtRecords == nil || !reflect.DeepEqual(*(*private.TxtRecords)[0].Value, []string{"a", "b"}) {
+		t.Errorf("Expected TXT record with values [a b], got %v", private.TxtRecords)
+	}
+}
+
+// testSecrets are the TSIG secrets of the keys used by the tests.
+var testSecrets = map[string]string{"update.key.": "c2VjcmV0", "other.key.": "c2VjcmV0"}
+
+// signed returns m signed with key.
+func signed(t *testing.T, m *dns.Msg, key string) *dns.Msg {
+	t.Helper()
+	m.SetTsig(key, dns.HmacSHA256, 300, time.Now().Unix())
+	buf, _, err := dns.TsigGenerate(m, testSecrets[key], "", false)
+	if err != nil {
+		t.Fatalf("Failed to sign update: %v", err)
+	}
+	r := new(dns.Msg)
+	if err := r.Unpack(buf); err != nil {
+		t.Fatalf("Failed to unpack update: %v", err)
+	}
+	return r
+}
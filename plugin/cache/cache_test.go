@@ -899,3 +899,21 @@ func wildcardMetadataBackend(qname, wildcard string) plugin.Handler {
 		return dns.RcodeSuccess, nil
 	})
 }
+
+func TestCachesSuccess(t *testing.T) {
+	c := New()
+	if !c.CachesSuccess("cluster.local.") {
+		t.Error("Expected successful answers to be cached by default")
+	}
+	c.pexcept = []string{"example.org.", "cluster.local."}
+	if c.CachesSuccess("cluster.local.") {
+		t.Error("Expected successful answers for cluster.local. not to be cached")
+	}
+	if !c.CachesSuccess("example.net.") {
+		t.Error("Expected successful answers for example.net. to be cached")
+	}
+	c.pexcept = []string{"local."}
+	if c.CachesSuccess("cluster.local.") {
+		t.Error("Expected successful answers for a subzone of a disabled zone not to be cached")
+	}
+}
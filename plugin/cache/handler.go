@@ -120,6 +120,12 @@ func (c *Cache) shouldPrefetch(i *item, now time.Time) bool {
 // Name implements the Handler interface.
 func (c *Cache) Name() string { return "cache" }
 
+// CachesSuccess returns true if successful answers for names in zone may be cached, i.e. the success cache isn't
+// disabled for zone.
+func (c *Cache) CachesSuccess(zone string) bool {
+	return plugin.Zones(c.pexcept).Matches(zone) == ""
+}
+
 // getIgnoreTTL unconditionally returns an item if it exists in the cache.
 func (c *Cache) getIgnoreTTL(now time.Time, state request.Request, server string) *item {
 	k := hash(state.Name(), state.QType(), state.Do(), state.Req.CheckingDisabled)
@@ -13,6 +13,7 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/throttle"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/request"
 
@@ -31,6 +32,9 @@ type CloudDNS struct {
 
 	zMu   sync.RWMutex
 	zones zones
+
+	updates bool         // accept dynamic updates
+	updater file.Updater // its TSIG secrets are set on startup
 }
 
 type zone struct {
@@ -38,6 +42,9 @@ type zone struct {
 	zoneName    string
 	z           *file.Zone
 	dns         string
+
+	change string // the id of the latest change of the hosted zone when it was last listed
+	synced bool   // true if the hosted zone was listed after its latest change was done
 }
 
 type zones map[string][]*zone
@@ -82,7 +89,7 @@ func (h *CloudDNS) Run(ctx context.Context) error {
 		return err
 	}
 	go func() {
-		delay := 1 * time.Minute
+		delay := refresh
 		timer := time.NewTimer(delay)
 		defer timer.Stop()
 		for {
@@ -92,7 +99,9 @@ func (h *CloudDNS) Run(ctx context.Context) error {
 				log.Debugf("Breaking out of CloudDNS update loop for %v: %v", h.zoneNames, ctx.Err())
 				return
 			case <-timer.C:
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
+				err := h.updateZones(ctx)
+				delay = throttle.Backoff(delay, refresh, err)
+				if err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
 					log.Errorf("Failed to update zones %v: %v", h.zoneNames, err)
 				}
 			}
@@ -115,6 +124,9 @@ func (h *CloudDNS) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 	if !ok || z == nil {
 		return dns.RcodeServerFailure, nil
 	}
+	if r.Opcode == dns.OpcodeUpdate && h.updates {
+		return h.serveUpdate(ctx, w, r, z)
+	}
 
 	m := new(dns.Msg)
 	m.SetReply(r)
@@ -180,28 +192,35 @@ func updateZoneFromRRS(rrs *gcp.ResourceRecordSetsListResponse, z *file.Zone) er
 // updateZones re-queries resource record sets for each zone and updates the
 // zone object.
 // Returns error if any zones error'ed out, but waits for other zones to
-// complete first.
+// complete first. The error wraps throttle.ErrThrottled if Cloud DNS throttled any of
+// the requests.
 func (h *CloudDNS) updateZones(ctx context.Context) error {
 	errc := make(chan error)
 	defer close(errc)
 	for zName, z := range h.zones {
 		go func(zName string, z []*zone) {
 			var err error
-			var rrListResponse *gcp.ResourceRecordSetsListResponse
 			defer func() {
 				errc <- err
 			}()
 
 			for i, hostedZone := range z {
-				newZ := file.NewZone(zName, "")
-				newZ.Upstream = h.upstream
-				rrListResponse, err = h.client.listRRSets(ctx, hostedZone.projectName, hostedZone.zoneName)
+				var newZ *file.Zone
+				newZ, err = h.syncZone(ctx, zName, hostedZone)
+				hosted := hostedZone.projectName + ":" + hostedZone.zoneName
 				if err != nil {
-					err = fmt.Errorf("failed to list resource records for %v:%v:%v from gcp: %v", zName, hostedZone.projectName, hostedZone.zoneName, err)
+					zoneSyncErrors.WithLabelValues(zName, hosted).Inc()
+					if isThrottled(err) {
+						err = fmt.Errorf("failed to list resource records for %v:%v from gcp: %w: %v", zName, hosted, throttle.ErrThrottled, err)
+						return
+					}
+					err = fmt.Errorf("failed to list resource records for %v:%v from gcp: %v", zName, hosted, err)
 					return
 				}
-				updateZoneFromRRS(rrListResponse, newZ)
-
+				zoneLastSync.WithLabelValues(zName, hosted).SetToCurrentTime()
+				if newZ == nil {
+					continue
+				}
 				h.zMu.Lock()
 				(*z[i]).z = newZ
 				h.zMu.Unlock()
@@ -211,17 +230,50 @@ func (h *CloudDNS) updateZones(ctx context.Context) error {
 	// Collect errors (if any). This will also sync on all zones updates
 	// completion.
 	var errs []string
+	throttled := false
 	for i := 0; i < len(h.zones); i++ {
 		err := <-errc
 		if err != nil {
 			errs = append(errs, err.Error())
+			throttled = throttled || errors.Is(err, throttle.ErrThrottled)
 		}
 	}
+	if throttled {
+		return fmt.Errorf("errors updating zones (%w): %v", throttle.ErrThrottled, errs)
+	}
 	if len(errs) != 0 {
 		return fmt.Errorf("errors updating zones: %v", errs)
 	}
 	return nil
 }
 
+// syncZone lists the resource record sets of hostedZone and returns them as a new zone. It returns nil if
+// hostedZone didn't change since it was last listed.
+func (h *CloudDNS) syncZone(ctx context.Context, zName string, hostedZone *zone) (*file.Zone, error) {
+	change, err := h.client.latestChange(ctx, hostedZone.projectName, hostedZone.zoneName)
+	if err != nil {
+		return nil, err
+	}
+	id := ""
+	if change != nil {
+		id = change.Id
+	}
+	if hostedZone.synced && id == hostedZone.change {
+		return nil, nil
+	}
+
+	rrListResponse, err := h.client.listRRSets(ctx, hostedZone.projectName, hostedZone.zoneName)
+	if err != nil {
+		return nil, err
+	}
+	newZ := file.NewZone(zName, "")
+	newZ.Upstream = h.upstream
+	updateZoneFromRRS(rrListResponse, newZ)
+	// A pending change may not be in the listing yet, so only remember the change once it's done; until then the
+	// hosted zone is listed on every sync.
+	hostedZone.change, hostedZone.synced = id, change == nil || change.Status == "done"
+	return newZ, nil
+}
+
 // Name implements the Handler interface.
 func (h *CloudDNS) Name() string { return "clouddns" }
@@ -3,17 +3,20 @@ package clouddns
 import (
 	"context"
 	"errors"
+	"net/http"
 	"reflect"
 	"testing"
 
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/throttle"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/plugin/test"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
 	gcp "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
 )
 
 type fakeGCPClient struct {
@@ -24,6 +27,14 @@ func (c fakeGCPClient) zoneExists(projectName, hostedZoneName string) error {
 	return nil
 }
 
+func (c fakeGCPClient) applyChange(ctx context.Context, projectName, hostedZoneName string, change *gcp.Change) error {
+	return nil
+}
+
+func (c fakeGCPClient) latestChange(ctx context.Context, projectName, hostedZoneName string) (*gcp.Change, error) {
+	return &gcp.Change{Id: "1", Status: "done"}, nil
+}
+
 func (c fakeGCPClient) listRRSets(ctx context.Context, projectName, hostedZoneName string) (*gcp.ResourceRecordSetsListResponse, error) {
 	if projectName == "bad-project" || hostedZoneName == "bad-zone" {
 		return nil, errors.New("the 'parameters.managedZone' resource named 'bad-zone' does not exist")
@@ -325,3 +336,63 @@ func TestCloudDNS(t *testing.T) {
 		}
 	}
 }
+
+// syncGCPClient counts the listings of the hosted zones, and returns change as their latest change.
+type syncGCPClient struct {
+	fakeGCPClient
+	lists  int
+	change *gcp.Change
+	err    error
+}
+
+func (c *syncGCPClient) latestChange(ctx context.Context, projectName, hostedZoneName string) (*gcp.Change, error) {
+	return c.change, c.err
+}
+
+func (c *syncGCPClient) listRRSets(ctx context.Context, projectName, hostedZoneName string) (*gcp.ResourceRecordSetsListResponse, error) {
+	c.lists++
+	return c.fakeGCPClient.listRRSets(ctx, projectName, hostedZoneName)
+}
+
+func TestCloudDNSSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &syncGCPClient{}
+	r, err := New(ctx, client, map[string][]string{"org.": {"sample-project-1:sample-zone-1"}}, &upstream.Upstream{})
+	if err != nil {
+		t.Fatalf("Failed to create clouddns: %v", err)
+	}
+
+	for i, tc := range []struct {
+		change        *gcp.Change
+		expectedLists int
+	}{
+		{&gcp.Change{Id: "1", Status: "done"}, 1}, // The first sync always lists the hosted zone.
+		{&gcp.Change{Id: "1", Status: "done"}, 1},
+		{&gcp.Change{Id: "2", Status: "done"}, 2},
+		{&gcp.Change{Id: "2", Status: "done"}, 2},
+		// A pending change is listed until it's done.
+		{&gcp.Change{Id: "3", Status: "pending"}, 3},
+		{&gcp.Change{Id: "3", Status: "pending"}, 4},
+		{&gcp.Change{Id: "3", Status: "done"}, 5},
+		{&gcp.Change{Id: "3", Status: "done"}, 5},
+	} {
+		client.change = tc.change
+		if err := r.updateZones(ctx); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if client.lists != tc.expectedLists {
+			t.Errorf("Test %d: expected %d listings, got %d", i, tc.expectedLists, client.lists)
+		}
+	}
+
+	client.err = &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}
+	if err := r.updateZones(ctx); !errors.Is(err, throttle.ErrThrottled) {
+		t.Errorf("Expected throttled error, got %v", err)
+	}
+	client.err = &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}}
+	if err := r.updateZones(ctx); err == nil || errors.Is(err, throttle.ErrThrottled) {
+		t.Errorf("Expected error that isn't throttled, got %v", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 type gcpDNS interface {
 	zoneExists(projectName, hostedZoneName string) error
 	listRRSets(ctx context.Context, projectName, hostedZoneName string) (*gcp.ResourceRecordSetsListResponse, error)
+	applyChange(ctx context.Context, projectName, hostedZoneName string, change *gcp.Change) error
+	latestChange(ctx context.Context, projectName, hostedZoneName string) (*gcp.Change, error)
 }
 
 type gcpClient struct {
@@ -38,3 +40,23 @@ func (c gcpClient) listRRSets(ctx context.Context, projectName, hostedZoneName s
 	}
 	return &gcp.ResourceRecordSetsListResponse{Rrsets: rs}, nil
 }
+
+// applyChange is a wrapper method around `gcp.Service.Changes.Create`
+// it atomically applies the additions and deletions of change to a hosted zone.
+func (c gcpClient) applyChange(ctx context.Context, projectName, hostedZoneName string, change *gcp.Change) error {
+	_, err := c.Changes.Create(projectName, hostedZoneName, change).Context(ctx).Do()
+	return err
+}
+
+// latestChange is a wrapper method around `gcp.Service.Changes.List`
+// it returns the most recent change of a hosted zone, or nil if there are none.
+func (c gcpClient) latestChange(ctx context.Context, projectName, hostedZoneName string) (*gcp.Change, error) {
+	resp, err := c.Changes.List(projectName, hostedZoneName).SortBy("changeSequence").SortOrder("descending").MaxResults(1).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Changes) == 0 {
+		return nil, nil
+	}
+	return resp.Changes[0], nil
+}
@@ -39,6 +39,8 @@ func setup(c *caddy.Controller) error {
 		keys := map[string][]string{}
 
 		var fall fall.F
+		var updates bool
+		var updateKeys []string
 		up := upstream.New()
 
 		args := c.RemainingArgs()
@@ -73,6 +75,11 @@ func setup(c *caddy.Controller) error {
 				}
 			case "fallthrough":
 				fall.SetZonesFromArgs(c.RemainingArgs())
+			case "update":
+				updates = true
+				for _, k := range c.RemainingArgs() {
+					updateKeys = append(updateKeys, plugin.Name(k).Normalize())
+				}
 			default:
 				return plugin.Error("clouddns", c.Errf("unknown property %q", c.Val()))
 			}
@@ -91,6 +98,7 @@ func setup(c *caddy.Controller) error {
 			return plugin.Error("clouddns", c.Errf("failed to create plugin: %v", err))
 		}
 		h.Fall = fall
+		h.updates, h.updater.Keys = updates, updateKeys
 
 		if err := h.Run(ctx); err != nil {
 			cancel()
@@ -102,6 +110,7 @@ func setup(c *caddy.Controller) error {
 			return h
 		})
 		c.OnShutdown(func() error { cancel(); return nil })
+		c.OnStartup(func() error { h.updater.Secrets = dnsserver.GetConfig(c).TsigSecret; return nil })
 	}
 
 	return nil
@@ -29,6 +29,12 @@ func TestSetupCloudDNS(t *testing.T) {
 }`, true},
 		{`clouddns example.org.:example-project:zone-name {
     fallthrough
+}`, false},
+		{`clouddns example.org.:example-project:zone-name {
+    update
+}`, false},
+		{`clouddns example.org.:example-project:zone-name {
+    update key.example.org.
 }`, false},
 		{`clouddns example.org.:example-project:zone-name {
     credentials
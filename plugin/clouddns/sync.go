@@ -0,0 +1,29 @@
+package clouddns
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// refresh is the time between two refreshes of the zones.
+const refresh = time.Minute
+
+// isThrottled returns true if err is the response to a request that exceeded a rate limit.
+func isThrottled(err error) bool {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	if gerr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	for _, e := range gerr.Errors {
+		if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package clouddns
+
+import (
+	"context"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/file"
+
+	"github.com/miekg/dns"
+	gcp "google.golang.org/api/dns/v1"
+)
+
+// serveUpdate handles the dynamic update r of a zone, see RFC 2136. The update is applied to the first hosted zone
+// in z, the hosted zones of the zone.
+func (h *CloudDNS) serveUpdate(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, z []*zone) (int, error) {
+	hostedZone := z[0]
+	m := new(dns.Msg)
+	m.SetRcode(r, h.updater.Update(w, r, hostedZone.z, &h.zMu, func(changes []file.Change) (int, int) {
+		return h.commit(ctx, hostedZone, changes)
+	}))
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// commit writes changes to hostedZone in a single change, so either all of them are written or none are. Cloud DNS
+// replaces a record set by deleting the current one and adding the new one in the same change.
+func (h *CloudDNS) commit(ctx context.Context, hostedZone *zone, changes []file.Change) (int, int) {
+	change := &gcp.Change{}
+	for _, c := range changes {
+		if len(c.Old) > 0 {
+			change.Deletions = append(change.Deletions, toRRSet(c.Name, c.Type, c.Old))
+		}
+		if len(c.New) > 0 {
+			change.Additions = append(change.Additions, toRRSet(c.Name, c.Type, c.New))
+		}
+	}
+	if err := h.client.applyChange(ctx, hostedZone.projectName, hostedZone.zoneName, change); err != nil {
+		log.Errorf("Failed to update %v:%v in clouddns: %v", hostedZone.projectName, hostedZone.zoneName, err)
+		return 0, dns.RcodeServerFailure
+	}
+	return len(changes), dns.RcodeSuccess
+}
+
+// toRRSet returns the resource record set for the RRset rrs of name and type t.
+func toRRSet(name string, t uint16, rrs []dns.RR) *gcp.ResourceRecordSet {
+	rrset := &gcp.ResourceRecordSet{
+		Name: name,
+		Type: dns.TypeToString[t],
+		Ttl:  int64(rrs[0].Header().Ttl),
+	}
+	for _, rr := range rrs {
+		rrset.Rrdatas = append(rrset.Rrdatas, strings.TrimPrefix(rr.String(), rr.Header().String()))
+	}
+	return rrset
+}
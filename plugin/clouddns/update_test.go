@@ -0,0 +1,187 @@
+package clouddns
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/upstream"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	gcp "google.golang.org/api/dns/v1"
+)
+
+type fakeUpdateGCPClient struct {
+	fakeGCPClient
+	zones   []string
+	changes []*gcp.Change
+	err     error
+}
+
+func (c *fakeUpdateGCPClient) applyChange(_ context.Context, projectName, hostedZoneName string, change *gcp.Change) error {
+	c.zones = append(c.zones, projectName+":"+hostedZoneName)
+	c.changes = append(c.changes, change)
+	return c.err
+}
+
+func TestUpdate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		key           string
+		keys          []string
+		update        func(m *dns.Msg)
+		err           error
+		expectedRcode int
+		expected      *gcp.Change
+	}{
+		// Unsigned updates are refused.
+		{
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeRefused,
+		},
+		// Signed with a key that isn't allowed.
+		{
+			key:           "other.key.",
+			keys:          []string{"update.key."},
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeRefused,
+		},
+		{
+			key:           "update.key.",
+			keys:          []string{"update.key."},
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected: &gcp.Change{
+				Deletions: []*gcp.ResourceRecordSet{{Name: "www.example.org.", Type: "A", Ttl: 300, Rrdatas: []string{"1.2.3.4"}}},
+				Additions: []*gcp.ResourceRecordSet{{Name: "www.example.org.", Type: "A", Ttl: 300, Rrdatas: []string{"1.2.3.4", "1.2.3.5"}}},
+			},
+		},
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.TXT("new.example.org. 60 IN TXT \"hello\"")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected: &gcp.Change{
+				Additions: []*gcp.ResourceRecordSet{{Name: "new.example.org.", Type: "TXT", Ttl: 60, Rrdatas: []string{"\"hello\""}}},
+			},
+		},
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.RemoveName([]dns.RR{test.A("sample.example.org. 0 IN A 0.0.0.0")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected: &gcp.Change{
+				Deletions: []*gcp.ResourceRecordSet{{Name: "sample.example.org.", Type: "CNAME", Ttl: 300, Rrdatas: []string{"example.org."}}},
+			},
+		},
+		// Failed prerequisite.
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.RRsetUsed([]dns.RR{test.A("new.example.org. 0 IN A 0.0.0.0")}) },
+			expectedRcode: dns.RcodeNXRrset,
+		},
+		// Failed change.
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			err:           errors.New("quota exceeded"),
+			expectedRcode: dns.RcodeServerFailure,
+		},
+	}
+
+	for i, tc := range tests {
+		client := &fakeUpdateGCPClient{err: tc.err}
+		h, err := New(ctx, client, map[string][]string{"org.": {"sample-project-1:sample-zone-1"}}, &upstream.Upstream{})
+		if err != nil {
+			t.Fatalf("Failed to create clouddns: %v", err)
+		}
+		if err := h.Run(ctx); err != nil {
+			t.Fatalf("Failed to initialize clouddns: %v", err)
+		}
+		h.updates, h.updater.Keys, h.updater.Secrets = true, tc.keys, testSecrets
+
+		m := new(dns.Msg)
+		m.SetUpdate("org.")
+		tc.update(m)
+		if tc.key != "" {
+			m = signed(t, m, tc.key)
+		}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := h.ServeDNS(ctx, rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if rec.Msg.Rcode != tc.expectedRcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.expectedRcode], dns.RcodeToString[rec.Msg.Rcode])
+		}
+
+		if tc.expected == nil {
+			if tc.err == nil && len(client.changes) != 0 {
+				t.Errorf("Test %d: expected no changes, got %v", i, client.changes)
+			}
+			continue
+		}
+		if len(client.changes) != 1 {
+			t.Fatalf("Test %d: expected a single change, got %d", i, len(client.changes))
+		}
+		if client.zones[0] != "sample-project-1:sample-zone-1" {
+			t.Errorf("Test %d: expected change of sample-project-1:sample-zone-1, got %s", i, client.zones[0])
+		}
+		if !reflect.DeepEqual(client.changes[0], tc.expected) {
+			t.Errorf("Test %d: expected change %+v, got %+v", i, tc.expected, client.changes[0])
+		}
+	}
+}
+
+func TestUpdateVisible(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h, err := New(ctx, &fakeUpdateGCPClient{}, map[string][]string{"org.": {"sample-project-1:sample-zone-1"}}, &upstream.Upstream{})
+	if err != nil {
+		t.Fatalf("Failed to create clouddns: %v", err)
+	}
+	if err := h.Run(ctx); err != nil {
+		t.Fatalf("Failed to initialize clouddns: %v", err)
+	}
+	h.updates, h.updater.Secrets = true, testSecrets
+
+	m := new(dns.Msg)
+	m.SetUpdate("org.")
+	m.Insert([]dns.RR{test.AAAA("new.example.org. 60 IN AAAA 2001:db8::1")})
+	m = signed(t, m, "update.key.")
+	if _, err := h.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), m); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("new.example.org.", dns.TypeAAAA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	if _, err := h.ServeDNS(ctx, rec, req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].String() != "new.example.org.\t60\tIN\tAAAA\t2001:db8::1" {
+		t.Errorf("Expected the updated record, got %v", rec.Msg.Answer)
+	}
+}
+
+// testSecrets are the TSIG secrets of the keys used by the tests.
+var testSecrets = map[string]string{"update.key.": "c2VjcmV0", "other.key.": "c2VjcmV0"}
+
+// signed returns m signed with key.
+func signed(t *testing.T, m *dns.Msg, key string) *dns.Msg {
+	t.Helper()
+	m.SetTsig(key, dns.HmacSHA256, 300, time.Now().Unix())
+	buf, _, err := dns.TsigGenerate(m, testSecrets[key], "", false)
+	if err != nil {
+		t.Fatalf("Failed to sign update: %v", err)
+	}
+	r := new(dns.Msg)
+	if err := r.Unpack(buf); err != nil {
+		t.Fatalf("Failed to unpack update: %v", err)
+	}
+	return r
+}
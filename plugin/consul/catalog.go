@@ -0,0 +1,500 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	blockingWait = 5 * time.Minute  // how long a blocking query may wait for changes
+	backoffMin   = 1 * time.Second  // time we wait before retrying a failed query
+	backoffMax   = 30 * time.Second // maximum time between the retries of a failed query
+	fetchWorkers = 8                // maximum number of concurrent queries for the instances of services
+)
+
+// client queries the HTTP API of a Consul agent.
+type client struct {
+	address string
+	token   string
+	http    *http.Client
+	wait    time.Duration
+}
+
+// get does a blocking query for path, it decodes the response into v and returns its index.
+func (c *client) get(ctx context.Context, path string, params url.Values, index uint64, v interface{}) (uint64, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	if index > 0 {
+		params.Set("index", strconv.FormatUint(index, 10))
+		params.Set("wait", c.wait.String())
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %q for %s", resp.Status, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index for %s: %s", path, err)
+	}
+	return i, nil
+}
+
+// blocking calls fetch with the index of the previous call until ctx is canceled. Failed calls are retried with
+// an exponential backoff.
+func blocking(ctx context.Context, what string, fetch func(ctx context.Context, index uint64) (uint64, error)) {
+	var index uint64
+	backoff := backoffMin
+	for {
+		i, err := fetch(ctx, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warningf("Failed to query %s, retrying in %s: %s", what, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff *= 2
+			if backoff > backoffMax {
+				backoff = backoffMax
+			}
+			continue
+		}
+		backoff = backoffMin
+		switch {
+		case i < index:
+			// The index went backwards, e.g. after a restore of Consul's state, start over.
+			index = 0
+		case i == 0:
+			// Never block with an index of 0, that returns immediately.
+			index = 1
+		default:
+			index = i
+		}
+	}
+}
+
+// instance is a healthy instance of a service.
+type instance struct {
+	Node        string
+	NodeAddress string
+	Address     string
+	Port        int
+	Tags        []string
+}
+
+// hasTag returns true if the instance has tag.
+func (i instance) hasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// healthEntry is an entry of the response to /v1/health/service/<service>.
+type healthEntry struct {
+	Node struct {
+		Node    string
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+		Tags    []string
+	}
+	Checks []struct {
+		Status string
+	}
+}
+
+// passing returns true if all health checks of the entry pass.
+func (e healthEntry) passing() bool {
+	for _, c := range e.Checks {
+		if c.Status != "passing" {
+			return false
+		}
+	}
+	return true
+}
+
+// healthCheck is an entry of the response to /v1/health/state/any. Node checks have no service name.
+type healthCheck struct {
+	Node        string
+	CheckID     string
+	Status      string
+	ServiceName string
+}
+
+// catalog holds the healthy instances of all services in a datacenter. It's kept up to date with two blocking
+// queries: one for the list of services and one for the state of all health checks. When either changes, the
+// instances of the affected services are queried again by at most fetchWorkers workers. This keeps the number
+// of connections to the Consul agent independent of the number of services.
+type catalog struct {
+	dc      string
+	client  *client
+	refresh chan struct{}
+
+	sync.RWMutex
+	listed       bool                   // the services have been listed
+	checked      bool                   // the health checks have been listed
+	index        uint64                 // highest index seen, used as the SOA serial
+	listIndex    uint64                 // index of the last list of services
+	known        map[string]struct{}    // services in the catalog
+	services     map[string][]instance  // healthy instances, a service is only present after it's been queried once
+	serviceNodes map[string][]string    // nodes with an instance of a service, healthy or not
+	nodes        map[string]string      // node name to address
+	checks       map[string]healthCheck // health checks by node and check ID
+	dirty        map[string]struct{}    // services whose instances need to be queried
+}
+
+func newCatalog(dc string, c *client) *catalog {
+	return &catalog{
+		dc:           dc,
+		client:       c,
+		refresh:      make(chan struct{}, 1),
+		known:        make(map[string]struct{}),
+		services:     make(map[string][]instance),
+		serviceNodes: make(map[string][]string),
+		nodes:        make(map[string]string),
+		checks:       make(map[string]healthCheck),
+		dirty:        make(map[string]struct{}),
+	}
+}
+
+// params returns the query parameters for the datacenter.
+func (c *catalog) params() url.Values {
+	params := url.Values{}
+	if c.dc != "" {
+		params.Set("dc", c.dc)
+	}
+	return params
+}
+
+// run watches the service catalog and the health checks, and queries the instances of the services that
+// changed, until ctx is canceled.
+func (c *catalog) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		blocking(ctx, "service catalog", func(ctx context.Context, index uint64) (uint64, error) {
+			services := map[string][]string{}
+			i, err := c.client.get(ctx, "/v1/catalog/services", c.params(), index, &services)
+			if err != nil {
+				return 0, err
+			}
+			c.updateServices(services, i)
+			return i, nil
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		blocking(ctx, "health checks", func(ctx context.Context, index uint64) (uint64, error) {
+			var checks []healthCheck
+			i, err := c.client.get(ctx, "/v1/health/state/any", c.params(), index, &checks)
+			if err != nil {
+				return 0, err
+			}
+			c.updateChecks(checks, i)
+			return i, nil
+		})
+	}()
+
+	c.refreshServices(ctx)
+	wg.Wait()
+}
+
+// updateServices sets the services in the catalog. When the catalog changed all services are queried again,
+// because the list doesn't tell which services were changed.
+func (c *catalog) updateServices(services map[string][]string, index uint64) {
+	c.Lock()
+	defer c.Unlock()
+
+	for name := range c.known {
+		if _, ok := services[name]; ok {
+			continue
+		}
+		delete(c.known, name)
+		delete(c.services, name)
+		delete(c.serviceNodes, name)
+		delete(c.dirty, name)
+	}
+	for name := range services {
+		c.known[name] = struct{}{}
+		if index != c.listIndex {
+			c.dirty[name] = struct{}{}
+		} else if _, ok := c.services[name]; !ok {
+			c.dirty[name] = struct{}{}
+		}
+	}
+	c.listed = true
+	c.listIndex = index
+	if index > c.index {
+		c.index = index
+	}
+	c.updateNodes()
+	c.signal()
+}
+
+// updateChecks sets the health checks. The services with a changed health check, and the services with an
+// instance on a node with a changed node check, are queried again.
+func (c *catalog) updateChecks(list []healthCheck, index uint64) {
+	checks := make(map[string]healthCheck, len(list))
+	for _, hc := range list {
+		checks[hc.Node+"/"+hc.CheckID] = hc
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	changed := func(hc healthCheck) {
+		if hc.ServiceName != "" {
+			if _, ok := c.known[hc.ServiceName]; ok {
+				c.dirty[hc.ServiceName] = struct{}{}
+			}
+			return
+		}
+		for name, nodes := range c.serviceNodes {
+			for _, n := range nodes {
+				if n == hc.Node {
+					c.dirty[name] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+	for key, hc := range checks {
+		if old, ok := c.checks[key]; !ok || old != hc {
+			changed(hc)
+			if ok && old.ServiceName != hc.ServiceName {
+				changed(old)
+			}
+		}
+	}
+	for key, old := range c.checks {
+		if _, ok := checks[key]; !ok {
+			changed(old)
+		}
+	}
+
+	c.checks = checks
+	c.checked = true
+	if index > c.index {
+		c.index = index
+	}
+	c.signal()
+}
+
+// signal wakes up refreshServices. The lock must be held.
+func (c *catalog) signal() {
+	if len(c.dirty) == 0 {
+		return
+	}
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// refreshServices queries the instances of the dirty services, until ctx is canceled. Services that fail are
+// retried with an exponential backoff.
+func (c *catalog) refreshServices(ctx context.Context) {
+	backoff := backoffMin
+	for {
+		select {
+		case <-c.refresh:
+		case <-ctx.Done():
+			return
+		}
+
+		c.Lock()
+		names := make([]string, 0, len(c.dirty))
+		for name := range c.dirty {
+			names = append(names, name)
+		}
+		c.dirty = make(map[string]struct{})
+		c.Unlock()
+
+		if failed := c.fetchServices(ctx, names); failed == 0 {
+			backoff = backoffMin
+			continue
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+		if backoff > backoffMax {
+			backoff = backoffMax
+		}
+		c.Lock()
+		c.signal()
+		c.Unlock()
+	}
+}
+
+// fetchServices queries the instances of the services in names with at most fetchWorkers concurrent queries. It
+// returns the number of services that failed, these are marked dirty again.
+func (c *catalog) fetchServices(ctx context.Context, names []string) int {
+	var (
+		wg     sync.WaitGroup
+		failed int32
+	)
+	queue := make(chan string)
+	for w := 0; w < fetchWorkers && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queue {
+				if err := c.fetchService(ctx, name); err != nil {
+					if ctx.Err() == nil {
+						log.Warningf("Failed to query service %s: %s", name, err)
+					}
+					atomic.AddInt32(&failed, 1)
+					c.Lock()
+					if _, ok := c.known[name]; ok {
+						c.dirty[name] = struct{}{}
+					}
+					c.Unlock()
+				}
+			}
+		}()
+	}
+	for _, name := range names {
+		queue <- name
+	}
+	close(queue)
+	wg.Wait()
+	return int(failed)
+}
+
+// fetchService queries the instances of service.
+func (c *catalog) fetchService(ctx context.Context, service string) error {
+	var entries []healthEntry
+	i, err := c.client.get(ctx, "/v1/health/service/"+url.PathEscape(service), c.params(), 0, &entries)
+	if err != nil {
+		return err
+	}
+	c.updateInstances(service, entries, i)
+	return nil
+}
+
+// updateInstances sets the healthy instances of service.
+func (c *catalog) updateInstances(service string, entries []healthEntry, index uint64) {
+	instances := make([]instance, 0, len(entries))
+	nodes := make([]string, 0, len(entries))
+	for _, e := range entries {
+		nodes = append(nodes, e.Node.Node)
+		if !e.passing() {
+			continue
+		}
+		i := instance{Node: e.Node.Node, NodeAddress: e.Node.Address, Address: e.Service.Address, Port: e.Service.Port, Tags: e.Service.Tags}
+		if i.Address == "" {
+			i.Address = e.Node.Address
+		}
+		instances = append(instances, i)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.known[service]; !ok {
+		// The service was removed while we were querying it.
+		return
+	}
+	c.services[service] = instances
+	c.serviceNodes[service] = nodes
+	if index > c.index {
+		c.index = index
+	}
+	c.updateNodes()
+}
+
+// updateNodes rebuilds the node addresses from the instances. The lock must be held.
+func (c *catalog) updateNodes() {
+	nodes := make(map[string]string)
+	for _, instances := range c.services {
+		for _, i := range instances {
+			nodes[i.Node] = i.NodeAddress
+		}
+	}
+	c.nodes = nodes
+}
+
+// instances returns the healthy instances of service, with tag if it's not empty. It returns false if the service
+// doesn't exist.
+func (c *catalog) instances(service, tag string) ([]instance, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	instances, ok := c.services[service]
+	if !ok {
+		return nil, false
+	}
+	if tag == "" {
+		return instances, true
+	}
+	var tagged []instance
+	for _, i := range instances {
+		if i.hasTag(tag) {
+			tagged = append(tagged, i)
+		}
+	}
+	return tagged, true
+}
+
+// node returns the address of node, it returns false if there is no healthy instance on node.
+func (c *catalog) node(name string) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	addr, ok := c.nodes[name]
+	return addr, ok
+}
+
+// hasSynced returns true if the catalog, the health checks and all services have been queried.
+func (c *catalog) hasSynced() bool {
+	c.RLock()
+	defer c.RUnlock()
+	if !c.listed || !c.checked {
+		return false
+	}
+	for name := range c.known {
+		if _, ok := c.services[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// serial returns the highest index seen.
+func (c *catalog) serial() uint32 {
+	c.RLock()
+	defer c.RUnlock()
+	return uint32(c.index)
+}
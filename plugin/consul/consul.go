@@ -0,0 +1,199 @@
+// Package consul implements a plugin that serves the healthy service instances of the Consul catalog.
+package consul
+
+import (
+	"context"
+	"errors"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/upstream"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultTTL = 30
+	priority   = 1 // priority of all SRV records, just like Consul's own DNS interface
+)
+
+var errNoItems = errors.New("no items found")
+
+// Consul is a plugin that serves the service instances of the Consul catalog that pass their health checks.
+type Consul struct {
+	Next     plugin.Handler
+	Zones    []string
+	Fall     fall.F
+	Upstream *upstream.Upstream
+
+	ttl uint32
+	// catalogs holds a catalog per datacenter, the default datacenter is used for names without a datacenter.
+	catalogs  map[string]*catalog
+	defaultDC string
+
+	cancel context.CancelFunc
+}
+
+// New returns a Consul that serves the catalog of the default datacenter with c.
+func New(zones []string, c *client, datacenters []string) *Consul {
+	co := &Consul{Zones: zones, ttl: defaultTTL, catalogs: make(map[string]*catalog)}
+	if len(datacenters) == 0 {
+		// The datacenter of the agent.
+		datacenters = []string{""}
+	}
+	co.defaultDC = datacenters[0]
+	for _, dc := range datacenters {
+		co.catalogs[dc] = newCatalog(dc, c)
+	}
+	return co
+}
+
+// Run starts watching the catalogs.
+func (co *Consul) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	co.cancel = cancel
+	for _, c := range co.catalogs {
+		go c.run(ctx)
+	}
+}
+
+// Stop stops watching the catalogs.
+func (co *Consul) Stop() error {
+	if co.cancel != nil {
+		co.cancel()
+	}
+	return nil
+}
+
+// HasSynced returns true if all catalogs have been synced.
+func (co *Consul) HasSynced() bool {
+	for _, c := range co.catalogs {
+		if !c.hasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// catalog returns the catalog for the datacenter dc, or the default one if dc is empty.
+func (co *Consul) catalog(dc string) (*catalog, bool) {
+	if dc == "" {
+		dc = co.defaultDC
+	}
+	c, ok := co.catalogs[dc]
+	return c, ok
+}
+
+// Services implements the ServiceBackend interface.
+func (co *Consul) Services(ctx context.Context, state request.Request, exact bool, opt plugin.Options) ([]msg.Service, error) {
+	return co.Records(ctx, state, exact)
+}
+
+// Reverse implements the ServiceBackend interface, reverse lookups are not supported.
+func (co *Consul) Reverse(ctx context.Context, state request.Request, exact bool, opt plugin.Options) ([]msg.Service, error) {
+	return nil, errNoItems
+}
+
+// Lookup implements the ServiceBackend interface.
+func (co *Consul) Lookup(ctx context.Context, state request.Request, name string, typ uint16) (*dns.Msg, error) {
+	return co.Upstream.Lookup(ctx, state, name, typ)
+}
+
+// IsNameError implements the ServiceBackend interface.
+func (co *Consul) IsNameError(err error) bool {
+	return err == errNoItems || err == errInvalidRequest
+}
+
+// Records implements the ServiceBackend interface. It returns the healthy instances of the service, or the address
+// of the node, in the query.
+func (co *Consul) Records(ctx context.Context, state request.Request, exact bool) ([]msg.Service, error) {
+	zone := plugin.Zones(co.Zones).Matches(state.Name())
+	if zone == "" {
+		return nil, errNoItems
+	}
+	if state.Name() == zone || co.emptyNonTerminal(state.Name(), zone) {
+		// The apex exists, but has no records.
+		return nil, nil
+	}
+	r, err := parseRequest(state.Name(), zone)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := co.catalog(r.datacenter)
+	if !ok {
+		return nil, errNoItems
+	}
+
+	if r.kind == kindNode {
+		addr, ok := c.node(r.node)
+		if !ok {
+			return nil, errNoItems
+		}
+		return []msg.Service{{Host: addr, TTL: co.ttl, Key: co.nodeKey(r.node, r.datacenter, zone)}}, nil
+	}
+
+	instances, ok := c.instances(r.service, r.tag)
+	if !ok || len(instances) == 0 {
+		return nil, errNoItems
+	}
+	services := make([]msg.Service, 0, len(instances))
+	for _, i := range instances {
+		services = append(services, msg.Service{
+			Host:     i.Address,
+			Port:     i.Port,
+			Priority: priority,
+			TTL:      co.ttl,
+			// The key determines the target of SRV records, this is the name of the node.
+			Key: co.nodeKey(i.Node, r.datacenter, zone),
+		})
+	}
+	return services, nil
+}
+
+// emptyNonTerminal returns true if name is one of the names between the zone and the names of the services and
+// nodes, e.g. service.<zone> or <datacenter>.<zone>. These exist, but have no records.
+func (co *Consul) emptyNonTerminal(name, zone string) bool {
+	base, err := dnsutil.TrimZone(name, zone)
+	if err != nil || base == "" {
+		return false
+	}
+	labels := dns.SplitDomainName(base)
+	kind := func(l string) bool { return l == kindService || l == kindNode }
+	dc := func(l string) bool {
+		_, ok := co.catalogs[l]
+		return ok && l != ""
+	}
+	switch len(labels) {
+	case 1:
+		return kind(labels[0]) || dc(labels[0])
+	case 2:
+		return kind(labels[0]) && dc(labels[1])
+	}
+	return false
+}
+
+// nodeKey returns the etcd style key for the name of node.
+func (co *Consul) nodeKey(node, dc, zone string) string {
+	name := dnsutil.Join(node, kindNode, zone)
+	if dc != "" {
+		name = dnsutil.Join(node, kindNode, dc, zone)
+	}
+	return msg.Path(name, "coredns")
+}
+
+// Serial implements the ServiceBackend interface, the serial is the highest index seen in the catalog.
+func (co *Consul) Serial(state request.Request) uint32 {
+	var serial uint32
+	for _, c := range co.catalogs {
+		if s := c.serial(); s > serial {
+			serial = s
+		}
+	}
+	return serial
+}
+
+// MinTTL implements the ServiceBackend interface.
+func (co *Consul) MinTTL(state request.Request) uint32 { return co.ttl }
@@ -0,0 +1,348 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// standIn is a minimal stand-in for the HTTP API of a Consul agent, that supports blocking queries.
+type standIn struct {
+	sync.Mutex
+	index   uint64
+	changed chan struct{}
+	// datacenter to service to its instances
+	catalog map[string]map[string][]standInInstance
+}
+
+type standInInstance struct {
+	node, nodeAddr, addr string
+	port                 int
+	tags                 []string
+	passing              bool
+}
+
+func (i standInInstance) status() string {
+	if i.passing {
+		return "passing"
+	}
+	return "critical"
+}
+
+func newStandIn() *standIn {
+	return &standIn{index: 1, changed: make(chan struct{}), catalog: make(map[string]map[string][]standInInstance)}
+}
+
+func (s *standIn) set(dc, service string, instances ...standInInstance) {
+	s.Lock()
+	defer s.Unlock()
+	if s.catalog[dc] == nil {
+		s.catalog[dc] = make(map[string][]standInInstance)
+	}
+	if instances == nil {
+		delete(s.catalog[dc], service)
+	} else {
+		s.catalog[dc][service] = instances
+	}
+	s.index++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dc := q.Get("dc")
+	if dc == "" {
+		dc = "dc1"
+	}
+	index, _ := strconv.ParseUint(q.Get("index"), 10, 64)
+	wait, _ := time.ParseDuration(q.Get("wait"))
+
+	s.Lock()
+	if index >= s.index {
+		changed := s.changed
+		s.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+		s.Lock()
+	}
+	defer s.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(s.index, 10))
+	switch {
+	case r.URL.Path == "/v1/catalog/services":
+		services := map[string][]string{}
+		for name := range s.catalog[dc] {
+			services[name] = []string{}
+		}
+		json.NewEncoder(w).Encode(services)
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		name := strings.TrimPrefix(r.URL.Path, "/v1/health/service/")
+		entries := []healthEntry{}
+		for _, i := range s.catalog[dc][name] {
+			if q.Get("passing") != "" && !i.passing {
+				continue
+			}
+			e := healthEntry{}
+			e.Node.Node, e.Node.Address = i.node, i.nodeAddr
+			e.Service.Address, e.Service.Port, e.Service.Tags = i.addr, i.port, i.tags
+			e.Checks = append(e.Checks, struct{ Status string }{i.status()})
+			entries = append(entries, e)
+		}
+		json.NewEncoder(w).Encode(entries)
+	case r.URL.Path == "/v1/health/state/any":
+		checks := []healthCheck{}
+		for name, instances := range s.catalog[dc] {
+			for _, i := range instances {
+				checks = append(checks, healthCheck{Node: i.node, CheckID: "service:" + name, Status: i.status(), ServiceName: name})
+			}
+		}
+		json.NewEncoder(w).Encode(checks)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestConsul(t *testing.T, s *standIn, datacenters ...string) *Consul {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	co := New([]string{"consul.local."}, &client{address: srv.URL, http: srv.Client(), wait: 100 * time.Millisecond}, datacenters)
+	co.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	co.Run()
+	t.Cleanup(func() { co.Stop() })
+	waitFor(t, co.HasSynced)
+	return co
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the catalog")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func testStandIn() *standIn {
+	s := newStandIn()
+	s.set("dc1", "web",
+		standInInstance{node: "node1", nodeAddr: "10.0.0.1", port: 8080, tags: []string{"v1", "primary"}, passing: true},
+		standInInstance{node: "node2", nodeAddr: "10.0.0.2", addr: "10.0.1.2", port: 8081, tags: []string{"v2"}, passing: true},
+		standInInstance{node: "node3", nodeAddr: "10.0.0.3", port: 8080, tags: []string{"v1"}, passing: false},
+	)
+	s.set("dc1", "db", standInInstance{node: "node4", nodeAddr: "fd00::4", port: 5432, passing: true})
+	s.set("dc1", "down", standInInstance{node: "node5", nodeAddr: "10.0.0.5", port: 80, passing: false})
+	s.set("dc2", "web", standInInstance{node: "node9", nodeAddr: "10.2.0.9", port: 8080, passing: true})
+	return s
+}
+
+var consulCases = []test.Case{
+	{
+		Qname: "web.service.consul.local.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("web.service.consul.local.	30	IN	A	10.0.0.1"),
+			test.A("web.service.consul.local.	30	IN	A	10.0.1.2"),
+		},
+	},
+	{
+		Qname: "v1.web.service.consul.local.", Qtype: dns.TypeA,
+		Answer: []dns.RR{test.A("v1.web.service.consul.local.	30	IN	A	10.0.0.1")},
+	},
+	{
+		Qname: "_web._v2.service.consul.local.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{test.SRV("_web._v2.service.consul.local.	30	IN	SRV	1 100 8081 node2.node.consul.local.")},
+		Extra:  []dns.RR{test.A("node2.node.consul.local.	30	IN	A	10.0.1.2")},
+	},
+	{
+		Qname: "web.service.dc2.consul.local.", Qtype: dns.TypeA,
+		Answer: []dns.RR{test.A("web.service.dc2.consul.local.	30	IN	A	10.2.0.9")},
+	},
+	{
+		Qname: "db.service.consul.local.", Qtype: dns.TypeAAAA,
+		Answer: []dns.RR{test.AAAA("db.service.consul.local.	30	IN	AAAA	fd00::4")},
+	},
+	{
+		Qname: "node2.node.consul.local.", Qtype: dns.TypeA,
+		Answer: []dns.RR{test.A("node2.node.consul.local.	30	IN	A	10.0.0.2")},
+	},
+	// NODATA
+	{
+		Qname: "db.service.consul.local.", Qtype: dns.TypeA,
+		Ns: []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	// Empty non-terminals
+	{
+		Qname: "service.consul.local.", Qtype: dns.TypeA,
+		Ns: []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "node.consul.local.", Qtype: dns.TypeA,
+		Ns: []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "dc2.consul.local.", Qtype: dns.TypeA,
+		Ns: []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "service.dc2.consul.local.", Qtype: dns.TypeSRV,
+		Ns: []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	// Only failing instances
+	{
+		Qname: "down.service.consul.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "v3.web.service.consul.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "dc3.consul.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "web.service.dc3.consul.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+	{
+		Qname: "node3.node.consul.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{test.SOA("consul.local.	30	IN	SOA	ns.dns.consul.local. hostmaster.consul.local. 0 7200 1800 86400 30")},
+	},
+}
+
+func TestServeDNS(t *testing.T) {
+	co := newTestConsul(t, testStandIn(), "dc1", "dc2")
+
+	for i, tc := range consulCases {
+		m := tc.Msg()
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := co.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		for _, rr := range w.Msg.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				soa.Serial = 0
+			}
+		}
+		if err := test.SortAndCheck(w.Msg, tc); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s := testStandIn()
+	co := newTestConsul(t, s)
+
+	lookup := func(name string) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, dns.TypeA)
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		co.ServeDNS(context.TODO(), w, m)
+		return w.Msg
+	}
+
+	serial := co.Serial(request.Request{})
+	// A new service is picked up.
+	s.set("dc1", "api", standInInstance{node: "node6", nodeAddr: "10.0.0.6", port: 80, passing: true})
+	waitFor(t, func() bool { return len(lookup("api.service.consul.local.").Answer) == 1 })
+
+	// An instance that starts passing its health checks is added.
+	s.set("dc1", "down", standInInstance{node: "node5", nodeAddr: "10.0.0.5", port: 80, passing: true})
+	waitFor(t, func() bool { return len(lookup("down.service.consul.local.").Answer) == 1 })
+
+	// A removed service is gone.
+	s.set("dc1", "web")
+	waitFor(t, func() bool { return lookup("web.service.consul.local.").Rcode == dns.RcodeNameError })
+
+	if co.Serial(request.Request{}) <= serial {
+		t.Errorf("Expected the serial to increase")
+	}
+}
+
+func TestUpdateChecks(t *testing.T) {
+	c := newCatalog("", nil)
+	c.updateServices(map[string][]string{"web": nil, "db": nil, "api": nil}, 2)
+	c.updateInstances("web", nil, 2)
+	c.updateInstances("db", nil, 2)
+	c.updateInstances("api", nil, 2)
+	c.serviceNodes["web"] = []string{"node1", "node2"}
+	c.serviceNodes["db"] = []string{"node3"}
+	c.serviceNodes["api"] = []string{"node2"}
+	c.updateChecks([]healthCheck{
+		{Node: "node1", CheckID: "serfHealth", Status: "passing"},
+		{Node: "node2", CheckID: "serfHealth", Status: "passing"},
+		{Node: "node3", CheckID: "serfHealth", Status: "passing"},
+		{Node: "node3", CheckID: "service:db", Status: "passing", ServiceName: "db"},
+	}, 3)
+
+	tests := []struct {
+		checks []healthCheck
+		dirty  []string
+	}{
+		// A failing service check only affects its service.
+		{[]healthCheck{
+			{Node: "node1", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node2", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node3", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node3", CheckID: "service:db", Status: "critical", ServiceName: "db"},
+		}, []string{"db"}},
+		// A failing node affects all services with an instance on it.
+		{[]healthCheck{
+			{Node: "node1", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node2", CheckID: "serfHealth", Status: "critical"},
+			{Node: "node3", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node3", CheckID: "service:db", Status: "critical", ServiceName: "db"},
+		}, []string{"api", "web"}},
+		// A removed check is a change as well.
+		{[]healthCheck{
+			{Node: "node1", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node2", CheckID: "serfHealth", Status: "critical"},
+			{Node: "node3", CheckID: "serfHealth", Status: "passing"},
+		}, []string{"db"}},
+		// Nothing changed.
+		{[]healthCheck{
+			{Node: "node1", CheckID: "serfHealth", Status: "passing"},
+			{Node: "node2", CheckID: "serfHealth", Status: "critical"},
+			{Node: "node3", CheckID: "serfHealth", Status: "passing"},
+		}, []string{}},
+	}
+	for i, tc := range tests {
+		c.dirty = make(map[string]struct{})
+		c.updateChecks(tc.checks, uint64(4+i))
+		dirty := []string{}
+		for name := range c.dirty {
+			dirty = append(dirty, name)
+		}
+		sort.Strings(dirty)
+		if strings.Join(dirty, " ") != strings.Join(tc.dirty, " ") {
+			t.Errorf("Test %d: expected dirty services %v, got %v", i, tc.dirty, dirty)
+		}
+	}
+}
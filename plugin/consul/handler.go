@@ -0,0 +1,72 @@
+package consul
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// ServeDNS implements the plugin.Handler interface.
+func (co *Consul) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	opt := plugin.Options{}
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(co.Zones).Matches(state.Name())
+	if zone == "" {
+		return plugin.NextOrFailure(co.Name(), co.Next, ctx, w, r)
+	}
+
+	var (
+		records, extra []dns.RR
+		truncated      bool
+		err            error
+	)
+
+	switch state.QType() {
+	case dns.TypeA:
+		records, truncated, err = plugin.A(ctx, co, zone, state, nil, opt)
+	case dns.TypeAAAA:
+		records, truncated, err = plugin.AAAA(ctx, co, zone, state, nil, opt)
+	case dns.TypeSRV:
+		records, extra, err = plugin.SRV(ctx, co, zone, state, opt)
+	case dns.TypeSOA:
+		if state.Name() == zone {
+			records, err = plugin.SOA(ctx, co, zone, state, opt)
+			break
+		}
+		fallthrough
+	default:
+		// Do a fake A lookup, so we can distinguish between NODATA and NXDOMAIN
+		_, _, err = plugin.A(ctx, co, zone, state, nil, opt)
+	}
+	if err != nil && co.IsNameError(err) {
+		if co.Fall.Through(state.Name()) {
+			return plugin.NextOrFailure(co.Name(), co.Next, ctx, w, r)
+		}
+		// Make err nil when returning here, so we don't log spam for NXDOMAIN.
+		return plugin.BackendError(ctx, co, zone, dns.RcodeNameError, state, nil /* err */, opt)
+	}
+	if err != nil {
+		return plugin.BackendError(ctx, co, zone, dns.RcodeServerFailure, state, err, opt)
+	}
+
+	if len(records) == 0 {
+		return plugin.BackendError(ctx, co, zone, dns.RcodeSuccess, state, err, opt)
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Truncated = truncated
+	m.Authoritative = true
+	m.Answer = append(m.Answer, records...)
+	m.Extra = append(m.Extra, extra...)
+
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// Name implements the Handler interface.
+func (co *Consul) Name() string { return "consul" }
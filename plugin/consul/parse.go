@@ -0,0 +1,83 @@
+package consul
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	kindService = "service"
+	kindNode    = "node"
+)
+
+var errInvalidRequest = errors.New("invalid query name")
+
+// recordRequest is a parsed query name, this is one of:
+//
+//	[tag.]<service>.service[.<datacenter>].<zone>
+//	_<service>._<tag>.service[.<datacenter>].<zone>
+//	<node>.node[.<datacenter>].<zone>
+//
+// For the RFC 2782 style name, a tag of "tcp" means no tag.
+type recordRequest struct {
+	kind       string
+	service    string
+	tag        string
+	node       string
+	datacenter string
+}
+
+// parseRequest parses name, which must be a subdomain of zone.
+func parseRequest(name, zone string) (recordRequest, error) {
+	r := recordRequest{}
+	if !dns.IsSubDomain(zone, name) {
+		return r, errInvalidRequest
+	}
+	base, err := dnsutil.TrimZone(name, zone)
+	if err != nil || base == "" {
+		return r, errInvalidRequest
+	}
+	labels := dns.SplitDomainName(base)
+
+	k := -1
+	for i, l := range labels {
+		if l == kindService || l == kindNode {
+			k = i
+			break
+		}
+	}
+	// The kind is preceded by one or two labels, and followed by at most the datacenter.
+	if k < 1 || k > 2 || len(labels)-k > 2 {
+		return r, errInvalidRequest
+	}
+	r.kind = labels[k]
+	if k+1 < len(labels) {
+		r.datacenter = labels[k+1]
+	}
+
+	if r.kind == kindNode {
+		if k != 1 {
+			return r, errInvalidRequest
+		}
+		r.node = labels[0]
+		return r, nil
+	}
+
+	if k == 1 {
+		r.service = labels[0]
+		return r, nil
+	}
+	if strings.HasPrefix(labels[0], "_") && strings.HasPrefix(labels[1], "_") {
+		r.service = labels[0][1:]
+		if tag := labels[1][1:]; tag != "tcp" {
+			r.tag = tag
+		}
+		return r, nil
+	}
+	r.tag, r.service = labels[0], labels[1]
+	return r, nil
+}
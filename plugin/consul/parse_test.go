@@ -0,0 +1,44 @@
+package consul
+
+import "testing"
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected recordRequest
+		err      bool
+	}{
+		{name: "web.service.consul.", expected: recordRequest{kind: kindService, service: "web"}},
+		{name: "v1.web.service.consul.", expected: recordRequest{kind: kindService, service: "web", tag: "v1"}},
+		{name: "web.service.dc1.consul.", expected: recordRequest{kind: kindService, service: "web", datacenter: "dc1"}},
+		{name: "_web._tcp.service.consul.", expected: recordRequest{kind: kindService, service: "web"}},
+		{name: "_web._v1.service.dc1.consul.", expected: recordRequest{kind: kindService, service: "web", tag: "v1", datacenter: "dc1"}},
+		{name: "node1.node.consul.", expected: recordRequest{kind: kindNode, node: "node1"}},
+		{name: "node1.node.dc2.consul.", expected: recordRequest{kind: kindNode, node: "node1", datacenter: "dc2"}},
+		// errors
+		{name: "consul.", err: true},
+		{name: "service.consul.", err: true},
+		{name: "web.consul.", err: true},
+		{name: "a.b.web.service.consul.", err: true},
+		{name: "web.service.dc1.extra.consul.", err: true},
+		{name: "v1.node1.node.consul.", err: true},
+		{name: "web.service.example.org.", err: true},
+	}
+
+	for i, tc := range tests {
+		r, err := parseRequest(tc.name, "consul.")
+		if tc.err {
+			if err == nil {
+				t.Errorf("Test %d: expected an error for %s, got %+v", i, tc.name, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error for %s, got %s", i, tc.name, err)
+			continue
+		}
+		if r != tc.expected {
+			t.Errorf("Test %d: expected %+v, got %+v", i, tc.expected, r)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package consul
+
+// Ready implements the ready.Readiness interface.
+func (co *Consul) Ready() bool { return co.HasSynced() }
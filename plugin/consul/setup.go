@@ -0,0 +1,156 @@
+package consul
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	mwtls "github.com/coredns/coredns/plugin/pkg/tls"
+	"github.com/coredns/coredns/plugin/pkg/upstream"
+)
+
+const pluginName = "consul"
+
+var log = clog.NewWithPlugin(pluginName)
+
+func init() { plugin.Register(pluginName, setup) }
+
+func setup(c *caddy.Controller) error {
+	co, err := parse(c)
+	if err != nil {
+		return plugin.Error(pluginName, err)
+	}
+
+	c.OnStartup(func() error {
+		co.Run()
+
+		timeout := time.After(5 * time.Second)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if co.HasSynced() {
+					return nil
+				}
+			case <-timeout:
+				log.Warning("starting server with unsynced Consul catalog")
+				return nil
+			}
+		}
+	})
+	c.OnShutdown(co.Stop)
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		co.Next = next
+		return co
+	})
+
+	return nil
+}
+
+const defaultAddress = "http://127.0.0.1:8500"
+
+func parse(c *caddy.Controller) (*Consul, error) {
+	config := dnsserver.GetConfig(c)
+	cl := &client{address: defaultAddress, wait: blockingWait}
+	var (
+		tlsConfig   *tls.Config
+		datacenters []string
+		zones       []string
+		ttl         uint32 = defaultTTL
+		f           fall.F
+	)
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+		for c.NextBlock() {
+			switch c.Val() {
+			case "address":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				u, err := url.Parse(c.Val())
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return nil, c.Errf("invalid address %q", c.Val())
+				}
+				cl.address = strings.TrimSuffix(c.Val(), "/")
+				if c.NextArg() {
+					return nil, c.ArgErr()
+				}
+			case "token":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				cl.token = c.Val()
+				if c.NextArg() {
+					return nil, c.ArgErr()
+				}
+			case "datacenters":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, dc := range args {
+					datacenters = append(datacenters, strings.ToLower(dc))
+				}
+			case "tls": // cert key cacertfile
+				args := c.RemainingArgs()
+				for i := range args {
+					if !filepath.IsAbs(args[i]) && config.Root != "" {
+						args[i] = filepath.Join(config.Root, args[i])
+					}
+				}
+				var err error
+				tlsConfig, err = mwtls.NewTLSConfigFromArgs(args...)
+				if err != nil {
+					return nil, err
+				}
+			case "ttl":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				t, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, err
+				}
+				if t < 0 || t > 3600 {
+					return nil, c.Errf("ttl must be in range [0, 3600]: %d", t)
+				}
+				ttl = uint32(t)
+			case "fallthrough":
+				f.SetZonesFromArgs(c.RemainingArgs())
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+
+	// The blocking queries wait for up to blockingWait, plus the jitter Consul adds to it.
+	cl.http = &http.Client{Timeout: blockingWait + blockingWait/16 + 10*time.Second}
+	if tlsConfig != nil {
+		cl.http.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	co := New(zones, cl, datacenters)
+	co.ttl = ttl
+	co.Fall = f
+	co.Upstream = upstream.New()
+	return co, nil
+}
@@ -0,0 +1,83 @@
+package consul
+
+import (
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input               string
+		shouldErr           bool
+		expectedAddress     string
+		expectedToken       string
+		expectedDatacenters []string
+		expectedTTL         uint32
+	}{
+		{`consul`, false, defaultAddress, "", []string{""}, defaultTTL},
+		{`consul consul.local {
+			address https://consul.example.org:8501/
+			token secret
+			datacenters DC1 dc2
+			ttl 10
+			fallthrough
+		}`, false, "https://consul.example.org:8501", "secret", []string{"dc1", "dc2"}, 10},
+		// fails
+		{`consul {
+			address consul.example.org
+		}`, true, "", "", nil, 0},
+		{`consul {
+			token
+		}`, true, "", "", nil, 0},
+		{`consul {
+			datacenters
+		}`, true, "", "", nil, 0},
+		{`consul {
+			ttl -1
+		}`, true, "", "", nil, 0},
+		{`consul {
+			blah
+		}`, true, "", "", nil, 0},
+		{`consul
+		consul`, true, "", "", nil, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		co, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if co.ttl != test.expectedTTL {
+			t.Errorf("Test %d: expected ttl %d, got %d", i, test.expectedTTL, co.ttl)
+		}
+		if co.defaultDC != test.expectedDatacenters[0] {
+			t.Errorf("Test %d: expected default datacenter %q, got %q", i, test.expectedDatacenters[0], co.defaultDC)
+		}
+		if len(co.catalogs) != len(test.expectedDatacenters) {
+			t.Errorf("Test %d: expected %d datacenters, got %d", i, len(test.expectedDatacenters), len(co.catalogs))
+		}
+		for _, dc := range test.expectedDatacenters {
+			c, ok := co.catalogs[dc]
+			if !ok {
+				t.Errorf("Test %d: expected datacenter %q", i, dc)
+				continue
+			}
+			if c.client.address != test.expectedAddress {
+				t.Errorf("Test %d: expected address %q, got %q", i, test.expectedAddress, c.client.address)
+			}
+			if c.client.token != test.expectedToken {
+				t.Errorf("Test %d: expected token %q, got %q", i, test.expectedToken, c.client.token)
+			}
+		}
+	}
+}
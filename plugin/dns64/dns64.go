@@ -25,19 +25,45 @@ type UpstreamInt interface {
 
 // DNS64 performs DNS64.
 type DNS64 struct {
-	Next         plugin.Handler
-	Prefix       *net.IPNet
+	Next   plugin.Handler
+	Prefix *net.IPNet // Used for clients that don't match any of ClientPrefixes, if nil they aren't translated.
+	// ClientPrefixes are the prefixes used for specific client networks, the first match wins.
+	ClientPrefixes []ClientPrefix
+	// Exclude holds the IPv4 networks that are never synthesized, and the IPv6 networks of AAAA records that are
+	// handled as if they don't exist. See RFC 6147 5.1.4.
+	Exclude      []*net.IPNet
 	TranslateAll bool // Not comply with 5.1.1
 	AllowIPv4    bool
 	Upstream     UpstreamInt
 }
 
+// ClientPrefix is a prefix that is used for the clients in Clients.
+type ClientPrefix struct {
+	Prefix  *net.IPNet
+	Clients []*net.IPNet
+}
+
 // ServeDNS implements the plugin.Handler interface.
 func (d *DNS64) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	if state.QType() == dns.TypePTR && state.QClass() == dns.ClassINET {
+		if addr := d.reverse(state.Name()); addr != nil {
+			return d.serveReverse(ctx, state, addr)
+		}
+	}
+
 	// Don't proxy if we don't need to.
-	if !d.requestShouldIntercept(&request.Request{W: w, Req: r}) {
+	if !d.requestShouldIntercept(&state) {
 		return plugin.NextOrFailure(d.Name(), d.Next, ctx, w, r)
 	}
+	prefix := d.prefixFor(net.ParseIP(state.IP()))
+	if prefix == nil {
+		return plugin.NextOrFailure(d.Name(), d.Next, ctx, w, r)
+	}
+	if state.Name() == ipv4only {
+		w.WriteMsg(d.discovery(r, prefix))
+		return dns.RcodeSuccess, nil
+	}
 
 	// Pass the request to the next plugin in the chain, but intercept the response.
 	nw := nonwriter.New(w)
@@ -105,29 +131,79 @@ func (d *DNS64) responseShouldDNS64(origResponse *dns.Msg) bool {
 		return true
 	}
 
-	// if response includes AAAA record, no need to rewrite
+	// if response includes AAAA record, no need to rewrite. Excluded AAAA records are handled as if they
+	// don't exist, see RFC 6147 5.1.4.
 	for _, rr := range origResponse.Answer {
-		if rr.Header().Rrtype == dns.TypeAAAA {
+		if aaaa, ok := rr.(*dns.AAAA); ok && !d.excluded(aaaa.AAAA, true) {
 			return false
 		}
 	}
 	return true
 }
 
+// prefixFor returns the prefix used for client, or nil if client's requests aren't translated.
+func (d *DNS64) prefixFor(client net.IP) *net.IPNet {
+	for _, p := range d.ClientPrefixes {
+		for _, n := range p.Clients {
+			if n.Contains(client) {
+				return p.Prefix
+			}
+		}
+	}
+	return d.Prefix
+}
+
+// excluded returns true if ip is in one of the excluded networks of its family. IPv4-mapped IPv6 addresses, as found
+// in AAAA records, are matched against the IPv6 networks only.
+func (d *DNS64) excluded(ip net.IP, v6 bool) bool {
+	for _, n := range d.Exclude {
+		if (len(n.Mask) == net.IPv6len) != v6 {
+			continue
+		}
+		if !v6 {
+			if n.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		// net.IPNet.Contains handles ::ffff:0:0/96 as 0.0.0.0/0, so compare the IPv6 addresses bytewise.
+		ip16 := ip.To16()
+		contains := true
+		for i := range ip16 {
+			if ip16[i]&n.Mask[i] != n.IP[i] {
+				contains = false
+				break
+			}
+		}
+		if contains {
+			return true
+		}
+	}
+	return false
+}
+
 // DoDNS64 takes an (empty) response to an AAAA question, issues the A request,
 // and synthesizes the answer. Returns the response message, or error on internal failure.
 func (d *DNS64) DoDNS64(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, origResponse *dns.Msg) (*dns.Msg, error) {
-	req := request.Request{W: w, Req: r} // req is unused
+	req := request.Request{W: w, Req: r}
+	prefix := d.prefixFor(net.ParseIP(req.IP()))
+	if prefix == nil {
+		return nil, errors.New("no prefix for client " + req.IP())
+	}
 	resp, err := d.Upstream.Lookup(ctx, req, req.Name(), dns.TypeA)
 	if err != nil {
 		return nil, err
 	}
-	out := d.Synthesize(r, origResponse, resp)
+	out := d.synthesize(prefix, r, origResponse, resp)
 	return out, nil
 }
 
-// Synthesize merges the AAAA response and the records from the A response
+// Synthesize merges the AAAA response and the records from the A response, using the default prefix.
 func (d *DNS64) Synthesize(origReq, origResponse, resp *dns.Msg) *dns.Msg {
+	return d.synthesize(d.Prefix, origReq, origResponse, resp)
+}
+
+func (d *DNS64) synthesize(prefix *net.IPNet, origReq, origResponse, resp *dns.Msg) *dns.Msg {
 	ret := dns.Msg{}
 	ret.SetReply(origReq)
 
@@ -157,7 +233,11 @@ func (d *DNS64) Synthesize(origReq, origResponse, resp *dns.Msg) *dns.Msg {
 			continue
 		}
 
-		aaaa, _ := to6(d.Prefix, rr.(*dns.A).A)
+		// Addresses in excluded networks are never synthesized.
+		if d.excluded(rr.(*dns.A).A, false) {
+			continue
+		}
+		aaaa, _ := to6(prefix, rr.(*dns.A).A)
 
 		// ttl is min of SOA TTL and A TTL
 		ttl := SOATtl
@@ -206,3 +286,22 @@ func to6(prefix *net.IPNet, addr net.IP) (net.IP, error) {
 
 	return v6, nil
 }
+
+// from6 returns the IPv4 address embedded in the IPv6 address addr according to RFC 6052. It's the reverse of to6.
+func from6(prefix *net.IPNet, addr net.IP) net.IP {
+	n, _ := prefix.Mask.Size()
+	v4 := make(net.IP, net.IPv4len)
+	i, j := n/8, 0
+
+	for ; i < 8 && j < 4; i, j = i+1, j+1 {
+		v4[j] = addr[i]
+	}
+	if i == 8 {
+		i++
+	}
+	for ; j < 4; i, j = i+1, j+1 {
+		v4[j] = addr[i]
+	}
+
+	return v4
+}
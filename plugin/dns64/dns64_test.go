@@ -554,3 +554,122 @@ func (fu *fakeUpstream) Lookup(_ context.Context, _ request.Request, name string
 
 	return fu.resp, nil
 }
+
+func TestFrom6(t *testing.T) {
+	for _, prefix := range []string{"64:ff9b::/96", "64:ff9b::/64", "64:ff9b::/56", "64::/32"} {
+		v6, _ := To6(prefix, "192.0.2.33")
+		_, pfx, _ := net.ParseCIDR(prefix)
+		if v4 := from6(pfx, v6); v4.String() != "192.0.2.33" {
+			t.Errorf("Expected 192.0.2.33 from %s in %s, got %s", v6, prefix, v4)
+		}
+	}
+}
+
+func TestDNS64Prefixes(t *testing.T) {
+	_, site1, _ := net.ParseCIDR("64:ff9b:1::/96")
+	_, site2, _ := net.ParseCIDR("64:ff9b:2::/96")
+	_, clients1, _ := net.ParseCIDR("2001:db8:1::/48")
+	_, clients2, _ := net.ParseCIDR("2001:db8:2::/48")
+	_, excluded, _ := net.ParseCIDR("192.0.2.43/32")
+	_, mapped, _ := net.ParseCIDR("::ffff:0:0/96")
+
+	tests := []struct {
+		client   string
+		qname    string
+		qtype    uint16
+		initResp []dns.RR
+		aResp    []dns.RR
+		expected []string
+	}{
+		{
+			client:   "2001:db8:1::1",
+			qname:    "example.com.",
+			qtype:    dns.TypeAAAA,
+			aResp:    []dns.RR{test.A("example.com. 60 IN A 192.0.2.42"), test.A("example.com. 60 IN A 192.0.2.43")},
+			expected: []string{"example.com.\t60\tIN\tAAAA\t64:ff9b:1::c000:22a"},
+		},
+		{
+			client:   "2001:db8:2::1",
+			qname:    "example.com.",
+			qtype:    dns.TypeAAAA,
+			aResp:    []dns.RR{test.A("example.com. 60 IN A 192.0.2.42")},
+			expected: []string{"example.com.\t60\tIN\tAAAA\t64:ff9b:2::c000:22a"},
+		},
+		// Excluded AAAA records are handled as if they don't exist.
+		{
+			client:   "2001:db8:2::1",
+			qname:    "example.com.",
+			qtype:    dns.TypeAAAA,
+			initResp: []dns.RR{test.AAAA("example.com. 60 IN AAAA ::ffff:192.0.2.42")},
+			aResp:    []dns.RR{test.A("example.com. 60 IN A 192.0.2.42")},
+			expected: []string{"example.com.\t60\tIN\tAAAA\t64:ff9b:2::c000:22a"},
+		},
+		// Clients without a prefix aren't translated.
+		{
+			client: "2001:db8:3::1",
+			qname:  "example.com.",
+			qtype:  dns.TypeAAAA,
+		},
+		{
+			client: "2001:db8:1::1",
+			qname:  "ipv4only.arpa.",
+			qtype:  dns.TypeAAAA,
+			expected: []string{
+				"ipv4only.arpa.\t600\tIN\tAAAA\t64:ff9b:1::c000:aa",
+				"ipv4only.arpa.\t600\tIN\tAAAA\t64:ff9b:1::c000:ab",
+			},
+		},
+		{
+			client:   "2001:db8:3::1",
+			qname:    "a.2.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa.",
+			qtype:    dns.TypePTR,
+			aResp:    []dns.RR{test.PTR("42.2.0.192.in-addr.arpa. 60 IN PTR example.com.")},
+			expected: []string{"a.2.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa.\t60\tIN\tPTR\texample.com."},
+		},
+	}
+
+	for i, tc := range tests {
+		d := DNS64{
+			Next: test.HandlerFunc(func(_ context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				m := new(dns.Msg)
+				m.SetReply(r)
+				m.Answer = tc.initResp
+				w.WriteMsg(m)
+				return dns.RcodeSuccess, nil
+			}),
+			ClientPrefixes: []ClientPrefix{
+				{Prefix: site1, Clients: []*net.IPNet{clients1}},
+				{Prefix: site2, Clients: []*net.IPNet{clients2}},
+			},
+			Exclude:  []*net.IPNet{excluded, mapped},
+			Upstream: &fakeAnyUpstream{tc.aResp},
+		}
+
+		req := new(dns.Msg)
+		req.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: tc.client})
+		if _, err := d.ServeDNS(context.Background(), rec, req); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		var got []string
+		for _, rr := range rec.Msg.Answer {
+			got = append(got, rr.String())
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+}
+
+// fakeAnyUpstream returns resp for any lookup.
+type fakeAnyUpstream struct {
+	resp []dns.RR
+}
+
+func (fu *fakeAnyUpstream) Lookup(_ context.Context, _ request.Request, name string, typ uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, typ)
+	m.Response = true
+	m.Answer = fu.resp
+	return m, nil
+}
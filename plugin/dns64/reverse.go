@@ -0,0 +1,81 @@
+package dns64
+
+import (
+	"context"
+	"net"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// ipv4only is the name used by clients to discover the prefix, see RFC 7050.
+const ipv4only = "ipv4only.arpa."
+
+// ipv4onlyAddrs are the well known addresses of ipv4only.arpa, see RFC 7050.
+var ipv4onlyAddrs = []net.IP{net.IPv4(192, 0, 0, 170), net.IPv4(192, 0, 0, 171)}
+
+// discovery returns the response to the AAAA query r for ipv4only.arpa. The well known addresses are synthesized
+// locally, so each client discovers the prefix it is served with.
+func (d *DNS64) discovery(r *dns.Msg, prefix *net.IPNet) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	for _, ip := range ipv4onlyAddrs {
+		aaaa, _ := to6(prefix, ip)
+		// 600 is the TTL of synthesized records when the SOA is unknown, see RFC 6147 5.1.7.
+		m.Answer = append(m.Answer, &dns.AAAA{
+			Hdr:  dns.RR_Header{Name: ipv4only, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 600},
+			AAAA: aaaa,
+		})
+	}
+	return m
+}
+
+// reverse returns the IPv4 address that is embedded in the ip6.arpa name, if the IPv6 address is in one of the
+// prefixes. It returns nil otherwise.
+func (d *DNS64) reverse(name string) net.IP {
+	ip := net.ParseIP(dnsutil.ExtractAddressFromReverse(name))
+	if ip == nil || ip.To4() != nil {
+		return nil
+	}
+
+	prefixes := make([]*net.IPNet, 0, len(d.ClientPrefixes)+1)
+	for _, p := range d.ClientPrefixes {
+		prefixes = append(prefixes, p.Prefix)
+	}
+	if d.Prefix != nil {
+		prefixes = append(prefixes, d.Prefix)
+	}
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return from6(p, ip)
+		}
+	}
+	return nil
+}
+
+// serveReverse answers the PTR query of a synthesized address with the PTR records of the IPv4 address addr, see
+// RFC 6147 5.3.1.
+func (d *DNS64) serveReverse(ctx context.Context, state request.Request, addr net.IP) (int, error) {
+	name, _ := dns.ReverseAddr(addr.String())
+	resp, err := d.Upstream.Lookup(ctx, state, name, dns.TypePTR)
+	if err != nil {
+		return dns.RcodeServerFailure, err
+	}
+
+	m := new(dns.Msg)
+	m.SetRcode(state.Req, resp.Rcode)
+	for _, rr := range resp.Answer {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+		ptr = dns.Copy(ptr).(*dns.PTR)
+		ptr.Hdr.Name = state.QName()
+		m.Answer = append(m.Answer, ptr)
+	}
+	state.W.WriteMsg(m)
+	return m.Rcode, nil
+}
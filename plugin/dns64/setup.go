@@ -2,6 +2,7 @@ package dns64
 
 import (
 	"net"
+	"strings"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
@@ -34,6 +35,8 @@ func dns64Parse(c *caddy.Controller) (*DNS64, error) {
 		Prefix:   defaultPref,
 	}
 
+	// The default prefix is only used if no prefix is configured at all, or one without client networks.
+	hasDefault, hasClients := false, false
 	for c.Next() {
 		args := c.RemainingArgs()
 		if len(args) == 1 {
@@ -43,24 +46,45 @@ func dns64Parse(c *caddy.Controller) (*DNS64, error) {
 				return nil, err
 			}
 			dns64.Prefix = pref
-			continue
+			hasDefault = true
 		}
-		if len(args) > 0 {
+		if len(args) > 1 {
 			return nil, c.ArgErr()
 		}
 
 		for c.NextBlock() {
 			switch c.Val() {
 			case "prefix":
-				if !c.NextArg() {
+				args := c.RemainingArgs()
+				if len(args) == 0 {
 					return nil, c.ArgErr()
 				}
-				pref, err := parsePrefix(c, c.Val())
+				pref, err := parsePrefix(c, args[0])
 
 				if err != nil {
 					return nil, err
 				}
-				dns64.Prefix = pref
+				if len(args) == 1 {
+					dns64.Prefix = pref
+					hasDefault = true
+					continue
+				}
+				clients, err := parseNetworks(c, args[1:])
+				if err != nil {
+					return nil, err
+				}
+				dns64.ClientPrefixes = append(dns64.ClientPrefixes, ClientPrefix{Prefix: pref, Clients: clients})
+				hasClients = true
+			case "exclude":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				nets, err := parseNetworks(c, args)
+				if err != nil {
+					return nil, err
+				}
+				dns64.Exclude = append(dns64.Exclude, nets...)
 			case "translate_all":
 				dns64.TranslateAll = true
 			case "allow_ipv4":
@@ -70,9 +94,36 @@ func dns64Parse(c *caddy.Controller) (*DNS64, error) {
 			}
 		}
 	}
+	if hasClients && !hasDefault {
+		dns64.Prefix = nil
+	}
 	return dns64, nil
 }
 
+// parseNetworks parses args as networks in CIDR notation, a single address is handled as a host network.
+func parseNetworks(c *caddy.Controller, args []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(args))
+	for _, a := range args {
+		if !strings.Contains(a, "/") {
+			ip := net.ParseIP(a)
+			if ip == nil {
+				return nil, c.Errf("invalid network %q", a)
+			}
+			if ip.To4() != nil {
+				a += "/32"
+			} else {
+				a += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			return nil, c.Errf("invalid network %q: %v", a, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
 func parsePrefix(c *caddy.Controller, addr string) (*net.IPNet, error) {
 	_, pref, err := net.ParseCIDR(addr)
 	if err != nil {
@@ -1,6 +1,7 @@
 package dns64
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/coredns/caddy"
@@ -151,3 +152,78 @@ func TestSetupDns64(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupDns64Prefixes(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		wantPrefix     string
+		wantClients    []string
+		wantExclusions int
+	}{
+		{
+			`dns64 {
+				prefix 64:ff9b:1::/96 10.1.0.0/16 2001:db8:1::/48
+				prefix 64:ff9b:2::/96 10.2.0.0/16
+			}`,
+			false, "<nil>", []string{"64:ff9b:1::/96 10.1.0.0/16", "64:ff9b:1::/96 2001:db8:1::/48", "64:ff9b:2::/96 10.2.0.0/16"}, 0,
+		},
+		{
+			`dns64 64:ff9b::/96 {
+				prefix 64:ff9b:1::/96 192.0.2.1
+			}`,
+			false, "64:ff9b::/96", []string{"64:ff9b:1::/96 192.0.2.1/32"}, 0,
+		},
+		{
+			`dns64 {
+				exclude 10.0.0.0/8 ::ffff:0:0/96
+				exclude 192.0.2.1
+			}`,
+			false, "64:ff9b::/96", nil, 3,
+		},
+		{
+			`dns64 {
+				prefix 64:ff9b:1::/96 foobar
+			}`,
+			true, "", nil, 0,
+		},
+		{
+			`dns64 {
+				exclude
+			}`,
+			true, "", nil, 0,
+		},
+		{
+			`dns64 {
+				exclude 10.0.0.0/33
+			}`,
+			true, "", nil, 0,
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		dns64, err := dns64Parse(c)
+		if (err != nil) != test.shouldErr {
+			t.Errorf("Test %d expected %v error, got %v for %s", i+1, test.shouldErr, err, test.input)
+		}
+		if err != nil {
+			continue
+		}
+		if dns64.Prefix.String() != test.wantPrefix {
+			t.Errorf("Test %d expected prefix %s, got %v", i+1, test.wantPrefix, dns64.Prefix.String())
+		}
+		var clients []string
+		for _, p := range dns64.ClientPrefixes {
+			for _, n := range p.Clients {
+				clients = append(clients, p.Prefix.String()+" "+n.String())
+			}
+		}
+		if !reflect.DeepEqual(clients, test.wantClients) {
+			t.Errorf("Test %d expected client prefixes %v, got %v", i+1, test.wantClients, clients)
+		}
+		if len(dns64.Exclude) != test.wantExclusions {
+			t.Errorf("Test %d expected %d exclusions, got %d", i+1, test.wantExclusions, len(dns64.Exclude))
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package ecs implements a plugin that controls the EDNS0 Client Subnet option, see RFC 7871.
+package ecs
+
+import (
+	"context"
+	"net"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// ECS normalizes the client subnet option of queries, and makes sure the client only gets the option back if it
+// sent one.
+type ECS struct {
+	Next plugin.Handler
+
+	trusted   []*net.IPNet // Clients whose client subnet option is used, the option of other clients is stripped.
+	add       bool         // Add a client subnet option derived from the client's address if there is none.
+	v4Len     uint8        // Maximum source prefix length of IPv4 subnets.
+	v6Len     uint8        // Maximum source prefix length of IPv6 subnets.
+	upstreams map[string]struct{}
+}
+
+type ecsKey struct{}
+
+// ServeDNS implements the plugin.Handler interface.
+func (e *ECS) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	server := metrics.WithServer(ctx)
+
+	var orig *dns.EDNS0_SUBNET
+	if s := subnet(r); s != nil {
+		orig = copySubnet(s)
+		switch {
+		case !e.trust(net.ParseIP(state.IP())) || (s.Family != 1 && s.Family != 2):
+			removeSubnet(r)
+			requestsCount.WithLabelValues(server, "stripped").Inc()
+		case e.limit(s):
+			requestsCount.WithLabelValues(server, "limited").Inc()
+		}
+	}
+	if e.add && subnet(r) == nil {
+		if o := r.IsEdns0(); o != nil {
+			if s := e.fromClient(state); s != nil {
+				o.Option = append(o.Option, s)
+				requestsCount.WithLabelValues(server, "added").Inc()
+			}
+		}
+	}
+
+	ctx = context.WithValue(ctx, ecsKey{}, e)
+	rw := &ResponseWriter{ResponseWriter: w, orig: orig}
+	return plugin.NextOrFailure(e.Name(), e.Next, ctx, rw, r)
+}
+
+// Name implements the Handler interface.
+func (e *ECS) Name() string { return "ecs" }
+
+// trust returns true if the client subnet option of client is used.
+func (e *ECS) trust(client net.IP) bool {
+	for _, n := range e.trusted {
+		if n.Contains(client) {
+			return true
+		}
+	}
+	return false
+}
+
+// limit caps the source prefix length of s, it returns true if s was changed. The scope prefix length of queries
+// must be zero.
+func (e *ECS) limit(s *dns.EDNS0_SUBNET) bool {
+	maxLen, bits := e.v4Len, 32
+	if s.Family == 2 {
+		maxLen, bits = e.v6Len, 128
+	}
+	changed := s.SourceScope != 0
+	s.SourceScope = 0
+	if s.SourceNetmask > maxLen {
+		s.SourceNetmask = maxLen
+		changed = true
+	}
+	s.Address = s.Address.Mask(net.CIDRMask(int(s.SourceNetmask), bits))
+	return changed
+}
+
+// fromClient returns the client subnet option for the address of the client of state.
+func (e *ECS) fromClient(state request.Request) *dns.EDNS0_SUBNET {
+	ip := net.ParseIP(state.IP())
+	if ip == nil {
+		return nil
+	}
+	s := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 2, SourceNetmask: 128, Address: ip}
+	if ip4 := ip.To4(); ip4 != nil {
+		s.Family, s.SourceNetmask, s.Address = 1, 32, ip4
+	}
+	e.limit(s)
+	return s
+}
+
+// Forward returns true if the client subnet option of a query may be sent to the upstream addr, which is of the
+// form host:port. It is always true if the query wasn't handled by the ecs plugin.
+func Forward(ctx context.Context, addr string) bool {
+	e, ok := ctx.Value(ecsKey{}).(*ECS)
+	if !ok {
+		return true
+	}
+	_, ok = e.upstreams[addr]
+	return ok
+}
+
+// Strip returns r without the client subnet option. If r has no such option it is returned as is, otherwise a copy
+// is returned.
+func Strip(r *dns.Msg) *dns.Msg {
+	if subnet(r) == nil {
+		return r
+	}
+	r = r.Copy()
+	removeSubnet(r)
+	return r
+}
+
+// ResponseWriter is a response writer that makes sure the client subnet option of the response matches the
+// option the client sent.
+type ResponseWriter struct {
+	dns.ResponseWriter
+	orig *dns.EDNS0_SUBNET // The option sent by the client, nil if there was none.
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (r *ResponseWriter) WriteMsg(res *dns.Msg) error {
+	s := subnet(res)
+	if s == nil {
+		return r.ResponseWriter.WriteMsg(res)
+	}
+	if r.orig == nil {
+		removeSubnet(res)
+		return r.ResponseWriter.WriteMsg(res)
+	}
+
+	// Echo the client's subnet, the scope of the answer can't be more specific than what the client sent.
+	scope := s.SourceScope
+	if scope > r.orig.SourceNetmask {
+		scope = r.orig.SourceNetmask
+	}
+	*s = *copySubnet(r.orig)
+	s.SourceScope = scope
+	return r.ResponseWriter.WriteMsg(res)
+}
+
+// subnet returns the client subnet option of m, or nil.
+func subnet(m *dns.Msg) *dns.EDNS0_SUBNET {
+	o := m.IsEdns0()
+	if o == nil {
+		return nil
+	}
+	for _, opt := range o.Option {
+		if s, ok := opt.(*dns.EDNS0_SUBNET); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// removeSubnet removes the client subnet option from m.
+func removeSubnet(m *dns.Msg) {
+	o := m.IsEdns0()
+	if o == nil {
+		return
+	}
+	kept := make([]dns.EDNS0, 0, len(o.Option))
+	for _, opt := range o.Option {
+		if _, ok := opt.(*dns.EDNS0_SUBNET); !ok {
+			kept = append(kept, opt)
+		}
+	}
+	o.Option = kept
+}
+
+func copySubnet(s *dns.EDNS0_SUBNET) *dns.EDNS0_SUBNET {
+	c := *s
+	c.Address = append(net.IP(nil), s.Address...)
+	return &c
+}
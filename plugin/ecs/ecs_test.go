@@ -0,0 +1,111 @@
+package ecs
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestECS(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+
+	tests := []struct {
+		client   string
+		add      bool
+		subnet   *dns.EDNS0_SUBNET // Sent by the client.
+		expected string            // Seen by the next plugin.
+		response string            // Seen by the client.
+	}{
+		// Limited to /24.
+		{client: "10.0.0.1", subnet: ipv4Subnet("192.0.2.55", 32), expected: "192.0.2.0/24/0", response: "192.0.2.55/32/24"},
+		// Limited to /56.
+		{client: "10.0.0.1", subnet: &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 2, SourceNetmask: 64, Address: net.ParseIP("2001:db8:1:2::")}, expected: "[2001:db8:1::]/56/0", response: "[2001:db8:1:2::]/64/56"},
+		// Shorter prefixes are kept.
+		{client: "10.0.0.1", subnet: ipv4Subnet("192.0.0.0", 16), expected: "192.0.0.0/16/0", response: "192.0.0.0/16/16"},
+		// Untrusted clients' subnets are stripped.
+		{client: "192.0.2.1", subnet: ipv4Subnet("192.0.2.0", 24), expected: "", response: ""},
+		{client: "192.0.2.1", add: true, subnet: ipv4Subnet("198.51.100.0", 24), expected: "192.0.2.0/24/0", response: "198.51.100.0/24/24"},
+		// Added subnets aren't returned to the client.
+		{client: "192.0.2.1", add: true, expected: "192.0.2.0/24/0", response: ""},
+		{client: "2001:db8::1", add: true, expected: "[2001:db8::]/56/0", response: ""},
+		{client: "192.0.2.1", expected: "", response: ""},
+	}
+
+	for i, tc := range tests {
+		var seen string
+		e := &ECS{
+			trusted: []*net.IPNet{trusted},
+			add:     tc.add,
+			v4Len:   24,
+			v6Len:   56,
+			Next: test.HandlerFunc(func(_ context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				seen = subnetString(subnet(r))
+				m := new(dns.Msg)
+				m.SetReply(r)
+				m.SetEdns0(4096, false)
+				if s := subnet(r); s != nil {
+					echo := copySubnet(s)
+					echo.SourceScope = s.SourceNetmask
+					m.IsEdns0().Option = append(m.IsEdns0().Option, echo)
+				}
+				w.WriteMsg(m)
+				return dns.RcodeSuccess, nil
+			}),
+		}
+
+		m := new(dns.Msg)
+		m.SetQuestion("example.org.", dns.TypeA)
+		m.SetEdns0(4096, false)
+		if tc.subnet != nil {
+			m.IsEdns0().Option = append(m.IsEdns0().Option, tc.subnet)
+		}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: tc.client})
+		if _, err := e.ServeDNS(context.Background(), rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if seen != tc.expected {
+			t.Errorf("Test %d: expected %q to be forwarded, got %q", i, tc.expected, seen)
+		}
+		if got := subnetString(subnet(rec.Msg)); got != tc.response {
+			t.Errorf("Test %d: expected %q in the response, got %q", i, tc.response, got)
+		}
+	}
+}
+
+func TestForward(t *testing.T) {
+	if !Forward(context.Background(), "192.0.2.53:53") {
+		t.Errorf("Expected the option to be forwarded without the ecs plugin")
+	}
+	e := &ECS{upstreams: map[string]struct{}{"192.0.2.53:53": {}}}
+	ctx := context.WithValue(context.Background(), ecsKey{}, e)
+	if !Forward(ctx, "192.0.2.53:53") {
+		t.Errorf("Expected the option to be forwarded to 192.0.2.53:53")
+	}
+	if Forward(ctx, "198.51.100.53:53") {
+		t.Errorf("Expected the option not to be forwarded to 198.51.100.53:53")
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	m.SetEdns0(4096, false)
+	m.IsEdns0().Option = append(m.IsEdns0().Option, ipv4Subnet("192.0.2.0", 24))
+	if stripped := Strip(m); subnet(stripped) != nil || subnet(m) == nil {
+		t.Errorf("Expected a copy without the option")
+	}
+}
+
+func ipv4Subnet(addr string, length uint8) *dns.EDNS0_SUBNET {
+	return &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: length, Address: net.ParseIP(addr).To4()}
+}
+
+func subnetString(s *dns.EDNS0_SUBNET) string {
+	if s == nil {
+		return ""
+	}
+	return s.String()
+}
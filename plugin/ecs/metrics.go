@@ -0,0 +1,16 @@
+package ecs
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// requestsCount is the number of queries whose client subnet option was changed, per action.
+var requestsCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "ecs",
+	Name:      "requests_total",
+	Help:      "Counter of requests whose client subnet option was stripped, limited or added.",
+}, []string{"server", "action"})
@@ -0,0 +1,123 @@
+package ecs
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/edns"
+
+	"github.com/miekg/dns"
+)
+
+func init() { plugin.Register("ecs", setup) }
+
+func setup(c *caddy.Controller) error {
+	e, err := parse(c)
+	if err != nil {
+		return plugin.Error("ecs", err)
+	}
+
+	// Keep the option in responses to clients that sent one.
+	edns.SetSupportedOption(dns.EDNS0SUBNET)
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		e.Next = next
+		return e
+	})
+
+	if len(e.upstreams) > 0 {
+		// The cache doesn't key on the client subnet, so it would serve the answer for one subnet to all clients.
+		c.OnStartup(func() error {
+			if dnsserver.GetConfig(c).Handler("cache") != nil {
+				return plugin.Error("ecs", errors.New("upstreams can not be used together with the cache plugin"))
+			}
+			return nil
+		})
+	}
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (*ECS, error) {
+	e := &ECS{v4Len: 24, v6Len: 56, upstreams: make(map[string]struct{})}
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+		if len(c.RemainingArgs()) != 0 {
+			return nil, c.ArgErr()
+		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "trust":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					_, n, err := net.ParseCIDR(a)
+					if err != nil {
+						return nil, c.Errf("invalid network %q: %v", a, err)
+					}
+					e.trusted = append(e.trusted, n)
+				}
+			case "prefix":
+				args := c.RemainingArgs()
+				if len(args) != 2 {
+					return nil, c.ArgErr()
+				}
+				v4, err := strconv.ParseUint(args[0], 10, 8)
+				if err != nil || v4 > 32 {
+					return nil, c.Errf("invalid IPv4 prefix length %q", args[0])
+				}
+				v6, err := strconv.ParseUint(args[1], 10, 8)
+				if err != nil || v6 > 128 {
+					return nil, c.Errf("invalid IPv6 prefix length %q", args[1])
+				}
+				e.v4Len, e.v6Len = uint8(v4), uint8(v6)
+			case "add":
+				if len(c.RemainingArgs()) != 0 {
+					return nil, c.ArgErr()
+				}
+				e.add = true
+			case "upstreams":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					addr, err := upstreamAddr(a)
+					if err != nil {
+						return nil, c.Errf("invalid upstream %q: %v", a, err)
+					}
+					e.upstreams[addr] = struct{}{}
+				}
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+	return e, nil
+}
+
+// upstreamAddr returns a as host:port, as used by the forward plugin. The port defaults to 53.
+func upstreamAddr(a string) (string, error) {
+	host, port, err := net.SplitHostPort(a)
+	if err != nil {
+		host, port = strings.Trim(a, "[]"), "53"
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", net.InvalidAddrError("not an IP address")
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
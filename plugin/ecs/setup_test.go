@@ -0,0 +1,75 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input         string
+		shouldErr     bool
+		trusted       int
+		v4Len, v6Len  uint8
+		add           bool
+		upstreamAddrs []string
+	}{
+		{`ecs`, false, 0, 24, 56, false, nil},
+		{`ecs {
+			trust 10.0.0.0/8 2001:db8::/32
+			prefix 20 48
+			add
+			upstreams 192.0.2.53 [2001:db8::53]:5353 198.51.100.53:53
+		}`, false, 2, 20, 48, true, []string{"192.0.2.53:53", "[2001:db8::53]:5353", "198.51.100.53:53"}},
+		{`ecs example.org`, true, 0, 0, 0, false, nil},
+		{`ecs {
+			trust 10.0.0.0
+		}`, true, 0, 0, 0, false, nil},
+		{`ecs {
+			prefix 33 56
+		}`, true, 0, 0, 0, false, nil},
+		{`ecs {
+			prefix 24
+		}`, true, 0, 0, 0, false, nil},
+		{`ecs {
+			upstreams dns.example.org
+		}`, true, 0, 0, 0, false, nil},
+		{`ecs {
+			add yes
+		}`, true, 0, 0, 0, false, nil},
+		{`ecs {
+			foo
+		}`, true, 0, 0, 0, false, nil},
+		{`ecs
+		ecs`, true, 0, 0, 0, false, nil},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		e, err := parse(c)
+		if (err != nil) != tc.shouldErr {
+			t.Fatalf("Test %d: expected error %v, got %v", i, tc.shouldErr, err)
+		}
+		if err != nil {
+			continue
+		}
+		if len(e.trusted) != tc.trusted {
+			t.Errorf("Test %d: expected %d trusted networks, got %d", i, tc.trusted, len(e.trusted))
+		}
+		if e.v4Len != tc.v4Len || e.v6Len != tc.v6Len {
+			t.Errorf("Test %d: expected prefix lengths %d and %d, got %d and %d", i, tc.v4Len, tc.v6Len, e.v4Len, e.v6Len)
+		}
+		if e.add != tc.add {
+			t.Errorf("Test %d: expected add %v, got %v", i, tc.add, e.add)
+		}
+		if len(e.upstreams) != len(tc.upstreamAddrs) {
+			t.Errorf("Test %d: expected upstreams %v, got %v", i, tc.upstreamAddrs, e.upstreams)
+		}
+		for _, a := range tc.upstreamAddrs {
+			if _, ok := e.upstreams[a]; !ok {
+				t.Errorf("Test %d: expected upstream %s, got %v", i, a, e.upstreams)
+			}
+		}
+	}
+}
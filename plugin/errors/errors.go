@@ -10,6 +10,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/syslog"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -17,11 +18,20 @@ import (
 
 var log = clog.NewWithPlugin("errors")
 
+// logger is implemented by the plugin logger and a syslog writer.
+type logger interface {
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Warningf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
 type pattern struct {
 	ptimer      unsafe.Pointer
 	count       uint32
 	period      time.Duration
 	pattern     *regexp.Regexp
+	level       string
 	logCallback func(format string, v ...interface{})
 }
 
@@ -37,6 +47,7 @@ func (p *pattern) setTimer(t *time.Timer) {
 type errorHandler struct {
 	patterns []*pattern
 	stopFlag uint32
+	syslog   *syslog.Writer // nil logs to standard output
 	Next     plugin.Handler
 }
 
@@ -44,6 +55,14 @@ func newErrorHandler() *errorHandler {
 	return &errorHandler{}
 }
 
+// out returns the logger errors are written to.
+func (h *errorHandler) out() logger {
+	if h.syslog != nil {
+		return h.syslog
+	}
+	return log
+}
+
 func (h *errorHandler) logPattern(i int) {
 	cnt := atomic.SwapUint32(&h.patterns[i].count, 0)
 	if cnt > 0 {
@@ -94,7 +113,7 @@ func (h *errorHandler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dn
 			}
 		}
 		state := request.Request{W: w, Req: r}
-		log.Errorf("%d %s %s: %s", rcode, state.Name(), state.Type(), strErr)
+		h.out().Errorf("%d %s %s: %s", rcode, state.Name(), state.Type(), strErr)
 	}
 
 	return rcode, err
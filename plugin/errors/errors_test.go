@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	golog "log"
+	"net"
 	"regexp"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
@@ -235,3 +237,48 @@ func genErrorHandler(rcode int, err error) plugin.Handler {
 		return rcode, err
 	})
 }
+
+func TestErrorsSyslog(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	c := caddy.NewTestController("dns", `errors {
+		syslog udp://`+pc.LocalAddr().String()+` local0
+		consolidate 1m "^timeout$" warning
+	}`)
+	h, err := errorsParse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	h.syslog.Start()
+	defer h.syslog.Stop()
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	h.Next = genErrorHandler(dns.RcodeServerFailure, errors.New("test error"))
+	h.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), req)
+
+	h.Next = genErrorHandler(dns.RcodeServerFailure, errors.New("timeout"))
+	h.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), req)
+	h.stop()
+
+	expected := []string{
+		"<131>1 ", " errors - 2 example.org. A: test error",
+		"<132>1 ", " errors - 1 errors like '^timeout$' occurred in last 1m0s",
+	}
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i := 0; i < len(expected); i += 2 {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Failed to receive syslog message: %s", err)
+		}
+		msg := string(buf[:n])
+		if !strings.HasPrefix(msg, expected[i]) || !strings.HasSuffix(msg, expected[i+1]) {
+			t.Errorf("Expected syslog message %q...%q, got %q", expected[i], expected[i+1], msg)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/syslog"
 )
 
 func init() { plugin.Register("errors", setup) }
@@ -17,8 +18,14 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("errors", err)
 	}
 
+	if handler.syslog != nil {
+		c.OnStartup(handler.syslog.Start)
+	}
 	c.OnShutdown(func() error {
 		handler.stop()
+		if handler.syslog != nil {
+			return handler.syslog.Stop()
+		}
 		return nil
 	})
 
@@ -51,6 +58,7 @@ func errorsParse(c *caddy.Controller) (*errorHandler, error) {
 			return nil, c.ArgErr()
 		}
 
+		var syslogArgs, tlsArgs []string
 		for c.NextBlock() {
 			switch c.Val() {
 			case "stacktrace":
@@ -61,10 +69,36 @@ func errorsParse(c *caddy.Controller) (*errorHandler, error) {
 					return nil, err
 				}
 				handler.patterns = append(handler.patterns, pattern)
+			case "syslog":
+				syslogArgs = c.RemainingArgs()
+				if len(syslogArgs) == 0 || len(syslogArgs) > 2 {
+					return nil, c.ArgErr()
+				}
+			case "syslog_tls":
+				// A non-nil slice marks the option as present.
+				tlsArgs = append([]string{}, c.RemainingArgs()...)
+				if len(tlsArgs) > 3 {
+					return nil, c.ArgErr()
+				}
 			default:
 				return handler, c.SyntaxErr("Unknown field " + c.Val())
 			}
 		}
+
+		if tlsArgs != nil && syslogArgs == nil {
+			return nil, c.Err("syslog_tls requires syslog")
+		}
+		if syslogArgs != nil {
+			w, err := syslog.NewFromArgs("errors", syslogArgs, tlsArgs)
+			if err != nil {
+				return nil, c.Err(err.Error())
+			}
+			handler.syslog = w
+		}
+	}
+
+	for _, p := range handler.patterns {
+		p.logCallback = logFunc(handler.out(), p.level)
 	}
 	return handler, nil
 }
@@ -82,28 +116,36 @@ func parseConsolidate(c *caddy.Controller) (*pattern, error) {
 	if err != nil {
 		return nil, c.Err(err.Error())
 	}
-	lc, err := parseLogLevel(c, args)
+	level, err := parseLogLevel(c, args)
 	if err != nil {
 		return nil, err
 	}
-	return &pattern{period: p, pattern: re, logCallback: lc}, nil
+	return &pattern{period: p, pattern: re, level: level}, nil
 }
 
-func parseLogLevel(c *caddy.Controller, args []string) (func(format string, v ...interface{}), error) {
+func parseLogLevel(c *caddy.Controller, args []string) (string, error) {
 	if len(args) != 3 {
-		return log.Errorf, nil
+		return "error", nil
 	}
 
 	switch args[2] {
+	case "warning", "error", "info", "debug":
+		return args[2], nil
+	default:
+		return "", c.Errf("unknown log level argument in consolidate: %s", args[2])
+	}
+}
+
+// logFunc returns the function of l that logs with level.
+func logFunc(l logger, level string) func(format string, v ...interface{}) {
+	switch level {
 	case "warning":
-		return log.Warningf, nil
-	case "error":
-		return log.Errorf, nil
+		return l.Warningf
 	case "info":
-		return log.Infof, nil
+		return l.Infof
 	case "debug":
-		return log.Debugf, nil
+		return l.Debugf
 	default:
-		return nil, c.Errf("unknown log level argument in consolidate: %s", args[2])
+		return l.Errorf
 	}
 }
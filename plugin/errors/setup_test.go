@@ -65,6 +65,26 @@ func TestErrorsParse(t *testing.T) {
 		    consolidate 1m error1
 		    consolidate 5s error2
 		  }`, false, 2, false},
+		{`errors {
+		    syslog tcp://10.0.0.1 local0
+		    consolidate 1m error1 warning
+		  }`, false, 1, false},
+		{`errors {
+		    syslog tls://syslog.example.org
+		    syslog_tls
+		  }`, false, 0, false},
+		{`errors {
+		    syslog
+		  }`, true, 0, false},
+		{`errors {
+		    syslog 10.0.0.1 local0 extra
+		  }`, true, 0, false},
+		{`errors {
+		    syslog http://10.0.0.1
+		  }`, true, 0, false},
+		{`errors {
+		    syslog_tls
+		  }`, true, 0, false},
 	}
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.inputErrorsRules)
@@ -20,7 +20,7 @@ func TestCnameLookup(t *testing.T) {
 
 	for _, serv := range servicesCname {
 		set(t, etc, serv.Key, 0, serv)
-		defer delete(t, etc, serv.Key)
+		defer del(t, etc, serv.Key)
 	}
 	for i, tc := range dnsTestCasesCname {
 		m := tc.Msg()
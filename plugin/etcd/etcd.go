@@ -7,12 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/etcd/msg"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
+	"github.com/coredns/coredns/plugin/transfer"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -37,7 +39,11 @@ type Etcd struct {
 	Upstream   *upstream.Upstream
 	Client     *etcdcv3.Client
 
-	endpoints []string // Stored here as well, to aid in testing.
+	endpoints []string     // Stored here as well, to aid in testing.
+	mirror    *mirror      // If not nil, queries are answered from this copy of the path.
+	rev       atomic.Int64 // Highest etcd revision handed out as a serial.
+
+	transfer *transfer.Transfer
 }
 
 // Services implements the ServiceBackend interface.
@@ -72,15 +78,29 @@ func (e *Etcd) Records(ctx context.Context, state request.Request, exact bool) (
 	name := state.Name()
 
 	path, star := msg.PathWithWildcard(name, e.PathPrefix)
-	r, err := e.get(ctx, path, !exact)
+	kvs, err := e.get(ctx, path, !exact)
 	if err != nil {
 		return nil, err
 	}
 	segments := strings.Split(msg.Path(name, e.PathPrefix), "/")
-	return e.loopNodes(r.Kvs, segments, star, state.QType())
+	return e.loopNodes(kvs, segments, star, state.QType())
+}
+
+func (e *Etcd) get(ctx context.Context, path string, recursive bool) ([]*mvccpb.KeyValue, error) {
+	if e.mirror != nil {
+		if kvs, ok, err := e.mirror.get(path, recursive); ok {
+			return kvs, err
+		}
+	}
+	r, err := e.read(ctx, path, recursive)
+	if err != nil {
+		return nil, err
+	}
+	return r.Kvs, nil
 }
 
-func (e *Etcd) get(ctx context.Context, path string, recursive bool) (*etcdcv3.GetResponse, error) {
+// read reads path from etcd.
+func (e *Etcd) read(ctx context.Context, path string, recursive bool) (*etcdcv3.GetResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
 	defer cancel()
 	if recursive {
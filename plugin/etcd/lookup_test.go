@@ -327,7 +327,7 @@ func set(t *testing.T, e *Etcd, k string, ttl time.Duration, m *msg.Service) {
 	e.Client.KV.Put(ctxt, path, string(b))
 }
 
-func delete(t *testing.T, e *Etcd, k string) {
+func del(t *testing.T, e *Etcd, k string) {
 	path, _ := msg.PathWithWildcard(k, e.PathPrefix)
 	e.Client.Delete(ctxt, path)
 }
@@ -336,7 +336,7 @@ func TestLookup(t *testing.T) {
 	etc := newEtcdPlugin()
 	for _, serv := range services {
 		set(t, etc, serv.Key, 0, serv)
-		defer delete(t, etc, serv.Key)
+		defer del(t, etc, serv.Key)
 	}
 
 	for i, tc := range dnsTestCases {
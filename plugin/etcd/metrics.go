@@ -0,0 +1,26 @@
+package etcd
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// mirrorRevision is the etcd revision of the in-memory mirror of the path.
+	mirrorRevision = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "etcd",
+		Name:      "mirror_revision",
+		Help:      "The etcd revision of the in-memory mirror of the path.",
+	}, []string{"path"})
+
+	// mirrorSyncLag is the time since the mirror was last known to be up to date.
+	mirrorSyncLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "etcd",
+		Name:      "mirror_sync_lag_seconds",
+		Help:      "The time in seconds since the in-memory mirror of the path was last known to be up to date.",
+	}, []string{"path"})
+)
@@ -0,0 +1,287 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	mirrorBackoff    = 1 * time.Second // time we wait before the first retry of a broken watch
+	mirrorBackoffMax = 1 * time.Minute // maximum time between the retries of a broken watch
+	progressInterval = 5 * time.Second // how often we ask etcd whether the mirror is up to date
+)
+
+var errWatchClosed = errors.New("watch channel closed")
+
+// mirror is an in-memory copy of all keys under prefix. It is kept up to date by watching the prefix, so queries
+// don't need to go to etcd.
+type mirror struct {
+	prefix string
+	zones  map[string]string // zone to the prefix of its keys
+	notify func(zone string) // called when the keys of a zone change, may be nil
+
+	sync.RWMutex
+	kvs      map[string]*mvccpb.KeyValue
+	keys     []string         // sorted keys of kvs
+	rev      int64            // etcd revision the mirror is at
+	zoneRev  map[string]int64 // revision of the last change to the keys of a zone
+	synced   bool             // false when the watch is broken, the mirror must not be used then
+	lastSync time.Time
+}
+
+// newMirror returns a mirror of the keys under prefix, zones maps the zones to the prefix of their keys.
+func newMirror(prefix string, zones map[string]string) *mirror {
+	return &mirror{prefix: prefix, zones: zones, kvs: make(map[string]*mvccpb.KeyValue), zoneRev: make(map[string]int64)}
+}
+
+// get returns the key values for path, just as Etcd.get does. It returns false if the mirror isn't synced.
+func (m *mirror) get(path string, recursive bool) ([]*mvccpb.KeyValue, bool, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if !m.synced {
+		return nil, false, nil
+	}
+	if recursive {
+		if !strings.HasSuffix(path, "/") {
+			path = path + "/"
+		}
+		if kvs := m.withPrefix(path); len(kvs) > 0 {
+			return kvs, true, nil
+		}
+		path = strings.TrimSuffix(path, "/")
+	}
+	kv, ok := m.kvs[path]
+	if !ok {
+		return nil, true, errKeyNotFound
+	}
+	return []*mvccpb.KeyValue{kv}, true, nil
+}
+
+// withPrefix returns the key values of all keys starting with prefix, sorted on key. The lock must be held.
+func (m *mirror) withPrefix(prefix string) []*mvccpb.KeyValue {
+	var kvs []*mvccpb.KeyValue
+	for i := sort.SearchStrings(m.keys, prefix); i < len(m.keys) && strings.HasPrefix(m.keys[i], prefix); i++ {
+		kvs = append(kvs, m.kvs[m.keys[i]])
+	}
+	return kvs
+}
+
+// snapshot returns the key values under prefix and the revision of the last change to zone, it returns false if
+// the mirror isn't synced.
+func (m *mirror) snapshot(zone, prefix string) ([]*mvccpb.KeyValue, int64, bool) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if !m.synced {
+		return nil, 0, false
+	}
+	return m.withPrefix(prefix), m.zoneRev[zone], true
+}
+
+// zoneRevision returns the revision of the last change to zone, it returns false if the mirror isn't synced.
+func (m *mirror) zoneRevision(zone string) (int64, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.zoneRev[zone], m.synced
+}
+
+// reset replaces the contents of the mirror with kvs, listed at revision rev.
+func (m *mirror) reset(kvs []*mvccpb.KeyValue, rev int64) {
+	m.Lock()
+
+	old := m.kvs
+	m.kvs = make(map[string]*mvccpb.KeyValue, len(kvs))
+	m.keys = make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		m.kvs[string(kv.Key)] = kv
+		m.keys = append(m.keys, string(kv.Key))
+	}
+	sort.Strings(m.keys)
+
+	// We don't know when keys were deleted, so every zone gets the current revision. While the watch was broken
+	// the serial came from etcd directly, keeping the older revision of an unchanged zone would make it go down.
+	// Only the zones that changed are notified.
+	var changed []string
+	first := m.rev == 0
+	for zone, prefix := range m.zones {
+		m.zoneRev[zone] = rev
+		if !first && zoneChanged(old, m.kvs, prefix) {
+			changed = append(changed, zone)
+		}
+	}
+
+	m.rev = rev
+	m.synced = true
+	m.lastSync = time.Now()
+
+	mirrorRevision.WithLabelValues(m.prefix).Set(float64(rev))
+	mirrorSyncLag.WithLabelValues(m.prefix).Set(0)
+	m.Unlock()
+
+	m.notifyZones(changed)
+}
+
+// zoneChanged returns true if the keys under prefix differ between a and b.
+func zoneChanged(a, b map[string]*mvccpb.KeyValue, prefix string) bool {
+	n := 0
+	for key, kv := range a {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		n++
+		if kv1, ok := b[key]; !ok || kv1.ModRevision != kv.ModRevision {
+			return true
+		}
+	}
+	for key := range b {
+		if strings.HasPrefix(key, prefix) {
+			n--
+		}
+	}
+	return n != 0
+}
+
+// apply applies the events of a watch response at revision rev to the mirror.
+func (m *mirror) apply(events []*etcdcv3.Event, rev int64) {
+	m.Lock()
+
+	changed := make(map[string]struct{})
+	for _, ev := range events {
+		key := string(ev.Kv.Key)
+		for zone, prefix := range m.zones {
+			if strings.HasPrefix(key, prefix) {
+				m.zoneRev[zone] = ev.Kv.ModRevision
+				changed[zone] = struct{}{}
+			}
+		}
+
+		i := sort.SearchStrings(m.keys, key)
+		_, exists := m.kvs[key]
+		switch ev.Type {
+		case mvccpb.PUT:
+			if !exists {
+				m.keys = append(m.keys, "")
+				copy(m.keys[i+1:], m.keys[i:])
+				m.keys[i] = key
+			}
+			m.kvs[key] = ev.Kv
+		case mvccpb.DELETE:
+			if exists {
+				m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			}
+			delete(m.kvs, key)
+		}
+	}
+	if rev > m.rev {
+		m.rev = rev
+	}
+	m.lastSync = time.Now()
+
+	mirrorRevision.WithLabelValues(m.prefix).Set(float64(m.rev))
+	mirrorSyncLag.WithLabelValues(m.prefix).Set(0)
+	m.Unlock()
+
+	zones := make([]string, 0, len(changed))
+	for zone := range changed {
+		zones = append(zones, zone)
+	}
+	m.notifyZones(zones)
+}
+
+// notifyZones calls m.notify for the zones.
+func (m *mirror) notifyZones(zones []string) {
+	if m.notify == nil {
+		return
+	}
+	for _, zone := range zones {
+		m.notify(zone)
+	}
+}
+
+// broken marks the mirror as not synced, it returns true if the mirror was synced.
+func (m *mirror) broken() bool {
+	m.Lock()
+	defer m.Unlock()
+	synced := m.synced
+	m.synced = false
+	return synced
+}
+
+// updateLag sets the sync lag metric to the time since the mirror was last known to be up to date.
+func (m *mirror) updateLag() {
+	m.RLock()
+	defer m.RUnlock()
+	mirrorSyncLag.WithLabelValues(m.prefix).Set(time.Since(m.lastSync).Seconds())
+}
+
+// run keeps the mirror in sync with etcd until ctx is canceled. While the watch is broken the mirror isn't used,
+// and queries are answered with direct reads from etcd.
+func (m *mirror) run(ctx context.Context, c *etcdcv3.Client) {
+	backoff := mirrorBackoff
+	for {
+		err := m.sync(ctx, c)
+		if m.broken() {
+			backoff = mirrorBackoff
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf("Watch on %q is broken, reading from etcd directly: %s", m.prefix, err)
+		m.updateLag()
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+		if backoff > mirrorBackoffMax {
+			backoff = mirrorBackoffMax
+		}
+	}
+}
+
+// sync lists all keys under the prefix and then watches for changes, it only returns on errors.
+func (m *mirror) sync(ctx context.Context, c *etcdcv3.Client) error {
+	getctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	r, err := c.Get(getctx, m.prefix, etcdcv3.WithPrefix())
+	cancel()
+	if err != nil {
+		return err
+	}
+	m.reset(r.Kvs, r.Header.Revision)
+
+	wctx, cancel := context.WithCancel(etcdcv3.WithRequireLeader(ctx))
+	defer cancel()
+	wch := c.Watch(wctx, m.prefix, etcdcv3.WithPrefix(), etcdcv3.WithRev(r.Header.Revision+1), etcdcv3.WithProgressNotify())
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.updateLag()
+			if err := c.RequestProgress(wctx); err != nil {
+				return err
+			}
+		case wr, ok := <-wch:
+			if !ok {
+				return errWatchClosed
+			}
+			if err := wr.Err(); err != nil {
+				return err
+			}
+			m.apply(wr.Events, wr.Header.Revision)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package etcd
+
+import (
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+func kv(key, value string, rev int64) *mvccpb.KeyValue {
+	return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: rev}
+}
+
+func TestMirror(t *testing.T) {
+	m := newMirror("/skydns/", nil)
+
+	if _, ok, _ := m.get("/skydns/test/skydns/a", true); ok {
+		t.Fatal("Expected an unsynced mirror not to be used")
+	}
+
+	m.reset([]*mvccpb.KeyValue{
+		kv("/skydns/test/skydns/mx/b", `{"host":"mx2.example.org"}`, 2),
+		kv("/skydns/test/skydns/mx/a", `{"host":"mx1.example.org"}`, 1),
+		kv("/skydns/test/skydns/mx1", `{"host":"mx3.example.org"}`, 3),
+	}, 3)
+
+	tests := []struct {
+		path      string
+		recursive bool
+		keys      []string
+	}{
+		{"/skydns/test/skydns/mx", true, []string{"/skydns/test/skydns/mx/a", "/skydns/test/skydns/mx/b"}},
+		{"/skydns/test/skydns/mx1", true, []string{"/skydns/test/skydns/mx1"}},
+		{"/skydns/test/skydns/mx/a", false, []string{"/skydns/test/skydns/mx/a"}},
+		{"/skydns/test/skydns/mx", false, nil},
+		{"/skydns/test/skydns/mx2", true, nil},
+	}
+	check := func() {
+		t.Helper()
+		for i, tc := range tests {
+			kvs, ok, err := m.get(tc.path, tc.recursive)
+			if !ok {
+				t.Fatalf("Test %d: expected the mirror to be used", i)
+			}
+			if tc.keys == nil {
+				if err != errKeyNotFound {
+					t.Errorf("Test %d: expected %s, got %v", i, errKeyNotFound, err)
+				}
+				continue
+			}
+			if len(kvs) != len(tc.keys) {
+				t.Errorf("Test %d: expected %d keys, got %d", i, len(tc.keys), len(kvs))
+				continue
+			}
+			for j := range kvs {
+				if string(kvs[j].Key) != tc.keys[j] {
+					t.Errorf("Test %d: expected key %s, got %s", i, tc.keys[j], kvs[j].Key)
+				}
+			}
+		}
+	}
+	check()
+
+	m.apply([]*etcdcv3.Event{
+		{Type: mvccpb.DELETE, Kv: kv("/skydns/test/skydns/mx1", "", 4)},
+		{Type: mvccpb.PUT, Kv: kv("/skydns/test/skydns/mx/c", `{"host":"mx4.example.org"}`, 5)},
+		{Type: mvccpb.PUT, Kv: kv("/skydns/test/skydns/mx/a", `{"host":"mx5.example.org"}`, 5)},
+		{Type: mvccpb.PUT, Kv: kv("/skydns/test/skydns/mx2", `{"host":"mx6.example.org"}`, 5)},
+	}, 5)
+
+	tests = []struct {
+		path      string
+		recursive bool
+		keys      []string
+	}{
+		{"/skydns/test/skydns/mx", true, []string{"/skydns/test/skydns/mx/a", "/skydns/test/skydns/mx/b", "/skydns/test/skydns/mx/c"}},
+		{"/skydns/test/skydns/mx1", true, nil},
+		{"/skydns/test/skydns/mx2", true, []string{"/skydns/test/skydns/mx2"}},
+	}
+	check()
+
+	kvs, _, _ := m.get("/skydns/test/skydns/mx/a", false)
+	if string(kvs[0].Value) != `{"host":"mx5.example.org"}` {
+		t.Errorf("Expected the updated value, got %s", kvs[0].Value)
+	}
+	if m.rev != 5 {
+		t.Errorf("Expected revision %d, got %d", 5, m.rev)
+	}
+
+	if !m.broken() {
+		t.Error("Expected the mirror to have been synced")
+	}
+	if _, ok, _ := m.get("/skydns/test/skydns/mx", true); ok {
+		t.Error("Expected a broken mirror not to be used")
+	}
+}
+
+func TestMirrorZoneRevision(t *testing.T) {
+	m := newMirror("/skydns/", map[string]string{
+		"example.org.": "/skydns/org/example/",
+		"example.net.": "/skydns/net/example/",
+	})
+	var notified []string
+	m.notify = func(zone string) { notified = append(notified, zone) }
+
+	m.reset([]*mvccpb.KeyValue{
+		kv("/skydns/org/example/a", `{"host":"10.0.0.1"}`, 7),
+		kv("/skydns/org/example/b", `{"host":"10.0.0.2"}`, 3),
+		kv("/skydns/net/example/a", `{"host":"10.0.0.3"}`, 5),
+	}, 10)
+	if rev, _ := m.zoneRevision("example.org."); rev != 10 {
+		t.Errorf("Expected revision %d for example.org., got %d", 10, rev)
+	}
+	if rev, _ := m.zoneRevision("example.net."); rev != 10 {
+		t.Errorf("Expected revision %d for example.net., got %d", 10, rev)
+	}
+	if len(notified) != 0 {
+		t.Errorf("Expected no notifies on the first sync, got %v", notified)
+	}
+
+	m.apply([]*etcdcv3.Event{{Type: mvccpb.DELETE, Kv: kv("/skydns/org/example/b", "", 11)}}, 11)
+	if rev, _ := m.zoneRevision("example.org."); rev != 11 {
+		t.Errorf("Expected revision %d for example.org., got %d", 11, rev)
+	}
+	if rev, _ := m.zoneRevision("example.net."); rev != 10 {
+		t.Errorf("Expected revision %d for example.net., got %d", 10, rev)
+	}
+	if len(notified) != 1 || notified[0] != "example.org." {
+		t.Errorf("Expected a notify for example.org., got %v", notified)
+	}
+
+	// A resync after a broken watch where example.net. changed.
+	notified = nil
+	m.broken()
+	m.reset([]*mvccpb.KeyValue{
+		kv("/skydns/org/example/a", `{"host":"10.0.0.1"}`, 7),
+	}, 14)
+	if rev, _ := m.zoneRevision("example.org."); rev != 14 {
+		t.Errorf("Expected revision %d for example.org., got %d", 14, rev)
+	}
+	if rev, _ := m.zoneRevision("example.net."); rev != 14 {
+		t.Errorf("Expected revision %d for example.net., got %d", 14, rev)
+	}
+	if len(notified) != 1 || notified[0] != "example.net." {
+		t.Errorf("Expected a notify for example.net., got %v", notified)
+	}
+}
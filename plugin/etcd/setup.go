@@ -1,18 +1,24 @@
 package etcd
 
 import (
+	"context"
 	"crypto/tls"
+	"path"
 	"path/filepath"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
 	mwtls "github.com/coredns/coredns/plugin/pkg/tls"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
+	"github.com/coredns/coredns/plugin/transfer"
 
 	etcdcv3 "go.etcd.io/etcd/client/v3"
 )
 
+var log = clog.NewWithPlugin("etcd")
+
 func init() { plugin.Register("etcd", setup) }
 
 func setup(c *caddy.Controller) error {
@@ -21,6 +27,23 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("etcd", err)
 	}
 
+	if e.mirror != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.OnStartup(func() error {
+			// get the transfer plugin, so we can send notifies when the keys of a zone change.
+			if t := dnsserver.GetConfig(c).Handler("transfer"); t != nil {
+				e.transfer = t.(*transfer.Transfer) // if found this must be OK.
+				e.mirror.notify = func(zone string) { go e.transfer.Notify(zone) }
+			}
+			go e.mirror.run(ctx, e.Client)
+			return nil
+		})
+		c.OnShutdown(func() error {
+			cancel()
+			return nil
+		})
+	}
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		e.Next = next
 		return e
@@ -38,6 +61,7 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 		endpoints = []string{defaultEndpoint}
 		username  string
 		password  string
+		watch     bool
 	)
 
 	etc.Upstream = upstream.New()
@@ -86,6 +110,11 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 					return &Etcd{}, c.Errf("credentials requires 2 arguments, username and password")
 				}
 				username, password = args[0], args[1]
+			case "watch":
+				if c.NextArg() {
+					return &Etcd{}, c.ArgErr()
+				}
+				watch = true
 			default:
 				if c.Val() != "}" {
 					return &Etcd{}, c.Errf("unknown property '%s'", c.Val())
@@ -98,6 +127,13 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 		}
 		etc.Client = client
 		etc.endpoints = endpoints
+		if watch {
+			zones := make(map[string]string, len(etc.Zones))
+			for _, z := range etc.Zones {
+				zones[z] = zonePath(z, etc.PathPrefix)
+			}
+			etc.mirror = newMirror(path.Join("/", etc.PathPrefix)+"/", zones)
+		}
 
 		return &etc, nil
 	}
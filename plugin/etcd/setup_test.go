@@ -66,6 +66,19 @@ func TestSetupEtcd(t *testing.T) {
 		}
 			`, true, "skydns", []string{"http://localhost:2379"}, "Wrong argument count", "", "",
 		},
+		// with watch
+		{
+			`etcd {
+			watch
+		}
+			`, false, "skydns", []string{"http://localhost:2379"}, "", "", "",
+		},
+		{
+			`etcd {
+			watch always
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "Wrong argument count", "", "",
+		},
 	}
 
 	for i, test := range tests {
@@ -88,6 +101,10 @@ func TestSetupEtcd(t *testing.T) {
 			}
 		}
 
+		if !test.shouldErr && strings.Contains(test.input, "watch") && etcd.mirror == nil {
+			t.Errorf("Test %d: Expected the mirror to be set for input %s", i, test.input)
+		}
+
 		if !test.shouldErr && etcd.PathPrefix != test.expectedPath {
 			t.Errorf("Etcd not correctly set for input %s. Expected: %s, actual: %s", test.input, test.expectedPath, etcd.PathPrefix)
 		}
@@ -1,17 +1,196 @@
 package etcd
 
 import (
+	"context"
+	"encoding/json"
 	"time"
 
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/transfer"
 	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
 )
 
-// Serial returns the serial number to use.
+// Serial returns the serial number to use. This is the etcd revision of the last change to the keys of the zone
+// when watching, and the current etcd revision otherwise. Only SOA queries and transfers ask etcd for the current
+// revision, other answers use the last one seen. If that can't be determined the current time is used.
 func (e *Etcd) Serial(state request.Request) uint32 {
-	return uint32(time.Now().Unix())
+	zone := plugin.Zones(e.Zones).Matches(state.Name())
+	if zone == "" {
+		return uint32(time.Now().Unix())
+	}
+	if e.mirror != nil {
+		if rev, ok := e.mirror.zoneRevision(zone); ok {
+			return e.serial(rev)
+		}
+	}
+
+	// Negative answers carry the SOA record too, they shouldn't wait on etcd.
+	switch state.QType() {
+	case dns.TypeSOA, dns.TypeAXFR, dns.TypeIXFR:
+	default:
+		if rev := e.rev.Load(); rev > 0 {
+			return uint32(rev)
+		}
+		return uint32(time.Now().Unix())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	r, err := e.Client.Get(ctx, zonePath(zone, e.PathPrefix), etcdcv3.WithPrefix(), etcdcv3.WithCountOnly())
+	if err != nil {
+		return uint32(time.Now().Unix())
+	}
+	return e.serial(r.Header.Revision)
+}
+
+// serial records rev as seen and returns the highest revision seen so far, so the serial never goes down when we
+// switch between the mirror and etcd.
+func (e *Etcd) serial(rev int64) uint32 {
+	for {
+		seen := e.rev.Load()
+		if rev <= seen {
+			return uint32(seen)
+		}
+		if e.rev.CompareAndSwap(seen, rev) {
+			return uint32(rev)
+		}
+	}
 }
 
 // MinTTL returns the minimal TTL.
 func (e *Etcd) MinTTL(state request.Request) uint32 {
 	return 30
 }
+
+// SOA implements the transfer.SOAer interface.
+func (e *Etcd) SOA(zone string) (*dns.SOA, error) {
+	match := plugin.Zones(e.Zones).Matches(zone)
+	if match != zone {
+		return nil, transfer.ErrNotAuthoritative
+	}
+	state := request.Request{Req: new(dns.Msg)}
+	state.Req.SetQuestion(zone, dns.TypeSOA)
+	soa, err := plugin.SOA(context.TODO(), e, zone, state, plugin.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return soa[0].(*dns.SOA), nil
+}
+
+// Transfer implements the transfer.Transferer interface. All keys of the zone are read at a single revision, so
+// the transfer is a consistent snapshot of the zone.
+func (e *Etcd) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	match := plugin.Zones(e.Zones).Matches(zone)
+	if match != zone {
+		return nil, transfer.ErrNotAuthoritative
+	}
+
+	kvs, rev, err := e.snapshot(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	state := request.Request{Req: new(dns.Msg)}
+	state.Req.SetQuestion(zone, dns.TypeSOA)
+	soa, err := plugin.SOA(context.TODO(), e, zone, state, plugin.Options{})
+	if err != nil {
+		return nil, err
+	}
+	soa[0].(*dns.SOA).Serial = e.serial(rev)
+
+	ch := make(chan []dns.RR)
+	go func() {
+		// ixfr fallback
+		if serial != 0 && soa[0].(*dns.SOA).Serial == serial {
+			ch <- soa
+			close(ch)
+			return
+		}
+		ch <- soa
+
+		for _, kv := range kvs {
+			serv := new(msg.Service)
+			if err := json.Unmarshal(kv.Value, serv); err != nil {
+				log.Warningf("Skipping %s in transfer of %q: %s", kv.Key, zone, err)
+				continue
+			}
+			serv.Key = string(kv.Key)
+			serv.TTL = e.TTL(kv, serv)
+			if serv.Priority == 0 {
+				serv.Priority = priority
+			}
+			if rrs := serviceRRs(serv); len(rrs) > 0 {
+				ch <- rrs
+			}
+		}
+
+		ch <- soa
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// snapshot returns the keys of zone as of a single etcd revision, and the revision to use as the serial.
+func (e *Etcd) snapshot(zone string) ([]*mvccpb.KeyValue, int64, error) {
+	prefix := zonePath(zone, e.PathPrefix)
+	if e.mirror != nil {
+		if kvs, rev, ok := e.mirror.snapshot(zone, prefix); ok {
+			return kvs, rev, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	// A single range request is served at a single revision. The keys don't tell when the last one was deleted,
+	// so use that revision, it only ever goes up.
+	r, err := e.Client.Get(ctx, prefix, etcdcv3.WithPrefix())
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.Kvs, r.Header.Revision, nil
+}
+
+// serviceRRs returns the records for serv, the name of the records is the domain name of its key.
+func serviceRRs(serv *msg.Service) []dns.RR {
+	name := msg.Domain(serv.Key)
+	var rrs []dns.RR
+	if serv.Text != "" {
+		rrs = append(rrs, serv.NewTXT(name))
+	}
+
+	what, ip := serv.HostType()
+	switch what {
+	case dns.TypeA:
+		rrs = append(rrs, serv.NewA(name, ip))
+	case dns.TypeAAAA:
+		rrs = append(rrs, serv.NewAAAA(name, ip))
+	case dns.TypeCNAME:
+		switch {
+		case serv.Mail:
+			rrs = append(rrs, serv.NewMX(name))
+		case serv.Port > 0:
+			rrs = append(rrs, serv.NewSRV(name, uint16(serv.Weight)))
+		default:
+			rrs = append(rrs, serv.NewCNAME(name, dns.Fqdn(serv.Host)))
+		}
+		return rrs
+	default:
+		return rrs
+	}
+
+	// An address with a port is the target of a SRV record with the same name.
+	if serv.Port > 0 {
+		srv := serv.NewSRV(name, uint16(serv.Weight))
+		srv.Target = name
+		rrs = append(rrs, srv)
+	}
+	return rrs
+}
+
+// zonePath returns the prefix of the keys of zone.
+func zonePath(zone, prefix string) string { return msg.Path(zone, prefix) + "/" }
@@ -0,0 +1,117 @@
+package etcd
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/transfer"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestTransfer(t *testing.T) {
+	e := &Etcd{Zones: []string{"skydns.test."}, PathPrefix: "skydns"}
+	e.mirror = newMirror("/skydns/", map[string]string{"skydns.test.": zonePath("skydns.test.", "skydns")})
+	e.mirror.reset([]*mvccpb.KeyValue{
+		kv("/skydns/test/skydns/a", `{"host":"10.0.0.1","ttl":60}`, 4),
+		kv("/skydns/test/skydns/b", `{"host":"::1","port":8080}`, 5),
+		kv("/skydns/test/skydns/mx", `{"host":"mail.example.org","mail":true,"priority":20}`, 6),
+		kv("/skydns/test/skydns/www", `{"host":"a.skydns.test"}`, 7),
+		kv("/skydns/test/skydns/txt", `{"text":"hello"}`, 8),
+		kv("/skydns/test/skydns/bad", `not json`, 2),
+		kv("/skydns/test/other/x", `{"host":"10.0.0.9"}`, 20),
+	}, 21)
+
+	if _, err := e.Transfer("example.org.", 0); err != transfer.ErrNotAuthoritative {
+		t.Fatalf("Expected %s, got %v", transfer.ErrNotAuthoritative, err)
+	}
+
+	ch, err := e.Transfer("skydns.test.", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	var rrs []dns.RR
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+
+	expect := []string{
+		"skydns.test.	30	IN	SOA	ns.dns.skydns.test. hostmaster.skydns.test. 21 7200 1800 86400 30",
+		"a.skydns.test.	60	IN	A	10.0.0.1",
+		"b.skydns.test.	300	IN	AAAA	::1",
+		"b.skydns.test.	300	IN	SRV	10 0 8080 b.skydns.test.",
+		"mx.skydns.test.	300	IN	MX	20 mail.example.org.",
+		"txt.skydns.test.	300	IN	TXT	\"hello\"",
+		"www.skydns.test.	300	IN	CNAME	a.skydns.test.",
+		"skydns.test.	30	IN	SOA	ns.dns.skydns.test. hostmaster.skydns.test. 21 7200 1800 86400 30",
+	}
+	if len(rrs) != len(expect) {
+		t.Fatalf("Expected %d records, got %d: %v", len(expect), len(rrs), rrs)
+	}
+	for i := range rrs {
+		if rrs[i].String() != expect[i] {
+			t.Errorf("Record %d: expected %q, got %q", i, expect[i], rrs[i].String())
+		}
+	}
+
+	// ixfr fallback
+	ch, _ = e.Transfer("skydns.test.", 21)
+	rrs = nil
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	if len(rrs) != 1 {
+		t.Errorf("Expected only the SOA for an up to date serial, got %v", rrs)
+	}
+
+	soa, err := e.SOA("skydns.test.")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if soa.Serial != 21 {
+		t.Errorf("Expected serial %d, got %d", 21, soa.Serial)
+	}
+	if _, err := e.SOA("example.org."); err != transfer.ErrNotAuthoritative {
+		t.Errorf("Expected %s, got %v", transfer.ErrNotAuthoritative, err)
+	}
+}
+
+func TestSerialAfterResync(t *testing.T) {
+	// No Client: a query that isn't for the SOA must not ask etcd.
+	e := &Etcd{Zones: []string{"example.org.", "example.net."}, PathPrefix: "skydns"}
+	e.mirror = newMirror("/skydns/", map[string]string{
+		"example.org.": zonePath("example.org.", "skydns"),
+		"example.net.": zonePath("example.net.", "skydns"),
+	})
+	e.mirror.reset([]*mvccpb.KeyValue{
+		kv("/skydns/org/example/a", `{"host":"10.0.0.1"}`, 3),
+		kv("/skydns/net/example/a", `{"host":"10.0.0.2"}`, 4),
+	}, 10)
+	e.mirror.apply([]*etcdcv3.Event{{Type: mvccpb.PUT, Kv: kv("/skydns/org/example/b", `{"host":"10.0.0.3"}`, 11)}}, 11)
+
+	serial := func(name string) uint32 {
+		state := request.Request{Req: new(dns.Msg)}
+		state.Req.SetQuestion(name, dns.TypeA)
+		return e.Serial(state)
+	}
+	if s := serial("a.example.org."); s != 11 {
+		t.Fatalf("Expected serial %d, got %d", 11, s)
+	}
+
+	e.mirror.broken()
+	broken := serial("nx.example.net.")
+	if broken != 11 {
+		t.Errorf("Expected serial %d with a broken watch, got %d", 11, broken)
+	}
+
+	// Only example.org. changed while the watch was broken.
+	e.mirror.reset([]*mvccpb.KeyValue{
+		kv("/skydns/org/example/a", `{"host":"10.0.0.1"}`, 3),
+		kv("/skydns/net/example/a", `{"host":"10.0.0.2"}`, 4),
+	}, 15)
+	if s := serial("nx.example.net."); s < broken {
+		t.Errorf("Expected the serial not to go down after a resync, got %d after %d", s, broken)
+	}
+}
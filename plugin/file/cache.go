@@ -0,0 +1,130 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// ReadCache loads the zone from z.CacheFile, where TransferIn saved the last transferred version of it. The
+// zone's expiry is counted from the time the file was last written or verified against the primaries: an expired
+// zone is not loaded, and a loaded zone is marked expired when it hasn't been transferred or verified again before
+// the SOA's expire time runs out.
+func (z *Zone) ReadCache() error {
+	f, err := os.Open(filepath.Clean(z.CacheFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	z1, err := Parse(f, z.origin, z.CacheFile, -1)
+	if err != nil {
+		return err
+	}
+	if err := z1.VerifyZONEMD(z.ZONEMD); err != nil {
+		return err
+	}
+	soa := z1.Apex.SOA
+	expire := fi.ModTime().Add(time.Duration(soa.Expire) * time.Second)
+	if time.Now().After(expire) {
+		return fmt.Errorf("cached zone %q in %q with %d SOA serial expired at %s", z.origin, z.CacheFile, soa.Serial, expire.Format(time.RFC3339))
+	}
+
+	z.Lock()
+	z.Tree = z1.Tree
+	z.Apex = z1.Apex
+	z.Expired = false
+	z.Unlock()
+
+	z.expireCache(soa, expire)
+	return nil
+}
+
+// expireCache marks the zone loaded from the cache with soa expired at expire, unless it was transferred again or
+// the cache file was verified against the primaries in the mean time.
+func (z *Zone) expireCache(soa *dns.SOA, expire time.Time) {
+	time.AfterFunc(time.Until(expire), func() {
+		if fi, err := os.Stat(z.CacheFile); err == nil {
+			if next := fi.ModTime().Add(time.Duration(soa.Expire) * time.Second); next.After(expire) {
+				z.expireCache(soa, next)
+				return
+			}
+		}
+
+		z.Lock()
+		defer z.Unlock()
+		// If the SOA is still the cached one, the zone was never transferred again.
+		if z.Apex.SOA == soa {
+			log.Warningf("Cached zone %q with %d SOA serial has expired", z.origin, soa.Serial)
+			z.Expired = true
+		}
+	})
+}
+
+// touchCache records that the primaries were checked and the cached zone is still current, by updating the
+// modification time of the cache file. This restarts the expiry of the cached zone.
+func (z *Zone) touchCache() {
+	if z.CacheFile == "" {
+		return
+	}
+	now := time.Now()
+	if err := os.Chtimes(z.CacheFile, now, now); err != nil && !os.IsNotExist(err) {
+		log.Warningf("Failed to update the modification time of %q: %v", z.CacheFile, err)
+	}
+}
+
+// writeCache saves z to z.CacheFile. The zone is written to a temporary file first, which is then moved into place.
+func (z *Zone) writeCache() error {
+	f, err := os.CreateTemp(filepath.Dir(z.CacheFile), filepath.Base(z.CacheFile)+".")
+	if err != nil {
+		return err
+	}
+
+	z.RLock()
+	err = z.write(f)
+	z.RUnlock()
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), z.CacheFile)
+}
+
+// write writes z in zone file format to w, the SOA record comes first.
+func (z *Zone) write(w io.Writer) error {
+	if z.Apex.SOA == nil {
+		return fmt.Errorf("no SOA for origin %s", z.origin)
+	}
+	rrs := []dns.RR{z.Apex.SOA}
+	rrs = append(rrs, z.Apex.SIGSOA...)
+	rrs = append(rrs, z.Apex.NS...)
+	rrs = append(rrs, z.Apex.SIGNS...)
+	for _, rr := range rrs {
+		if _, err := io.WriteString(w, rr.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return z.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+		for _, rr := range e.All() {
+			if _, err := io.WriteString(w, rr.String()+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+)
+
+func TestCache(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "db.miek.nl.cache")
+
+	z, err := Parse(strings.NewReader(dbMiekNL), "miek.nl.", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Expected no error when parsing, got %s", err)
+	}
+	z.CacheFile = cache
+	if err := z.writeCache(); err != nil {
+		t.Fatalf("Expected no error when writing the cache, got %s", err)
+	}
+
+	z1 := NewZone("miek.nl.", "stdin")
+	z1.CacheFile = cache
+	if err := z1.ReadCache(); err != nil {
+		t.Fatalf("Expected no error when reading the cache, got %s", err)
+	}
+	if z1.Apex.SOA.Serial != z.Apex.SOA.Serial {
+		t.Errorf("Expected SOA serial %d, got %d", z.Apex.SOA.Serial, z1.Apex.SOA.Serial)
+	}
+	if len(z1.Apex.NS) != len(z.Apex.NS) {
+		t.Errorf("Expected %d NS records, got %d", len(z.Apex.NS), len(z1.Apex.NS))
+	}
+	if len(z1.All()) != len(z.All()) {
+		t.Errorf("Expected %d names, got %d", len(z.All()), len(z1.All()))
+	}
+
+	// Make the cache older than the SOA's expire time.
+	old := time.Now().Add(-time.Duration(z.Apex.SOA.Expire+1) * time.Second)
+	if err := os.Chtimes(cache, old, old); err != nil {
+		t.Fatal(err)
+	}
+	z2 := NewZone("miek.nl.", "stdin")
+	z2.CacheFile = cache
+	if err := z2.ReadCache(); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Expected an error when reading an expired cache, got %v", err)
+	}
+}
+
+func TestCacheTouch(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "db.example.org.cache")
+
+	// The SOA expires 1 second after the cache was written or verified.
+	zone := `example.org. 3600 IN SOA ns.example.org. hostmaster.example.org. 1 7200 3600 1 3600
+example.org. 3600 IN NS ns.example.org.
+`
+	if err := os.WriteFile(cache, []byte(zone), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-10 * time.Second)
+	if err := os.Chtimes(cache, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	z := NewZone("example.org.", "stdin")
+	z.CacheFile = cache
+	if err := z.ReadCache(); err == nil {
+		t.Fatal("Expected an error when reading an expired cache")
+	}
+
+	// A successful refresh without a transfer verifies the cached zone again.
+	z.touchCache()
+	if err := z.ReadCache(); err != nil {
+		t.Fatalf("Expected no error when reading a verified cache, got %s", err)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	z.touchCache()
+	time.Sleep(600 * time.Millisecond)
+	z.RLock()
+	expired := z.Expired
+	z.RUnlock()
+	if expired {
+		t.Error("Expected the zone not to expire after it was verified")
+	}
+
+	time.Sleep(time.Second)
+	z.RLock()
+	expired = z.Expired
+	z.RUnlock()
+	if !expired {
+		t.Error("Expected the zone to expire")
+	}
+}
+
+func TestTransferInCache(t *testing.T) {
+	soa := soa{250}
+
+	s := dnstest.NewServer(soa.Handler)
+	defer s.Close()
+
+	z := NewZone(testZone, "stdin")
+	z.TransferFrom = []string{s.Addr}
+	z.CacheFile = filepath.Join(t.TempDir(), "db."+testZone+"cache")
+
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Unable to run TransferIn: %v", err)
+	}
+	z1, err := os.ReadFile(z.CacheFile)
+	if err != nil {
+		t.Fatalf("Expected the transferred zone to be cached, got %s", err)
+	}
+	if !strings.HasPrefix(string(z1), z.Apex.SOA.String()) {
+		t.Errorf("Expected the cache to start with the SOA record, got %q", z1)
+	}
+}
@@ -0,0 +1,122 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// Problem is an issue found in a zone by Check.
+type Problem struct {
+	Name    string // owner name the problem was found at
+	Msg     string
+	Warning bool // warnings don't make a zone invalid
+}
+
+func (p Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", level, p.Name, p.Msg)
+}
+
+// signatureWarning is how long before their expiration we start warning about signatures.
+const signatureWarning = 7 * 24 * time.Hour
+
+// Check looks for problems in z that the zone parser does not catch: out-of-zone data, in-zone nameservers without
+// address records, CNAMEs with other data and (soon to be) expired DNSSEC signatures. Signature validity is checked
+// against now. The problems are returned in canonical order of their owner names.
+func (z *Zone) Check(now time.Time) []Problem {
+	problems := []Problem{}
+
+	if z.Apex.SOA != nil && !strings.EqualFold(z.Apex.SOA.Header().Name, z.origin) {
+		problems = append(problems, Problem{Name: z.Apex.SOA.Header().Name, Msg: fmt.Sprintf("SOA record is not at the origin %s", z.origin)})
+	}
+	if len(z.Apex.NS) == 0 {
+		problems = append(problems, Problem{Name: z.origin, Msg: "no NS records at the origin"})
+	}
+	problems = append(problems, z.checkNS(z.origin, z.Apex.NS, false)...)
+	problems = append(problems, checkSignatures(z.origin, z.Apex.SIGSOA, now)...)
+	problems = append(problems, checkSignatures(z.origin, z.Apex.SIGNS, now)...)
+
+	z.Tree.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+		name := e.Name()
+		if !dns.IsSubDomain(z.origin, name) {
+			problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("out-of-zone data for origin %s", z.origin)})
+			return nil
+		}
+
+		types := e.Types()
+		sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+		if cname := e.Type(dns.TypeCNAME); len(cname) > 0 {
+			if len(cname) > 1 {
+				problems = append(problems, Problem{Name: name, Msg: "multiple CNAME records"})
+			}
+			for _, t := range types {
+				switch t {
+				case dns.TypeCNAME, dns.TypeRRSIG, dns.TypeNSEC:
+				default:
+					problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("CNAME and other data (%s)", dns.TypeToString[t])})
+				}
+			}
+		}
+
+		if ns := e.Type(dns.TypeNS); len(ns) > 0 && name != z.origin {
+			problems = append(problems, z.checkNS(name, ns, true)...)
+		}
+		problems = append(problems, checkSignatures(name, e.Type(dns.TypeRRSIG), now)...)
+		return nil
+	})
+
+	return problems
+}
+
+// checkNS checks that the nameservers in ns that live in the zone have address records. When delegation is true,
+// ns are the NS records of a zone cut at name and the address records are glue.
+func (z *Zone) checkNS(name string, ns []dns.RR, delegation bool) []Problem {
+	problems := []Problem{}
+	for _, rr := range ns {
+		target := rr.(*dns.NS).Ns
+		if !dns.IsSubDomain(z.origin, target) {
+			continue
+		}
+		if e, ok := z.Tree.Search(target); ok && (len(e.Type(dns.TypeA)) > 0 || len(e.Type(dns.TypeAAAA)) > 0) {
+			continue
+		}
+		if delegation && dns.IsSubDomain(name, target) {
+			problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("missing glue for nameserver %s", target)})
+			continue
+		}
+		problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("no address records for in-zone nameserver %s", target)})
+	}
+	return problems
+}
+
+// checkSignatures checks the validity period of the signatures in sigs.
+func checkSignatures(name string, sigs []dns.RR, now time.Time) []Problem {
+	problems := []Problem{}
+	for _, rr := range sigs {
+		sig := rr.(*dns.RRSIG)
+		covered := dns.TypeToString[sig.TypeCovered]
+		if !sig.ValidityPeriod(now) {
+			// Serial number arithmetic (RFC 1982), as in ValidityPeriod.
+			if int32(sig.Inception-uint32(now.Unix())) > 0 {
+				problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("RRSIG for %s is not valid until %s", covered, dns.TimeToString(sig.Inception))})
+				continue
+			}
+			problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("RRSIG for %s expired at %s", covered, dns.TimeToString(sig.Expiration))})
+			continue
+		}
+		if !sig.ValidityPeriod(now.Add(signatureWarning)) {
+			problems = append(problems, Problem{Name: name, Msg: fmt.Sprintf("RRSIG for %s expires at %s", covered, dns.TimeToString(sig.Expiration)), Warning: true})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,54 @@
+package file
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const dbCheck = `$ORIGIN example.org.
+@       IN SOA  ns1 hostmaster 2017042745 7200 3600 1209600 3600
+        IN NS   ns1
+        IN NS   ns2
+ns1     IN A    127.0.0.1
+www     IN CNAME ns1
+        IN TXT  "and other data"
+sub     IN NS   ns.sub
+good    IN NS   ns.good
+ns.good IN A    127.0.0.2
+$GENERATE 1-2 host-$ IN A 127.0.1.$
+host-1  IN RRSIG A 13 3 3600 20240101000000 20231201000000 12345 example.org. aGVsbG8=
+example.com. IN A 127.0.0.1
+`
+
+func TestCheck(t *testing.T) {
+	z, err := Parse(strings.NewReader(dbCheck), "example.org.", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Expected no error when parsing, got %s", err)
+	}
+	if _, ok := z.Search("host-2.example.org."); !ok {
+		t.Errorf("Expected $GENERATE to create %s", "host-2.example.org.")
+	}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	expected := []string{
+		"error: example.org.: no address records for in-zone nameserver ns2.example.org.",
+		"error: example.com.: out-of-zone data for origin example.org.",
+		"error: host-1.example.org.: RRSIG for A expired at 20240101000000",
+		"error: sub.example.org.: missing glue for nameserver ns.sub.example.org.",
+		"error: www.example.org.: CNAME and other data (TXT)",
+	}
+	problems := z.Check(now)
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, p := range problems {
+		if p.String() != expected[i] {
+			t.Errorf("Test %d: expected %q, got %q", i, expected[i], p)
+		}
+	}
+
+	if problems := z.Check(time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC)); len(problems) != 5 || !problems[2].Warning {
+		t.Errorf("Expected a warning for a signature that is about to expire, got %v", problems)
+	}
+}
@@ -66,6 +66,9 @@ func (f File) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 				z.TransferIn()
 			} else {
 				log.Infof("Notify from %s for %s: no SOA serial increase seen", state.IP(), zone)
+				if err == nil {
+					z.touchCache()
+				}
 			}
 			if err != nil {
 				log.Warningf("Notify from %s for %s: failed primary check: %s", state.IP(), zone, err)
@@ -156,6 +159,9 @@ func Parse(f io.Reader, origin, fileName string, serial int64) (*Zone, error) {
 			return nil, err
 		}
 	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
 	if !seenSOA {
 		return nil, fmt.Errorf("file %q has no SOA record for origin %s", fileName, origin)
 	}
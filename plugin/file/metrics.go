@@ -0,0 +1,16 @@
+package file
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// zonemdCount is the number of ZONEMD verifications per zone and result.
+var zonemdCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "file",
+	Name:      "zonemd_verifications_total",
+	Help:      "Counter of ZONEMD verifications per zone and result.",
+}, []string{"zone", "result"})
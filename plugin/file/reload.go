@@ -35,6 +35,10 @@ func (z *Zone) Reload(t *transfer.Transfer) error {
 					}
 					continue
 				}
+				if err := zone.VerifyZONEMD(z.ZONEMD); err != nil {
+					log.Errorf("Not reloading zone %q in %q: %v", z.origin, zFile, err)
+					continue
+				}
 
 				// copy elements we need
 				z.Lock()
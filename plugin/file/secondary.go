@@ -50,6 +50,10 @@ Transfer:
 	if Err != nil {
 		return Err
 	}
+	if err := z1.VerifyZONEMD(z.ZONEMD); err != nil {
+		log.Errorf("Not using transfer of `%s' from %q: %v", z.origin, tr, err)
+		return err
+	}
 
 	z.Lock()
 	z.Tree = z1.Tree
@@ -57,6 +61,12 @@ Transfer:
 	z.Expired = false
 	z.Unlock()
 	log.Infof("Transferred: %s from %s", z.origin, tr)
+
+	if z.CacheFile != "" {
+		if err := z.writeCache(); err != nil {
+			log.Warningf("Failed to write transferred zone %q to %q: %v", z.origin, z.CacheFile, err)
+		}
+	}
 	return nil
 }
 
@@ -149,6 +159,8 @@ Restart:
 					// transfer failed, leave retryActive true
 					break
 				}
+			} else {
+				z.touchCache()
 			}
 
 			// no errors, stop timers and restart
@@ -175,6 +187,8 @@ Restart:
 					retryActive = true
 					break
 				}
+			} else {
+				z.touchCache()
 			}
 
 			// no errors, stop timers and restart
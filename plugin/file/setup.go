@@ -75,6 +75,7 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 
 	var openErr error
 	reload := 1 * time.Minute
+	zonemd := ZONEMDIgnore
 
 	for c.Next() {
 		// file db.file [zones...]
@@ -130,6 +131,16 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 			case "upstream":
 				// remove soon
 				c.RemainingArgs()
+			case "zonemd":
+				args := c.RemainingArgs()
+				switch {
+				case len(args) == 0:
+					zonemd = ZONEMDVerify
+				case len(args) == 1 && args[0] == "required":
+					zonemd = ZONEMDRequire
+				default:
+					return Zones{}, c.ArgErr()
+				}
 
 			default:
 				return Zones{}, c.Errf("unknown property '%s'", c.Val())
@@ -139,6 +150,13 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 		for i := range origins {
 			z[origins[i]].ReloadInterval = reload
 			z[origins[i]].Upstream = upstream.New()
+			z[origins[i]].ZONEMD = zonemd
+			if z[origins[i]].Apex.SOA == nil {
+				continue
+			}
+			if err := z[origins[i]].VerifyZONEMD(zonemd); err != nil {
+				return Zones{}, plugin.Error("file", err)
+			}
 		}
 	}
 
@@ -21,6 +21,12 @@ func TestFileParse(t *testing.T) {
 	}
 	defer rm()
 
+	zoneFileName3, rm, err := test.TempFile(".", dbSimpleZONEMD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rm()
+
 	tests := []struct {
 		inputFileRules string
 		shouldErr      bool
@@ -41,6 +47,20 @@ func TestFileParse(t *testing.T) {
 			false,
 			Zones{Names: []string{"10.in-addr.arpa."}},
 		},
+		{
+			`file ` + zoneFileName3 + ` example. {
+				zonemd required
+			}`,
+			false,
+			Zones{Names: []string{"example."}},
+		},
+		{
+			`file ` + zoneFileName1 + ` miek.nl. {
+				zonemd
+			}`,
+			false,
+			Zones{Names: []string{"miek.nl."}},
+		},
 		// errors.
 		{
 			`file ` + zoneFileName1 + ` miek.nl {
@@ -68,6 +88,20 @@ func TestFileParse(t *testing.T) {
 			true,
 			Zones{},
 		},
+		{
+			`file ` + zoneFileName1 + ` miek.nl. {
+				zonemd required
+			}`,
+			true,
+			Zones{},
+		},
+		{
+			`file ` + zoneFileName1 + ` miek.nl. {
+				zonemd optional
+			}`,
+			true,
+			Zones{},
+		},
 	}
 
 	for i, test := range tests {
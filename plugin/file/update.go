@@ -0,0 +1,419 @@
+package file
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
+)
+
+// Change is the change of a single RRset by a dynamic update.
+type Change struct {
+	Name string
+	Type uint16
+	Old  []dns.RR // The RRset before the update, empty if the RRset is created.
+	New  []dns.RR // The RRset after the update, empty if the RRset is deleted.
+}
+
+// DynamicUpdate checks the prerequisites of the dynamic update (RFC 2136) r against z, and returns the changes the update
+// makes to the RRsets of z. The zone itself isn't changed, see Apply. The returned rcode is dns.RcodeSuccess if the
+// update can be applied. Updates of the SOA record are ignored, as the serial isn't maintained by us.
+func (z *Zone) DynamicUpdate(r *dns.Msg) ([]Change, int) {
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA {
+		return nil, dns.RcodeFormatError
+	}
+	if !strings.EqualFold(r.Question[0].Name, z.origin) {
+		return nil, dns.RcodeNotAuth
+	}
+
+	u := &update{z: z, names: make(map[string]map[uint16][]dns.RR), orig: make(map[string]map[uint16][]dns.RR)}
+	if rcode := u.prerequisites(r.Answer); rcode != dns.RcodeSuccess {
+		return nil, rcode
+	}
+	if rcode := u.prescan(r.Ns); rcode != dns.RcodeSuccess {
+		return nil, rcode
+	}
+	for _, rr := range r.Ns {
+		u.apply(rr)
+	}
+	return u.changes(), dns.RcodeSuccess
+}
+
+// Apply applies the changes to z. The caller must make sure there are no concurrent lookups.
+func (z *Zone) Apply(changes []Change) {
+	for _, c := range changes {
+		if c.Type == dns.TypeNS && c.Name == z.origin {
+			z.Apex.NS = nil
+		} else if len(c.Old) > 0 {
+			z.Tree.Delete(c.Old[0])
+		}
+		for _, rr := range c.New {
+			z.Insert(rr)
+		}
+	}
+}
+
+// UpdateAuthorized returns true if the dynamic update r is TSIG signed with a valid signature of one of keys. If keys
+// is empty, all keys in secrets are allowed. The signature is verified against secrets here, as not every transport
+// checks it: the DoH, DoQ and gRPC servers always report a nil TSIG status.
+func UpdateAuthorized(w dns.ResponseWriter, r *dns.Msg, secrets map[string]string, keys []string) bool {
+	t := r.IsTsig()
+	if t == nil || w.TsigStatus() != nil {
+		return false
+	}
+	name := plugin.Name(t.Hdr.Name).Normalize()
+	secret, ok := secrets[name]
+	if !ok {
+		return false
+	}
+	if !tsigValid(r, secret) {
+		return false
+	}
+	if len(keys) == 0 {
+		return true
+	}
+	for _, k := range keys {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// tsigValid returns true if the TSIG signature of r is valid for secret. The original wire format isn't available,
+// so r is packed again, both without and with name compression, as the sender may have used either.
+func tsigValid(r *dns.Msg, secret string) bool {
+	m := r.Copy()
+	for _, compress := range []bool{false, true} {
+		m.Compress = compress
+		buf, err := m.Pack()
+		if err != nil {
+			return false
+		}
+		if dns.TsigVerify(buf, secret, "", false) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Updater applies dynamic updates to copies of zones whose records are kept elsewhere, such as by a cloud DNS
+// service. The zero value refuses all updates.
+type Updater struct {
+	Secrets map[string]string // the TSIG secrets of the server
+	Keys    []string          // the TSIG keys that may sign updates, all keys if empty
+
+	// mu serializes the updates, so the prerequisites still hold when the changes are committed.
+	mu sync.Mutex
+}
+
+// Update handles the dynamic update r of z, and returns the rcode for the response. The changes of an authorized
+// update are handed to commit, which writes them to where the records are kept. It returns how many of the changes
+// it wrote, in order, and the rcode. The changes that were written are applied to z right away, instead of after
+// the next refresh. zMu guards z.
+func (u *Updater) Update(w dns.ResponseWriter, r *dns.Msg, z *Zone, zMu *sync.RWMutex, commit func([]Change) (int, int)) int {
+	if !UpdateAuthorized(w, r, u.Secrets, u.Keys) {
+		log.Debugf("Refusing unauthorized update of %s", z.origin)
+		return dns.RcodeRefused
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	zMu.RLock()
+	changes, rcode := z.DynamicUpdate(r)
+	zMu.RUnlock()
+	if rcode != dns.RcodeSuccess || len(changes) == 0 {
+		return rcode
+	}
+
+	n, rcode := commit(changes)
+	if n > 0 {
+		zMu.Lock()
+		z.Apply(changes[:n])
+		zMu.Unlock()
+	}
+	return rcode
+}
+
+// update holds the state of a dynamic update of a zone.
+type update struct {
+	z *Zone
+	// names holds the RRsets of the names touched by the update as they are after the update, orig holds them as
+	// they are in the zone.
+	names map[string]map[uint16][]dns.RR
+	orig  map[string]map[uint16][]dns.RR
+}
+
+// rrsets returns the RRsets of name, they can be changed by the caller.
+func (u *update) rrsets(name string) map[uint16][]dns.RR {
+	name = strings.ToLower(name)
+	if m, ok := u.names[name]; ok {
+		return m
+	}
+
+	orig := make(map[uint16][]dns.RR)
+	if e, ok := u.z.Tree.Search(name); ok {
+		for _, t := range e.Types() {
+			orig[t] = e.Type(t)
+		}
+	}
+	if name == u.z.origin {
+		if u.z.Apex.SOA != nil {
+			orig[dns.TypeSOA] = []dns.RR{u.z.Apex.SOA}
+		}
+		if len(u.z.Apex.NS) > 0 {
+			orig[dns.TypeNS] = u.z.Apex.NS
+		}
+	}
+
+	m := make(map[uint16][]dns.RR, len(orig))
+	for t, rrs := range orig {
+		for _, rr := range rrs {
+			m[t] = append(m[t], dns.Copy(rr))
+		}
+	}
+	u.orig[name] = orig
+	u.names[name] = m
+	return m
+}
+
+// prerequisites checks the prerequisite section of the update, see RFC 2136, section 3.2.
+func (u *update) prerequisites(rrs []dns.RR) int {
+	values := make(map[string]map[uint16][]dns.RR)
+	for _, rr := range rrs {
+		h := rr.Header()
+		if h.Ttl != 0 {
+			return dns.RcodeFormatError
+		}
+		if !dns.IsSubDomain(u.z.origin, h.Name) {
+			return dns.RcodeNotZone
+		}
+		switch h.Class {
+		case dns.ClassANY:
+			if h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+			m := u.rrsets(h.Name)
+			if h.Rrtype == dns.TypeANY {
+				if len(m) == 0 {
+					return dns.RcodeNameError
+				}
+			} else if len(m[h.Rrtype]) == 0 {
+				return dns.RcodeNXRrset
+			}
+		case dns.ClassNONE:
+			if h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+			m := u.rrsets(h.Name)
+			if h.Rrtype == dns.TypeANY {
+				if len(m) > 0 {
+					return dns.RcodeYXDomain
+				}
+			} else if len(m[h.Rrtype]) > 0 {
+				return dns.RcodeYXRrset
+			}
+		case dns.ClassINET:
+			name := strings.ToLower(h.Name)
+			if values[name] == nil {
+				values[name] = make(map[uint16][]dns.RR)
+			}
+			values[name][h.Rrtype] = append(values[name][h.Rrtype], rr)
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	// RRsets that must exist with exactly these values.
+	for name, types := range values {
+		m := u.rrsets(name)
+		for t, rrs := range types {
+			if !sameRdata(m[t], rrs) {
+				return dns.RcodeNXRrset
+			}
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// prescan checks the update section of the update, see RFC 2136, section 3.4.1.
+func (u *update) prescan(rrs []dns.RR) int {
+	for _, rr := range rrs {
+		h := rr.Header()
+		if !dns.IsSubDomain(u.z.origin, h.Name) {
+			return dns.RcodeNotZone
+		}
+		switch h.Class {
+		case dns.ClassINET:
+			if isMetaType(h.Rrtype) {
+				return dns.RcodeFormatError
+			}
+		case dns.ClassANY:
+			if h.Ttl != 0 || h.Rdlength != 0 || (isMetaType(h.Rrtype) && h.Rrtype != dns.TypeANY) {
+				return dns.RcodeFormatError
+			}
+		case dns.ClassNONE:
+			if h.Ttl != 0 || isMetaType(h.Rrtype) {
+				return dns.RcodeFormatError
+			}
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// apply applies a single RR of the update section, see RFC 2136, section 3.4.2.
+func (u *update) apply(rr dns.RR) {
+	h := rr.Header()
+	if h.Rrtype == dns.TypeSOA {
+		return
+	}
+	name := strings.ToLower(h.Name)
+	apex := name == u.z.origin
+	m := u.rrsets(name)
+
+	switch h.Class {
+	case dns.ClassINET:
+		if h.Rrtype == dns.TypeCNAME {
+			for t := range m {
+				if t != dns.TypeCNAME {
+					return
+				}
+			}
+		} else if len(m[dns.TypeCNAME]) > 0 {
+			return
+		}
+
+		rr = dns.Copy(rr)
+		rr.Header().Name = name
+		if h.Rrtype == dns.TypeCNAME {
+			m[dns.TypeCNAME] = []dns.RR{rr}
+			return
+		}
+		rrs := m[h.Rrtype]
+		found := false
+		for i := range rrs {
+			if dns.IsDuplicate(rrs[i], rr) {
+				rrs[i] = rr
+				found = true
+			}
+		}
+		if !found {
+			rrs = append(rrs, rr)
+		}
+		// All records in an RRset have the same TTL.
+		for _, x := range rrs {
+			x.Header().Ttl = h.Ttl
+		}
+		m[h.Rrtype] = rrs
+
+	case dns.ClassANY:
+		for t := range m {
+			if h.Rrtype != dns.TypeANY && t != h.Rrtype {
+				continue
+			}
+			if apex && (t == dns.TypeSOA || t == dns.TypeNS) {
+				continue
+			}
+			delete(m, t)
+		}
+
+	case dns.ClassNONE:
+		rrs := m[h.Rrtype]
+		if apex && h.Rrtype == dns.TypeNS && len(rrs) <= 1 {
+			// The last NS record of the zone can't be deleted.
+			return
+		}
+		del := dns.Copy(rr)
+		del.Header().Class = dns.ClassINET
+		kept := rrs[:0]
+		for _, x := range rrs {
+			if !dns.IsDuplicate(x, del) {
+				kept = append(kept, x)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m, h.Rrtype)
+			return
+		}
+		m[h.Rrtype] = kept
+	}
+}
+
+// changes returns the RRsets that are changed by the update, ordered by name and type.
+func (u *update) changes() []Change {
+	names := make([]string, 0, len(u.names))
+	for name := range u.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		m, orig := u.names[name], u.orig[name]
+		types := make(map[uint16]struct{})
+		for t := range m {
+			types[t] = struct{}{}
+		}
+		for t := range orig {
+			types[t] = struct{}{}
+		}
+		sorted := make([]uint16, 0, len(types))
+		for t := range types {
+			sorted = append(sorted, t)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		for _, t := range sorted {
+			if sameRdata(orig[t], m[t]) && sameTTL(orig[t], m[t]) {
+				continue
+			}
+			changes = append(changes, Change{Name: name, Type: t, Old: orig[t], New: m[t]})
+		}
+	}
+	return changes
+}
+
+// sameRdata returns true if a and b hold the same records, ignoring the TTL.
+func sameRdata(a, b []dns.RR) bool {
+	contains := func(rrs []dns.RR, rr dns.RR) bool {
+		for _, x := range rrs {
+			if dns.IsDuplicate(x, rr) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rr := range a {
+		if !contains(b, rr) {
+			return false
+		}
+	}
+	for _, rr := range b {
+		if !contains(a, rr) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTTL returns true if the first records of a and b have the same TTL.
+func sameTTL(a, b []dns.RR) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	return a[0].Header().Ttl == b[0].Header().Ttl
+}
+
+func isMetaType(t uint16) bool {
+	switch t {
+	case dns.TypeANY, dns.TypeAXFR, dns.TypeIXFR, dns.TypeMAILA, dns.TypeMAILB, dns.TypeOPT, dns.TypeTSIG:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package file
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+const dbUpdate = `
+$TTL    300
+example.org.	IN	SOA	ns1.example.org. admin.example.org. 1 3600 900 604800 300
+example.org.	IN	NS	ns1.example.org.
+ns1.example.org.	IN	A	192.0.2.53
+www.example.org.	IN	A	192.0.2.1
+www.example.org.	IN	A	192.0.2.2
+www.example.org.	IN	TXT	"hello"
+alias.example.org.	IN	CNAME	www.example.org.
+`
+
+func newUpdateZone(t *testing.T) *Zone {
+	z, err := Parse(strings.NewReader(dbUpdate), "example.org.", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Expected no error when reading zone, got %q", err)
+	}
+	return z
+}
+
+// updateMsg returns a packed and unpacked update for example.org, set up by fn.
+func updateMsg(t *testing.T, fn func(m *dns.Msg)) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetUpdate("example.org.")
+	fn(m)
+	buf, err := m.Pack()
+	if err != nil {
+		t.Fatalf("Failed to pack update: %v", err)
+	}
+	if err := m.Unpack(buf); err != nil {
+		t.Fatalf("Failed to unpack update: %v", err)
+	}
+	return m
+}
+
+// sign returns m signed by key with secret, as it is received.
+func sign(t *testing.T, m *dns.Msg, key, secret string) *dns.Msg {
+	m.SetTsig(key, dns.HmacSHA256, 300, time.Now().Unix())
+	buf, _, err := dns.TsigGenerate(m, secret, "", false)
+	if err != nil {
+		t.Fatalf("Failed to sign update: %v", err)
+	}
+	r := new(dns.Msg)
+	if err := r.Unpack(buf); err != nil {
+		t.Fatalf("Failed to unpack update: %v", err)
+	}
+	return r
+}
+
+func rrs(s ...string) []dns.RR {
+	var rrs []dns.RR
+	for _, x := range s {
+		rr, err := dns.NewRR(x)
+		if err != nil {
+			panic(err)
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs
+}
+
+func TestUpdatePrerequisites(t *testing.T) {
+	tests := []struct {
+		update   func(m *dns.Msg)
+		expected int
+	}{
+		{func(m *dns.Msg) { m.NameUsed(rrs("www.example.org. 0 IN A 0.0.0.0")) }, dns.RcodeSuccess},
+		{func(m *dns.Msg) { m.NameUsed(rrs("new.example.org. 0 IN A 0.0.0.0")) }, dns.RcodeNameError},
+		{func(m *dns.Msg) { m.NameNotUsed(rrs("new.example.org. 0 IN A 0.0.0.0")) }, dns.RcodeSuccess},
+		{func(m *dns.Msg) { m.NameNotUsed(rrs("www.example.org. 0 IN A 0.0.0.0")) }, dns.RcodeYXDomain},
+		{func(m *dns.Msg) { m.RRsetUsed(rrs("www.example.org. 0 IN TXT \"\"")) }, dns.RcodeSuccess},
+		{func(m *dns.Msg) { m.RRsetUsed(rrs("www.example.org. 0 IN AAAA ::")) }, dns.RcodeNXRrset},
+		{func(m *dns.Msg) { m.RRsetNotUsed(rrs("www.example.org. 0 IN AAAA ::")) }, dns.RcodeSuccess},
+		{func(m *dns.Msg) { m.RRsetNotUsed(rrs("www.example.org. 0 IN A 0.0.0.0")) }, dns.RcodeYXRrset},
+		{func(m *dns.Msg) {
+			m.Used(rrs("www.example.org. 0 IN A 192.0.2.2", "www.example.org. 0 IN A 192.0.2.1"))
+		}, dns.RcodeSuccess},
+		{func(m *dns.Msg) { m.Used(rrs("www.example.org. 0 IN A 192.0.2.1")) }, dns.RcodeNXRrset},
+		{func(m *dns.Msg) { m.NameUsed(rrs("www.example.net. 0 IN A 0.0.0.0")) }, dns.RcodeNotZone},
+		{func(m *dns.Msg) {
+			m.Answer = []dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: "www.example.org.", Rrtype: dns.TypeA, Class: dns.ClassANY, Ttl: 300}}}
+		}, dns.RcodeFormatError},
+	}
+
+	for i, tc := range tests {
+		z := newUpdateZone(t)
+		_, rcode := z.DynamicUpdate(updateMsg(t, tc.update))
+		if rcode != tc.expected {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.expected], dns.RcodeToString[rcode])
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		update   func(m *dns.Msg)
+		expected []Change
+	}{
+		// Add a record to an existing RRset, the TTL of the RRset changes.
+		{
+			func(m *dns.Msg) { m.Insert(rrs("www.example.org. 60 IN A 192.0.2.3")) },
+			[]Change{{"www.example.org.", dns.TypeA,
+				rrs("www.example.org. 300 IN A 192.0.2.1", "www.example.org. 300 IN A 192.0.2.2"),
+				rrs("www.example.org. 60 IN A 192.0.2.1", "www.example.org. 60 IN A 192.0.2.2", "www.example.org. 60 IN A 192.0.2.3")}},
+		},
+		// Add an existing record.
+		{
+			func(m *dns.Msg) { m.Insert(rrs("www.example.org. 300 IN A 192.0.2.1")) },
+			nil,
+		},
+		// Create a new name.
+		{
+			func(m *dns.Msg) { m.Insert(rrs("New.example.org. 300 IN AAAA 2001:db8::1")) },
+			[]Change{{"new.example.org.", dns.TypeAAAA, nil, rrs("new.example.org. 300 IN AAAA 2001:db8::1")}},
+		},
+		// Delete a single record.
+		{
+			func(m *dns.Msg) { m.Remove(rrs("www.example.org. 300 IN A 192.0.2.1")) },
+			[]Change{{"www.example.org.", dns.TypeA,
+				rrs("www.example.org. 300 IN A 192.0.2.1", "www.example.org. 300 IN A 192.0.2.2"),
+				rrs("www.example.org. 300 IN A 192.0.2.2")}},
+		},
+		// Delete an RRset.
+		{
+			func(m *dns.Msg) { m.RemoveRRset(rrs("www.example.org. 0 IN TXT \"\"")) },
+			[]Change{{"www.example.org.", dns.TypeTXT, rrs("www.example.org. 300 IN TXT \"hello\""), nil}},
+		},
+		// Delete a name.
+		{
+			func(m *dns.Msg) { m.RemoveName(rrs("alias.example.org. 0 IN A 0.0.0.0")) },
+			[]Change{{"alias.example.org.", dns.TypeCNAME, rrs("alias.example.org. 300 IN CNAME www.example.org."), nil}},
+		},
+		// Records can't be added next to a CNAME.
+		{
+			func(m *dns.Msg) { m.Insert(rrs("alias.example.org. 300 IN A 192.0.2.1")) },
+			nil,
+		},
+		// The apex NS and SOA records aren't deleted with the name, and the SOA isn't updated.
+		{
+			func(m *dns.Msg) {
+				m.RemoveName(rrs("example.org. 0 IN A 0.0.0.0"))
+				m.Remove(rrs("example.org. 300 IN NS ns1.example.org."))
+				m.Insert(rrs("example.org. 300 IN SOA ns1.example.org. admin.example.org. 2 3600 900 604800 300"))
+			},
+			nil,
+		},
+		// Replace a record in a single update.
+		{
+			func(m *dns.Msg) {
+				m.RemoveRRset(rrs("alias.example.org. 0 IN CNAME ."))
+				m.Insert(rrs("alias.example.org. 300 IN A 192.0.2.9"))
+			},
+			[]Change{
+				{"alias.example.org.", dns.TypeA, nil, rrs("alias.example.org. 300 IN A 192.0.2.9")},
+				{"alias.example.org.", dns.TypeCNAME, rrs("alias.example.org. 300 IN CNAME www.example.org."), nil},
+			},
+		},
+	}
+
+	for i, tc := range tests {
+		z := newUpdateZone(t)
+		changes, rcode := z.DynamicUpdate(updateMsg(t, tc.update))
+		if rcode != dns.RcodeSuccess {
+			t.Errorf("Test %d: expected success, got %s", i, dns.RcodeToString[rcode])
+			continue
+		}
+		if len(changes) != len(tc.expected) {
+			t.Errorf("Test %d: expected %d changes, got %d: %v", i, len(tc.expected), len(changes), changes)
+			continue
+		}
+		for j, c := range changes {
+			e := tc.expected[j]
+			if c.Name != e.Name || c.Type != e.Type {
+				t.Errorf("Test %d: expected change of %s/%d, got %s/%d", i, e.Name, e.Type, c.Name, c.Type)
+			}
+			if err := test.Section(test.Case{Answer: e.Old}, test.Answer, c.Old); err != nil {
+				t.Errorf("Test %d: old RRset: %v", i, err)
+			}
+			if err := test.Section(test.Case{Answer: e.New}, test.Answer, c.New); err != nil {
+				t.Errorf("Test %d: new RRset: %v", i, err)
+			}
+		}
+	}
+}
+
+func TestUpdateApply(t *testing.T) {
+	z := newUpdateZone(t)
+	changes, _ := z.DynamicUpdate(updateMsg(t, func(m *dns.Msg) {
+		m.Remove(rrs("www.example.org. 300 IN A 192.0.2.1"))
+		m.RemoveRRset(rrs("www.example.org. 0 IN TXT \"\""))
+		m.Insert(rrs("example.org. 300 IN NS ns2.example.org."))
+		m.Insert(rrs("new.example.org. 300 IN A 192.0.2.4"))
+	}))
+	z.Apply(changes)
+
+	e, _ := z.Tree.Search("www.example.org.")
+	if a := e.Type(dns.TypeA); len(a) != 1 || a[0].(*dns.A).A.String() != "192.0.2.2" {
+		t.Errorf("Expected a single A record for www, got %v", a)
+	}
+	if txt := e.Type(dns.TypeTXT); len(txt) != 0 {
+		t.Errorf("Expected no TXT records for www, got %v", txt)
+	}
+	if len(z.Apex.NS) != 2 {
+		t.Errorf("Expected 2 NS records, got %v", z.Apex.NS)
+	}
+	if _, ok := z.Tree.Search("new.example.org."); !ok {
+		t.Error("Expected new.example.org to exist")
+	}
+}
+
+func TestUpdateZoneSection(t *testing.T) {
+	z := newUpdateZone(t)
+	m := new(dns.Msg)
+	m.SetUpdate("example.net.")
+	if _, rcode := z.DynamicUpdate(m); rcode != dns.RcodeNotAuth {
+		t.Errorf("Expected NOTAUTH, got %s", dns.RcodeToString[rcode])
+	}
+	m.SetQuestion("example.org.", dns.TypeA)
+	if _, rcode := z.DynamicUpdate(m); rcode != dns.RcodeFormatError {
+		t.Errorf("Expected FORMERR, got %s", dns.RcodeToString[rcode])
+	}
+}
+
+func TestUpdateAuthorized(t *testing.T) {
+	const secret = "c2VjcmV0"
+	secrets := map[string]string{"key.example.org.": secret, "other.example.org.": secret}
+
+	// signed returns an update signed by key with secret, compressed or not.
+	signed := func(key, secret string, compress bool) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetUpdate("example.org.")
+		m.Insert(rrs("www.example.org. 300 IN A 192.0.2.3", "mail.example.org. 300 IN MX 10 www.example.org."))
+		m.Compress = compress
+		return sign(t, m, key, secret)
+	}
+	unsigned := updateMsg(t, func(m *dns.Msg) {})
+	tampered := signed("key.example.org.", secret, false)
+	tampered.Ns[0].(*dns.A).A = net.ParseIP("192.0.2.66")
+
+	tests := []struct {
+		r          *dns.Msg
+		keys       []string
+		authorized bool
+	}{
+		{signed("key.example.org.", secret, false), nil, true},
+		{signed("key.example.org.", secret, true), nil, true},
+		{signed("Key.Example.org.", secret, false), []string{"key.example.org."}, true},
+		{signed("other.example.org.", secret, false), []string{"key.example.org."}, false},
+		{signed("unknown.example.org.", secret, false), nil, false},
+		// The test writer reports a nil TSIG status, like the DoH, DoQ and gRPC writers do.
+		{signed("key.example.org.", "d3Jvbmc=", false), nil, false},
+		{tampered, nil, false},
+		{unsigned, nil, false},
+	}
+	for i, tc := range tests {
+		if authorized := UpdateAuthorized(&test.ResponseWriter{}, tc.r, secrets, tc.keys); authorized != tc.authorized {
+			t.Errorf("Test %d: expected authorized %t, got %t", i, tc.authorized, authorized)
+		}
+	}
+}
+
+func TestUpdater(t *testing.T) {
+	const secret = "c2VjcmV0"
+	u := &Updater{Secrets: map[string]string{"key.example.org.": secret}}
+	z := newUpdateZone(t)
+	zMu := &sync.RWMutex{}
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.org.")
+	m.Insert(rrs("a.example.org. 300 IN A 192.0.2.3", "b.example.org. 300 IN A 192.0.2.4"))
+
+	committed := 0
+	commit := func(changes []Change) (int, int) {
+		committed++
+		if len(changes) != 2 {
+			t.Errorf("Expected 2 changes, got %d", len(changes))
+		}
+		// Only the first change is written.
+		return 1, dns.RcodeServerFailure
+	}
+
+	unsigned := m.Copy()
+	if rcode := u.Update(&test.ResponseWriter{}, unsigned, z, zMu, commit); rcode != dns.RcodeRefused {
+		t.Errorf("Expected rcode %s for an unsigned update, got %s", dns.RcodeToString[dns.RcodeRefused], dns.RcodeToString[rcode])
+	}
+	if committed != 0 {
+		t.Error("Expected an unsigned update not to be committed")
+	}
+
+	if rcode := u.Update(&test.ResponseWriter{}, sign(t, m, "key.example.org.", secret), z, zMu, commit); rcode != dns.RcodeServerFailure {
+		t.Errorf("Expected rcode %s, got %s", dns.RcodeToString[dns.RcodeServerFailure], dns.RcodeToString[rcode])
+	}
+	if committed != 1 {
+		t.Errorf("Expected the update to be committed once, got %d", committed)
+	}
+	if _, ok := z.Tree.Search("a.example.org."); !ok {
+		t.Error("Expected the written change to be applied")
+	}
+	if _, ok := z.Tree.Search("b.example.org."); ok {
+		t.Error("Expected the change that wasn't written not to be applied")
+	}
+}
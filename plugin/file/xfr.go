@@ -16,6 +16,19 @@ func (f File) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
 	return z.Transfer(serial)
 }
 
+// SOA implements the transfer.SOAer interface.
+func (f File) SOA(zone string) (*dns.SOA, error) {
+	z, ok := f.Zones.Z[zone]
+	if !ok || z == nil {
+		return nil, transfer.ErrNotAuthoritative
+	}
+	apex, err := z.ApexIfDefined()
+	if err != nil {
+		return nil, err
+	}
+	return apex[0].(*dns.SOA), nil
+}
+
 // Transfer transfers a zone with serial in the returned channel and implements IXFR fallback, by just
 // sending a single SOA record.
 func (z *Zone) Transfer(serial uint32) (<-chan []dns.RR, error) {
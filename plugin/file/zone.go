@@ -26,10 +26,13 @@ type Zone struct {
 
 	StartupOnce  sync.Once
 	TransferFrom []string
+	CacheFile    string // If set, transferred zones are saved to this file, see ReadCache.
 
 	ReloadInterval time.Duration
 	reloadShutdown chan bool
 
+	ZONEMD ZONEMDPolicy // How ZONEMD records are verified when the zone is (re)loaded or transferred.
+
 	Upstream *upstream.Upstream // Upstream for looking up external names during the resolution process.
 }
 
@@ -56,7 +59,9 @@ func NewZone(name, file string) *Zone {
 func (z *Zone) Copy() *Zone {
 	z1 := NewZone(z.origin, z.file)
 	z1.TransferFrom = z.TransferFrom
+	z1.CacheFile = z.CacheFile
 	z1.Expired = z.Expired
+	z1.ZONEMD = z.ZONEMD
 
 	z1.Apex = z.Apex
 	return z1
@@ -66,7 +71,9 @@ func (z *Zone) Copy() *Zone {
 func (z *Zone) CopyWithoutApex() *Zone {
 	z1 := NewZone(z.origin, z.file)
 	z1.TransferFrom = z.TransferFrom
+	z1.CacheFile = z.CacheFile
 	z1.Expired = z.Expired
+	z1.ZONEMD = z.ZONEMD
 
 	return z1
 }
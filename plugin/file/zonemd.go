@@ -0,0 +1,251 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// ZONEMDPolicy tells how ZONEMD records (RFC 8976) in a zone are used.
+type ZONEMDPolicy int
+
+const (
+	// ZONEMDIgnore does not look at the ZONEMD records.
+	ZONEMDIgnore ZONEMDPolicy = iota
+	// ZONEMDVerify verifies the ZONEMD records if the zone has them.
+	ZONEMDVerify
+	// ZONEMDRequire requires the zone to have a ZONEMD record that can be verified.
+	ZONEMDRequire
+)
+
+var (
+	errNoZONEMD          = errors.New("no ZONEMD record")
+	errUnsupportedZONEMD = errors.New("no ZONEMD record with a supported scheme and hash algorithm")
+)
+
+// Digest calculates the ZONEMD digest of z using the SIMPLE scheme and the hash algorithm h, as described in
+// RFC 8976, Section 3. The apex ZONEMD records and their signatures are not included in the digest.
+func (z *Zone) Digest(h uint8) (string, error) {
+	var hh hash.Hash
+	switch h {
+	case dns.ZoneMDHashAlgSHA384:
+		hh = sha512.New384()
+	case dns.ZoneMDHashAlgSHA512:
+		hh = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported ZONEMD hash algorithm %d", h)
+	}
+
+	if z.Apex.SOA == nil {
+		return "", fmt.Errorf("no SOA for origin %s", z.origin)
+	}
+
+	apex := []dns.RR{z.Apex.SOA}
+	apex = append(apex, z.Apex.NS...)
+	apex = append(apex, z.Apex.SIGSOA...)
+	apex = append(apex, z.Apex.SIGNS...)
+	if e, ok := z.Tree.Search(z.origin); ok {
+		apex = append(apex, e.All()...)
+	}
+	if err := digestRRs(hh, z.origin, apex); err != nil {
+		return "", err
+	}
+
+	err := z.Tree.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+		if e.Name() == z.origin {
+			return nil
+		}
+		return digestRRs(hh, z.origin, e.All())
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hh.Sum(nil)), nil
+}
+
+// VerifyZONEMD checks the apex ZONEMD records of z according to policy. It returns an error if the zone's
+// contents don't match the digest or if policy is ZONEMDRequire and the zone has no usable ZONEMD record.
+func (z *Zone) VerifyZONEMD(policy ZONEMDPolicy) error {
+	if policy == ZONEMDIgnore {
+		return nil
+	}
+
+	err := z.verifyZONEMD()
+	result := "verified"
+	switch err {
+	case nil:
+	case errNoZONEMD:
+		result = "missing"
+		if policy != ZONEMDRequire {
+			err = nil
+		}
+	case errUnsupportedZONEMD:
+		result = "unsupported"
+		if policy != ZONEMDRequire {
+			err = nil
+		}
+	default:
+		result = "failed"
+	}
+	zonemdCount.WithLabelValues(z.origin, result).Inc()
+	return err
+}
+
+func (z *Zone) verifyZONEMD() error {
+	if z.Apex.SOA == nil {
+		return fmt.Errorf("no SOA for origin %s", z.origin)
+	}
+	e, ok := z.Tree.Search(z.origin)
+	if !ok {
+		return errNoZONEMD
+	}
+	rrs := e.Type(dns.TypeZONEMD)
+	if len(rrs) == 0 {
+		return errNoZONEMD
+	}
+
+	// RFC 8976, Section 4: the zone is verified if any of the ZONEMD records with a supported scheme and hash
+	// algorithm matches.
+	var err error = errUnsupportedZONEMD
+	seen := map[[2]uint8]bool{}
+	for _, rr := range rrs {
+		md := rr.(*dns.ZONEMD)
+		key := [2]uint8{md.Scheme, md.Hash}
+		if seen[key] {
+			return fmt.Errorf("multiple ZONEMD records with scheme %d and hash algorithm %d", md.Scheme, md.Hash)
+		}
+		seen[key] = true
+
+		if md.Scheme != dns.ZoneMDSchemeSimple {
+			continue
+		}
+		if md.Hash != dns.ZoneMDHashAlgSHA384 && md.Hash != dns.ZoneMDHashAlgSHA512 {
+			continue
+		}
+		if md.Serial != z.Apex.SOA.Serial {
+			err = fmt.Errorf("ZONEMD serial %d does not match SOA serial %d", md.Serial, z.Apex.SOA.Serial)
+			continue
+		}
+		digest, derr := z.Digest(md.Hash)
+		if derr != nil {
+			return derr
+		}
+		if !strings.EqualFold(digest, md.Digest) {
+			err = fmt.Errorf("ZONEMD digest mismatch for zone %s with SOA serial %d", z.origin, md.Serial)
+			continue
+		}
+		return nil
+	}
+	return err
+}
+
+// digestRRs adds the RRs of a single owner name to hh. The RRs are put in canonical form and order, apex ZONEMD
+// records and the signatures covering them are skipped.
+func digestRRs(hh hash.Hash, origin string, rrs []dns.RR) error {
+	type wire struct {
+		rrtype uint16
+		rdata  int // offset of the rdata in buf
+		buf    []byte
+	}
+	wires := make([]wire, 0, len(rrs))
+	for _, rr := range rrs {
+		h := rr.Header()
+		if strings.EqualFold(h.Name, origin) {
+			if h.Rrtype == dns.TypeZONEMD {
+				continue
+			}
+			if s, ok := rr.(*dns.RRSIG); ok && s.TypeCovered == dns.TypeZONEMD {
+				continue
+			}
+		}
+
+		c := canonical(rr)
+		buf := make([]byte, dns.Len(c)+1)
+		off, err := dns.PackRR(c, buf, 0, nil, false)
+		if err != nil {
+			return err
+		}
+		name, err := dns.PackDomainName(c.Header().Name, buf, 0, nil, false)
+		if err != nil {
+			return err
+		}
+		// The rdata follows the owner name, type, class, TTL and rdlength.
+		wires = append(wires, wire{rrtype: h.Rrtype, rdata: name + 10, buf: buf[:off]})
+	}
+
+	sort.Slice(wires, func(i, j int) bool {
+		if wires[i].rrtype != wires[j].rrtype {
+			return wires[i].rrtype < wires[j].rrtype
+		}
+		return bytes.Compare(wires[i].buf[wires[i].rdata:], wires[j].buf[wires[j].rdata:]) < 0
+	})
+	for i, w := range wires {
+		if i > 0 && bytes.Equal(w.buf, wires[i-1].buf) {
+			continue
+		}
+		hh.Write(w.buf)
+	}
+	return nil
+}
+
+// canonical returns a copy of rr in the canonical form of RFC 4034, Section 6.2, as amended by RFC 6840.
+func canonical(rr dns.RR) dns.RR {
+	r := dns.Copy(rr)
+	h := r.Header()
+	h.Name = dns.CanonicalName(h.Name)
+	switch x := r.(type) {
+	case *dns.NS:
+		x.Ns = dns.CanonicalName(x.Ns)
+	case *dns.MD:
+		x.Md = dns.CanonicalName(x.Md)
+	case *dns.MF:
+		x.Mf = dns.CanonicalName(x.Mf)
+	case *dns.CNAME:
+		x.Target = dns.CanonicalName(x.Target)
+	case *dns.SOA:
+		x.Ns = dns.CanonicalName(x.Ns)
+		x.Mbox = dns.CanonicalName(x.Mbox)
+	case *dns.MB:
+		x.Mb = dns.CanonicalName(x.Mb)
+	case *dns.MG:
+		x.Mg = dns.CanonicalName(x.Mg)
+	case *dns.MR:
+		x.Mr = dns.CanonicalName(x.Mr)
+	case *dns.PTR:
+		x.Ptr = dns.CanonicalName(x.Ptr)
+	case *dns.MINFO:
+		x.Rmail = dns.CanonicalName(x.Rmail)
+		x.Email = dns.CanonicalName(x.Email)
+	case *dns.MX:
+		x.Mx = dns.CanonicalName(x.Mx)
+	case *dns.RP:
+		x.Mbox = dns.CanonicalName(x.Mbox)
+		x.Txt = dns.CanonicalName(x.Txt)
+	case *dns.AFSDB:
+		x.Hostname = dns.CanonicalName(x.Hostname)
+	case *dns.RT:
+		x.Host = dns.CanonicalName(x.Host)
+	case *dns.PX:
+		x.Map822 = dns.CanonicalName(x.Map822)
+		x.Mapx400 = dns.CanonicalName(x.Mapx400)
+	case *dns.NAPTR:
+		x.Replacement = dns.CanonicalName(x.Replacement)
+	case *dns.KX:
+		x.Exchanger = dns.CanonicalName(x.Exchanger)
+	case *dns.SRV:
+		x.Target = dns.CanonicalName(x.Target)
+	case *dns.DNAME:
+		x.Target = dns.CanonicalName(x.Target)
+	}
+	return r
+}
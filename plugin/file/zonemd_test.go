@@ -0,0 +1,78 @@
+package file
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// The simple EXAMPLE zone from RFC 8976, Appendix A.1.
+const dbSimpleZONEMD = `
+example.      86400  IN  SOA     ns1 admin 2018031900 (
+                                 1800 900 604800 86400 )
+              86400  IN  NS      ns1
+              86400  IN  NS      ns2
+              86400  IN  ZONEMD  2018031900 1 1 (
+                                 c68090d90a7aed716bc459f9340e3d7c
+                                 1370d4d24b7e2fc3a1ddc0b9a87153b9
+                                 a9713b3c9ae5cc27777f98b8e730044c )
+ns1           3600   IN  A       203.0.113.63
+ns2           3600   IN  AAAA    2001:db8::63
+`
+
+func TestDigest(t *testing.T) {
+	z, err := Parse(strings.NewReader(dbSimpleZONEMD), "example.", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Expected no error when reading zone, got %q", err)
+	}
+
+	digest, err := z.Digest(dns.ZoneMDHashAlgSHA384)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	expected := "c68090d90a7aed716bc459f9340e3d7c1370d4d24b7e2fc3a1ddc0b9a87153b9a9713b3c9ae5cc27777f98b8e730044c"
+	if digest != expected {
+		t.Errorf("Expected digest %s, got %s", expected, digest)
+	}
+}
+
+func TestVerifyZONEMD(t *testing.T) {
+	tests := []struct {
+		zone      string
+		policy    ZONEMDPolicy
+		shouldErr bool
+	}{
+		{dbSimpleZONEMD, ZONEMDVerify, false},
+		{dbSimpleZONEMD, ZONEMDRequire, false},
+		// Changed data.
+		{strings.Replace(dbSimpleZONEMD, "203.0.113.63", "203.0.113.64", 1), ZONEMDVerify, true},
+		{strings.Replace(dbSimpleZONEMD, "203.0.113.63", "203.0.113.64", 1), ZONEMDIgnore, false},
+		// Serial mismatch.
+		{strings.Replace(dbSimpleZONEMD, "admin 2018031900", "admin 2018031901", 1), ZONEMDVerify, true},
+		// Unsupported hash algorithm.
+		{strings.Replace(dbSimpleZONEMD, "2018031900 1 1 (", "2018031900 1 240 (", 1), ZONEMDVerify, false},
+		{strings.Replace(dbSimpleZONEMD, "2018031900 1 1 (", "2018031900 1 240 (", 1), ZONEMDRequire, true},
+		// No ZONEMD.
+		{dbMiekNL, ZONEMDVerify, false},
+		{dbMiekNL, ZONEMDRequire, true},
+	}
+
+	for i, tc := range tests {
+		origin := "example."
+		if tc.zone == dbMiekNL {
+			origin = "miek.nl."
+		}
+		z, err := Parse(strings.NewReader(tc.zone), origin, "stdin", 0)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error when reading zone, got %q", i, err)
+		}
+		err = z.VerifyZONEMD(tc.policy)
+		if tc.shouldErr && err == nil {
+			t.Errorf("Test %d: expected error, got none", i)
+		}
+		if !tc.shouldErr && err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+		}
+	}
+}
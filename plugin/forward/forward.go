@@ -14,6 +14,7 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/debug"
 	"github.com/coredns/coredns/plugin/dnstap"
+	"github.com/coredns/coredns/plugin/ecs"
 	"github.com/coredns/coredns/plugin/metadata"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 	"github.com/coredns/coredns/plugin/pkg/proxy"
@@ -148,8 +149,14 @@ func (f *Forward) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 		)
 		opts := f.opts
 
+		// The client subnet option is only sent to the upstreams the ecs plugin allows.
+		connState := state
+		if !ecs.Forward(ctx, proxy.Addr()) {
+			connState = request.Request{W: w, Req: ecs.Strip(r)}
+		}
+
 		for {
-			ret, err = proxy.Connect(ctx, state, opts)
+			ret, err = proxy.Connect(ctx, connState, opts)
 
 			if err == ErrCachedClosed { // Remote side closed conn, can only happen with TCP.
 				continue
@@ -0,0 +1,26 @@
+package geoip
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/coredns/coredns/plugin/metadata"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+func (g GeoIP) setASNMetadata(ctx context.Context, data *geoip2.ASN) {
+	setAutonomousSystem(ctx, data.AutonomousSystemNumber, data.AutonomousSystemOrganization)
+}
+
+// setAutonomousSystem sets the labels of the autonomous system, these are provided by both the ASN and ISP
+// databases.
+func setAutonomousSystem(ctx context.Context, number uint, organization string) {
+	asNumber := strconv.FormatUint(uint64(number), 10)
+	metadata.SetValueFunc(ctx, pluginName+"/asn/number", func() string {
+		return asNumber
+	})
+	metadata.SetValueFunc(ctx, pluginName+"/asn/organization", func() string {
+		return organization
+	})
+}
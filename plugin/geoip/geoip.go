@@ -17,11 +17,11 @@ import (
 
 var log = clog.NewWithPlugin(pluginName)
 
-// GeoIP is a plugin that add geo location data to the request context by looking up a maxmind
-// geoIP2 database, and which data can be later consumed by other middlewares.
+// GeoIP is a plugin that add geo location data to the request context by looking up maxmind
+// geoIP2 databases, and which data can be later consumed by other middlewares.
 type GeoIP struct {
 	Next  plugin.Handler
-	db    db
+	dbs   []db
 	edns0 bool
 }
 
@@ -34,40 +34,61 @@ type db struct {
 
 const (
 	city = 1 << iota
+	asn
+	isp
 )
 
 var probingIP = net.ParseIP("127.0.0.1")
 
-func newGeoIP(dbPath string, edns0 bool) (*GeoIP, error) {
+func newGeoIP(dbPaths []string, edns0 bool) (*GeoIP, error) {
+	g := &GeoIP{edns0: edns0}
+	provided := 0
+	for _, dbPath := range dbPaths {
+		db, err := openDB(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		// An ISP database provides the asn schema as well.
+		if provided&db.provides != 0 {
+			return nil, fmt.Errorf("database %q provides a schema that is already provided by another database", filepath.Base(dbPath))
+		}
+		provided |= db.provides
+		g.dbs = append(g.dbs, db)
+	}
+	return g, nil
+}
+
+func openDB(dbPath string) (db, error) {
 	reader, err := geoip2.Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database file: %v", err)
+		return db{}, fmt.Errorf("failed to open database file: %v", err)
 	}
-	db := db{Reader: reader}
+	d := db{Reader: reader}
 	schemas := []struct {
 		provides int
 		name     string
 		validate func() error
 	}{
 		{name: "city", provides: city, validate: func() error { _, err := reader.City(probingIP); return err }},
+		{name: "asn", provides: asn, validate: func() error { _, err := reader.ASN(probingIP); return err }},
+		{name: "isp", provides: isp, validate: func() error { _, err := reader.ISP(probingIP); return err }},
 	}
 	// Query the database to figure out the database type.
 	for _, schema := range schemas {
 		if err := schema.validate(); err != nil {
 			// If we get an InvalidMethodError then we know this database does not provide that schema.
 			if _, ok := err.(geoip2.InvalidMethodError); !ok {
-				return nil, fmt.Errorf("unexpected failure looking up database %q schema %q: %v", filepath.Base(dbPath), schema.name, err)
+				return db{}, fmt.Errorf("unexpected failure looking up database %q schema %q: %v", filepath.Base(dbPath), schema.name, err)
 			}
 		} else {
-			db.provides |= schema.provides
+			d.provides |= schema.provides
 		}
 	}
 
-	if db.provides&city == 0 {
-		return nil, fmt.Errorf("database does not provide city schema")
+	if d.provides == 0 {
+		return db{}, fmt.Errorf("database does not provide city, asn or isp schema")
 	}
-
-	return &GeoIP{db: db, edns0: edns0}, nil
+	return d, nil
 }
 
 // ServeDNS implements the plugin.Handler interface.
@@ -91,14 +112,33 @@ func (g GeoIP) Metadata(ctx context.Context, state request.Request) context.Cont
 		}
 	}
 
-	switch {
-	case g.db.provides&city == city:
-		data, err := g.db.City(srcIP)
-		if err != nil {
-			log.Debugf("Setting up metadata failed due to database lookup error: %v", err)
-			return ctx
+	for _, db := range g.dbs {
+		if db.provides&city == city {
+			data, err := db.City(srcIP)
+			if err != nil {
+				log.Debugf("Setting up metadata failed due to database lookup error: %v", err)
+				return ctx
+			}
+			g.setCityMetadata(ctx, data)
+		}
+
+		switch {
+		case db.provides&isp == isp:
+			// The ISP database also provides the autonomous system.
+			data, err := db.ISP(srcIP)
+			if err != nil {
+				log.Debugf("Setting up metadata failed due to database lookup error: %v", err)
+				return ctx
+			}
+			g.setISPMetadata(ctx, data)
+		case db.provides&asn == asn:
+			data, err := db.ASN(srcIP)
+			if err != nil {
+				log.Debugf("Setting up metadata failed due to database lookup error: %v", err)
+				return ctx
+			}
+			g.setASNMetadata(ctx, data)
 		}
-		g.setCityMetadata(ctx, data)
 	}
 	return ctx
 }
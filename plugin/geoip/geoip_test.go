@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"path/filepath"
 	"testing"
 
 	"github.com/coredns/coredns/plugin/metadata"
@@ -37,7 +38,7 @@ func TestMetadata(t *testing.T) {
 	knownIPAddr := "81.2.69.142" // This IP should be part of the CDIR address range used to create the database fixtures.
 	for _, tc := range tests {
 		t.Run(fmt.Sprintf("%s/%s", tc.label, "direct"), func(t *testing.T) {
-			geoIP, err := newGeoIP(cityDBPath, false)
+			geoIP, err := newGeoIP([]string{cityDBPath}, false)
 			if err != nil {
 				t.Fatalf("unable to create geoIP plugin: %v", err)
 			}
@@ -49,7 +50,7 @@ func TestMetadata(t *testing.T) {
 		})
 
 		t.Run(fmt.Sprintf("%s/%s", tc.label, "subnet"), func(t *testing.T) {
-			geoIP, err := newGeoIP(cityDBPath, true)
+			geoIP, err := newGeoIP([]string{cityDBPath}, true)
 			if err != nil {
 				t.Fatalf("unable to create geoIP plugin: %v", err)
 			}
@@ -70,6 +71,53 @@ func TestMetadata(t *testing.T) {
 	}
 }
 
+func TestNetworkMetadata(t *testing.T) {
+	tests := []struct {
+		dbPath        string
+		label         string
+		expectedValue string
+	}{
+		{asnDBPath, "geoip/asn/number", "64512"},
+		{asnDBPath, "geoip/asn/organization", "Example Networks"},
+
+		{ispDBPath, "geoip/asn/number", "64512"},
+		{ispDBPath, "geoip/asn/organization", "Example Networks"},
+		{ispDBPath, "geoip/isp/name", "Example ISP"},
+		{ispDBPath, "geoip/isp/organization", "Example Organization"},
+		{ispDBPath, "geoip/isp/mobile_country_code", "234"},
+		{ispDBPath, "geoip/isp/mobile_network_code", "15"},
+	}
+
+	knownIPAddr := "81.2.69.142"
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s/%s", filepath.Base(tc.dbPath), tc.label), func(t *testing.T) {
+			geoIP, err := newGeoIP([]string{tc.dbPath}, false)
+			if err != nil {
+				t.Fatalf("unable to create geoIP plugin: %v", err)
+			}
+			state := request.Request{
+				Req: new(dns.Msg),
+				W:   &test.ResponseWriter{RemoteIP: knownIPAddr},
+			}
+			testMetadata(t, state, geoIP, tc.label, tc.expectedValue)
+		})
+	}
+}
+
+func TestMultipleDatabasesMetadata(t *testing.T) {
+	geoIP, err := newGeoIP([]string{cityDBPath, asnDBPath}, false)
+	if err != nil {
+		t.Fatalf("unable to create geoIP plugin: %v", err)
+	}
+	state := request.Request{
+		Req: new(dns.Msg),
+		W:   &test.ResponseWriter{RemoteIP: "81.2.69.142"},
+	}
+	testMetadata(t, state, geoIP, "geoip/country/code", "GB")
+	testMetadata(t, state, geoIP, "geoip/latitude", "52.2242")
+	testMetadata(t, state, geoIP, "geoip/asn/number", "64512")
+}
+
 func testMetadata(t *testing.T, state request.Request, geoIP *GeoIP, label, expectedValue string) {
 	ctx := metadata.ContextWithMetadata(context.Background())
 	rCtx := geoIP.Metadata(ctx, state)
@@ -0,0 +1,30 @@
+package geoip
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin/metadata"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+func (g GeoIP) setISPMetadata(ctx context.Context, data *geoip2.ISP) {
+	setAutonomousSystem(ctx, data.AutonomousSystemNumber, data.AutonomousSystemOrganization)
+
+	ispName := data.ISP
+	metadata.SetValueFunc(ctx, pluginName+"/isp/name", func() string {
+		return ispName
+	})
+	organization := data.Organization
+	metadata.SetValueFunc(ctx, pluginName+"/isp/organization", func() string {
+		return organization
+	})
+	mobileCountryCode := data.MobileCountryCode
+	metadata.SetValueFunc(ctx, pluginName+"/isp/mobile_country_code", func() string {
+		return mobileCountryCode
+	})
+	mobileNetworkCode := data.MobileNetworkCode
+	metadata.SetValueFunc(ctx, pluginName+"/isp/mobile_network_code", func() string {
+		return mobileNetworkCode
+	})
+}
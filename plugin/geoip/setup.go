@@ -25,19 +25,15 @@ func setup(c *caddy.Controller) error {
 }
 
 func geoipParse(c *caddy.Controller) (*GeoIP, error) {
-	var dbPath string
+	var dbPaths []string
 	var edns0 bool
 
 	for c.Next() {
-		if !c.NextArg() {
-			return nil, c.ArgErr()
-		}
-		if dbPath != "" {
-			return nil, c.Errf("configuring multiple databases is not supported")
+		if dbPaths != nil {
+			return nil, c.Errf("geoip can only be used once per server block, list all databases in it")
 		}
-		dbPath = c.Val()
-		// There shouldn't be any more arguments.
-		if len(c.RemainingArgs()) != 0 {
+		dbPaths = c.RemainingArgs()
+		if len(dbPaths) == 0 {
 			return nil, c.ArgErr()
 		}
 
@@ -49,7 +45,7 @@ func geoipParse(c *caddy.Controller) (*GeoIP, error) {
 		}
 	}
 
-	geoIP, err := newGeoIP(dbPath, edns0)
+	geoIP, err := newGeoIP(dbPaths, edns0)
 	if err != nil {
 		return geoIP, c.Err(err.Error())
 	}
@@ -14,6 +14,8 @@ import (
 var (
 	fixturesDir   = "./testdata"
 	cityDBPath    = filepath.Join(fixturesDir, "GeoLite2-City.mmdb")
+	asnDBPath     = filepath.Join(fixturesDir, "GeoLite2-ASN.mmdb")
+	ispDBPath     = filepath.Join(fixturesDir, "GeoIP2-ISP.mmdb")
 	unknownDBPath = filepath.Join(fixturesDir, "GeoLite2-UnknownDbType.mmdb")
 )
 
@@ -53,12 +55,18 @@ func TestGeoIPParse(t *testing.T) {
 		// Valid
 		{false, fmt.Sprintf("%s %s\n", pluginName, cityDBPath), "", city},
 		{false, fmt.Sprintf("%s %s { edns-subnet }", pluginName, cityDBPath), "", city},
+		{false, fmt.Sprintf("%s %s\n", pluginName, asnDBPath), "", asn},
+		{false, fmt.Sprintf("%s %s\n", pluginName, ispDBPath), "", isp | asn},
+		{false, fmt.Sprintf("%s %s %s\n", pluginName, cityDBPath, asnDBPath), "", city | asn},
+		{false, fmt.Sprintf("%s %s %s { edns-subnet }", pluginName, ispDBPath, cityDBPath), "", city | isp | asn},
 
 		// Invalid
 		{true, pluginName, "Wrong argument count", 0},
 		{true, fmt.Sprintf("%s %s {\n\tlanguages en fr es zh-CN\n}\n", pluginName, cityDBPath), "unknown property \"languages\"", 0},
-		{true, fmt.Sprintf("%s %s\n%s %s\n", pluginName, cityDBPath, pluginName, cityDBPath), "configuring multiple databases is not supported", 0},
-		{true, fmt.Sprintf("%s 1 2 3", pluginName), "Wrong argument count", 0},
+		{true, fmt.Sprintf("%s %s\n%s %s\n", pluginName, cityDBPath, pluginName, asnDBPath), "geoip can only be used once per server block", 0},
+		{true, fmt.Sprintf("%s %s %s\n", pluginName, cityDBPath, cityDBPath), "provides a schema that is already provided", 0},
+		{true, fmt.Sprintf("%s %s %s\n", pluginName, ispDBPath, asnDBPath), "provides a schema that is already provided", 0},
+		{true, fmt.Sprintf("%s 1 2 3", pluginName), "failed to open database file", 0},
 		{true, fmt.Sprintf("%s { }", pluginName), "Error during parsing", 0},
 		{true, fmt.Sprintf("%s /dbpath { city }", pluginName), "unknown property \"city\"", 0},
 		{true, fmt.Sprintf("%s /invalidPath\n", pluginName), "failed to open database file: open /invalidPath: no such file or directory", 0},
@@ -84,12 +92,16 @@ func TestGeoIPParse(t *testing.T) {
 			continue
 		}
 
-		if geoIP.db.Reader == nil {
-			t.Errorf("Test %d: after parsing database reader should be initialized", i)
+		provides := 0
+		for _, db := range geoIP.dbs {
+			if db.Reader == nil {
+				t.Errorf("Test %d: after parsing database reader should be initialized", i)
+			}
+			provides |= db.provides
 		}
 
-		if geoIP.db.provides&test.expectedDBType == 0 {
-			t.Errorf("Test %d: expected db type %d not found, database file provides %d", i, test.expectedDBType, geoIP.db.provides)
+		if provides != test.expectedDBType {
+			t.Errorf("Test %d: expected db type %d not found, database files provide %d", i, test.expectedDBType, provides)
 		}
 	}
 
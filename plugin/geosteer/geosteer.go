@@ -0,0 +1,158 @@
+// Package geosteer implements a plugin that selects the addresses in responses by the location of the client.
+package geosteer
+
+import (
+	"context"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/pkg/addrfilter"
+
+	"github.com/miekg/dns"
+)
+
+// GeoSteer is a plugin that only returns the addresses of the region that is preferred by the client. The client
+// is located with the metadata of the geoip plugin.
+type GeoSteer struct {
+	Next  plugin.Handler
+	Zones []string
+
+	regions []*region
+	// Explicit mappings of clients to regions, these take precedence over the distance.
+	asns       map[string]*region
+	countries  map[string]*region
+	continents map[string]*region
+}
+
+// region is a location and the addresses that are served from it.
+type region struct {
+	name      string
+	latitude  float64
+	longitude float64
+	nets      []*net.IPNet
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (g *GeoSteer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	if len(r.Question) == 0 || plugin.Zones(g.Zones).Matches(strings.ToLower(r.Question[0].Name)) == "" {
+		return plugin.NextOrFailure(g.Name(), g.Next, ctx, w, r)
+	}
+	rw := &addrfilter.ResponseWriter{ResponseWriter: w, Filter: func(answer []dns.RR) []dns.RR {
+		prefs := g.preferences(ctx)
+		if len(prefs) == 0 {
+			return answer
+		}
+		return g.filter(answer, prefs)
+	}}
+	return plugin.NextOrFailure(g.Name(), g.Next, ctx, rw, r)
+}
+
+// Name implements the Handler interface.
+func (g *GeoSteer) Name() string { return "geosteer" }
+
+// preferences returns the regions in the order the client prefers them: the region the client is mapped to,
+// followed by all regions from near to far. It returns nil if the client can't be located.
+func (g *GeoSteer) preferences(ctx context.Context) []*region {
+	var prefs []*region
+	if r := g.mapped(ctx); r != nil {
+		prefs = append(prefs, r)
+	}
+
+	lat, err1 := strconv.ParseFloat(value(ctx, "geoip/latitude"), 64)
+	long, err2 := strconv.ParseFloat(value(ctx, "geoip/longitude"), 64)
+	// The database returns 0, 0 for addresses without a location.
+	if err1 != nil || err2 != nil || (lat == 0 && long == 0) {
+		return prefs
+	}
+	nearest := make([]*region, len(g.regions))
+	copy(nearest, g.regions)
+	sort.SliceStable(nearest, func(i, j int) bool {
+		return distance(lat, long, nearest[i].latitude, nearest[i].longitude) < distance(lat, long, nearest[j].latitude, nearest[j].longitude)
+	})
+	return append(prefs, nearest...)
+}
+
+// mapped returns the region the client is mapped to, the most specific mapping wins.
+func (g *GeoSteer) mapped(ctx context.Context) *region {
+	if r, ok := g.asns[value(ctx, "geoip/asn/number")]; ok {
+		return r
+	}
+	if r, ok := g.countries[value(ctx, "geoip/country/code")]; ok {
+		return r
+	}
+	if r, ok := g.continents[value(ctx, "geoip/continent/code")]; ok {
+		return r
+	}
+	return nil
+}
+
+// filter returns the records of rrs, with only the addresses of the most preferred region of each address
+// RRset. An RRset without addresses in any of the preferred regions is returned as is.
+func (g *GeoSteer) filter(rrs []dns.RR, prefs []*region) []dns.RR {
+	regions := make(map[dns.RR]*region)
+	present := make(map[addrfilter.Key]map[*region]bool)
+	for _, rr := range rrs {
+		ip := addrfilter.Address(rr)
+		if ip == nil {
+			continue
+		}
+		set := addrfilter.KeyOf(rr)
+		if present[set] == nil {
+			present[set] = make(map[*region]bool)
+		}
+		reg := g.region(ip)
+		regions[rr] = reg
+		present[set][reg] = true
+	}
+
+	selected := make(map[addrfilter.Key]*region, len(present))
+	for set, in := range present {
+		for _, reg := range prefs {
+			if in[reg] {
+				selected[set] = reg
+				break
+			}
+		}
+	}
+
+	return addrfilter.Filter(rrs, func(rr dns.RR) bool {
+		sel, ok := selected[addrfilter.KeyOf(rr)]
+		return !ok || sel == regions[rr]
+	})
+}
+
+// region returns the region of ip, or nil if it isn't in a region.
+func (g *GeoSteer) region(ip net.IP) *region {
+	for _, r := range g.regions {
+		for _, n := range r.nets {
+			if n.Contains(ip) {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// value returns the value of the metadata label, or the empty string if it isn't set.
+func value(ctx context.Context, label string) string {
+	if f := metadata.ValueFunc(ctx, label); f != nil {
+		return f()
+	}
+	return ""
+}
+
+const earthRadius = 6371 // in km
+
+// distance returns the great-circle distance in km between two coordinates, using the haversine formula.
+func distance(lat1, long1, lat2, long2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLong := rad(long2 - long1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,184 @@
+package geosteer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/pkg/addrfilter"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+const testConfig = `geosteer example.org {
+	region eu 50.11 8.68 10.0.1.0/24 2001:db8:1::/48
+	region us 39.04 -77.49 10.0.2.0/24
+	region ap 1.35 103.82 10.0.3.1
+	country us CA MX
+	continent ap OC
+	asn eu 64512
+}`
+
+func newTestGeoSteer(t *testing.T) *GeoSteer {
+	g, err := parse(caddy.NewTestController("dns", testConfig))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return g
+}
+
+// clientContext returns a context with the geoip metadata in labels.
+func clientContext(labels map[string]string) context.Context {
+	ctx := metadata.ContextWithMetadata(context.Background())
+	for l, v := range labels {
+		v := v
+		metadata.SetValueFunc(ctx, l, func() string { return v })
+	}
+	return ctx
+}
+
+func TestPreferences(t *testing.T) {
+	g := newTestGeoSteer(t)
+
+	tests := []struct {
+		labels   map[string]string
+		expected []string
+	}{
+		// London
+		{map[string]string{"geoip/latitude": "51.50", "geoip/longitude": "-0.12"}, []string{"eu", "us", "ap"}},
+		// Tokyo
+		{map[string]string{"geoip/latitude": "35.68", "geoip/longitude": "139.69"}, []string{"ap", "eu", "us"}},
+		// Toronto, mapped by its country, nearest is us as well.
+		{map[string]string{"geoip/country/code": "CA", "geoip/latitude": "43.65", "geoip/longitude": "-79.38"}, []string{"us", "us", "eu", "ap"}},
+		// Sydney, mapped by its continent without a location.
+		{map[string]string{"geoip/country/code": "AU", "geoip/continent/code": "OC"}, []string{"ap"}},
+		// The autonomous system is the most specific mapping.
+		{map[string]string{"geoip/asn/number": "64512", "geoip/country/code": "CA"}, []string{"eu"}},
+		// Only the autonomous system, as with just an ASN database.
+		{map[string]string{"geoip/asn/number": "64512"}, []string{"eu"}},
+		// Unknown location.
+		{map[string]string{"geoip/latitude": "0", "geoip/longitude": "0"}, nil},
+		{nil, nil},
+	}
+
+	for i, tc := range tests {
+		prefs := g.preferences(clientContext(tc.labels))
+		if len(prefs) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %d regions", i, tc.expected, len(prefs))
+			continue
+		}
+		for j, r := range prefs {
+			if r.name != tc.expected[j] {
+				t.Errorf("Test %d: expected region %s at %d, got %s", i, tc.expected[j], j, r.name)
+			}
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	g := newTestGeoSteer(t)
+	answer := []dns.RR{
+		test.CNAME("www.example.org.	300	IN	CNAME	app.example.org."),
+		test.A("app.example.org.	300	IN	A	10.0.1.1"),
+		test.A("app.example.org.	300	IN	A	10.0.1.2"),
+		test.A("app.example.org.	300	IN	A	10.0.2.1"),
+		test.A("app.example.org.	300	IN	A	10.0.3.1"),
+		test.A("app.example.org.	300	IN	A	10.0.4.1"),
+		test.AAAA("app.example.org.	300	IN	AAAA	2001:db8:2::1"),
+	}
+	lookup := func(name string) *region {
+		for _, r := range g.regions {
+			if r.name == name {
+				return r
+			}
+		}
+		t.Fatalf("Unknown region %s", name)
+		return nil
+	}
+
+	tests := []struct {
+		prefs    []string
+		expected []string
+	}{
+		{[]string{"eu", "us", "ap"}, []string{"10.0.1.1", "10.0.1.2", "2001:db8:2::1"}},
+		{[]string{"us", "eu", "ap"}, []string{"10.0.2.1", "2001:db8:2::1"}},
+		{[]string{"ap"}, []string{"10.0.3.1", "2001:db8:2::1"}},
+	}
+	for i, tc := range tests {
+		var prefs []*region
+		for _, p := range tc.prefs {
+			prefs = append(prefs, lookup(p))
+		}
+		rrs := g.filter(answer, prefs)
+		if _, ok := rrs[0].(*dns.CNAME); !ok {
+			t.Errorf("Test %d: expected the CNAME to be kept, got %v", i, rrs)
+		}
+		var got []string
+		for _, rr := range rrs {
+			if ip := addrfilter.Address(rr); ip != nil {
+				got = append(got, ip.String())
+			}
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+			continue
+		}
+		for j := range got {
+			if got[j] != tc.expected[j] {
+				t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestServeDNS(t *testing.T) {
+	g := newTestGeoSteer(t)
+	g.Next = test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Answer = []dns.RR{
+			test.A(r.Question[0].Name + "	300	IN	A	10.0.1.1"),
+			test.A(r.Question[0].Name + "	300	IN	A	10.0.2.1"),
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+	london := map[string]string{"geoip/latitude": "51.50", "geoip/longitude": "-0.12"}
+
+	tests := []struct {
+		qname    string
+		qtype    uint16
+		labels   map[string]string
+		expected int
+	}{
+		{"a.example.org.", dns.TypeA, london, 1},
+		{"a.example.org.", dns.TypeA, nil, 2},
+		{"a.example.net.", dns.TypeA, london, 2}, // not in our zone
+		{"example.org.", dns.TypeAXFR, london, 2},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := g.ServeDNS(clientContext(tc.labels), rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if len(rec.Msg.Answer) != tc.expected {
+			t.Errorf("Test %d: expected %d answers, got %d", i, tc.expected, len(rec.Msg.Answer))
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// London to Paris is about 344 km.
+	if d := distance(51.5074, -0.1278, 48.8566, 2.3522); d < 340 || d > 348 {
+		t.Errorf("Expected about 344 km, got %f", d)
+	}
+	if d := distance(10, 10, 10, 10); d != 0 {
+		t.Errorf("Expected 0 km, got %f", d)
+	}
+}
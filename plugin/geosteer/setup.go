@@ -0,0 +1,156 @@
+package geosteer
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+)
+
+const pluginName = "geosteer"
+
+func init() { plugin.Register(pluginName, setup) }
+
+func setup(c *caddy.Controller) error {
+	g, err := parse(c)
+	if err != nil {
+		return plugin.Error(pluginName, err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		g.Next = next
+		return g
+	})
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (*GeoSteer, error) {
+	g := &GeoSteer{
+		asns:       make(map[string]*region),
+		countries:  make(map[string]*region),
+		continents: make(map[string]*region),
+	}
+	// The mappings can refer to regions that are defined later in the block.
+	type mapping struct {
+		m      map[string]*region
+		region string
+		keys   []string
+	}
+	var mappings []mapping
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		g.Zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+		for c.NextBlock() {
+			switch c.Val() {
+			case "region":
+				args := c.RemainingArgs()
+				if len(args) < 4 {
+					return nil, c.ArgErr()
+				}
+				r, err := parseRegion(c, args)
+				if err != nil {
+					return nil, err
+				}
+				for _, other := range g.regions {
+					if other.name == r.name {
+						return nil, c.Errf("duplicate region %q", r.name)
+					}
+				}
+				g.regions = append(g.regions, r)
+			case "asn":
+				args := c.RemainingArgs()
+				if len(args) < 2 {
+					return nil, c.ArgErr()
+				}
+				keys := make([]string, len(args)-1)
+				for i, a := range args[1:] {
+					n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(a), "AS"), 10, 32)
+					if err != nil {
+						return nil, c.Errf("invalid autonomous system number %q", a)
+					}
+					// Normalize, the geoip plugin doesn't use leading zeros.
+					keys[i] = strconv.FormatUint(n, 10)
+				}
+				mappings = append(mappings, mapping{g.asns, args[0], keys})
+			case "country", "continent":
+				m := g.countries
+				if c.Val() == "continent" {
+					m = g.continents
+				}
+				args := c.RemainingArgs()
+				if len(args) < 2 {
+					return nil, c.ArgErr()
+				}
+				keys := make([]string, len(args)-1)
+				for i, a := range args[1:] {
+					keys[i] = strings.ToUpper(a)
+				}
+				mappings = append(mappings, mapping{m, args[0], keys})
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+
+	if len(g.regions) == 0 {
+		return nil, c.Err("no regions defined")
+	}
+	for _, mp := range mappings {
+		var r *region
+		for _, reg := range g.regions {
+			if reg.name == mp.region {
+				r = reg
+			}
+		}
+		if r == nil {
+			return nil, c.Errf("unknown region %q", mp.region)
+		}
+		for _, k := range mp.keys {
+			if _, ok := mp.m[k]; ok {
+				return nil, c.Errf("%q is mapped to multiple regions", k)
+			}
+			mp.m[k] = r
+		}
+	}
+	return g, nil
+}
+
+// parseRegion parses the arguments of region: NAME LATITUDE LONGITUDE ADDRESS|CIDR...
+func parseRegion(c *caddy.Controller, args []string) (*region, error) {
+	r := &region{name: args[0]}
+	var err error
+	r.latitude, err = strconv.ParseFloat(args[1], 64)
+	if err != nil || r.latitude < -90 || r.latitude > 90 {
+		return nil, c.Errf("invalid latitude %q", args[1])
+	}
+	r.longitude, err = strconv.ParseFloat(args[2], 64)
+	if err != nil || r.longitude < -180 || r.longitude > 180 {
+		return nil, c.Errf("invalid longitude %q", args[2])
+	}
+	for _, a := range args[3:] {
+		if ip := net.ParseIP(a); ip != nil {
+			n := &net.IPNet{IP: ip, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}
+			if ip4 := ip.To4(); ip4 != nil {
+				n = &net.IPNet{IP: ip4, Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)}
+			}
+			r.nets = append(r.nets, n)
+			continue
+		}
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			return nil, c.Errf("invalid region address %q", a)
+		}
+		r.nets = append(r.nets, n)
+	}
+	return r, nil
+}
@@ -0,0 +1,94 @@
+package geosteer
+
+import (
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input             string
+		shouldErr         bool
+		expectedRegions   int
+		expectedCountries int
+		expectedASNs      int
+	}{
+		{testConfig, false, 3, 2, 1},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			asn eu AS64512 065513
+		}`, false, 1, 0, 2},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			region us 39.04 -77.49 10.0.2.0/24
+			asn eu 64512
+			asn us AS64513
+		}`, false, 2, 0, 2},
+		// fails
+		{`geosteer`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 91 8.68 10.0.1.0/24
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 181 10.0.1.0/24
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 example.org
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			region eu 50.11 8.68 10.0.2.0/24
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			country us US
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			region us 39.04 -77.49 10.0.2.0/24
+			country eu GB
+			country us gb
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			asn eu example
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			continent eu
+		}`, true, 0, 0, 0},
+		{`geosteer {
+			region eu 50.11 8.68 10.0.1.0/24
+			blah
+		}`, true, 0, 0, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		g, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if len(g.regions) != test.expectedRegions {
+			t.Errorf("Test %d: Expected %d regions, got %d", i, test.expectedRegions, len(g.regions))
+		}
+		if len(g.countries) != test.expectedCountries {
+			t.Errorf("Test %d: Expected %d countries, got %d", i, test.expectedCountries, len(g.countries))
+		}
+		if len(g.asns) != test.expectedASNs {
+			t.Errorf("Test %d: Expected %d autonomous systems, got %d", i, test.expectedASNs, len(g.asns))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package gslb implements a plugin that removes unhealthy addresses from responses.
+package gslb
+
+import (
+	"context"
+	"math"
+	"net"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/addrfilter"
+
+	"github.com/miekg/dns"
+)
+
+// GSLB is a plugin that health checks the addresses in responses, and only returns the healthy addresses of the
+// most preferred pool.
+type GSLB struct {
+	Next  plugin.Handler
+	Zones []string
+
+	targets *targets
+	pools   []pool
+}
+
+// pool is a group of addresses with a priority, a lower number is preferred.
+type pool struct {
+	priority int
+	nets     []*net.IPNet
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (g *GSLB) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	if len(r.Question) == 0 || plugin.Zones(g.Zones).Matches(strings.ToLower(r.Question[0].Name)) == "" {
+		return plugin.NextOrFailure(g.Name(), g.Next, ctx, w, r)
+	}
+	rw := &addrfilter.ResponseWriter{ResponseWriter: w, Filter: g.filter}
+	return plugin.NextOrFailure(g.Name(), g.Next, ctx, rw, r)
+}
+
+// Name implements the Handler interface.
+func (g *GSLB) Name() string { return "gslb" }
+
+// filter returns the records of rrs, with only the healthy addresses of the most preferred pool of each
+// address RRset. If none of the addresses of an RRset are healthy, all of them are returned.
+func (g *GSLB) filter(rrs []dns.RR) []dns.RR {
+	type address struct {
+		priority int
+		healthy  bool
+	}
+	addrs := make(map[dns.RR]address)
+	best := make(map[addrfilter.Key]int)
+	for _, rr := range rrs {
+		ip := addrfilter.Address(rr)
+		if ip == nil {
+			continue
+		}
+		a := address{priority: g.priority(ip), healthy: g.targets.healthy(ip)}
+		addrs[rr] = a
+
+		set := addrfilter.KeyOf(rr)
+		if _, ok := best[set]; !ok {
+			best[set] = math.MaxInt
+		}
+		if a.healthy && a.priority < best[set] {
+			best[set] = a.priority
+		}
+	}
+
+	return addrfilter.Filter(rrs, func(rr dns.RR) bool {
+		a := addrs[rr]
+		p := best[addrfilter.KeyOf(rr)]
+		// If nothing is healthy, p is still math.MaxInt and all addresses are returned.
+		return p == math.MaxInt || (a.healthy && a.priority == p)
+	})
+}
+
+// priority returns the priority of the pool of ip, addresses that aren't in a pool have the lowest priority.
+func (g *GSLB) priority(ip net.IP) int {
+	for _, p := range g.pools {
+		for _, n := range p.nets {
+			if n.Contains(ip) {
+				return p.priority
+			}
+		}
+	}
+	return math.MaxInt - 1
+}
@@ -0,0 +1,251 @@
+package gslb
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/addrfilter"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// newTestGSLB returns a GSLB that isn't started, with the health of the targets set to health.
+func newTestGSLB(pools []pool, health map[string]bool) *GSLB {
+	g := &GSLB{Zones: []string{"example.org."}, pools: pools, targets: newTargets(check{kind: checkTCP, port: "80"})}
+	for ip, ok := range health {
+		g.targets.add(ip).healthy.Store(ok)
+	}
+	return g
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func addresses(rrs []dns.RR) []string {
+	var ips []string
+	for _, rr := range rrs {
+		if ip := addrfilter.Address(rr); ip != nil {
+			ips = append(ips, ip.String())
+		}
+	}
+	return ips
+}
+
+func TestFilter(t *testing.T) {
+	pools := []pool{
+		{priority: 1, nets: []*net.IPNet{mustCIDR("10.0.1.0/24")}},
+		{priority: 2, nets: []*net.IPNet{mustCIDR("10.0.2.0/24")}},
+	}
+	answer := []dns.RR{
+		test.CNAME("www.example.org.	300	IN	CNAME	app.example.org."),
+		test.A("app.example.org.	300	IN	A	10.0.1.1"),
+		test.A("app.example.org.	300	IN	A	10.0.1.2"),
+		test.A("app.example.org.	300	IN	A	10.0.2.1"),
+		test.A("app.example.org.	300	IN	A	10.0.3.1"),
+	}
+
+	tests := []struct {
+		health   map[string]bool
+		expected []string
+	}{
+		// All healthy, only the first pool is returned.
+		{map[string]bool{"10.0.1.1": true, "10.0.1.2": true, "10.0.2.1": true, "10.0.3.1": true}, []string{"10.0.1.1", "10.0.1.2"}},
+		// An unhealthy address is removed.
+		{map[string]bool{"10.0.1.1": false, "10.0.1.2": true, "10.0.2.1": true, "10.0.3.1": true}, []string{"10.0.1.2"}},
+		// The first pool is down, fail over to the second.
+		{map[string]bool{"10.0.1.1": false, "10.0.1.2": false, "10.0.2.1": true, "10.0.3.1": true}, []string{"10.0.2.1"}},
+		// Only the address that isn't in a pool is left.
+		{map[string]bool{"10.0.1.1": false, "10.0.1.2": false, "10.0.2.1": false, "10.0.3.1": true}, []string{"10.0.3.1"}},
+		// Nothing is healthy, everything is returned.
+		{map[string]bool{"10.0.1.1": false, "10.0.1.2": false, "10.0.2.1": false, "10.0.3.1": false}, []string{"10.0.1.1", "10.0.1.2", "10.0.2.1", "10.0.3.1"}},
+	}
+
+	for i, tc := range tests {
+		g := newTestGSLB(pools, tc.health)
+		rrs := g.filter(answer)
+		if _, ok := rrs[0].(*dns.CNAME); !ok {
+			t.Errorf("Test %d: expected the CNAME to be kept, got %v", i, rrs)
+		}
+		got := addresses(rrs)
+		if len(got) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+			continue
+		}
+		for j := range got {
+			if got[j] != tc.expected[j] {
+				t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestFilterPerRRset(t *testing.T) {
+	g := newTestGSLB(nil, map[string]bool{"10.0.0.1": false, "10.0.0.2": true, "::1": false})
+	answer := []dns.RR{
+		test.A("a.example.org.	300	IN	A	10.0.0.1"),
+		test.A("a.example.org.	300	IN	A	10.0.0.2"),
+		test.AAAA("a.example.org.	300	IN	AAAA	::1"),
+	}
+	got := addresses(g.filter(answer))
+	// The AAAA RRset has no healthy addresses, so it is left alone.
+	if len(got) != 2 || got[0] != "10.0.0.2" || got[1] != "::1" {
+		t.Errorf("Expected [10.0.0.2 ::1], got %v", got)
+	}
+}
+
+func TestServeDNS(t *testing.T) {
+	g := newTestGSLB(nil, map[string]bool{"10.0.0.1": false, "10.0.0.2": true})
+	g.Next = test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Answer = []dns.RR{
+			test.A(r.Question[0].Name + "	300	IN	A	10.0.0.1"),
+			test.A(r.Question[0].Name + "	300	IN	A	10.0.0.2"),
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+
+	tests := []struct {
+		qname    string
+		qtype    uint16
+		expected int
+	}{
+		{"a.example.org.", dns.TypeA, 1},
+		{"a.example.net.", dns.TypeA, 2}, // not in our zone
+		{"example.org.", dns.TypeAXFR, 2},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := g.ServeDNS(context.TODO(), rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if len(rec.Msg.Answer) != tc.expected {
+			t.Errorf("Test %d: expected %d answers, got %d", i, tc.expected, len(rec.Msg.Answer))
+		}
+	}
+}
+
+// waitHealthy waits until the health of ip is healthy.
+func waitHealthy(t *testing.T, ts *targets, ip string, healthy bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if ts.healthy(net.ParseIP(ip)) == healthy {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected %s to have health %t", ip, healthy)
+}
+
+func testCheck(kind, port string) check {
+	return check{kind: kind, port: port, path: "/", interval: 20 * time.Millisecond, timeout: time.Second, fails: 2, passes: 2, expire: time.Hour}
+}
+
+func TestTCPCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := newTargets(testCheck(checkTCP, port))
+	ts.start(ctx)
+
+	// 127.0.0.2 doesn't have a listener on port.
+	waitHealthy(t, ts, "127.0.0.2", false)
+	waitHealthy(t, ts, "127.0.0.1", true)
+
+	// Without the listener, 127.0.0.1 becomes unhealthy.
+	l.Close()
+	waitHealthy(t, ts, "127.0.0.1", false)
+}
+
+func TestHTTPCheck(t *testing.T) {
+	healthy := make(chan bool, 1)
+	healthy <- true
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok := <-healthy
+		healthy <- ok
+		if r.URL.Path != "/health" || !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := testCheck(checkHTTP, port)
+	c.path = "/health"
+	ts := newTargets(c)
+	ts.addStatic(net.ParseIP(host))
+	ts.start(ctx)
+
+	waitHealthy(t, ts, host, true)
+
+	<-healthy
+	healthy <- false
+	waitHealthy(t, ts, host, false)
+
+	<-healthy
+	healthy <- true
+	waitHealthy(t, ts, host, true)
+}
+
+func TestExpire(t *testing.T) {
+	c := testCheck(checkTCP, "1")
+	c.expire = 50 * time.Millisecond
+	ts := newTargets(c)
+	ts.addStatic(net.ParseIP("127.0.0.1"))
+	ts.healthy(net.ParseIP("127.0.0.2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts.start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		ts.Lock()
+		_, seen := ts.m["127.0.0.2"]
+		_, static := ts.m["127.0.0.1"]
+		ts.Unlock()
+		if !seen {
+			if !static {
+				t.Fatal("Expected the static target not to expire")
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Expected 127.0.0.2 to expire")
+}
@@ -0,0 +1,196 @@
+package gslb
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	checkTCP  = "tcp"
+	checkHTTP = "http"
+)
+
+// check describes the health check of the targets.
+type check struct {
+	kind     string // checkTCP or checkHTTP
+	port     string
+	path     string // the path of HTTP checks
+	interval time.Duration
+	timeout  time.Duration
+	fails    int           // consecutive failures after which a healthy target is unhealthy
+	passes   int           // consecutive successes after which an unhealthy target is healthy again
+	expire   time.Duration // a target that isn't seen in a response for this long isn't checked anymore
+}
+
+// target is an address that is health checked.
+type target struct {
+	addr     string // address and port that is checked
+	healthy  atomic.Bool
+	lastSeen atomic.Int64
+
+	// Only used by the goroutine checking the target.
+	fails  int
+	passes int
+}
+
+// targets holds all addresses that are health checked. Addresses are added when they're first seen in a
+// response, or when they are listed in a pool, and are checked until they haven't been seen for check.expire.
+type targets struct {
+	check
+	client *http.Client
+
+	sync.Mutex
+	m      map[string]*target
+	ctx    context.Context // nil until started
+	static map[string]struct{}
+}
+
+func newTargets(c check) *targets {
+	return &targets{
+		check: c,
+		client: &http.Client{
+			Timeout: c.timeout,
+			// A redirect is a healthy response, don't follow it.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		m:      make(map[string]*target),
+		static: make(map[string]struct{}),
+	}
+}
+
+// start starts checking the targets until ctx is canceled.
+func (ts *targets) start(ctx context.Context) {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.ctx = ctx
+	for _, t := range ts.m {
+		go ts.run(ctx, t)
+	}
+}
+
+// addStatic adds ip as a target that is always checked, even when it isn't seen in responses.
+func (ts *targets) addStatic(ip net.IP) {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.static[ip.String()] = struct{}{}
+	ts.add(ip.String())
+}
+
+// add adds the target for ip, the lock must be held.
+func (ts *targets) add(ip string) *target {
+	t := &target{addr: net.JoinHostPort(ip, ts.port)}
+	// Targets are healthy until the checks say otherwise.
+	t.healthy.Store(true)
+	t.lastSeen.Store(time.Now().UnixNano())
+	ts.m[ip] = t
+	targetHealthy.WithLabelValues(t.addr).Set(1)
+	if ts.ctx != nil {
+		go ts.run(ts.ctx, t)
+	}
+	return t
+}
+
+// healthy returns true if ip is healthy, ip is added as a target if it's seen for the first time.
+func (ts *targets) healthy(ip net.IP) bool {
+	key := ip.String()
+	ts.Lock()
+	t, ok := ts.m[key]
+	if !ok {
+		t = ts.add(key)
+	}
+	ts.Unlock()
+
+	t.lastSeen.Store(time.Now().UnixNano())
+	return t.healthy.Load()
+}
+
+// run checks t every interval, until it expires or ctx is canceled.
+func (ts *targets) run(ctx context.Context, t *target) {
+	ticker := time.NewTicker(ts.interval)
+	defer ticker.Stop()
+	for {
+		ts.update(t, ts.probe(ctx, t.addr))
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if ts.expired(t) {
+			return
+		}
+	}
+}
+
+// expired removes t when it hasn't been seen for ts.expire, it returns true if t was removed.
+func (ts *targets) expired(t *target) bool {
+	if time.Since(time.Unix(0, t.lastSeen.Load())) < ts.expire {
+		return false
+	}
+	ts.Lock()
+	defer ts.Unlock()
+	host, _, _ := net.SplitHostPort(t.addr)
+	if _, ok := ts.static[host]; ok {
+		return false
+	}
+	delete(ts.m, host)
+	targetHealthy.DeleteLabelValues(t.addr)
+	checkCount.DeleteLabelValues(t.addr, resultSuccess)
+	checkCount.DeleteLabelValues(t.addr, resultFailure)
+	return true
+}
+
+// update updates the health of t with the result of a check.
+func (ts *targets) update(t *target, ok bool) {
+	if ok {
+		checkCount.WithLabelValues(t.addr, resultSuccess).Inc()
+		t.fails = 0
+		t.passes++
+		if !t.healthy.Load() && t.passes >= ts.passes {
+			log.Infof("Target %s is healthy", t.addr)
+			t.healthy.Store(true)
+			targetHealthy.WithLabelValues(t.addr).Set(1)
+		}
+		return
+	}
+
+	checkCount.WithLabelValues(t.addr, resultFailure).Inc()
+	t.passes = 0
+	t.fails++
+	if t.healthy.Load() && t.fails >= ts.fails {
+		log.Warningf("Target %s is unhealthy", t.addr)
+		t.healthy.Store(false)
+		targetHealthy.WithLabelValues(t.addr).Set(0)
+	}
+}
+
+// probe checks addr once, it returns true if addr is healthy.
+func (ts *targets) probe(ctx context.Context, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, ts.timeout)
+	defer cancel()
+
+	if ts.kind == checkTCP {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+ts.path, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
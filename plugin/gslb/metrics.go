@@ -0,0 +1,31 @@
+package gslb
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	// targetHealthy is 1 if the target is healthy and 0 if it isn't.
+	targetHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "gslb",
+		Name:      "target_healthy",
+		Help:      "Gauge of the health of each target, 1 if the target is healthy and 0 if it isn't.",
+	}, []string{"target"})
+
+	// checkCount is the number of health checks per target and result.
+	checkCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "gslb",
+		Name:      "checks_total",
+		Help:      "Counter of health checks per target and result.",
+	}, []string{"target", "result"})
+)
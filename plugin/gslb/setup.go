@@ -0,0 +1,179 @@
+package gslb
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+)
+
+const pluginName = "gslb"
+
+var log = clog.NewWithPlugin(pluginName)
+
+func init() { plugin.Register(pluginName, setup) }
+
+func setup(c *caddy.Controller) error {
+	g, err := parse(c)
+	if err != nil {
+		return plugin.Error(pluginName, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.OnStartup(func() error {
+		g.targets.start(ctx)
+		return nil
+	})
+	c.OnShutdown(func() error {
+		cancel()
+		return nil
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		g.Next = next
+		return g
+	})
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (*GSLB, error) {
+	chk := check{
+		interval: 10 * time.Second,
+		timeout:  2 * time.Second,
+		fails:    2,
+		passes:   2,
+		expire:   time.Hour,
+	}
+	g := &GSLB{}
+	var static []net.IP
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		g.Zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+		for c.NextBlock() {
+			switch c.Val() {
+			case "check":
+				args := c.RemainingArgs()
+				if len(args) < 2 {
+					return nil, c.ArgErr()
+				}
+				switch args[0] {
+				case checkTCP:
+					if len(args) != 2 {
+						return nil, c.ArgErr()
+					}
+				case checkHTTP:
+					if len(args) > 3 {
+						return nil, c.ArgErr()
+					}
+					chk.path = "/"
+					if len(args) == 3 {
+						if !strings.HasPrefix(args[2], "/") {
+							return nil, c.Errf("path must start with a slash: %q", args[2])
+						}
+						chk.path = args[2]
+					}
+				default:
+					return nil, c.Errf("unknown check type %q", args[0])
+				}
+				if p, err := strconv.Atoi(args[1]); err != nil || p < 1 || p > 65535 {
+					return nil, c.Errf("invalid port %q", args[1])
+				}
+				chk.kind, chk.port = args[0], args[1]
+			case "interval", "timeout", "expire":
+				opt := c.Val()
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(args[0])
+				if err != nil {
+					return nil, err
+				}
+				if d <= 0 {
+					return nil, c.Errf("%s must be positive: %s", opt, d)
+				}
+				switch opt {
+				case "interval":
+					chk.interval = d
+				case "timeout":
+					chk.timeout = d
+				case "expire":
+					chk.expire = d
+				}
+			case "threshold":
+				args := c.RemainingArgs()
+				if len(args) != 2 {
+					return nil, c.ArgErr()
+				}
+				fails, err := strconv.Atoi(args[0])
+				if err != nil || fails < 1 {
+					return nil, c.Errf("invalid threshold %q", args[0])
+				}
+				passes, err := strconv.Atoi(args[1])
+				if err != nil || passes < 1 {
+					return nil, c.Errf("invalid threshold %q", args[1])
+				}
+				chk.fails, chk.passes = fails, passes
+			case "pool":
+				args := c.RemainingArgs()
+				if len(args) < 2 {
+					return nil, c.ArgErr()
+				}
+				prio, err := strconv.Atoi(args[0])
+				if err != nil || prio < 0 {
+					return nil, c.Errf("invalid pool priority %q", args[0])
+				}
+				p := pool{priority: prio}
+				for _, a := range args[1:] {
+					if ip := net.ParseIP(a); ip != nil {
+						// A single address is checked even before it's seen in a response.
+						static = append(static, ip)
+						n := &net.IPNet{IP: ip, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}
+						if ip4 := ip.To4(); ip4 != nil {
+							n = &net.IPNet{IP: ip4, Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)}
+						}
+						p.nets = append(p.nets, n)
+						continue
+					}
+					_, n, err := net.ParseCIDR(a)
+					if err != nil {
+						return nil, c.Errf("invalid pool address %q", a)
+					}
+					p.nets = append(p.nets, n)
+				}
+				g.pools = append(g.pools, p)
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+
+	if chk.kind == "" {
+		return nil, c.Err("missing check")
+	}
+	if chk.timeout > chk.interval {
+		return nil, c.Errf("timeout %s is longer than the interval %s", chk.timeout, chk.interval)
+	}
+	// Pools are matched in order of priority, so an address in multiple pools gets the best one.
+	sort.SliceStable(g.pools, func(i, j int) bool { return g.pools[i].priority < g.pools[j].priority })
+
+	g.targets = newTargets(chk)
+	for _, ip := range static {
+		g.targets.addStatic(ip)
+	}
+	return g, nil
+}
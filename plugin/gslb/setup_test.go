@@ -0,0 +1,128 @@
+package gslb
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input            string
+		shouldErr        bool
+		expectedKind     string
+		expectedPath     string
+		expectedInterval time.Duration
+		expectedPools    int
+	}{
+		{`gslb {
+			check tcp 443
+		}`, false, checkTCP, "", 10 * time.Second, 0},
+		{`gslb example.org {
+			check http 80 /healthz
+			interval 5s
+			timeout 1s
+			threshold 3 1
+			expire 10m
+			pool 1 10.0.1.0/24 2001:db8::1
+			pool 2 10.0.2.1
+		}`, false, checkHTTP, "/healthz", 5 * time.Second, 2},
+		{`gslb {
+			check http 80
+		}`, false, checkHTTP, "/", 10 * time.Second, 0},
+		// fails
+		{`gslb`, true, "", "", 0, 0},
+		{`gslb {
+			check udp 53
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 0
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check http 80 healthz
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 80
+			interval 1s
+			timeout 2s
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 80
+			threshold 0 1
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 80
+			pool 1 example.org
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 80
+			pool 1
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 80
+			blah
+		}`, true, "", "", 0, 0},
+		{`gslb {
+			check tcp 80
+		}
+		gslb {
+			check tcp 80
+		}`, true, "", "", 0, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		g, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if g.targets.kind != test.expectedKind {
+			t.Errorf("Test %d: Expected check %q, got %q", i, test.expectedKind, g.targets.kind)
+		}
+		if g.targets.path != test.expectedPath {
+			t.Errorf("Test %d: Expected path %q, got %q", i, test.expectedPath, g.targets.path)
+		}
+		if g.targets.interval != test.expectedInterval {
+			t.Errorf("Test %d: Expected interval %s, got %s", i, test.expectedInterval, g.targets.interval)
+		}
+		if len(g.pools) != test.expectedPools {
+			t.Errorf("Test %d: Expected %d pools, got %d", i, test.expectedPools, len(g.pools))
+		}
+	}
+}
+
+func TestSetupPools(t *testing.T) {
+	c := caddy.NewTestController("dns", `gslb {
+		check tcp 80
+		pool 2 10.0.2.0/24
+		pool 1 10.0.1.1 10.0.2.1
+	}`)
+	g, err := parse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if g.pools[0].priority != 1 {
+		t.Errorf("Expected the pools to be sorted by priority, got %d first", g.pools[0].priority)
+	}
+	// 10.0.2.1 is in both pools, the best priority wins.
+	if p := g.priority(net.ParseIP("10.0.2.1")); p != 1 {
+		t.Errorf("Expected priority 1 for 10.0.2.1, got %d", p)
+	}
+	if p := g.priority(net.ParseIP("10.0.2.2")); p != 2 {
+		t.Errorf("Expected priority 2 for 10.0.2.2, got %d", p)
+	}
+	// Single addresses in a pool are checked from the start.
+	if len(g.targets.m) != 2 {
+		t.Errorf("Expected 2 static targets, got %d", len(g.targets.m))
+	}
+}
@@ -44,12 +44,8 @@ func (h Hosts) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (
 			return plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
 		}
 		answers = h.ptr(qname, h.options.ttl, names)
-	case dns.TypeA:
-		ips := h.LookupStaticHostV4(qname)
-		answers = a(qname, h.options.ttl, ips)
-	case dns.TypeAAAA:
-		ips := h.LookupStaticHostV6(qname)
-		answers = aaaa(qname, h.options.ttl, ips)
+	default:
+		answers = h.answer(qname, state.QType())
 	}
 
 	// Only on NXDOMAIN we will fallthrough.
@@ -72,6 +68,37 @@ func (h Hosts) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (
 	return dns.RcodeSuccess, nil
 }
 
+// maxCNAMEs is the maximum number of CNAMEs followed for an answer.
+const maxCNAMEs = 8
+
+// answer returns the records of type qtype for qname. If qname has a CNAME record, the answer holds the CNAME, and
+// the records of its target if they are in the hosts file.
+func (h Hosts) answer(qname string, qtype uint16) []dns.RR {
+	if qtype == dns.TypeCNAME {
+		return h.LookupStaticRecords(qname, dns.TypeCNAME)
+	}
+
+	var answers []dns.RR
+	for i := 0; i < maxCNAMEs; i++ {
+		cname := h.LookupStaticRecords(qname, dns.TypeCNAME)
+		if len(cname) == 0 {
+			break
+		}
+		answers = append(answers, cname[0])
+		qname = cname[0].(*dns.CNAME).Target
+	}
+
+	switch qtype {
+	case dns.TypeA:
+		return append(answers, a(qname, h.options.ttl, h.LookupStaticHostV4(qname))...)
+	case dns.TypeAAAA:
+		return append(answers, aaaa(qname, h.options.ttl, h.LookupStaticHostV6(qname))...)
+	case dns.TypeTXT, dns.TypeSRV:
+		return append(answers, h.LookupStaticRecords(qname, qtype)...)
+	}
+	return answers
+}
+
 func (h Hosts) otherRecordsExist(qname string) bool {
 	if len(h.LookupStaticHostV4(qname)) > 0 {
 		return true
@@ -79,6 +106,11 @@ func (h Hosts) otherRecordsExist(qname string) bool {
 	if len(h.LookupStaticHostV6(qname)) > 0 {
 		return true
 	}
+	for _, t := range []uint16{dns.TypeCNAME, dns.TypeTXT, dns.TypeSRV} {
+		if len(h.LookupStaticRecords(qname, t)) > 0 {
+			return true
+		}
+	}
 	return false
 }
 
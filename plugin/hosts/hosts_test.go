@@ -118,3 +118,78 @@ const hostsExample = `
 reload 5s
 timeout 3600
 `
+
+func TestLookupExtended(t *testing.T) {
+	h := Hosts{
+		Next: test.NextHandler(dns.RcodeNameError, nil),
+		Hostsfile: &Hostsfile{
+			Origins: []string{"."},
+			hmap:    newMap(),
+			inline:  newMap(),
+			options: newOptions(),
+		},
+	}
+	h.options.extended = true
+	h.hmap = h.parse(strings.NewReader(hostsExtendedExample))
+
+	for _, tc := range hostsExtendedTestCases {
+		m := tc.Msg()
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := h.ServeDNS(context.Background(), rec, m); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+			return
+		}
+		// The order of the CNAME chain matters, so the answer isn't sorted.
+		if err := test.Section(tc, test.Answer, rec.Msg.Answer); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+var hostsExtendedTestCases = []test.Case{
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.CNAME("www.example.org. 3600 IN CNAME web.example.org."),
+			test.CNAME("web.example.org. 3600 IN CNAME example.org."),
+			test.A("example.org. 3600 IN A 10.0.0.1"),
+		},
+	},
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeCNAME,
+		Answer: []dns.RR{
+			test.CNAME("www.example.org. 3600 IN CNAME web.example.org."),
+		},
+	},
+	{
+		Qname: "external.example.org.", Qtype: dns.TypeAAAA,
+		Answer: []dns.RR{
+			test.CNAME("external.example.org. 3600 IN CNAME example.net."),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeTXT,
+		Answer: []dns.RR{
+			test.TXT(`example.org. 3600 IN TXT "v=spf1 -all"`),
+		},
+	},
+	{
+		Qname: "_http._tcp.example.org.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.example.org. 3600 IN SRV 10 20 80 www.example.org."),
+		},
+	},
+	{
+		Qname: "_http._tcp.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{},
+	},
+}
+
+const hostsExtendedExample = `
+10.0.0.1 example.org
+CNAME www.example.org web.example.org.
+CNAME web.example.org example.org.
+CNAME external.example.org example.net.
+TXT example.org "v=spf1 -all"
+SRV _http._tcp.example.org 10 20 80 www.example.org.
+`
@@ -9,14 +9,20 @@ package hosts
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
 )
 
 // parseIP calls discards any v6 zone info, before calling net.ParseIP.
@@ -39,6 +45,9 @@ type options struct {
 
 	// The time between two reload of the configuration
 	reload time.Duration
+
+	// accept CNAME, TXT and SRV entries
+	extended bool
 }
 
 func newOptions() *options {
@@ -59,13 +68,17 @@ type Map struct {
 	// including IPv6 address without zone identifier.
 	// We don't support old-classful IP address notation.
 	addr map[string][]string
+
+	// Key for the list of CNAME, TXT and SRV records must be a FQDN lowercased name.
+	records map[string][]dns.RR
 }
 
 func newMap() *Map {
 	return &Map{
-		name4: make(map[string][]net.IP),
-		name6: make(map[string][]net.IP),
-		addr:  make(map[string][]string),
+		name4:   make(map[string][]net.IP),
+		name6:   make(map[string][]net.IP),
+		addr:    make(map[string][]string),
+		records: make(map[string][]dns.RR),
 	}
 }
 
@@ -81,6 +94,9 @@ func (h *Map) Len() int {
 	for _, a := range h.addr {
 		l += len(a)
 	}
+	for _, r := range h.records {
+		l += len(r)
+	}
 	return l
 }
 
@@ -97,49 +113,79 @@ type Hostsfile struct {
 	// inline saves the hosts file that is inlined in a Corefile.
 	inline *Map
 
-	// path to the hosts file
+	// path to the hosts file or directory
 	path string
 
-	// mtime and size are only read and modified by a single goroutine
-	mtime time.Time
-	size  int64
+	// paths to additional hosts files or directories, read after path
+	include []string
+
+	// stamps are only read and modified by a single goroutine
+	stamps []stamp
 
 	options *options
 }
 
-// readHosts determines if the cached data needs to be updated based on the size and modification time of the hostsfile.
-func (h *Hostsfile) readHosts() {
-	file, err := os.Open(h.path)
-	if err != nil {
-		// We already log a warning if the file doesn't exist or can't be opened on setup. No need to return the error here.
-		return
-	}
-	defer file.Close()
+// stamp identifies a version of a hosts file.
+type stamp struct {
+	path  string
+	mtime time.Time
+	size  int64
+}
 
-	stat, err := file.Stat()
-	if err != nil {
-		return
+// files returns the stamps of the hosts files, in the order they are read. A directory holds the files with a
+// .hosts extension, in lexical order. Files that can't be accessed are skipped.
+func (h *Hostsfile) files() []stamp {
+	var stamps []stamp
+	for _, path := range append([]string{h.path}, h.include...) {
+		stat, err := os.Stat(path)
+		if err != nil {
+			// We already log a warning if the file doesn't exist or can't be opened on setup. No need to return the error here.
+			continue
+		}
+		if !stat.IsDir() {
+			stamps = append(stamps, stamp{path: path, mtime: stat.ModTime(), size: stat.Size()})
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(path, "*.hosts"))
+		for _, m := range matches {
+			if stat, err := os.Stat(m); err == nil && !stat.IsDir() {
+				stamps = append(stamps, stamp{path: m, mtime: stat.ModTime(), size: stat.Size()})
+			}
+		}
 	}
-	h.RLock()
-	size := h.size
-	h.RUnlock()
+	return stamps
+}
 
-	if h.mtime.Equal(stat.ModTime()) && size == stat.Size() {
+// readHosts determines if the cached data needs to be updated based on the size and modification time of the hosts files.
+func (h *Hostsfile) readHosts() {
+	stamps := h.files()
+	if len(stamps) == 0 || slices.Equal(stamps, h.stamps) {
 		return
 	}
 
-	newMap := h.parse(file)
-	log.Debugf("Parsed hosts file into %d entries", newMap.Len())
+	newMap := newMap()
+	var latest time.Time
+	for _, s := range stamps {
+		file, err := os.Open(s.path)
+		if err != nil {
+			continue
+		}
+		h.parseInto(newMap, file)
+		file.Close()
+		if s.mtime.After(latest) {
+			latest = s.mtime
+		}
+	}
+	log.Debugf("Parsed %d hosts files into %d entries", len(stamps), newMap.Len())
 
 	h.Lock()
 
 	h.hmap = newMap
 	// Update the data cache.
-	h.mtime = stat.ModTime()
-	h.size = stat.Size()
+	h.stamps = stamps
 
 	hostsEntries.WithLabelValues().Set(float64(h.inline.Len() + h.hmap.Len()))
-	hostsReloadTime.Set(float64(stat.ModTime().UnixNano()) / 1e9)
+	hostsReloadTime.Set(float64(latest.UnixNano()) / 1e9)
 	h.Unlock()
 }
 
@@ -154,7 +200,12 @@ func (h *Hostsfile) initInline(inline []string) {
 // Parse reads the hostsfile and populates the byName and addr maps.
 func (h *Hostsfile) parse(r io.Reader) *Map {
 	hmap := newMap()
+	h.parseInto(hmap, r)
+	return hmap
+}
 
+// parseInto reads the hostsfile and adds its entries to hmap.
+func (h *Hostsfile) parseInto(hmap *Map, r io.Reader) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Bytes()
@@ -168,6 +219,9 @@ func (h *Hostsfile) parse(r io.Reader) *Map {
 		}
 		addr := parseIP(string(f[0]))
 		if addr == nil {
+			if h.options.extended {
+				h.parseRecord(hmap, string(line))
+			}
 			continue
 		}
 
@@ -198,8 +252,44 @@ func (h *Hostsfile) parse(r io.Reader) *Map {
 			hmap.addr[addr.String()] = append(hmap.addr[addr.String()], name)
 		}
 	}
+}
 
-	return hmap
+// parseRecord parses a line of the extended syntax, which is of the form `TYPE NAME RDATA`, and adds the record to
+// hmap. TYPE is one of CNAME, TXT or SRV, and RDATA is in the zone file format.
+func (h *Hostsfile) parseRecord(hmap *Map, line string) {
+	typ, rest := cutField(line)
+	name, rdata := cutField(rest)
+	switch strings.ToUpper(typ) {
+	case "CNAME", "TXT", "SRV":
+	default:
+		return
+	}
+
+	if rdata == "" {
+		log.Warningf("Missing data in %s entry of %s", strings.ToUpper(typ), name)
+		return
+	}
+	name = plugin.Name(name).Normalize()
+	if plugin.Zones(h.Origins).Matches(name) == "" {
+		// name is not in Origins
+		return
+	}
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, h.options.ttl, strings.ToUpper(typ), rdata))
+	if err != nil || rr == nil {
+		log.Warningf("Failed to parse %s entry of %s: %v", strings.ToUpper(typ), name, err)
+		return
+	}
+	hmap.records[name] = append(hmap.records[name], rr)
+}
+
+// cutField returns the first field of s and the rest of s after it.
+func cutField(s string) (string, string) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
 }
 
 // lookupStaticHost looks up the IP addresses for the given host from the hosts file.
@@ -236,6 +326,23 @@ func (h *Hostsfile) LookupStaticHostV6(host string) []net.IP {
 	return append(ip1, ip2...)
 }
 
+// LookupStaticRecords looks up the CNAME, TXT or SRV records of type qtype for the given host from the hosts file.
+func (h *Hostsfile) LookupStaticRecords(host string, qtype uint16) []dns.RR {
+	host = strings.ToLower(host)
+
+	h.RLock()
+	defer h.RUnlock()
+	var rrs []dns.RR
+	for _, m := range []*Map{h.hmap, h.inline} {
+		for _, rr := range m.records[host] {
+			if rr.Header().Rrtype == qtype {
+				rrs = append(rrs, dns.Copy(rr))
+			}
+		}
+	}
+	return rrs
+}
+
 // LookupStaticAddr looks up the hosts for the given address from the hosts file.
 func (h *Hostsfile) LookupStaticAddr(addr string) []string {
 	addr = parseIP(addr).String()
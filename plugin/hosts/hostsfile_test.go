@@ -6,11 +6,15 @@ package hosts
 
 import (
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
 )
 
 func testHostsfile(file string) *Hostsfile {
@@ -239,3 +243,104 @@ func TestHostCacheModification(t *testing.T) {
 	}
 	testStaticAddr(t, entip, h)
 }
+
+const extendedHosts = `127.0.0.1	localhost
+CNAME	www.example.org.	example.org.
+TXT	example.org	"v=spf1 -all" "second string" # comment
+srv	_http._tcp.example.org	10 20 80 www.example.org.
+SRV	_http._tcp.example.org	10 30 8080 example.org.
+MX	example.org	10 mail.example.org.
+TXT	example.net	"not in origins"
+CNAME	broken.example.org.
+`
+
+func TestLookupStaticRecords(t *testing.T) {
+	h := &Hostsfile{
+		Origins: []string{"example.org."},
+		hmap:    newMap(),
+		inline:  newMap(),
+		options: newOptions(),
+	}
+	h.hmap = h.parse(strings.NewReader(extendedHosts))
+	if rrs := h.LookupStaticRecords("www.example.org.", dns.TypeCNAME); len(rrs) != 0 {
+		t.Fatalf("Expected no records without the extended syntax, got %v", rrs)
+	}
+
+	h.options.extended = true
+	h.hmap = h.parse(strings.NewReader(extendedHosts))
+	tests := []struct {
+		name     string
+		qtype    uint16
+		expected []string
+	}{
+		{"www.example.org.", dns.TypeCNAME, []string{"www.example.org.\t3600\tIN\tCNAME\texample.org."}},
+		{"EXAMPLE.org.", dns.TypeTXT, []string{"example.org.\t3600\tIN\tTXT\t\"v=spf1 -all\" \"second string\""}},
+		{"_http._tcp.example.org.", dns.TypeSRV, []string{
+			"_http._tcp.example.org.\t3600\tIN\tSRV\t10 20 80 www.example.org.",
+			"_http._tcp.example.org.\t3600\tIN\tSRV\t10 30 8080 example.org.",
+		}},
+		{"example.org.", dns.TypeMX, nil},
+		{"example.net.", dns.TypeTXT, nil},
+		{"broken.example.org.", dns.TypeCNAME, nil},
+	}
+	for i, tc := range tests {
+		var got []string
+		for _, rr := range h.LookupStaticRecords(tc.name, tc.qtype) {
+			got = append(got, rr.String())
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+	if ips := h.LookupStaticHostV4("localhost."); len(ips) != 0 {
+		t.Errorf("Expected no addresses for localhost outside origins, got %v", ips)
+	}
+}
+
+func TestReadHostsFiles(t *testing.T) {
+	dir := t.TempDir()
+	fragments := filepath.Join(dir, "hosts.d")
+	if err := os.Mkdir(fragments, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"hosts":                "10.0.0.1 a.example.org",
+		"hosts.d/20-b.hosts":   "10.0.0.3 b.example.org",
+		"hosts.d/10-b.hosts":   "10.0.0.2 b.example.org",
+		"hosts.d/ignored.conf": "10.0.0.4 c.example.org",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &Hostsfile{
+		Origins: []string{"."},
+		path:    filepath.Join(dir, "hosts"),
+		include: []string{fragments, filepath.Join(dir, "missing")},
+		hmap:    newMap(),
+		inline:  newMap(),
+		options: newOptions(),
+	}
+	h.readHosts()
+
+	if ips := h.LookupStaticHostV4("a.example.org."); len(ips) != 1 || !ips[0].Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Expected 10.0.0.1 for a.example.org, got %v", ips)
+	}
+	// Fragments are merged in lexical order.
+	if ips := h.LookupStaticHostV4("b.example.org."); len(ips) != 2 || !ips[0].Equal(net.ParseIP("10.0.0.2")) || !ips[1].Equal(net.ParseIP("10.0.0.3")) {
+		t.Errorf("Expected 10.0.0.2 and 10.0.0.3 for b.example.org, got %v", ips)
+	}
+	if ips := h.LookupStaticHostV4("c.example.org."); len(ips) != 0 {
+		t.Errorf("Expected no addresses for c.example.org, got %v", ips)
+	}
+
+	// A removed fragment is no longer served.
+	if err := os.Remove(filepath.Join(fragments, "20-b.hosts")); err != nil {
+		t.Fatal(err)
+	}
+	h.readHosts()
+	if ips := h.LookupStaticHostV4("b.example.org."); len(ips) != 1 {
+		t.Errorf("Expected a single address for b.example.org, got %v", ips)
+	}
+}
@@ -1,11 +1,13 @@
 package hosts
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
@@ -88,23 +90,12 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 		args := c.RemainingArgs()
 
 		if len(args) >= 1 {
-			h.path = args[0]
-			args = args[1:]
-
-			if !filepath.IsAbs(h.path) && config.Root != "" {
-				h.path = filepath.Join(config.Root, h.path)
-			}
-			s, err := os.Stat(h.path)
+			path, err := hostsPath(config.Root, args[0])
 			if err != nil {
-				if os.IsNotExist(err) {
-					log.Warningf("File does not exist: %s", h.path)
-				} else {
-					return h, c.Errf("unable to access hosts file '%s': %v", h.path, err)
-				}
-			}
-			if s != nil && s.IsDir() {
-				log.Warningf("Hosts file %q is a directory", h.path)
+				return h, c.Err(err.Error())
 			}
+			h.path = path
+			args = args[1:]
 		}
 
 		h.Origins = plugin.OriginsFromArgsOrServerBlock(args, c.ServerBlockKeys)
@@ -115,6 +106,23 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 				h.Fall.SetZonesFromArgs(c.RemainingArgs())
 			case "no_reverse":
 				h.options.autoReverse = false
+			case "include":
+				remaining := c.RemainingArgs()
+				if len(remaining) == 0 {
+					return h, c.ArgErr()
+				}
+				for _, p := range remaining {
+					path, err := hostsPath(config.Root, p)
+					if err != nil {
+						return h, c.Err(err.Error())
+					}
+					h.include = append(h.include, path)
+				}
+			case "extended":
+				if len(c.RemainingArgs()) != 0 {
+					return h, c.ArgErr()
+				}
+				h.options.extended = true
 			case "ttl":
 				remaining := c.RemainingArgs()
 				if len(remaining) < 1 {
@@ -143,8 +151,14 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 				h.options.reload = reload
 			default:
 				if len(h.Fall.Zones) == 0 {
-					line := strings.Join(append([]string{c.Val()}, c.RemainingArgs()...), " ")
-					inline = append(inline, line)
+					fields := append([]string{c.Val()}, c.RemainingArgs()...)
+					for i, f := range fields {
+						// Keep quoted strings, such as the ones of TXT records, together.
+						if strings.ContainsFunc(f, unicode.IsSpace) {
+							fields[i] = strconv.Quote(f)
+						}
+					}
+					inline = append(inline, strings.Join(fields, " "))
 					continue
 				}
 				return h, c.Errf("unknown property '%s'", c.Val())
@@ -156,3 +170,18 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 
 	return h, nil
 }
+
+// hostsPath returns path, relative to root if it isn't absolute. A path that doesn't exist is only logged, as it may
+// be created later.
+func hostsPath(root, path string) (string, error) {
+	if !filepath.IsAbs(path) && root != "" {
+		path = filepath.Join(root, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		if !os.IsNotExist(err) {
+			return path, fmt.Errorf("unable to access hosts file '%s': %v", path, err)
+		}
+		log.Warningf("File does not exist: %s", path)
+	}
+	return path, nil
+}
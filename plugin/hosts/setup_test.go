@@ -1,10 +1,14 @@
 package hosts
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
 )
 
 func TestHostsParse(t *testing.T) {
@@ -167,3 +171,48 @@ func TestHostsInlineParse(t *testing.T) {
 		}
 	}
 }
+
+func TestHostsParseExtended(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "extra.hosts"), []byte("10.0.0.2 extra.example.org\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := caddy.NewTestController("dns", `hosts highly_unlikely_to_exist_hosts_file example.org {
+		include `+dir+`
+		extended
+		TXT example.org "v=spf1 -all"
+	}`)
+	h, err := hostsParse(c)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got '%v'", err)
+	}
+	if len(h.include) != 1 || h.include[0] != dir {
+		t.Errorf("Expected include of %s, got %v", dir, h.include)
+	}
+	if !h.options.extended {
+		t.Errorf("Expected extended syntax to be enabled")
+	}
+	rrs := h.LookupStaticRecords("example.org.", dns.TypeTXT)
+	if len(rrs) != 1 || rrs[0].(*dns.TXT).Txt[0] != "v=spf1 -all" {
+		t.Errorf("Expected TXT record with \"v=spf1 -all\", got %v", rrs)
+	}
+
+	h.readHosts()
+	if ips := h.LookupStaticHostV4("extra.example.org."); len(ips) != 1 {
+		t.Errorf("Expected an address for extra.example.org, got %v", ips)
+	}
+
+	for _, input := range []string{
+		`hosts {
+			include
+		}`,
+		`hosts {
+			extended yes
+		}`,
+	} {
+		if _, err := hostsParse(caddy.NewTestController("dns", input)); err == nil {
+			t.Errorf("Expected error for %q, got none", input)
+		}
+	}
+}
@@ -35,6 +35,16 @@ type Externaler interface {
 	ExternalSerial(string) uint32
 }
 
+// Hostnamer defines the interface that a plugin should implement in order to serve the hostnames of Ingresses
+// and Gateway API resources.
+type Hostnamer interface {
+	// WatchHostnames starts watching the Ingresses if ingress is true and the Gateway API resources if gateway is
+	// true. This is called before the plugin is started.
+	WatchHostnames(ingress, gateway bool) error
+	// ExternalHostnames returns a slice of msg.Services with the addresses for the hostname in the request.
+	ExternalHostnames(request.Request) []msg.Service
+}
+
 // External serves records for External IPs and Loadbalance IPs of Services in Kubernetes clusters.
 type External struct {
 	Next  plugin.Handler
@@ -45,6 +55,8 @@ type External struct {
 	apex       string
 	ttl        uint32
 	headless   bool
+	ingress    bool
+	gateway    bool
 
 	upstream *upstream.Upstream
 
@@ -52,6 +64,8 @@ type External struct {
 	externalAddrFunc     func(request.Request, bool) []dns.RR
 	externalSerialFunc   func(string) uint32
 	externalServicesFunc func(string, bool) ([]msg.Service, map[string][]msg.Service)
+
+	externalHostnamesFunc func(request.Request) []msg.Service
 }
 
 // New returns a new and initialized *External.
@@ -83,6 +97,12 @@ func (e *External) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		}
 	}
 
+	if e.externalHostnamesFunc != nil {
+		if svc := e.externalHostnamesFunc(state); len(svc) > 0 {
+			return e.serveHostname(ctx, w, state, svc)
+		}
+	}
+
 	svc, rcode := e.externalFunc(state, e.headless)
 
 	m := new(dns.Msg)
@@ -55,6 +55,64 @@ func TestExternal(t *testing.T) {
 	}
 }
 
+func TestExternalHostnames(t *testing.T) {
+	k := kubernetes.New([]string{"cluster.local."})
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.APIConn = &external{}
+
+	e := New()
+	e.Zones = []string{"example.com."}
+	e.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	e.externalFunc = k.External
+	e.externalHostnamesFunc = k.ExternalHostnames
+	e.externalAddrFunc = externalAddress  // internal test function
+	e.externalSerialFunc = externalSerial // internal test function
+
+	ctx := context.TODO()
+	for i, tc := range testsHostnames {
+		r := tc.Msg()
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+
+		if _, err := e.ServeDNS(ctx, w, r); err != nil {
+			t.Fatalf("Test %d expected no error, got %v", i, err)
+		}
+		if err := test.SortAndCheck(w.Msg, tc); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+var testsHostnames = []test.Case{
+	// A hostname from an Ingress
+	{
+		Qname: "www.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("www.example.com.	5	IN	A	1.2.3.4"),
+		},
+	},
+	// A hostname from an Ingress, but for another qtype
+	{
+		Qname: "www.example.com.", Qtype: dns.TypeSRV, Rcode: dns.RcodeSuccess,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.dns.example.com. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// A hostname matching a wildcard listener of a Gateway
+	{
+		Qname: "shop.apps.example.com.", Qtype: dns.TypeAAAA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.AAAA("shop.apps.example.com.	5	IN	AAAA	1:2::5"),
+		},
+	},
+	// A hostname in a namespace that is not exposed
+	{
+		Qname: "hidden.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.dns.example.com. 1499347823 7200 1800 86400 5"),
+		},
+	},
+}
+
 var tests = []test.Case{
 	// PTR reverse lookup
 	{
@@ -281,12 +339,14 @@ var tests = []test.Case{
 
 type external struct{}
 
-func (external) HasSynced() bool                           { return true }
-func (external) Run()                                      {}
-func (external) Stop() error                               { return nil }
-func (external) EpIndexReverse(string) []*object.Endpoints { return nil }
-func (external) SvcIndexReverse(string) []*object.Service  { return nil }
-func (external) Modified(bool) int64                       { return 0 }
+func (external) HasSynced() bool                                  { return true }
+func (external) Run()                                             {}
+func (external) Stop() error                                      { return nil }
+func (external) SvcImportIndex(string) []*object.ServiceImport    { return nil }
+func (external) McEpIndex(string) []*object.MultiClusterEndpoints { return nil }
+func (external) EpIndexReverse(string) []*object.Endpoints        { return nil }
+func (external) SvcIndexReverse(string) []*object.Service         { return nil }
+func (external) Modified(bool) int64                              { return 0 }
 func (external) EpIndex(s string) []*object.Endpoints {
 	return epIndexExternal[s]
 }
@@ -408,6 +468,22 @@ var svcIndexExternal = map[string][]*object.Service{
 	},
 }
 
+func (external) HostnameIndex(name string) []*object.Hostnames {
+	return hostnameIndexExternal[name]
+}
+
+var hostnameIndexExternal = map[string][]*object.Hostnames{
+	"www.example.com.": {
+		{Name: "web", Namespace: "testns", Hostnames: []string{"www.example.com."}, Addresses: []string{"1.2.3.4"}},
+	},
+	"*.apps.example.com.": {
+		{Name: "gw", Namespace: "testns", Hostnames: []string{"*.apps.example.com."}, Addresses: []string{"1:2::5"}},
+	},
+	"hidden.example.com.": {
+		{Name: "hidden", Namespace: "hiddenns", Hostnames: []string{"hidden.example.com."}, Addresses: []string{"1.2.3.5"}},
+	},
+}
+
 func (external) ServiceList() []*object.Service {
 	var svcs []*object.Service
 	for _, svc := range svcIndexExternal {
@@ -0,0 +1,32 @@
+package external
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// serveHostname answers a query for a hostname declared in an Ingress or Gateway API resource. The hostnames only
+// have addresses, other qtypes get a NODATA response.
+func (e *External) serveHostname(ctx context.Context, w dns.ResponseWriter, state request.Request, svc []msg.Service) (int, error) {
+	m := new(dns.Msg)
+	m.SetReply(state.Req)
+	m.Authoritative = true
+
+	switch state.QType() {
+	case dns.TypeA:
+		m.Answer, m.Truncated = e.a(ctx, svc, state)
+	case dns.TypeAAAA:
+		m.Answer, m.Truncated = e.aaaa(ctx, svc, state)
+	}
+
+	if len(m.Answer) == 0 {
+		m.Ns = []dns.RR{e.soa(state)}
+	}
+
+	w.WriteMsg(m)
+	return 0, nil
+}
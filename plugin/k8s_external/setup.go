@@ -36,6 +36,17 @@ func setup(c *caddy.Controller) error {
 		e.externalAddrFunc = x.ExternalAddress
 		e.externalServicesFunc = x.ExternalServices
 		e.externalSerialFunc = x.ExternalSerial
+
+		if e.ingress || e.gateway {
+			h, ok := m.(Hostnamer)
+			if !ok {
+				return plugin.Error(pluginName, errors.New("kubernetes plugin does not implement the Hostnamer interface"))
+			}
+			if err := h.WatchHostnames(e.ingress, e.gateway); err != nil {
+				return plugin.Error(pluginName, err)
+			}
+			e.externalHostnamesFunc = h.ExternalHostnames
+		}
 		return nil
 	})
 
@@ -77,6 +88,21 @@ func parse(c *caddy.Controller) (*External, error) {
 				e.apex = args[0]
 			case "headless":
 				e.headless = true
+			case "hostnames":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					e.ingress, e.gateway = true, true
+				}
+				for _, a := range args {
+					switch a {
+					case "ingress":
+						e.ingress = true
+					case "gateway":
+						e.gateway = true
+					default:
+						return nil, c.Errf("unknown hostnames source '%s', must be one of: ingress, gateway", a)
+					}
+				}
 			case "fallthrough":
 				e.Fall.SetZonesFromArgs(c.RemainingArgs())
 			default:
@@ -69,3 +69,44 @@ func TestSetup(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupHostnames(t *testing.T) {
+	tests := []struct {
+		input           string
+		shouldErr       bool
+		expectedIngress bool
+		expectedGateway bool
+	}{
+		{`k8s_external example.org`, false, false, false},
+		{`k8s_external example.org {
+	hostnames
+}`, false, true, true},
+		{`k8s_external example.org {
+	hostnames ingress
+}`, false, true, false},
+		{`k8s_external example.org {
+	hostnames gateway
+}`, false, false, true},
+		{`k8s_external example.org {
+	hostnames route
+}`, true, false, false},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		e, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found %s for input %s", i, err, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+		if e.ingress != test.expectedIngress || e.gateway != test.expectedGateway {
+			t.Errorf("Test %d, expected ingress %t and gateway %t for input %s, got: %t and %t", i, test.expectedIngress, test.expectedGateway, test.input, e.ingress, e.gateway)
+		}
+	}
+}
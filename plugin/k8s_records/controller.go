@@ -0,0 +1,217 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	ownerIndex = "Owner"
+	nameIndex  = "Name"
+)
+
+var errObj = errors.New("obj was not of the correct type")
+
+// defaultTypes are the record types that are allowed when the types option isn't used.
+var defaultTypes = []uint16{dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeTXT, dns.TypeSRV, dns.TypeMX, dns.TypePTR, dns.TypeCAA}
+
+// controller watches the DNSRecords, either in all namespaces or only in the configured ones. In the latter case
+// every namespace gets its own informer, so a Role in each of these namespaces is enough to list and watch them.
+type controller struct {
+	// modified is the timestamp of the most recent change, it needs to be first to be 8-byte aligned.
+	modified int64
+
+	client     dynamic.Interface
+	namespaces []string
+	types      map[uint16]struct{}
+	ttl        uint32
+
+	listers     []cache.Indexer
+	controllers []cache.Controller
+
+	stopLock sync.Mutex
+	shutdown bool
+	stopCh   chan struct{}
+}
+
+func newController(ctx context.Context, client dynamic.Interface, namespaces []string, types []uint16, ttl uint32) *controller {
+	c := &controller{
+		client:     client,
+		namespaces: namespaces,
+		types:      make(map[uint16]struct{}),
+		ttl:        ttl,
+		stopCh:     make(chan struct{}),
+	}
+	if len(types) == 0 {
+		types = defaultTypes
+	}
+	for _, t := range types {
+		c.types[t] = struct{}{}
+	}
+	if len(namespaces) == 0 {
+		namespaces = []string{api.NamespaceAll}
+	}
+
+	for _, ns := range namespaces {
+		lister, ctrl := object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  recordListFunc(ctx, c.client, ns),
+				WatchFunc: recordWatchFunc(ctx, c.client, ns),
+			},
+			&unstructured.Unstructured{},
+			cache.ResourceEventHandlerFuncs{AddFunc: c.Add, UpdateFunc: c.Update, DeleteFunc: c.Delete},
+			cache.Indexers{ownerIndex: ownerIndexFunc, nameIndex: nameIndexFunc},
+			object.DefaultProcessor(c.toRecord, nil),
+		)
+		c.listers = append(c.listers, lister)
+		c.controllers = append(c.controllers, ctrl)
+	}
+	return c
+}
+
+func recordListFunc(ctx context.Context, c dynamic.Interface, ns string) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		return c.Resource(DNSRecordResource).Namespace(ns).List(ctx, opts)
+	}
+}
+
+func recordWatchFunc(ctx context.Context, c dynamic.Interface, ns string) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		return c.Resource(DNSRecordResource).Namespace(ns).Watch(ctx, options)
+	}
+}
+
+func ownerIndexFunc(obj interface{}) ([]string, error) {
+	r, ok := obj.(*Record)
+	if !ok {
+		return nil, errObj
+	}
+	return []string{r.Owner}, nil
+}
+
+// nameIndexFunc indexes a record on its owner and all the names above it, so we can tell if a name exists.
+func nameIndexFunc(obj interface{}) ([]string, error) {
+	r, ok := obj.(*Record)
+	if !ok {
+		return nil, errObj
+	}
+	names := []string{r.Owner}
+	for name := r.Owner; ; {
+		i := strings.Index(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (c *controller) allowed(t uint16) bool {
+	_, ok := c.types[t]
+	return ok
+}
+
+// Run starts the informers, it blocks until Stop is called.
+func (c *controller) Run() {
+	for _, ctrl := range c.controllers {
+		go ctrl.Run(c.stopCh)
+	}
+	<-c.stopCh
+}
+
+// HasSynced returns true when all informers have synced.
+func (c *controller) HasSynced() bool {
+	for _, ctrl := range c.controllers {
+		if !ctrl.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Stop stops the informers.
+func (c *controller) Stop() error {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if !c.shutdown {
+		close(c.stopCh)
+		c.shutdown = true
+		return nil
+	}
+	return errors.New("shutdown already in progress")
+}
+
+// Modified returns the timestamp of the most recent change to the records.
+func (c *controller) Modified() int64 { return atomic.LoadInt64(&c.modified) }
+
+func (c *controller) updateModified() { atomic.StoreInt64(&c.modified, time.Now().Unix()) }
+
+// Add implements the cache.ResourceEventHandler interface.
+func (c *controller) Add(obj interface{}) { c.updateModified() }
+
+// Delete implements the cache.ResourceEventHandler interface.
+func (c *controller) Delete(obj interface{}) { c.updateModified() }
+
+// Update implements the cache.ResourceEventHandler interface.
+func (c *controller) Update(oldObj, newObj interface{}) {
+	if oldObj.(meta.Object).GetResourceVersion() == newObj.(meta.Object).GetResourceVersion() {
+		return
+	}
+	c.updateModified()
+}
+
+// Lookup returns the records with owner name, which is relative to the zone. The boolean is true if name exists,
+// i.e. it has records or there are records below it.
+func (c *controller) Lookup(name string) ([]*Record, bool) {
+	var (
+		records []*Record
+		exists  bool
+	)
+	for _, lister := range c.listers {
+		if os, err := lister.ByIndex(nameIndex, name); err == nil && len(os) > 0 {
+			exists = true
+		}
+		os, err := lister.ByIndex(ownerIndex, name)
+		if err != nil {
+			continue
+		}
+		for _, o := range os {
+			if r, ok := o.(*Record); ok {
+				records = append(records, r)
+			}
+		}
+	}
+	return records, exists
+}
+
+// List returns all records, sorted on their owner name.
+func (c *controller) List() []*Record {
+	var records []*Record
+	for _, lister := range c.listers {
+		for _, o := range lister.List() {
+			if r, ok := o.(*Record); ok {
+				records = append(records, r)
+			}
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Owner < records[j].Owner })
+	return records
+}
@@ -0,0 +1,4 @@
+package records
+
+// Ready implements the ready.Readiness interface.
+func (re *Records) Ready() bool { return re.ctrl.HasSynced() }
@@ -0,0 +1,120 @@
+package records
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+
+	"github.com/miekg/dns"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DNSRecordResource is the resource of the DNSRecord custom resources served by this plugin.
+var DNSRecordResource = schema.GroupVersionResource{Group: "coredns.io", Version: "v1alpha1", Resource: "dnsrecords"}
+
+// Record is a stripped down DNSRecord with only the resource records it defines.
+type Record struct {
+	Version   string
+	Name      string
+	Namespace string
+	// Owner is the lowercased owner name of the records relative to the zone: <name>.<namespace>, or just
+	// <namespace> for records at the apex of the namespace.
+	Owner string
+	// RRs are the records, their owner name is Owner as a fully qualified name. The zone is appended when they
+	// are served.
+	RRs []dns.RR
+
+	*object.Empty
+}
+
+// toRecord converts an unstructured DNSRecord to a *Record. Invalid records and records with a type that isn't
+// allowed are logged and skipped, returning an error would stop the informer.
+func (c *controller) toRecord(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	r := &Record{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	name, _, _ := unstructured.NestedString(u.Object, "spec", "name")
+	typ, _, _ := unstructured.NestedString(u.Object, "spec", "type")
+	data, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "data")
+	ttl := int64(c.ttl)
+	if t, ok, _ := unstructured.NestedInt64(u.Object, "spec", "ttl"); ok && t >= 0 {
+		ttl = t
+	}
+
+	r.Owner = strings.ToLower(r.Namespace)
+	if name != "" && name != "@" {
+		r.Owner = strings.ToLower(strings.TrimSuffix(name, ".")) + "." + r.Owner
+	}
+	u.Object = nil
+
+	qtype, ok := dns.StringToType[strings.ToUpper(typ)]
+	if !ok || !c.allowed(qtype) {
+		log.Warningf("Ignoring DNSRecord %s/%s: type %q is not allowed", r.Namespace, r.Name, typ)
+		return r, nil
+	}
+	for _, d := range data {
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(r.Owner), ttl, dns.TypeToString[qtype], d))
+		if err != nil || rr == nil {
+			log.Warningf("Ignoring invalid data %q in DNSRecord %s/%s: %v", d, r.Namespace, r.Name, err)
+			continue
+		}
+		r.RRs = append(r.RRs, rr)
+	}
+	return r, nil
+}
+
+// inZone returns copies of the records of r with zone appended to their owner name.
+func (r *Record) inZone(zone string) []dns.RR {
+	rrs := make([]dns.RR, len(r.RRs))
+	for i, rr := range r.RRs {
+		rrs[i] = dns.Copy(rr)
+		rrs[i].Header().Name = r.Owner + "." + zone
+	}
+	return rrs
+}
+
+var _ runtime.Object = &Record{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (r *Record) DeepCopyObject() runtime.Object {
+	r1 := &Record{
+		Version:   r.Version,
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		Owner:     r.Owner,
+		RRs:       make([]dns.RR, len(r.RRs)),
+	}
+	for i, rr := range r.RRs {
+		r1.RRs[i] = dns.Copy(rr)
+	}
+	return r1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (r *Record) GetNamespace() string { return r.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (r *Record) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (r *Record) GetName() string { return r.Name }
+
+// SetName implements the metav1.Object interface.
+func (r *Record) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (r *Record) GetResourceVersion() string { return r.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (r *Record) SetResourceVersion(version string) {}
@@ -0,0 +1,115 @@
+// Package records implements a plugin that serves the records defined in DNSRecord custom resources.
+package records
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Records serves the records of the DNSRecord custom resources in a Kubernetes cluster.
+type Records struct {
+	Next  plugin.Handler
+	Zones []string
+	Fall  fall.F
+
+	ttl        uint32
+	namespaces []string
+	types      []uint16
+
+	ctrl *controller
+}
+
+// New returns a new and initialized *Records.
+func New() *Records { return &Records{ttl: defaultTTL} }
+
+const defaultTTL = 30
+
+// ServeDNS implements the plugin.Handler interface.
+func (re *Records) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(re.Zones).Matches(state.Name())
+	if zone == "" {
+		return plugin.NextOrFailure(re.Name(), re.Next, ctx, w, r)
+	}
+	if !re.ctrl.HasSynced() {
+		// No records are loaded yet, answering now would give wrong NXDOMAIN responses.
+		return dns.RcodeServerFailure, plugin.Error(re.Name(), errNotSynced)
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	name, _ := dnsutil.TrimZone(state.Name(), zone)
+	if name == "" {
+		// apex query
+		if state.QType() == dns.TypeSOA {
+			m.Answer = []dns.RR{re.soa(zone)}
+		} else {
+			m.Ns = []dns.RR{re.soa(zone)}
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	}
+
+	records, exists := re.ctrl.Lookup(name)
+	if !exists {
+		if re.Fall.Through(state.Name()) {
+			return plugin.NextOrFailure(re.Name(), re.Next, ctx, w, r)
+		}
+		m.Rcode = dns.RcodeNameError
+		m.Ns = []dns.RR{re.soa(zone)}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	}
+
+	qtype := state.QType()
+	var cnames []dns.RR
+	for _, rec := range records {
+		for _, rr := range rec.inZone(zone) {
+			rr.Header().Name = state.QName()
+			switch rr.Header().Rrtype {
+			case qtype:
+				m.Answer = append(m.Answer, rr)
+			case dns.TypeCNAME:
+				cnames = append(cnames, rr)
+			}
+		}
+	}
+	if len(m.Answer) == 0 && len(cnames) > 0 {
+		m.Answer = cnames
+	}
+	if len(m.Answer) == 0 {
+		m.Ns = []dns.RR{re.soa(zone)}
+	}
+
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// Name implements the plugin.Handler interface.
+func (re *Records) Name() string { return pluginName }
+
+// Serial returns the SOA serial, this is the timestamp of the most recent change to the records.
+func (re *Records) Serial() uint32 { return uint32(re.ctrl.Modified()) }
+
+// soa returns the SOA record for zone.
+func (re *Records) soa(zone string) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: re.ttl},
+		Ns:      dnsutil.Join("ns.dns", zone),
+		Mbox:    dnsutil.Join("hostmaster.dns", zone),
+		Serial:  re.Serial(),
+		Refresh: 7200,
+		Retry:   1800,
+		Expire:  86400,
+		Minttl:  re.ttl,
+	}
+}
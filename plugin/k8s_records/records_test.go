@@ -0,0 +1,140 @@
+package records
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func dnsRecord(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "coredns.io/v1alpha1",
+		"kind":       "DNSRecord",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec":       spec,
+	}}
+}
+
+var testRecords = []runtime.Object{
+	dnsRecord("team-a", "verify", map[string]interface{}{"name": "verify", "type": "TXT", "data": []interface{}{`"token=1234"`}}),
+	dnsRecord("team-a", "saas", map[string]interface{}{"name": "saas", "type": "CNAME", "ttl": int64(60), "data": []interface{}{"saas.example.net."}}),
+	dnsRecord("team-a", "legacy", map[string]interface{}{"name": "_ldap._tcp.legacy", "type": "SRV", "data": []interface{}{"10 50 389 ldap.example.net."}}),
+	dnsRecord("team-a", "apex", map[string]interface{}{"type": "A", "data": []interface{}{"10.0.0.1", "10.0.0.2"}}),
+	dnsRecord("team-b", "ns", map[string]interface{}{"name": "sub", "type": "NS", "data": []interface{}{"ns.example.net."}}),
+	dnsRecord("team-b", "bad", map[string]interface{}{"name": "bad", "type": "A", "data": []interface{}{"not-an-ip"}}),
+}
+
+func newTestRecords(t *testing.T, namespaces []string) *Records {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		DNSRecordResource: "DNSRecordList",
+	}, testRecords...)
+
+	re := New()
+	re.Zones = []string{"records.cluster.local."}
+	re.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	re.ctrl = newController(context.TODO(), client, namespaces, nil, re.ttl)
+	go re.ctrl.Run()
+	t.Cleanup(func() { re.ctrl.Stop() })
+
+	for !re.ctrl.HasSynced() {
+		time.Sleep(time.Millisecond)
+	}
+	return re
+}
+
+var soa = test.SOA("records.cluster.local.	30	IN	SOA	ns.dns.records.cluster.local. hostmaster.dns.records.cluster.local. 0 7200 1800 86400 30")
+
+var recordsCases = []test.Case{
+	{
+		Qname: "verify.team-a.records.cluster.local.", Qtype: dns.TypeTXT,
+		Answer: []dns.RR{test.TXT(`verify.team-a.records.cluster.local.	30	IN	TXT	"token=1234"`)},
+	},
+	{
+		Qname: "saas.team-a.records.cluster.local.", Qtype: dns.TypeA,
+		Answer: []dns.RR{test.CNAME("saas.team-a.records.cluster.local.	60	IN	CNAME	saas.example.net.")},
+	},
+	{
+		Qname: "_ldap._tcp.legacy.team-a.records.cluster.local.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{test.SRV("_ldap._tcp.legacy.team-a.records.cluster.local.	30	IN	SRV	10 50 389 ldap.example.net.")},
+	},
+	{
+		Qname: "team-a.records.cluster.local.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("team-a.records.cluster.local.	30	IN	A	10.0.0.1"),
+			test.A("team-a.records.cluster.local.	30	IN	A	10.0.0.2"),
+		},
+	},
+	// NODATA
+	{
+		Qname: "verify.team-a.records.cluster.local.", Qtype: dns.TypeA,
+		Ns: []dns.RR{soa},
+	},
+	// Empty non-terminal
+	{
+		Qname: "_tcp.legacy.team-a.records.cluster.local.", Qtype: dns.TypeSRV,
+		Ns: []dns.RR{soa},
+	},
+	// NS records are not allowed by default
+	{
+		Qname: "sub.team-b.records.cluster.local.", Qtype: dns.TypeNS,
+		Ns: []dns.RR{soa},
+	},
+	// Invalid data is skipped
+	{
+		Qname: "bad.team-b.records.cluster.local.", Qtype: dns.TypeA,
+		Ns: []dns.RR{soa},
+	},
+	{
+		Qname: "nope.team-a.records.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{soa},
+	},
+	{
+		Qname: "records.cluster.local.", Qtype: dns.TypeSOA,
+		Answer: []dns.RR{soa},
+	},
+}
+
+func TestServeDNS(t *testing.T) {
+	re := newTestRecords(t, nil)
+	serial := re.Serial()
+
+	for i, tc := range recordsCases {
+		m := tc.Msg()
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := re.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		// the serial is the time of the last change, fix it for the comparison
+		for _, rr := range append(w.Msg.Answer, w.Msg.Ns...) {
+			if s, ok := rr.(*dns.SOA); ok && s.Serial == serial {
+				s.Serial = 0
+			}
+		}
+		if err := test.SortAndCheck(w.Msg, tc); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		}
+	}
+}
+
+func TestServeDNSNamespaces(t *testing.T) {
+	re := newTestRecords(t, []string{"team-b"})
+
+	m := new(dns.Msg)
+	m.SetQuestion("verify.team-a.records.cluster.local.", dns.TypeTXT)
+	w := dnstest.NewRecorder(&test.ResponseWriter{})
+	re.ServeDNS(context.TODO(), w, m)
+	if w.Msg.Rcode != dns.RcodeNameError {
+		t.Errorf("Expected NXDOMAIN for a record in a namespace that is not watched, got %s", dns.RcodeToString[w.Msg.Rcode])
+	}
+}
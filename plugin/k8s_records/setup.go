@@ -0,0 +1,141 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/miekg/dns"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const pluginName = "k8s_records"
+
+var log = clog.NewWithPlugin(pluginName)
+
+var errNotSynced = errors.New("DNSRecords are not synced yet")
+
+func init() { plugin.Register(pluginName, setup) }
+
+func setup(c *caddy.Controller) error {
+	re, clientConfig, err := parse(c)
+	if err != nil {
+		return plugin.Error(pluginName, err)
+	}
+
+	config, err := clientConfig()
+	if err != nil {
+		return plugin.Error(pluginName, err)
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return plugin.Error(pluginName, err)
+	}
+	re.ctrl = newController(context.Background(), client, re.namespaces, re.types, re.ttl)
+
+	c.OnStartup(func() error {
+		go re.ctrl.Run()
+
+		timeout := time.After(5 * time.Second)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if re.ctrl.HasSynced() {
+					return nil
+				}
+			case <-timeout:
+				log.Warning("starting server with unsynced DNSRecords")
+				return nil
+			}
+		}
+	})
+	c.OnShutdown(re.ctrl.Stop)
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		re.Next = next
+		return re
+	})
+
+	return nil
+}
+
+// parse parses the configuration, it returns the function to get the configuration of the Kubernetes client.
+func parse(c *caddy.Controller) (*Records, func() (*rest.Config, error), error) {
+	re := New()
+	clientConfig := rest.InClusterConfig
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, nil, plugin.ErrOnce
+		}
+		i++
+
+		re.Zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+		for c.NextBlock() {
+			switch c.Val() {
+			case "kubeconfig":
+				args := c.RemainingArgs()
+				if len(args) != 1 && len(args) != 2 {
+					return nil, nil, c.ArgErr()
+				}
+				overrides := &clientcmd.ConfigOverrides{}
+				if len(args) == 2 {
+					overrides.CurrentContext = args[1]
+				}
+				config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+					&clientcmd.ClientConfigLoadingRules{ExplicitPath: args[0]},
+					overrides,
+				)
+				clientConfig = config.ClientConfig
+			case "namespaces":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, nil, c.ArgErr()
+				}
+				re.namespaces = append(re.namespaces, args...)
+			case "types":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, nil, c.ArgErr()
+				}
+				for _, a := range args {
+					t, ok := dns.StringToType[strings.ToUpper(a)]
+					if !ok || t == dns.TypeSOA {
+						return nil, nil, c.Errf("invalid record type %q", a)
+					}
+					re.types = append(re.types, t)
+				}
+			case "ttl":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, nil, c.ArgErr()
+				}
+				t, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, nil, err
+				}
+				if t < 0 || t > 3600 {
+					return nil, nil, c.Errf("ttl must be in range [0, 3600]: %d", t)
+				}
+				re.ttl = uint32(t)
+			case "fallthrough":
+				re.Fall.SetZonesFromArgs(c.RemainingArgs())
+			default:
+				return nil, nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+	return re, clientConfig, nil
+}
@@ -0,0 +1,95 @@
+package records
+
+import (
+	"testing"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedZones      []string
+		expectedNamespaces []string
+		expectedTypes      []uint16
+		expectedTTL        uint32
+		expectedFall       fall.F
+	}{
+		{`k8s_records`, false, nil, nil, nil, defaultTTL, fall.Zero},
+		{`k8s_records records.cluster.local`, false, []string{"records.cluster.local."}, nil, nil, defaultTTL, fall.Zero},
+		{`k8s_records records.cluster.local {
+			namespaces team-a team-b
+			types txt CNAME
+			ttl 60
+			fallthrough
+		}`, false, []string{"records.cluster.local."}, []string{"team-a", "team-b"}, []uint16{dns.TypeTXT, dns.TypeCNAME}, 60, fall.Root},
+		{`k8s_records {
+			kubeconfig /etc/kubeconfig context
+		}`, false, nil, nil, nil, defaultTTL, fall.Zero},
+		// fails
+		{`k8s_records {
+			types SOA
+		}`, true, nil, nil, nil, 0, fall.Zero},
+		{`k8s_records {
+			types FOO
+		}`, true, nil, nil, nil, 0, fall.Zero},
+		{`k8s_records {
+			namespaces
+		}`, true, nil, nil, nil, 0, fall.Zero},
+		{`k8s_records {
+			ttl 4000
+		}`, true, nil, nil, nil, 0, fall.Zero},
+		{`k8s_records {
+			blah
+		}`, true, nil, nil, nil, 0, fall.Zero},
+		{`k8s_records
+		k8s_records`, true, nil, nil, nil, 0, fall.Zero},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		re, _, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if test.expectedZones != nil && !equal(re.Zones, test.expectedZones) {
+			t.Errorf("Test %d: expected zones %v, got %v", i, test.expectedZones, re.Zones)
+		}
+		if !equal(re.namespaces, test.expectedNamespaces) {
+			t.Errorf("Test %d: expected namespaces %v, got %v", i, test.expectedNamespaces, re.namespaces)
+		}
+		if len(re.types) != len(test.expectedTypes) {
+			t.Errorf("Test %d: expected types %v, got %v", i, test.expectedTypes, re.types)
+		}
+		if re.ttl != test.expectedTTL {
+			t.Errorf("Test %d: expected ttl %d, got %d", i, test.expectedTTL, re.ttl)
+		}
+		if !re.Fall.Equal(test.expectedFall) {
+			t.Errorf("Test %d: expected fallthrough %v, got %v", i, test.expectedFall, re.Fall)
+		}
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
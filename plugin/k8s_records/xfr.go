@@ -0,0 +1,36 @@
+package records
+
+import (
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/transfer"
+
+	"github.com/miekg/dns"
+)
+
+// Transfer implements the transfer.Transferer interface.
+func (re *Records) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	match := plugin.Zones(re.Zones).Matches(zone)
+	if match != zone {
+		return nil, transfer.ErrNotAuthoritative
+	}
+
+	soa := re.soa(zone)
+	records := re.ctrl.List()
+
+	ch := make(chan []dns.RR)
+	go func() {
+		defer close(ch)
+		ch <- []dns.RR{soa}
+		// ixfr fallback
+		if serial != 0 && soa.Serial == serial {
+			return
+		}
+		for _, r := range records {
+			if len(r.RRs) > 0 {
+				ch <- r.inZone(zone)
+			}
+		}
+		ch <- []dns.RR{soa}
+	}()
+	return ch, nil
+}
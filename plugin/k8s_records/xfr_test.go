@@ -0,0 +1,45 @@
+package records
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/transfer"
+
+	"github.com/miekg/dns"
+)
+
+func TestTransfer(t *testing.T) {
+	re := newTestRecords(t, nil)
+
+	if _, err := re.Transfer("example.org.", 0); err != transfer.ErrNotAuthoritative {
+		t.Errorf("Expected %s for a zone we're not authoritative for, got %v", transfer.ErrNotAuthoritative, err)
+	}
+
+	ch, err := re.Transfer("records.cluster.local.", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	var rrs []dns.RR
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	// SOA, 5 records, SOA
+	if len(rrs) != 7 {
+		t.Fatalf("Expected %d records, got %d: %v", 7, len(rrs), rrs)
+	}
+	if rrs[0].Header().Rrtype != dns.TypeSOA || rrs[6].Header().Rrtype != dns.TypeSOA {
+		t.Errorf("Expected the transfer to start and end with the SOA, got %v", rrs)
+	}
+	if rrs[1].Header().Name != "_ldap._tcp.legacy.team-a.records.cluster.local." {
+		t.Errorf("Expected the records to be sorted, got %s first", rrs[1].Header().Name)
+	}
+
+	ch, _ = re.Transfer("records.cluster.local.", re.Serial())
+	rrs = nil
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	if len(rrs) != 1 {
+		t.Errorf("Expected only the SOA for an up to date serial, got %v", rrs)
+	}
+}
@@ -12,10 +12,14 @@ import (
 
 	api "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -27,6 +31,10 @@ const (
 	svcExtIPIndex         = "ServiceExternalIP"
 	epNameNamespaceIndex  = "EndpointNameNamespace"
 	epIPIndex             = "EndpointsIP"
+
+	hostnameIndex               = "Hostname"
+	svcImportNameNamespaceIndex = "ServiceImportNameNamespace"
+	mcEpNameNamespaceIndex      = "MultiClusterEndpointNameNamespace"
 )
 
 type dnsController interface {
@@ -38,6 +46,9 @@ type dnsController interface {
 	PodIndex(string) []*object.Pod
 	EpIndex(string) []*object.Endpoints
 	EpIndexReverse(string) []*object.Endpoints
+	SvcImportIndex(string) []*object.ServiceImport
+	McEpIndex(string) []*object.MultiClusterEndpoints
+	HostnameIndex(string) []*object.Hostnames
 
 	GetNodeByName(context.Context, string) (*api.Node, error)
 	GetNamespaceByName(string) (*object.Namespace, error)
@@ -60,7 +71,8 @@ type dnsControl struct {
 	// services with external facing IP addresses
 	extModified int64
 
-	client kubernetes.Interface
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
 
 	selector          labels.Selector
 	namespaceSelector labels.Selector
@@ -70,11 +82,25 @@ type dnsControl struct {
 	epController  cache.Controller
 	nsController  cache.Controller
 
+	svcImportController cache.Controller
+	mcEpController      cache.Controller
+
 	svcLister cache.Indexer
 	podLister cache.Indexer
 	epLister  cache.Indexer
 	nsLister  cache.Store
 
+	svcImportLister cache.Indexer
+	mcEpLister      cache.Indexer
+
+	// The informers for the hostnames of Ingresses and the Gateway API, these are added with WatchHostnames.
+	hostnameLock        sync.RWMutex
+	running             bool
+	hostnameControllers []cache.Controller
+	ingressLister       cache.Indexer
+	gatewayLister       cache.Indexer
+	routeLister         cache.Indexer
+
 	// stopLock is used to enforce only a single call to Stop is active.
 	// Needed because we allow stopping through an http endpoint and
 	// allowing concurrent stoppers leads to stack traces.
@@ -90,6 +116,8 @@ type dnsControlOpts struct {
 	initPodCache       bool
 	initEndpointsCache bool
 	ignoreEmptyService bool
+	// Answer with the terminating, but serving, endpoints when there are no ready ones.
+	terminatingFallback bool
 
 	// Label handling.
 	labelSelector          *meta.LabelSelector
@@ -99,12 +127,17 @@ type dnsControlOpts struct {
 
 	zones            []string
 	endpointNameMode bool
+
+	// Zones answered with the ServiceImports of the Multi-Cluster Services API.
+	multiclusterZones []string
 }
 
-// newdnsController creates a controller for CoreDNS.
-func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts dnsControlOpts) *dnsControl {
+// newdnsController creates a controller for CoreDNS. The dynamicClient is used for the custom resources of the
+// Multi-Cluster Services and Gateway APIs.
+func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, opts dnsControlOpts) *dnsControl {
 	dns := dnsControl{
 		client:            kubeClient,
+		dynamicClient:     dynamicClient,
 		selector:          opts.selector,
 		namespaceSelector: opts.namespaceSelector,
 		stopCh:            make(chan struct{}),
@@ -164,9 +197,90 @@ func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts
 		object.DefaultProcessor(object.ToNamespace, nil),
 	)
 
+	if len(opts.multiclusterZones) > 0 {
+		dns.svcImportLister, dns.svcImportController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  dynamicListFunc(ctx, dns.dynamicClient, object.ServiceImportResource, api.NamespaceAll, dns.selector),
+				WatchFunc: dynamicWatchFunc(ctx, dns.dynamicClient, object.ServiceImportResource, api.NamespaceAll, dns.selector),
+			},
+			&unstructured.Unstructured{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{svcImportNameNamespaceIndex: svcImportNameNamespaceIndexFunc},
+			object.DefaultProcessor(object.ToServiceImport, nil),
+		)
+
+		dns.mcEpLister, dns.mcEpController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  multiclusterEndpointSliceListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+				WatchFunc: multiclusterEndpointSliceWatchFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+			},
+			&discovery.EndpointSlice{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{mcEpNameNamespaceIndex: mcEpNameNamespaceIndexFunc},
+			object.DefaultProcessor(object.EndpointSliceToMultiClusterEndpoints, nil),
+		)
+	}
+
 	return &dns
 }
 
+// WatchHostnames adds the informers for the hostnames of Ingresses, if ingress is true, and of Gateways and
+// HTTPRoutes of the Gateway API, if gateway is true.
+func (dns *dnsControl) WatchHostnames(ctx context.Context, ingress, gateway bool) {
+	dns.hostnameLock.Lock()
+	defer dns.hostnameLock.Unlock()
+
+	handlers := cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete}
+	var controllers []cache.Controller
+	if ingress && dns.ingressLister == nil {
+		var c cache.Controller
+		dns.ingressLister, c = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  ingressListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+				WatchFunc: ingressWatchFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+			},
+			&networking.Ingress{},
+			handlers,
+			cache.Indexers{hostnameIndex: hostnameIndexFunc},
+			object.DefaultProcessor(object.IngressToHostnames, nil),
+		)
+		controllers = append(controllers, c)
+	}
+	if gateway && dns.gatewayLister == nil {
+		var c cache.Controller
+		dns.gatewayLister, c = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  dynamicListFunc(ctx, dns.dynamicClient, object.GatewayResource, api.NamespaceAll, dns.selector),
+				WatchFunc: dynamicWatchFunc(ctx, dns.dynamicClient, object.GatewayResource, api.NamespaceAll, dns.selector),
+			},
+			&unstructured.Unstructured{},
+			handlers,
+			cache.Indexers{hostnameIndex: hostnameIndexFunc},
+			object.DefaultProcessor(object.GatewayToHostnames, nil),
+		)
+		controllers = append(controllers, c)
+
+		dns.routeLister, c = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  dynamicListFunc(ctx, dns.dynamicClient, object.HTTPRouteResource, api.NamespaceAll, dns.selector),
+				WatchFunc: dynamicWatchFunc(ctx, dns.dynamicClient, object.HTTPRouteResource, api.NamespaceAll, dns.selector),
+			},
+			&unstructured.Unstructured{},
+			handlers,
+			cache.Indexers{hostnameIndex: hostnameIndexFunc},
+			object.DefaultProcessor(object.HTTPRouteToHostnames, nil),
+		)
+		controllers = append(controllers, c)
+	}
+
+	dns.hostnameControllers = append(dns.hostnameControllers, controllers...)
+	if dns.running {
+		for _, c := range controllers {
+			go c.Run(dns.stopCh)
+		}
+	}
+}
+
 func (dns *dnsControl) EndpointsLatencyRecorder() *object.EndpointLatencyRecorder {
 	return &object.EndpointLatencyRecorder{
 		ServiceFunc: func(o meta.Object) []*object.Service {
@@ -226,6 +340,30 @@ func epNameNamespaceIndexFunc(obj interface{}) ([]string, error) {
 	return []string{s.Index}, nil
 }
 
+func hostnameIndexFunc(obj interface{}) ([]string, error) {
+	h, ok := obj.(*object.Hostnames)
+	if !ok {
+		return nil, errObj
+	}
+	return h.Hostnames, nil
+}
+
+func svcImportNameNamespaceIndexFunc(obj interface{}) ([]string, error) {
+	s, ok := obj.(*object.ServiceImport)
+	if !ok {
+		return nil, errObj
+	}
+	return []string{s.Index}, nil
+}
+
+func mcEpNameNamespaceIndexFunc(obj interface{}) ([]string, error) {
+	e, ok := obj.(*object.MultiClusterEndpoints)
+	if !ok {
+		return nil, errObj
+	}
+	return []string{e.Index}, nil
+}
+
 func epIPIndexFunc(obj interface{}) ([]string, error) {
 	ep, ok := obj.(*object.Endpoints)
 	if !ok {
@@ -265,6 +403,39 @@ func endpointSliceListFunc(ctx context.Context, c kubernetes.Interface, ns strin
 	}
 }
 
+func dynamicListFunc(ctx context.Context, c dynamic.Interface, r schema.GroupVersionResource, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		return c.Resource(r).Namespace(ns).List(ctx, opts)
+	}
+}
+
+func ingressListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		return c.NetworkingV1().Ingresses(ns).List(ctx, opts)
+	}
+}
+
+// multiclusterSelector returns the label selector for the EndpointSlices derived from ServiceImports.
+func multiclusterSelector(s labels.Selector) string {
+	if s == nil || s.Empty() {
+		return object.LabelMultiClusterServiceName
+	}
+	return s.String() + "," + object.LabelMultiClusterServiceName
+}
+
+func multiclusterEndpointSliceListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		opts.LabelSelector = multiclusterSelector(s)
+		return c.DiscoveryV1().EndpointSlices(ns).List(ctx, opts)
+	}
+}
+
 func namespaceListFunc(ctx context.Context, c kubernetes.Interface, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
 	return func(opts meta.ListOptions) (runtime.Object, error) {
 		if s != nil {
@@ -305,6 +476,31 @@ func endpointSliceWatchFunc(ctx context.Context, c kubernetes.Interface, ns stri
 	}
 }
 
+func dynamicWatchFunc(ctx context.Context, c dynamic.Interface, r schema.GroupVersionResource, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		return c.Resource(r).Namespace(ns).Watch(ctx, options)
+	}
+}
+
+func ingressWatchFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		return c.NetworkingV1().Ingresses(ns).Watch(ctx, options)
+	}
+}
+
+func multiclusterEndpointSliceWatchFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		options.LabelSelector = multiclusterSelector(s)
+		return c.DiscoveryV1().EndpointSlices(ns).Watch(ctx, options)
+	}
+}
+
 func namespaceWatchFunc(ctx context.Context, c kubernetes.Interface, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
 	return func(options meta.ListOptions) (watch.Interface, error) {
 		if s != nil {
@@ -342,6 +538,16 @@ func (dns *dnsControl) Run() {
 		go dns.podController.Run(dns.stopCh)
 	}
 	go dns.nsController.Run(dns.stopCh)
+	if dns.svcImportController != nil {
+		go dns.svcImportController.Run(dns.stopCh)
+		go dns.mcEpController.Run(dns.stopCh)
+	}
+	dns.hostnameLock.Lock()
+	dns.running = true
+	for _, c := range dns.hostnameControllers {
+		go c.Run(dns.stopCh)
+	}
+	dns.hostnameLock.Unlock()
 	<-dns.stopCh
 }
 
@@ -357,7 +563,17 @@ func (dns *dnsControl) HasSynced() bool {
 		c = dns.podController.HasSynced()
 	}
 	d := dns.nsController.HasSynced()
-	return a && b && c && d
+	e := true
+	if dns.svcImportController != nil {
+		e = dns.svcImportController.HasSynced() && dns.mcEpController.HasSynced()
+	}
+	f := true
+	dns.hostnameLock.RLock()
+	for _, ctrl := range dns.hostnameControllers {
+		f = f && ctrl.HasSynced()
+	}
+	dns.hostnameLock.RUnlock()
+	return a && b && c && d && e && f
 }
 
 func (dns *dnsControl) ServiceList() (svcs []*object.Service) {
@@ -476,6 +692,89 @@ func (dns *dnsControl) EpIndexReverse(ip string) (ep []*object.Endpoints) {
 	return ep
 }
 
+func (dns *dnsControl) SvcImportIndex(idx string) (svcs []*object.ServiceImport) {
+	if dns.svcImportLister == nil {
+		return nil
+	}
+	os, err := dns.svcImportLister.ByIndex(svcImportNameNamespaceIndex, idx)
+	if err != nil {
+		return nil
+	}
+	for _, o := range os {
+		s, ok := o.(*object.ServiceImport)
+		if !ok {
+			continue
+		}
+		svcs = append(svcs, s)
+	}
+	return svcs
+}
+
+func (dns *dnsControl) McEpIndex(idx string) (ep []*object.MultiClusterEndpoints) {
+	if dns.mcEpLister == nil {
+		return nil
+	}
+	os, err := dns.mcEpLister.ByIndex(mcEpNameNamespaceIndex, idx)
+	if err != nil {
+		return nil
+	}
+	for _, o := range os {
+		e, ok := o.(*object.MultiClusterEndpoints)
+		if !ok {
+			continue
+		}
+		ep = append(ep, e)
+	}
+	return ep
+}
+
+// HostnameIndex returns the Ingresses, Gateways and HTTPRoutes declaring the hostname name. The addresses of an
+// HTTPRoute are set to those of the Gateways it is attached to.
+func (dns *dnsControl) HostnameIndex(name string) (hs []*object.Hostnames) {
+	dns.hostnameLock.RLock()
+	defer dns.hostnameLock.RUnlock()
+
+	for _, lister := range []cache.Indexer{dns.ingressLister, dns.gatewayLister} {
+		if lister == nil {
+			continue
+		}
+		os, err := lister.ByIndex(hostnameIndex, name)
+		if err != nil {
+			continue
+		}
+		for _, o := range os {
+			if h, ok := o.(*object.Hostnames); ok {
+				hs = append(hs, h)
+			}
+		}
+	}
+	if dns.routeLister == nil {
+		return hs
+	}
+	os, err := dns.routeLister.ByIndex(hostnameIndex, name)
+	if err != nil {
+		return hs
+	}
+	for _, o := range os {
+		r, ok := o.(*object.Hostnames)
+		if !ok {
+			continue
+		}
+		r1 := r.DeepCopyObject().(*object.Hostnames)
+		for _, key := range r.Parents {
+			g, exists, err := dns.gatewayLister.GetByKey(key)
+			if err != nil || !exists {
+				continue
+			}
+			if g, ok := g.(*object.Hostnames); ok {
+				r1.Addresses = append(r1.Addresses, g.Addresses...)
+			}
+		}
+		hs = append(hs, r1)
+	}
+	return hs
+}
+
 // GetNodeByName return the node by name. If nothing is found an error is
 // returned. This query causes a round trip to the k8s API server, so use
 // sparingly. Currently, this is only used for Federation.
@@ -529,6 +828,14 @@ func (dns *dnsControl) detectChanges(oldObj, newObj interface{}) {
 		if !endpointsEquivalent(oldObj.(*object.Endpoints), newObj.(*object.Endpoints)) {
 			dns.updateModified()
 		}
+	case *object.Hostnames:
+		dns.updateExtModified()
+	case *object.ServiceImport:
+		dns.updateModified()
+	case *object.MultiClusterEndpoints:
+		if !endpointsEquivalent(&oldObj.(*object.MultiClusterEndpoints).Endpoints, &newObj.(*object.MultiClusterEndpoints).Endpoints) {
+			dns.updateModified()
+		}
 	default:
 		log.Warningf("Updates for %T not supported.", ob)
 	}
@@ -544,6 +851,9 @@ func subsetsEquivalent(sa, sb object.EndpointSubset) bool {
 	if len(sa.Ports) != len(sb.Ports) {
 		return false
 	}
+	if len(sa.TerminatingAddresses) != len(sb.TerminatingAddresses) {
+		return false
+	}
 
 	// in Addresses and Ports, we should be able to rely on
 	// these being sorted and able to be compared
@@ -557,6 +867,12 @@ func subsetsEquivalent(sa, sb object.EndpointSubset) bool {
 			return false
 		}
 	}
+	for addr, aaddr := range sa.TerminatingAddresses {
+		baddr := sb.TerminatingAddresses[addr]
+		if aaddr.IP != baddr.IP || aaddr.Hostname != baddr.Hostname {
+			return false
+		}
+	}
 
 	for port, aport := range sa.Ports {
 		bport := sb.Ports[port]
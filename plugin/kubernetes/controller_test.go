@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,7 +15,12 @@ import (
 	"github.com/miekg/dns"
 	api "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -34,7 +40,7 @@ func kubernetesWithFakeClient(ctx context.Context, zone, cidr string, initEndpoi
 		zones:              []string{zone},
 		initEndpointsCache: initEndpointsCache,
 	}
-	controller := newdnsController(ctx, client, dco)
+	controller := newdnsController(ctx, client, nil, dco)
 
 	// Add resources
 	_, err := client.CoreV1().Namespaces().Create(ctx, &api.Namespace{ObjectMeta: meta.ObjectMeta{Name: "testns"}}, meta.CreateOptions{})
@@ -301,3 +307,71 @@ func TestServiceModified(t *testing.T) {
 		}
 	}
 }
+
+func TestHostnameIndex(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset()
+	scheme := runtime.NewScheme()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{
+		object.GatewayResource:   "GatewayList",
+		object.HTTPRouteResource: "HTTPRouteList",
+	})
+
+	client.NetworkingV1().Ingresses("testns").Create(ctx, &networking.Ingress{
+		ObjectMeta: meta.ObjectMeta{Name: "web", Namespace: "testns"},
+		Spec:       networking.IngressSpec{Rules: []networking.IngressRule{{Host: "WWW.example.com"}}},
+		Status: networking.IngressStatus{LoadBalancer: networking.IngressLoadBalancerStatus{
+			Ingress: []networking.IngressLoadBalancerIngress{{IP: "1.2.3.4"}},
+		}},
+	}, meta.CreateOptions{})
+
+	gw := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata":   map[string]interface{}{"name": "gw", "namespace": "infra"},
+		"spec": map[string]interface{}{
+			"listeners": []interface{}{map[string]interface{}{"name": "http", "hostname": "*.apps.example.com"}},
+		},
+		"status": map[string]interface{}{
+			"addresses": []interface{}{map[string]interface{}{"type": "IPAddress", "value": "1.2.3.5"}},
+		},
+	}}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "shop", "namespace": "testns"},
+		"spec": map[string]interface{}{
+			"hostnames":  []interface{}{"shop.example.com"},
+			"parentRefs": []interface{}{map[string]interface{}{"name": "gw", "namespace": "infra"}},
+		},
+	}}
+	dynamicClient.Resource(object.GatewayResource).Namespace("infra").Create(ctx, gw, meta.CreateOptions{})
+	dynamicClient.Resource(object.HTTPRouteResource).Namespace("testns").Create(ctx, route, meta.CreateOptions{})
+
+	controller := newdnsController(ctx, client, dynamicClient, dnsControlOpts{zones: []string{"cluster.local."}})
+	controller.WatchHostnames(ctx, true, true)
+	go controller.Run()
+	defer controller.Stop()
+	for !controller.HasSynced() {
+		time.Sleep(time.Millisecond)
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"www.example.com.", []string{"1.2.3.4"}},
+		{"*.apps.example.com.", []string{"1.2.3.5"}},
+		{"shop.example.com.", []string{"1.2.3.5"}},
+		{"example.com.", nil},
+	}
+	for i, tc := range tests {
+		var addrs []string
+		for _, h := range controller.HostnameIndex(tc.name) {
+			addrs = append(addrs, h.Addresses...)
+		}
+		if strings.Join(addrs, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("Test %d: expected addresses %v for %s, got %v", i, tc.expected, tc.name, addrs)
+		}
+	}
+}
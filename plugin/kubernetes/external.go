@@ -1,6 +1,8 @@
 package kubernetes
 
 import (
+	"context"
+	"errors"
 	"strings"
 
 	"github.com/coredns/coredns/plugin/etcd/msg"
@@ -235,3 +237,43 @@ func (k *Kubernetes) serviceRecordForExternalIP(ip string) []msg.Service {
 	}
 	return svcs
 }
+
+// hostnameWatcher is implemented by a dnsController that can watch the hostnames of Ingresses and the Gateway API.
+type hostnameWatcher interface {
+	WatchHostnames(ctx context.Context, ingress, gateway bool)
+}
+
+// WatchHostnames implements the WatchHostnames call from the external plugin. It watches the Ingresses if ingress
+// is true, and the Gateways and HTTPRoutes of the Gateway API if gateway is true.
+func (k *Kubernetes) WatchHostnames(ingress, gateway bool) error {
+	w, ok := k.APIConn.(hostnameWatcher)
+	if !ok {
+		return errors.New("hostnames can not be watched")
+	}
+	w.WatchHostnames(context.Background(), ingress, gateway)
+	return nil
+}
+
+// ExternalHostnames implements the ExternalHostnames call from the external plugin. It returns the addresses of the
+// Ingresses, Gateways and HTTPRoutes that declare the name of the request. If there are none, the ones with a
+// wildcard hostname matching the name are used.
+func (k *Kubernetes) ExternalHostnames(state request.Request) []msg.Service {
+	name := state.Name()
+	hs := k.APIConn.HostnameIndex(name)
+	if len(hs) == 0 {
+		if i, end := dns.NextLabel(name, 0); !end {
+			hs = k.APIConn.HostnameIndex("*." + name[i:])
+		}
+	}
+
+	var services []msg.Service
+	for _, h := range hs {
+		if !k.namespaceExposed(h.Namespace) {
+			continue
+		}
+		for _, addr := range h.Addresses {
+			services = append(services, msg.Service{Host: addr, TTL: k.ttl, Key: msg.Path(name, coredns)})
+		}
+	}
+	return services
+}
@@ -567,13 +567,16 @@ type APIConnServeTest struct {
 	notSynced bool
 }
 
-func (a APIConnServeTest) HasSynced() bool                           { return !a.notSynced }
-func (APIConnServeTest) Run()                                        {}
-func (APIConnServeTest) Stop() error                                 { return nil }
-func (APIConnServeTest) EpIndexReverse(string) []*object.Endpoints   { return nil }
-func (APIConnServeTest) SvcIndexReverse(string) []*object.Service    { return nil }
-func (APIConnServeTest) SvcExtIndexReverse(string) []*object.Service { return nil }
-func (APIConnServeTest) Modified(bool) int64                         { return int64(3) }
+func (a APIConnServeTest) HasSynced() bool                                { return !a.notSynced }
+func (APIConnServeTest) Run()                                             {}
+func (APIConnServeTest) Stop() error                                      { return nil }
+func (APIConnServeTest) SvcImportIndex(string) []*object.ServiceImport    { return nil }
+func (APIConnServeTest) McEpIndex(string) []*object.MultiClusterEndpoints { return nil }
+func (APIConnServeTest) HostnameIndex(string) []*object.Hostnames         { return nil }
+func (APIConnServeTest) EpIndexReverse(string) []*object.Endpoints        { return nil }
+func (APIConnServeTest) SvcIndexReverse(string) []*object.Service         { return nil }
+func (APIConnServeTest) SvcExtIndexReverse(string) []*object.Service      { return nil }
+func (APIConnServeTest) Modified(bool) int64                              { return int64(3) }
 
 func (APIConnServeTest) PodIndex(ip string) []*object.Pod {
 	if ip != "10.240.0.1" {
@@ -813,6 +816,12 @@ func (APIConnServeTest) GetNamespaceByName(name string) (*object.Namespace, erro
 	if name == "nsnoexist" {
 		return nil, fmt.Errorf("namespace not found")
 	}
+	if name == "podns" {
+		return &object.Namespace{
+			Name:        name,
+			Annotations: map[string]string{"dns-policy": "restricted"},
+		}, nil
+	}
 	return &object.Namespace{
 		Name: name,
 	}, nil
@@ -20,6 +20,7 @@ import (
 	api "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -46,6 +47,8 @@ type Kubernetes struct {
 	primaryZoneIndex int
 	localIPs         []net.IP
 	autoPathSearch   []string // Local search path from /etc/resolv.conf. Needed for autopath.
+	topologyMode     string   // Either topologyPrefer or topologyFilter if answers are topology aware.
+	nodeZones        *nodeZones
 }
 
 // Upstreamer is used to resolve CNAME or other external targets
@@ -61,6 +64,7 @@ func New(zones []string) *Kubernetes {
 	k.Namespaces = make(map[string]struct{})
 	k.podMode = podModeDisabled
 	k.ttl = defaultTTL
+	k.nodeZones = &nodeZones{m: make(map[string]nodeZone)}
 
 	return k
 }
@@ -78,6 +82,10 @@ const (
 	Svc = "svc"
 	// Pod is the DNS schema for kubernetes pods
 	Pod = "pod"
+	// terminatingNever never answers with terminating endpoints.
+	terminatingNever = "never"
+	// terminatingFallback answers with the terminating, but serving, endpoints if there are no ready ones.
+	terminatingFallback = "fallback"
 	// defaultTTL to apply to all answers.
 	defaultTTL = 5
 )
@@ -256,12 +264,20 @@ func (k *Kubernetes) InitKubeCache(ctx context.Context) (onStart func() error, o
 	k.opts.zones = k.Zones
 	k.opts.endpointNameMode = k.endpointNameMode
 
-	k.APIConn = newdnsController(ctx, kubeClient, k.opts)
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubernetes dynamic client: %q", err)
+	}
+
+	// Instances with the same settings share the controller, and with that the connection to the API server.
+	key := controllerKey(config, k.opts)
+	ctrl := controllers.acquire(key, func() *dnsControl {
+		return newdnsController(ctx, kubeClient, dynamicClient, k.opts)
+	})
+	k.APIConn = ctrl
 
 	onStart = func() error {
-		go func() {
-			k.APIConn.Run()
-		}()
+		controllers.run(key)
 
 		timeout := 5 * time.Second
 		timeoutTicker := time.NewTicker(timeout)
@@ -287,7 +303,7 @@ func (k *Kubernetes) InitKubeCache(ctx context.Context) (onStart func() error, o
 	}
 
 	onShut = func() error {
-		return k.APIConn.Stop()
+		return controllers.release(key)
 	}
 
 	return onStart, onShut, err
@@ -295,7 +311,8 @@ func (k *Kubernetes) InitKubeCache(ctx context.Context) (onStart func() error, o
 
 // Records looks up services in kubernetes.
 func (k *Kubernetes) Records(ctx context.Context, state request.Request, exact bool) ([]msg.Service, error) {
-	r, e := parseRequest(state.Name(), state.Zone)
+	multicluster := k.isMultiClusterZone(state.Zone)
+	r, e := parseRequest(state.Name(), state.Zone, multicluster)
 	if e != nil {
 		return nil, e
 	}
@@ -311,12 +328,19 @@ func (k *Kubernetes) Records(ctx context.Context, state request.Request, exact b
 		return nil, errNsNotExposed
 	}
 
+	if multicluster {
+		if r.podOrSvc == Pod {
+			return nil, errNoItems
+		}
+		return k.findMultiClusterServices(r, state.Zone)
+	}
+
 	if r.podOrSvc == Pod {
 		pods, err := k.findPods(r, state.Zone)
 		return pods, err
 	}
 
-	services, err := k.findServices(r, state.Zone)
+	services, err := k.findServices(r, state.Zone, k.clientTopology(ctx, state))
 	return services, err
 }
 
@@ -394,8 +418,9 @@ func (k *Kubernetes) findPods(r recordRequest, zone string) (pods []msg.Service,
 	return pods, err
 }
 
-// findServices returns the services matching r from the cache.
-func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.Service, err error) {
+// findServices returns the services matching r from the cache. If t is not nil the endpoints of headless
+// services and endpoint queries are ordered, or filtered, by their distance to t.
+func (k *Kubernetes) findServices(r recordRequest, zone string, t *clientTopology) (services []msg.Service, err error) {
 	if !k.namespaceExposed(r.namespace) {
 		return nil, errNoItems
 	}
@@ -435,6 +460,9 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 			for _, ep := range endpointsListFunc() {
 				for _, eps := range ep.Subsets {
 					podsCount += len(eps.Addresses)
+					if k.opts.terminatingFallback {
+						podsCount += len(eps.TerminatingAddresses)
+					}
 				}
 			}
 
@@ -465,34 +493,14 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 				endpointsList = endpointsListFunc()
 			}
 
-			for _, ep := range endpointsList {
-				if object.EndpointsKey(svc.Name, svc.Namespace) != ep.Index {
-					continue
-				}
-
-				for _, eps := range ep.Subsets {
-					for _, addr := range eps.Addresses {
-						// See comments in parse.go parseRequest about the endpoint handling.
-						if r.endpoint != "" {
-							if !match(r.endpoint, endpointHostname(addr, k.endpointNameMode)) {
-								continue
-							}
-						}
-
-						for _, p := range eps.Ports {
-							if !(matchPortAndProtocol(r.port, p.Name, r.protocol, p.Protocol)) {
-								continue
-							}
-							s := msg.Service{Host: addr.IP, Port: int(p.Port), TTL: k.ttl}
-							s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name, endpointHostname(addr, k.endpointNameMode)}, "/")
-
-							err = nil
-
-							services = append(services, s)
-						}
-					}
-				}
+			endpointServices := k.endpointServices(r, svc, endpointsList, zonePath, t, false)
+			if len(endpointServices) == 0 && k.opts.terminatingFallback {
+				endpointServices = k.endpointServices(r, svc, endpointsList, zonePath, t, true)
 			}
+			if len(endpointServices) > 0 {
+				err = nil
+			}
+			services = append(services, endpointServices...)
 			continue
 		}
 
@@ -514,6 +522,52 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 	return services, err
 }
 
+// endpointServices returns the services for the endpoint addresses of svc in endpointsList that match r. If
+// terminating is true the addresses that are terminating, but still serving, are used instead of the ready ones.
+func (k *Kubernetes) endpointServices(r recordRequest, svc *object.Service, endpointsList []*object.Endpoints, zonePath string, t *clientTopology, terminating bool) []msg.Service {
+	var (
+		services []msg.Service
+		ranks    []int
+	)
+	for _, ep := range endpointsList {
+		if object.EndpointsKey(svc.Name, svc.Namespace) != ep.Index {
+			continue
+		}
+
+		for _, eps := range ep.Subsets {
+			addrs := eps.Addresses
+			if terminating {
+				addrs = eps.TerminatingAddresses
+			}
+			for _, addr := range addrs {
+				// See comments in parse.go parseRequest about the endpoint handling.
+				if r.endpoint != "" {
+					if !match(r.endpoint, endpointHostname(addr, k.endpointNameMode)) {
+						continue
+					}
+				}
+
+				for _, p := range eps.Ports {
+					if !(matchPortAndProtocol(r.port, p.Name, r.protocol, p.Protocol)) {
+						continue
+					}
+					s := msg.Service{Host: addr.IP, Port: int(p.Port), TTL: k.ttl}
+					s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name, endpointHostname(addr, k.endpointNameMode)}, "/")
+
+					services = append(services, s)
+					if t != nil {
+						ranks = append(ranks, t.rank(addr))
+					}
+				}
+			}
+		}
+	}
+	if t != nil {
+		services = k.byTopology(services, ranks)
+	}
+	return services
+}
+
 // Serial return the SOA serial.
 func (k *Kubernetes) Serial(state request.Request) uint32 { return uint32(k.APIConn.Modified(false)) }
 
@@ -2,8 +2,11 @@ package kubernetes
 
 import (
 	"context"
+	"strconv"
+	"sync"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/kubernetes/object"
 	"github.com/coredns/coredns/plugin/metadata"
 	"github.com/coredns/coredns/request"
 )
@@ -26,6 +29,15 @@ func (k *Kubernetes) Metadata(ctx context.Context, state request.Request) contex
 				return v
 			})
 		}
+
+		if ns, err := k.APIConn.GetNamespaceByName(pod.Namespace); err == nil {
+			for key, v := range ns.Annotations {
+				v := v
+				metadata.SetValueFunc(ctx, "kubernetes/client-namespace-annotation/"+key, func() string {
+					return v
+				})
+			}
+		}
 	}
 
 	zone := plugin.Zones(k.Zones).Matches(state.Name())
@@ -33,7 +45,7 @@ func (k *Kubernetes) Metadata(ctx context.Context, state request.Request) contex
 		return ctx
 	}
 	// possible optimization: cache r so it doesn't need to be calculated again in ServeDNS
-	r, err := parseRequest(state.Name(), zone)
+	r, err := parseRequest(state.Name(), zone, k.isMultiClusterZone(zone))
 	if err != nil {
 		metadata.SetValueFunc(ctx, "kubernetes/parse-error", func() string {
 			return err.Error()
@@ -65,5 +77,68 @@ func (k *Kubernetes) Metadata(ctx context.Context, state request.Request) contex
 		return r.podOrSvc
 	})
 
+	if r.podOrSvc == Svc && r.service != "" && !k.isMultiClusterZone(zone) {
+		var (
+			once sync.Once
+			c    endpointConditions
+		)
+		conditions := func() endpointConditions {
+			once.Do(func() { c = k.endpointConditions(r) })
+			return c
+		}
+
+		metadata.SetValueFunc(ctx, "kubernetes/endpoint-ready", func() string {
+			return conditions().ready
+		})
+
+		metadata.SetValueFunc(ctx, "kubernetes/endpoint-serving", func() string {
+			return conditions().serving
+		})
+
+		metadata.SetValueFunc(ctx, "kubernetes/endpoint-terminating", func() string {
+			return conditions().terminating
+		})
+	}
+
 	return ctx
 }
+
+// endpointConditions holds the EndpointSlice conditions of the endpoints for a query, as "true" or "false". They
+// are empty if there are no such endpoints.
+type endpointConditions struct {
+	ready       string
+	serving     string
+	terminating string
+}
+
+// endpointConditions returns the conditions of the endpoint in r, or, if r has no endpoint, of the endpoints of
+// the service in r: ready if there is a ready endpoint, serving if there is a ready or terminating but serving
+// endpoint, and terminating if there are only terminating endpoints.
+func (k *Kubernetes) endpointConditions(r recordRequest) endpointConditions {
+	ready, terminating := 0, 0
+	matches := func(addr object.EndpointAddress) bool {
+		return r.endpoint == "" || match(r.endpoint, endpointHostname(addr, k.endpointNameMode))
+	}
+	for _, ep := range k.APIConn.EpIndex(object.EndpointsKey(r.service, r.namespace)) {
+		for _, eps := range ep.Subsets {
+			for _, addr := range eps.Addresses {
+				if matches(addr) {
+					ready++
+				}
+			}
+			for _, addr := range eps.TerminatingAddresses {
+				if matches(addr) {
+					terminating++
+				}
+			}
+		}
+	}
+	if ready+terminating == 0 {
+		return endpointConditions{}
+	}
+	return endpointConditions{
+		ready:       strconv.FormatBool(ready > 0),
+		serving:     "true",
+		terminating: strconv.FormatBool(ready == 0),
+	}
+}
@@ -43,6 +43,10 @@ var metadataCases = []struct {
 			"kubernetes/port-name": "",
 			"kubernetes/protocol":  "",
 			"kubernetes/service":   "s",
+
+			"kubernetes/endpoint-ready":       "",
+			"kubernetes/endpoint-serving":     "",
+			"kubernetes/endpoint-terminating": "",
 		},
 	},
 	{
@@ -55,6 +59,10 @@ var metadataCases = []struct {
 			"kubernetes/port-name": "",
 			"kubernetes/protocol":  "",
 			"kubernetes/service":   "s",
+
+			"kubernetes/endpoint-ready":       "",
+			"kubernetes/endpoint-serving":     "",
+			"kubernetes/endpoint-terminating": "",
 		},
 	},
 	{
@@ -67,6 +75,10 @@ var metadataCases = []struct {
 			"kubernetes/port-name": "http",
 			"kubernetes/protocol":  "tcp",
 			"kubernetes/service":   "s",
+
+			"kubernetes/endpoint-ready":       "",
+			"kubernetes/endpoint-serving":     "",
+			"kubernetes/endpoint-terminating": "",
 		},
 	},
 	{
@@ -79,6 +91,26 @@ var metadataCases = []struct {
 			"kubernetes/port-name": "",
 			"kubernetes/protocol":  "",
 			"kubernetes/service":   "s",
+
+			"kubernetes/endpoint-ready":       "",
+			"kubernetes/endpoint-serving":     "",
+			"kubernetes/endpoint-terminating": "",
+		},
+	},
+	{
+		Qname: "hdls1.testns.svc.cluster.local.", Qtype: dns.TypeA,
+		RemoteIP: "10.10.10.10",
+		Md: map[string]string{
+			"kubernetes/endpoint":  "",
+			"kubernetes/kind":      "svc",
+			"kubernetes/namespace": "testns",
+			"kubernetes/port-name": "",
+			"kubernetes/protocol":  "",
+			"kubernetes/service":   "hdls1",
+
+			"kubernetes/endpoint-ready":       "true",
+			"kubernetes/endpoint-serving":     "true",
+			"kubernetes/endpoint-terminating": "false",
 		},
 	},
 	{
@@ -145,6 +177,8 @@ func TestMetadataPodsVerified(t *testing.T) {
 		"kubernetes/client-pod-name":                     "foo",
 		"kubernetes/client-label/app.kubernetes.io/name": "foo",
 		"kubernetes/client-label/bar":                    "baz",
+
+		"kubernetes/client-namespace-annotation/dns-policy": "restricted",
 	}
 
 	md := make(map[string]string)
@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+)
+
+// isMultiClusterZone returns true if zone is one of the zones for multi-cluster services.
+func (k *Kubernetes) isMultiClusterZone(zone string) bool {
+	return plugin.Zones(k.opts.multiclusterZones).Matches(zone) != ""
+}
+
+// findMultiClusterServices returns the ServiceImports matching r from the cache. See KEP-1645 for how these
+// names are formed: a ClusterSetIP service is answered with the IPs of the ServiceImport, a headless service
+// with the endpoints in all the clusters exporting it, these are named <hostname>.<clusterid>.<service>.
+func (k *Kubernetes) findMultiClusterServices(r recordRequest, zone string) (services []msg.Service, err error) {
+	if !k.namespaceExposed(r.namespace) {
+		return nil, errNoItems
+	}
+
+	// handle empty service name
+	if r.service == "" {
+		return nil, nil
+	}
+
+	err = errNoItems
+
+	var endpointsList []*object.MultiClusterEndpoints
+
+	idx := object.ServiceImportKey(r.service, r.namespace)
+	serviceList := k.APIConn.SvcImportIndex(idx)
+
+	zonePath := msg.Path(zone, coredns)
+	for _, svc := range serviceList {
+		if !(match(r.namespace, svc.Namespace) && match(r.service, svc.Name)) {
+			continue
+		}
+
+		// Endpoint query or headless service
+		if svc.Headless() || r.endpoint != "" {
+			if endpointsList == nil {
+				endpointsList = k.APIConn.McEpIndex(idx)
+			}
+
+			for _, ep := range endpointsList {
+				if object.EndpointsKey(svc.Name, svc.Namespace) != ep.Index {
+					continue
+				}
+				if r.cluster != "" && !match(r.cluster, ep.ClusterID) {
+					continue
+				}
+
+				for _, eps := range ep.Subsets {
+					for _, addr := range eps.Addresses {
+						if r.endpoint != "" {
+							if !match(r.endpoint, endpointHostname(addr, k.endpointNameMode)) {
+								continue
+							}
+						}
+
+						for _, p := range eps.Ports {
+							if !(matchPortAndProtocol(r.port, p.Name, r.protocol, p.Protocol)) {
+								continue
+							}
+							s := msg.Service{Host: addr.IP, Port: int(p.Port), TTL: k.ttl}
+							s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name, ep.ClusterID, endpointHostname(addr, k.endpointNameMode)}, "/")
+
+							err = nil
+
+							services = append(services, s)
+						}
+					}
+				}
+			}
+			continue
+		}
+
+		// ClusterSetIP service
+		for _, p := range svc.Ports {
+			if !(matchPortAndProtocol(r.port, p.Name, r.protocol, string(p.Protocol))) {
+				continue
+			}
+
+			err = nil
+
+			for _, ip := range svc.ClusterIPs {
+				s := msg.Service{Host: ip, Port: int(p.Port), TTL: k.ttl}
+				s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name}, "/")
+				services = append(services, s)
+			}
+		}
+	}
+	return services, err
+}
@@ -0,0 +1,177 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+)
+
+var multiclusterCases = []test.Case{
+	// A ClusterSetIP service
+	{
+		Qname: "svc1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.clusterset.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+	// SRV of a ClusterSetIP service
+	{
+		Qname: "_http._tcp.svc1.testns.svc.clusterset.local.", Qtype: dns.TypeSRV,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.svc1.testns.svc.clusterset.local.	5	IN	SRV	0 100 80 svc1.testns.svc.clusterset.local."),
+		},
+		Extra: []dns.RR{
+			test.A("svc1.testns.svc.clusterset.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+	// A headless service, exported by two clusters
+	{
+		Qname: "hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.2"),
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.3"),
+		},
+	},
+	// SRV of a headless service, the targets are named after the cluster
+	{
+		Qname: "_http._tcp.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeSRV,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 50 80 pod-0.cluster-a.hdls1.testns.svc.clusterset.local."),
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 50 80 pod-0.cluster-b.hdls1.testns.svc.clusterset.local."),
+		},
+		Extra: []dns.RR{
+			test.A("pod-0.cluster-a.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.2"),
+			test.A("pod-0.cluster-b.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.3"),
+		},
+	},
+	// An endpoint in a single cluster
+	{
+		Qname: "pod-0.cluster-b.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("pod-0.cluster-b.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.3"),
+		},
+	},
+	// An endpoint in an unknown cluster
+	{
+		Qname: "pod-0.cluster-c.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// A service that is not imported
+	{
+		Qname: "svc2.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// Pods are not answered in the clusterset zone
+	{
+		Qname: "10-240-0-1.podns.pod.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+}
+
+func TestServeDNSMultiCluster(t *testing.T) {
+	k := New([]string{"cluster.local.", "clusterset.local."})
+	k.APIConn = &APIConnMultiClusterTest{}
+	k.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.podMode = podModeInsecure
+	k.opts.multiclusterZones = []string{"clusterset.local."}
+	ctx := context.TODO()
+
+	for i, tc := range multiclusterCases {
+		r := tc.Msg()
+
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+
+		_, err := k.ServeDNS(ctx, w, r)
+		if err != tc.Error {
+			t.Errorf("Test %d expected no error, got %v", i, err)
+			return
+		}
+
+		resp := w.Msg
+		if resp == nil {
+			t.Fatalf("Test %d, got nil message and no error for %q", i, r.Question[0].Name)
+		}
+
+		if err := test.SortAndCheck(resp, tc); err != nil {
+			t.Errorf("Test %d: %s", i, err)
+		}
+	}
+}
+
+type APIConnMultiClusterTest struct {
+	APIConnServeTest
+}
+
+func (APIConnMultiClusterTest) SvcImportIndex(idx string) []*object.ServiceImport {
+	svcs := map[string][]*object.ServiceImport{
+		"svc1.testns": {
+			{
+				Name: "svc1", Namespace: "testns", Index: "svc1.testns",
+				Type:       object.ServiceImportClusterSetIP,
+				ClusterIPs: []string{"10.0.0.1"},
+				Ports:      []api.ServicePort{{Name: "http", Protocol: "tcp", Port: 80}},
+			},
+		},
+		"hdls1.testns": {
+			{
+				Name: "hdls1", Namespace: "testns", Index: "hdls1.testns",
+				Type:  object.ServiceImportHeadless,
+				Ports: []api.ServicePort{{Port: -1}},
+			},
+		},
+	}
+	return svcs[idx]
+}
+
+func (APIConnMultiClusterTest) McEpIndex(idx string) []*object.MultiClusterEndpoints {
+	eps := map[string][]*object.MultiClusterEndpoints{
+		"hdls1.testns": {
+			{
+				Endpoints: object.Endpoints{
+					Subsets: []object.EndpointSubset{
+						{
+							Addresses: []object.EndpointAddress{{IP: "172.0.0.2", Hostname: "pod-0"}},
+							Ports:     []object.EndpointPort{{Port: 80, Protocol: "tcp", Name: "http"}},
+						},
+					},
+					Name: "hdls1-a", Namespace: "testns", Index: object.EndpointsKey("hdls1", "testns"),
+				},
+				ClusterID: "cluster-a",
+			},
+			{
+				Endpoints: object.Endpoints{
+					Subsets: []object.EndpointSubset{
+						{
+							Addresses: []object.EndpointAddress{{IP: "172.0.0.3", Hostname: "pod-0"}},
+							Ports:     []object.EndpointPort{{Port: 80, Protocol: "tcp", Name: "http"}},
+						},
+					},
+					Name: "hdls1-b", Namespace: "testns", Index: object.EndpointsKey("hdls1", "testns"),
+				},
+				ClusterID: "cluster-b",
+			},
+		},
+	}
+	return eps[idx]
+}
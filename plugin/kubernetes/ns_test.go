@@ -58,6 +58,10 @@ func (APIConnTest) ServiceList() []*object.Service {
 	return svcs
 }
 
+func (APIConnTest) SvcImportIndex(string) []*object.ServiceImport    { return nil }
+func (APIConnTest) McEpIndex(string) []*object.MultiClusterEndpoints { return nil }
+func (APIConnTest) HostnameIndex(string) []*object.Hostnames         { return nil }
+
 func (APIConnTest) EpIndexReverse(ip string) []*object.Endpoints {
 	if ip != "10.244.0.20" {
 		return nil
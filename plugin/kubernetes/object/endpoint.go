@@ -26,6 +26,8 @@ type Endpoints struct {
 type EndpointSubset struct {
 	Addresses []EndpointAddress
 	Ports     []EndpointPort
+	// TerminatingAddresses are the addresses that are not ready, because they are terminating, but still serving.
+	TerminatingAddresses []EndpointAddress
 }
 
 // EndpointAddress is a tuple that describes single IP address.
@@ -34,6 +36,8 @@ type EndpointAddress struct {
 	Hostname      string
 	NodeName      string
 	TargetRefName string
+	Zone          string
+	ForZones      []string // topology hints, the zones this address should be consumed from
 }
 
 // EndpointPort is a tuple that describes a single port.
@@ -52,11 +56,20 @@ func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 	if !ok {
 		return nil, fmt.Errorf("unexpected object %v", obj)
 	}
+	e := endpointSliceToEndpoints(ends, ends.Labels[discovery.LabelServiceName])
+
+	*ends = discovery.EndpointSlice{}
+
+	return e, nil
+}
+
+// endpointSliceToEndpoints converts ends, which is a slice of the service named service, to a *Endpoints.
+func endpointSliceToEndpoints(ends *discovery.EndpointSlice, service string) *Endpoints {
 	e := &Endpoints{
 		Version:   ends.GetResourceVersion(),
 		Name:      ends.GetName(),
 		Namespace: ends.GetNamespace(),
-		Index:     EndpointsKey(ends.Labels[discovery.LabelServiceName], ends.GetNamespace()),
+		Index:     EndpointsKey(service, ends.GetNamespace()),
 		Subsets:   make([]EndpointSubset, 1),
 	}
 
@@ -84,7 +97,8 @@ func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 	}
 
 	for _, end := range ends.Endpoints {
-		if !endpointsliceReady(end.Conditions.Ready) {
+		ready := endpointsliceReady(end.Conditions.Ready)
+		if !ready && !endpointsliceTerminating(end.Conditions) {
 			continue
 		}
 		for _, a := range end.Addresses {
@@ -99,14 +113,23 @@ func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 			if end.NodeName != nil {
 				ea.NodeName = *end.NodeName
 			}
+			if end.Zone != nil {
+				ea.Zone = *end.Zone
+			}
+			if end.Hints != nil {
+				for _, z := range end.Hints.ForZones {
+					ea.ForZones = append(ea.ForZones, z.Name)
+				}
+			}
+			if !ready {
+				e.Subsets[0].TerminatingAddresses = append(e.Subsets[0].TerminatingAddresses, ea)
+				continue
+			}
 			e.Subsets[0].Addresses = append(e.Subsets[0].Addresses, ea)
 			e.IndexIP = append(e.IndexIP, a)
 		}
 	}
-
-	*ends = discovery.EndpointSlice{}
-
-	return e, nil
+	return e
 }
 
 func endpointsliceReady(ready *bool) bool {
@@ -118,6 +141,11 @@ func endpointsliceReady(ready *bool) bool {
 	return *ready
 }
 
+// endpointsliceTerminating returns true if the endpoint is terminating, but still serving.
+func endpointsliceTerminating(c discovery.EndpointConditions) bool {
+	return c.Serving != nil && *c.Serving && c.Terminating != nil && *c.Terminating
+}
+
 // CopyWithoutSubsets copies e, without the subsets.
 func (e *Endpoints) CopyWithoutSubsets() *Endpoints {
 	e1 := &Endpoints{
@@ -147,12 +175,11 @@ func (e *Endpoints) DeepCopyObject() runtime.Object {
 
 	for i, eps := range e.Subsets {
 		sub := EndpointSubset{
-			Addresses: make([]EndpointAddress, len(eps.Addresses)),
+			Addresses: copyAddresses(eps.Addresses),
 			Ports:     make([]EndpointPort, len(eps.Ports)),
 		}
-		for j, a := range eps.Addresses {
-			ea := EndpointAddress{IP: a.IP, Hostname: a.Hostname, NodeName: a.NodeName, TargetRefName: a.TargetRefName}
-			sub.Addresses[j] = ea
+		if eps.TerminatingAddresses != nil {
+			sub.TerminatingAddresses = copyAddresses(eps.TerminatingAddresses)
 		}
 		for k, p := range eps.Ports {
 			ep := EndpointPort{Port: p.Port, Name: p.Name, Protocol: p.Protocol}
@@ -163,6 +190,19 @@ func (e *Endpoints) DeepCopyObject() runtime.Object {
 	return e1
 }
 
+func copyAddresses(addrs []EndpointAddress) []EndpointAddress {
+	addrs1 := make([]EndpointAddress, len(addrs))
+	for i, a := range addrs {
+		ea := EndpointAddress{IP: a.IP, Hostname: a.Hostname, NodeName: a.NodeName, TargetRefName: a.TargetRefName, Zone: a.Zone}
+		if a.ForZones != nil {
+			ea.ForZones = make([]string, len(a.ForZones))
+			copy(ea.ForZones, a.ForZones)
+		}
+		addrs1[i] = ea
+	}
+	return addrs1
+}
+
 // GetNamespace implements the metav1.Object interface.
 func (e *Endpoints) GetNamespace() string { return e.Namespace }
 
@@ -0,0 +1,180 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Resources of the Gateway API.
+var (
+	GatewayResource   = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}
+	HTTPRouteResource = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+)
+
+// Hostnames is a stripped down Ingress, Gateway or HTTPRoute with only the hostnames they declare and the
+// addresses from their status.
+type Hostnames struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version   string
+	Name      string
+	Namespace string
+	Hostnames []string // lowercased and fully qualified, these may start with a "*." wildcard
+	Addresses []string // IP addresses or hostnames
+	Parents   []string // for HTTPRoutes, the namespace/name keys of the Gateways they are attached to
+
+	*Empty
+}
+
+// IngressToHostnames converts an *networking.Ingress to a *Hostnames.
+func IngressToHostnames(obj meta.Object) (meta.Object, error) {
+	ing, ok := obj.(*networking.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	h := &Hostnames{
+		Version:   ing.GetResourceVersion(),
+		Name:      ing.GetName(),
+		Namespace: ing.GetNamespace(),
+	}
+	for _, r := range ing.Spec.Rules {
+		h.addHostname(r.Host)
+	}
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			h.Addresses = append(h.Addresses, lb.IP)
+			continue
+		}
+		if lb.Hostname != "" {
+			h.Addresses = append(h.Addresses, lb.Hostname)
+		}
+	}
+
+	*ing = networking.Ingress{}
+
+	return h, nil
+}
+
+// GatewayToHostnames converts an unstructured Gateway to a *Hostnames.
+func GatewayToHostnames(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	h := &Hostnames{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	listeners, _, _ := unstructured.NestedSlice(u.Object, "spec", "listeners")
+	for _, l := range listeners {
+		if m, ok := l.(map[string]interface{}); ok {
+			name, _, _ := unstructured.NestedString(m, "hostname")
+			h.addHostname(name)
+		}
+	}
+	addrs, _, _ := unstructured.NestedSlice(u.Object, "status", "addresses")
+	for _, a := range addrs {
+		if m, ok := a.(map[string]interface{}); ok {
+			if v, _, _ := unstructured.NestedString(m, "value"); v != "" {
+				h.Addresses = append(h.Addresses, v)
+			}
+		}
+	}
+
+	u.Object = nil
+
+	return h, nil
+}
+
+// HTTPRouteToHostnames converts an unstructured HTTPRoute to a *Hostnames. The addresses of a route are those of
+// the Gateways it is attached to, so these are left empty.
+func HTTPRouteToHostnames(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	h := &Hostnames{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	names, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "hostnames")
+	for _, name := range names {
+		h.addHostname(name)
+	}
+	parents, _, _ := unstructured.NestedSlice(u.Object, "spec", "parentRefs")
+	for _, p := range parents {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, ok, _ := unstructured.NestedString(m, "kind"); ok && kind != "Gateway" {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		ns, ok, _ := unstructured.NestedString(m, "namespace")
+		if !ok {
+			ns = h.Namespace
+		}
+		h.Parents = append(h.Parents, ns+"/"+name)
+	}
+
+	u.Object = nil
+
+	return h, nil
+}
+
+func (h *Hostnames) addHostname(name string) {
+	if name == "" {
+		return
+	}
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	h.Hostnames = append(h.Hostnames, name)
+}
+
+var _ runtime.Object = &Hostnames{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (h *Hostnames) DeepCopyObject() runtime.Object {
+	h1 := &Hostnames{
+		Version:   h.Version,
+		Name:      h.Name,
+		Namespace: h.Namespace,
+		Hostnames: make([]string, len(h.Hostnames)),
+		Addresses: make([]string, len(h.Addresses)),
+		Parents:   make([]string, len(h.Parents)),
+	}
+	copy(h1.Hostnames, h.Hostnames)
+	copy(h1.Addresses, h.Addresses)
+	copy(h1.Parents, h.Parents)
+	return h1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (h *Hostnames) GetNamespace() string { return h.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (h *Hostnames) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (h *Hostnames) GetName() string { return h.Name }
+
+// SetName implements the metav1.Object interface.
+func (h *Hostnames) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (h *Hostnames) GetResourceVersion() string { return h.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (h *Hostnames) SetResourceVersion(version string) {}
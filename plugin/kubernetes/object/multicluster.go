@@ -0,0 +1,48 @@
+package object
+
+import (
+	"fmt"
+
+	discovery "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Labels set on the EndpointSlices that are derived from the clusters exporting a service (KEP-1645).
+const (
+	LabelMultiClusterServiceName = "multicluster.kubernetes.io/service-name"
+	LabelSourceCluster           = "multicluster.kubernetes.io/source-cluster"
+)
+
+// MultiClusterEndpoints is the Endpoints of a single cluster exporting a multi-cluster service.
+type MultiClusterEndpoints struct {
+	Endpoints
+	ClusterID string
+}
+
+// EndpointSliceToMultiClusterEndpoints converts a *discovery.EndpointSlice derived from a ServiceImport to a
+// *MultiClusterEndpoints.
+func EndpointSliceToMultiClusterEndpoints(obj meta.Object) (meta.Object, error) {
+	ends, ok := obj.(*discovery.EndpointSlice)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	e := &MultiClusterEndpoints{
+		Endpoints: *endpointSliceToEndpoints(ends, ends.Labels[LabelMultiClusterServiceName]),
+		ClusterID: ends.Labels[LabelSourceCluster],
+	}
+
+	*ends = discovery.EndpointSlice{}
+
+	return e, nil
+}
+
+var _ runtime.Object = &MultiClusterEndpoints{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (e *MultiClusterEndpoints) DeepCopyObject() runtime.Object {
+	return &MultiClusterEndpoints{
+		Endpoints: *e.Endpoints.DeepCopyObject().(*Endpoints),
+		ClusterID: e.ClusterID,
+	}
+}
@@ -11,8 +11,9 @@ import (
 // Namespace is a stripped down api.Namespace with only the items we need for CoreDNS.
 type Namespace struct {
 	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
-	Version string
-	Name    string
+	Version     string
+	Name        string
+	Annotations map[string]string
 
 	*Empty
 }
@@ -24,9 +25,12 @@ func ToNamespace(obj meta.Object) (meta.Object, error) {
 		return nil, fmt.Errorf("unexpected object %v", obj)
 	}
 	n := &Namespace{
-		Version: ns.GetResourceVersion(),
-		Name:    ns.GetName(),
+		Version:     ns.GetResourceVersion(),
+		Name:        ns.GetName(),
+		Annotations: ns.GetAnnotations(),
 	}
+	// This can hold a copy of the entire object, we never need it.
+	delete(n.Annotations, api.LastAppliedConfigAnnotation)
 	*ns = api.Namespace{}
 	return n, nil
 }
@@ -36,8 +40,12 @@ var _ runtime.Object = &Namespace{}
 // DeepCopyObject implements the ObjectKind interface.
 func (n *Namespace) DeepCopyObject() runtime.Object {
 	n1 := &Namespace{
-		Version: n.Version,
-		Name:    n.Name,
+		Version:     n.Version,
+		Name:        n.Name,
+		Annotations: make(map[string]string, len(n.Annotations)),
+	}
+	for k, v := range n.Annotations {
+		n1.Annotations[k] = v
 	}
 	return n1
 }
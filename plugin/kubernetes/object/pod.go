@@ -16,6 +16,7 @@ type Pod struct {
 	PodIP     string
 	Name      string
 	Namespace string
+	NodeName  string
 	Labels    map[string]string
 
 	*Empty
@@ -34,6 +35,7 @@ func ToPod(obj meta.Object) (meta.Object, error) {
 		PodIP:     apiPod.Status.PodIP,
 		Namespace: apiPod.GetNamespace(),
 		Name:      apiPod.GetName(),
+		NodeName:  apiPod.Spec.NodeName,
 		Labels:    apiPod.GetLabels(),
 	}
 	t := apiPod.ObjectMeta.DeletionTimestamp
@@ -57,6 +59,7 @@ func (p *Pod) DeepCopyObject() runtime.Object {
 		PodIP:     p.PodIP,
 		Namespace: p.Namespace,
 		Name:      p.Name,
+		NodeName:  p.NodeName,
 	}
 	return p1
 }
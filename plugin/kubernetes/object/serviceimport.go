@@ -0,0 +1,118 @@
+package object
+
+import (
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ServiceImportResource is the resource of the ServiceImports of the Multi-Cluster Services API (KEP-1645).
+var ServiceImportResource = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+
+// ServiceImport types, as set in its spec.
+const (
+	ServiceImportClusterSetIP = "ClusterSetIP"
+	ServiceImportHeadless     = "Headless"
+)
+
+// ServiceImport is a stripped down ServiceImport with only the items we need for CoreDNS.
+type ServiceImport struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version    string
+	Name       string
+	Namespace  string
+	Index      string
+	ClusterIPs []string
+	Type       string
+	Ports      []api.ServicePort
+
+	*Empty
+}
+
+// ServiceImportKey returns a string using for the index.
+func ServiceImportKey(name, namespace string) string { return name + "." + namespace }
+
+// ToServiceImport converts an unstructured ServiceImport to a *ServiceImport.
+func ToServiceImport(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	s := &ServiceImport{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+		Index:     ServiceImportKey(u.GetName(), u.GetNamespace()),
+	}
+
+	s.Type, _, _ = unstructured.NestedString(u.Object, "spec", "type")
+	s.ClusterIPs, _, _ = unstructured.NestedStringSlice(u.Object, "spec", "ips")
+
+	ports, _, _ := unstructured.NestedSlice(u.Object, "spec", "ports")
+	for _, p := range ports {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		port := api.ServicePort{Protocol: api.ProtocolTCP}
+		port.Name, _, _ = unstructured.NestedString(m, "name")
+		if proto, ok, _ := unstructured.NestedString(m, "protocol"); ok {
+			port.Protocol = api.Protocol(proto)
+		}
+		if n, ok, _ := unstructured.NestedInt64(m, "port"); ok {
+			port.Port = int32(n)
+		}
+		s.Ports = append(s.Ports, port)
+	}
+	if len(s.Ports) == 0 {
+		// Add sentinel if there are no ports.
+		s.Ports = []api.ServicePort{{Port: -1}}
+	}
+
+	u.Object = nil
+
+	return s, nil
+}
+
+// Headless returns true if the service import is headless.
+func (s *ServiceImport) Headless() bool { return s.Type == ServiceImportHeadless }
+
+var _ runtime.Object = &ServiceImport{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (s *ServiceImport) DeepCopyObject() runtime.Object {
+	s1 := &ServiceImport{
+		Version:    s.Version,
+		Name:       s.Name,
+		Namespace:  s.Namespace,
+		Index:      s.Index,
+		Type:       s.Type,
+		ClusterIPs: make([]string, len(s.ClusterIPs)),
+		Ports:      make([]api.ServicePort, len(s.Ports)),
+	}
+	copy(s1.ClusterIPs, s.ClusterIPs)
+	copy(s1.Ports, s.Ports)
+	return s1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (s *ServiceImport) GetNamespace() string { return s.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (s *ServiceImport) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (s *ServiceImport) GetName() string { return s.Name }
+
+// SetName implements the metav1.Object interface.
+func (s *ServiceImport) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (s *ServiceImport) GetResourceVersion() string { return s.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (s *ServiceImport) SetResourceVersion(version string) {}
@@ -1,6 +1,8 @@
 package kubernetes
 
 import (
+	"strings"
+
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 
 	"github.com/miekg/dns"
@@ -14,6 +16,8 @@ type recordRequest struct {
 	// SRV record.
 	protocol string
 	endpoint string
+	// The cluster id of the endpoint, only used for multi-cluster services.
+	cluster string
 	// The servicename used in Kubernetes.
 	service string
 	// The namespace used in Kubernetes.
@@ -24,12 +28,14 @@ type recordRequest struct {
 
 // parseRequest parses the qname to find all the elements we need for querying k8s. Anything
 // that is not parsed will have the wildcard "*" value (except r.endpoint).
-// Potential underscores are stripped from _port and _protocol.
-func parseRequest(name, zone string) (r recordRequest, err error) {
-	// 3 Possible cases:
+// Potential underscores are stripped from _port and _protocol. If multicluster is true, zone is a multi-cluster
+// services zone and the endpoint may be qualified with the cluster id.
+func parseRequest(name, zone string, multicluster bool) (r recordRequest, err error) {
+	// 4 Possible cases:
 	// 1. _port._protocol.service.namespace.pod|svc.zone
 	// 2. (endpoint): endpoint.service.namespace.pod|svc.zone
 	// 3. (service): service.namespace.pod|svc.zone
+	// 4. (multi-cluster endpoint): endpoint.cluster.service.namespace.svc.zone
 
 	base, _ := dnsutil.TrimZone(name, zone)
 	// return NODATA for apex queries
@@ -68,7 +74,12 @@ func parseRequest(name, zone string) (r recordRequest, err error) {
 	switch last {
 	case 0: // endpoint only
 		r.endpoint = segs[last]
-	case 1: // service and port
+	case 1: // service and port, or endpoint and cluster
+		if multicluster && !strings.HasPrefix(segs[last], "_") && !strings.HasPrefix(segs[last-1], "_") {
+			r.cluster = segs[last]
+			r.endpoint = segs[last-1]
+			break
+		}
 		r.protocol = stripUnderscore(segs[last])
 		r.port = stripUnderscore(segs[last-1])
 
@@ -96,6 +107,9 @@ func (r recordRequest) String() string {
 	s := r.port
 	s += "." + r.protocol
 	s += "." + r.endpoint
+	if r.cluster != "" {
+		s += "." + r.cluster
+	}
 	s += "." + r.service
 	s += "." + r.namespace
 	s += "." + r.podOrSvc
@@ -31,7 +31,31 @@ func TestParseRequest(t *testing.T) {
 		m.SetQuestion(tc.query, dns.TypeA)
 		state := request.Request{Zone: zone, Req: m}
 
-		r, e := parseRequest(state.Name(), state.Zone)
+		r, e := parseRequest(state.Name(), state.Zone, false)
+		if e != nil {
+			t.Errorf("Test %d, expected no error, got '%v'.", i, e)
+		}
+		rs := r.String()
+		if rs != tc.expected {
+			t.Errorf("Test %d, expected (stringified) recordRequest: %s, got %s", i, tc.expected, rs)
+		}
+	}
+}
+
+func TestParseMultiClusterRequest(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string // output from r.String()
+	}{
+		// endpoint in a cluster
+		{"pod-0.cluster-a.webs.mynamespace.svc.clusterset.local.", "..pod-0.cluster-a.webs.mynamespace.svc"},
+		// valid SRV request
+		{"_http._tcp.webs.mynamespace.svc.clusterset.local.", "http.tcp..webs.mynamespace.svc"},
+		// service
+		{"webs.mynamespace.svc.clusterset.local.", "...webs.mynamespace.svc"},
+	}
+	for i, tc := range tests {
+		r, e := parseRequest(tc.query, "clusterset.local.", true)
 		if e != nil {
 			t.Errorf("Test %d, expected no error, got '%v'.", i, e)
 		}
@@ -53,7 +77,7 @@ func TestParseInvalidRequest(t *testing.T) {
 		m.SetQuestion(query, dns.TypeA)
 		state := request.Request{Zone: zone, Req: m}
 
-		if _, e := parseRequest(state.Name(), state.Zone); e == nil {
+		if _, e := parseRequest(state.Name(), state.Zone, false); e == nil {
 			t.Errorf("Test %d: expected error from %s, got none", i, query)
 		}
 	}
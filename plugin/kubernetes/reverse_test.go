@@ -55,6 +55,10 @@ func (APIConnReverseTest) SvcIndexReverse(ip string) []*object.Service {
 	return svcs
 }
 
+func (APIConnReverseTest) SvcImportIndex(string) []*object.ServiceImport    { return nil }
+func (APIConnReverseTest) McEpIndex(string) []*object.MultiClusterEndpoints { return nil }
+func (APIConnReverseTest) HostnameIndex(string) []*object.Hostnames         { return nil }
+
 func (APIConnReverseTest) EpIndexReverse(ip string) []*object.Endpoints {
 	ep1s1 := object.Endpoints{
 		Subsets: []object.EndpointSubset{
@@ -53,6 +53,22 @@ func setup(c *caddy.Controller) error {
 		return k
 	})
 
+	if k.topologyMode != "" {
+		// The cache would serve the answer ordered or filtered for one client to all other clients.
+		c.OnStartup(func() error {
+			ca, ok := dnsserver.GetConfig(c).Handler("cache").(successCacher)
+			if !ok {
+				return nil
+			}
+			for _, z := range k.Zones {
+				if ca.CachesSuccess(z) {
+					return plugin.Error(pluginName, fmt.Errorf("topology requires the cache plugin not to cache the answers for %s, use 'disable success %s'", z, z))
+				}
+			}
+			return nil
+		})
+	}
+
 	// get locally bound addresses
 	c.OnStartup(func() error {
 		k.localIPs = boundIPs(c)
@@ -62,6 +78,11 @@ func setup(c *caddy.Controller) error {
 	return nil
 }
 
+// successCacher is implemented by the cache plugin.
+type successCacher interface {
+	CachesSuccess(zone string) bool
+}
+
 func kubernetesParse(c *caddy.Controller) (*Kubernetes, error) {
 	var (
 		k8s *Kubernetes
@@ -215,6 +236,46 @@ func ParseStanza(c *caddy.Controller) (*Kubernetes, error) {
 					return nil, fmt.Errorf("unable to parse ignore value: '%v'", ignore)
 				}
 			}
+		case "terminating_endpoints":
+			args := c.RemainingArgs()
+			if len(args) != 1 {
+				return nil, c.ArgErr()
+			}
+			switch args[0] {
+			case terminatingNever:
+				k8s.opts.terminatingFallback = false
+			case terminatingFallback:
+				k8s.opts.terminatingFallback = true
+			default:
+				return nil, fmt.Errorf("wrong value for terminating_endpoints: %s, must be one of: %s, %s", args[0], terminatingNever, terminatingFallback)
+			}
+			continue
+		case "topology":
+			args := c.RemainingArgs()
+			switch len(args) {
+			case 0:
+				k8s.topologyMode = topologyPrefer
+			case 1:
+				if args[0] != topologyPrefer && args[0] != topologyFilter {
+					return nil, fmt.Errorf("wrong value for topology: %s, must be one of: %s, %s", args[0], topologyPrefer, topologyFilter)
+				}
+				k8s.topologyMode = args[0]
+			default:
+				return nil, c.ArgErr()
+			}
+			continue
+		case "multicluster":
+			args := c.RemainingArgs()
+			if len(args) == 0 {
+				return nil, c.ArgErr()
+			}
+			for _, z := range plugin.OriginsFromArgsOrServerBlock(args, nil) {
+				if plugin.Zones(k8s.Zones).Matches(z) != z {
+					return nil, fmt.Errorf("multicluster zone %q is not one of the zones of the plugin", z)
+				}
+				k8s.opts.multiclusterZones = append(k8s.opts.multiclusterZones, z)
+			}
+			continue
 		case "kubeconfig":
 			args := c.RemainingArgs()
 			if len(args) != 1 && len(args) != 2 {
@@ -234,6 +295,10 @@ func ParseStanza(c *caddy.Controller) (*Kubernetes, error) {
 		}
 	}
 
+	if k8s.topologyMode != "" && k8s.podMode != podModeVerified {
+		return nil, c.Errf("topology requires pods verified")
+	}
+
 	if len(k8s.Namespaces) != 0 && k8s.opts.namespaceLabelSelector != nil {
 		return nil, c.Errf("namespaces and namespace_labels cannot both be set")
 	}
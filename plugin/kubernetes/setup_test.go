@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -610,3 +611,192 @@ func TestKubernetesParseIgnoreEmptyService(t *testing.T) {
 		}
 	}
 }
+
+func TestKubernetesParseMultiCluster(t *testing.T) {
+	tests := []struct {
+		input              string // Corefile data as string
+		shouldErr          bool   // true if test case is expected to produce an error.
+		expectedErrContent string // substring from the expected error. Empty for positive cases.
+		expectedZones      []string
+	}{
+		// valid
+		{
+			`kubernetes cluster.local clusterset.local {
+	multicluster clusterset.local
+}`,
+			false,
+			"",
+			[]string{"clusterset.local."},
+		},
+		// invalid
+		{
+			`kubernetes cluster.local {
+	multicluster clusterset.local
+}`,
+			true,
+			"is not one of the zones",
+			nil,
+		},
+		{
+			`kubernetes cluster.local clusterset.local {
+	multicluster
+}`,
+			true,
+			"Wrong argument count",
+			nil,
+		},
+		// not set
+		{
+			`kubernetes cluster.local {
+}`,
+			false,
+			"",
+			nil,
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'. Error was: '%v'", i, test.input, err)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+				continue
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+
+		if fmt.Sprint(k8sController.opts.multiclusterZones) != fmt.Sprint(test.expectedZones) {
+			t.Errorf("Test %d: Expected multicluster zones %v, got %v", i, test.expectedZones, k8sController.opts.multiclusterZones)
+		}
+	}
+}
+
+func TestKubernetesParseTopology(t *testing.T) {
+	tests := []struct {
+		input              string // Corefile data as string
+		shouldErr          bool   // true if test case is expected to produce an error.
+		expectedErrContent string // substring from the expected error. Empty for positive cases.
+		expectedMode       string
+	}{
+		// valid
+		{
+			`kubernetes coredns.local {
+	pods verified
+	topology
+}`,
+			false,
+			"",
+			topologyPrefer,
+		},
+		{
+			`kubernetes coredns.local {
+	topology filter
+	pods verified
+}`,
+			false,
+			"",
+			topologyFilter,
+		},
+		// invalid
+		{
+			`kubernetes coredns.local {
+	topology
+}`,
+			true,
+			"topology requires pods verified",
+			"",
+		},
+		{
+			`kubernetes coredns.local {
+	pods verified
+	topology nearest
+}`,
+			true,
+			"wrong value for topology",
+			"",
+		},
+		// not set
+		{
+			`kubernetes coredns.local {
+}`,
+			false,
+			"",
+			"",
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'. Error was: '%v'", i, test.input, err)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+				continue
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+
+		if k8sController.topologyMode != test.expectedMode {
+			t.Errorf("Test %d: Expected topology mode %q, got %q", i, test.expectedMode, k8sController.topologyMode)
+		}
+	}
+}
+
+func TestKubernetesParseTerminatingEndpoints(t *testing.T) {
+	tests := []struct {
+		input            string // Corefile data as string
+		shouldErr        bool   // true if test case is expected to produce an error.
+		expectedFallback bool
+	}{
+		{`kubernetes coredns.local {
+	terminating_endpoints fallback
+}`, false, true},
+		{`kubernetes coredns.local {
+	terminating_endpoints never
+}`, false, false},
+		{`kubernetes coredns.local {
+	terminating_endpoints always
+}`, true, false},
+		{`kubernetes coredns.local {
+	terminating_endpoints
+}`, true, false},
+		{`kubernetes coredns.local`, false, false},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+		if k8sController.opts.terminatingFallback != test.expectedFallback {
+			t.Errorf("Test %d: Expected terminating fallback %t, got %t", i, test.expectedFallback, k8sController.opts.terminatingFallback)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// controllers holds the dnsControls that are shared by all plugin instances with the same connection settings.
+// A Corefile with several kubernetes stanzas, or server blocks, then only has a single set of informers, and a
+// reload keeps the informers (and their caches) of the previous instance when the settings didn't change.
+var controllers = &sharedControllers{m: make(map[string]*sharedController)}
+
+type sharedControllers struct {
+	sync.Mutex
+	m map[string]*sharedController
+}
+
+type sharedController struct {
+	ctrl    *dnsControl
+	refs    int
+	running bool
+}
+
+// acquire returns the controller for key, newCtrl is called to create it if there is none yet.
+func (s *sharedControllers) acquire(key string, newCtrl func() *dnsControl) *dnsControl {
+	s.Lock()
+	defer s.Unlock()
+
+	sc, ok := s.m[key]
+	if !ok {
+		sc = &sharedController{ctrl: newCtrl()}
+		s.m[key] = sc
+	}
+	sc.refs++
+	return sc.ctrl
+}
+
+// run starts the controller for key, unless it is already running.
+func (s *sharedControllers) run(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	sc, ok := s.m[key]
+	if !ok || sc.running {
+		return
+	}
+	sc.running = true
+	go sc.ctrl.Run()
+}
+
+// release drops a reference to the controller for key, the controller is stopped when the last one is dropped.
+func (s *sharedControllers) release(key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	sc, ok := s.m[key]
+	if !ok {
+		return nil
+	}
+	sc.refs--
+	if sc.refs > 0 {
+		return nil
+	}
+	delete(s.m, key)
+	return sc.ctrl.Stop()
+}
+
+// controllerKey returns the key under which the controller for config and opts is shared. Only the settings that
+// change what the controller watches, or who it watches as, are part of the key.
+func controllerKey(config *rest.Config, opts dnsControlOpts) string {
+	// The exec and auth providers and the impersonation settings are structs, and marshal to the same JSON when
+	// they are the same. Marshaling can't fail for these.
+	auth, _ := json.Marshal(struct {
+		Exec         *clientcmdapi.ExecConfig
+		AuthProvider *clientcmdapi.AuthProviderConfig
+		Impersonate  rest.ImpersonationConfig
+	}{config.ExecProvider, config.AuthProvider, config.Impersonate})
+
+	selector, namespaceSelector := "", ""
+	if opts.selector != nil {
+		selector = opts.selector.String()
+	}
+	if opts.namespaceSelector != nil {
+		namespaceSelector = opts.namespaceSelector.String()
+	}
+	parts := []string{
+		config.Host,
+		config.APIPath,
+		config.Username,
+		config.Password,
+		config.BearerToken,
+		config.BearerTokenFile,
+		string(auth),
+		config.TLSClientConfig.ServerName,
+		fmt.Sprintf("%t", config.TLSClientConfig.Insecure),
+		config.TLSClientConfig.CAFile,
+		config.TLSClientConfig.CertFile,
+		config.TLSClientConfig.KeyFile,
+		string(config.TLSClientConfig.CAData),
+		string(config.TLSClientConfig.CertData),
+		string(config.TLSClientConfig.KeyData),
+		selector,
+		namespaceSelector,
+		fmt.Sprintf("%t/%t/%t", opts.initPodCache, opts.initEndpointsCache, len(opts.multiclusterZones) > 0),
+	}
+	// Hash the parts, so the credentials aren't kept around in the key.
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,107 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestSharedControllers(t *testing.T) {
+	s := &sharedControllers{m: make(map[string]*sharedController)}
+	created := 0
+	newCtrl := func() *dnsControl {
+		created++
+		return newdnsController(context.TODO(), fake.NewSimpleClientset(), nil, dnsControlOpts{initEndpointsCache: true})
+	}
+
+	c1 := s.acquire("a", newCtrl)
+	c2 := s.acquire("a", newCtrl)
+	if c1 != c2 {
+		t.Fatal("Expected the controller to be shared")
+	}
+	if c3 := s.acquire("b", newCtrl); c3 == c1 {
+		t.Fatal("Expected a new controller for a different key")
+	}
+	if created != 2 {
+		t.Fatalf("Expected %d controllers to be created, got %d", 2, created)
+	}
+
+	s.run("a")
+	s.run("a") // no-op, it's already running
+	for !c1.HasSynced() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := s.release("a"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if c1.shutdown {
+		t.Fatal("Expected the controller to run while it is referenced")
+	}
+	// A reload acquires the controller before the previous instance releases it.
+	if c4 := s.acquire("a", newCtrl); c4 != c1 {
+		t.Fatal("Expected the running controller to be reused")
+	}
+	s.release("a")
+	s.release("a")
+	if !c1.shutdown {
+		t.Fatal("Expected the controller to be stopped after the last release")
+	}
+	if c5 := s.acquire("a", newCtrl); c5 == c1 {
+		t.Fatal("Expected a new controller after the last release")
+	}
+}
+
+func TestControllerKey(t *testing.T) {
+	config := &rest.Config{Host: "https://10.0.0.1:443", BearerTokenFile: "/var/run/secrets/token"}
+	opts := dnsControlOpts{initEndpointsCache: true}
+
+	key := controllerKey(config, opts)
+	if controllerKey(&rest.Config{Host: "https://10.0.0.1:443", BearerTokenFile: "/var/run/secrets/token"}, opts) != key {
+		t.Error("Expected identical settings to give the same key")
+	}
+	// Options that don't change the informers don't change the key.
+	if controllerKey(config, dnsControlOpts{initEndpointsCache: true, ignoreEmptyService: true, zones: []string{"example.org."}}) != key {
+		t.Error("Expected the key not to depend on ignoreEmptyService or zones")
+	}
+
+	others := []struct {
+		config *rest.Config
+		opts   dnsControlOpts
+	}{
+		{&rest.Config{Host: "https://10.0.0.2:443", BearerTokenFile: "/var/run/secrets/token"}, opts},
+		{config, dnsControlOpts{initEndpointsCache: true, initPodCache: true}},
+		{config, dnsControlOpts{initEndpointsCache: true, selector: labels.SelectorFromSet(labels.Set{"app": "a"})}},
+		{config, dnsControlOpts{initEndpointsCache: true, namespaceSelector: labels.SelectorFromSet(labels.Set{"team": "a"})}},
+		{config, dnsControlOpts{initEndpointsCache: true, multiclusterZones: []string{"clusterset.local."}}},
+		{&rest.Config{Host: "https://10.0.0.1:443", BearerTokenFile: "/var/run/secrets/token", Impersonate: rest.ImpersonationConfig{Groups: []string{"dns"}}}, opts},
+		{&rest.Config{Host: "https://10.0.0.1:443", BearerTokenFile: "/var/run/secrets/token", TLSClientConfig: rest.TLSClientConfig{Insecure: true}}, opts},
+	}
+	for i, o := range others {
+		if controllerKey(o.config, o.opts) == key {
+			t.Errorf("Test %d: expected a different key", i)
+		}
+	}
+}
+
+func TestControllerKeyExecProvider(t *testing.T) {
+	exec := func(args ...string) *rest.Config {
+		return &rest.Config{
+			Host:         "https://10.0.0.1:443",
+			ExecProvider: &clientcmdapi.ExecConfig{Command: "aws", Args: args, APIVersion: "client.authentication.k8s.io/v1"},
+		}
+	}
+	opts := dnsControlOpts{initEndpointsCache: true}
+
+	if controllerKey(exec("eks", "get-token", "--cluster-name", "a"), opts) != controllerKey(exec("eks", "get-token", "--cluster-name", "a"), opts) {
+		t.Error("Expected the same exec provider to give the same key")
+	}
+	if controllerKey(exec("eks", "get-token", "--cluster-name", "a"), opts) == controllerKey(exec("eks", "get-token", "--cluster-name", "b"), opts) {
+		t.Error("Expected configs that only differ by exec provider not to share a controller")
+	}
+}
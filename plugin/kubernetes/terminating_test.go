@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEndpointSliceTerminating(t *testing.T) {
+	yes, no := true, false
+	slice := &discovery.EndpointSlice{
+		ObjectMeta: meta.ObjectMeta{Name: "drain-1", Namespace: "testns", Labels: map[string]string{discovery.LabelServiceName: "drain"}},
+		Endpoints: []discovery.Endpoint{
+			{Addresses: []string{"172.0.1.1"}, Conditions: discovery.EndpointConditions{Ready: &yes}},
+			{Addresses: []string{"172.0.1.2"}, Conditions: discovery.EndpointConditions{Ready: &no, Serving: &yes, Terminating: &yes}},
+			{Addresses: []string{"172.0.1.3"}, Conditions: discovery.EndpointConditions{Ready: &no, Serving: &no, Terminating: &yes}},
+			{Addresses: []string{"172.0.1.4"}, Conditions: discovery.EndpointConditions{Ready: &no}},
+		},
+	}
+	o, err := object.EndpointSliceToEndpoints(slice)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	e := o.(*object.Endpoints)
+	if x := e.Subsets[0].Addresses; len(x) != 1 || x[0].IP != "172.0.1.1" {
+		t.Errorf("Expected only %s to be ready, got %v", "172.0.1.1", x)
+	}
+	if x := e.Subsets[0].TerminatingAddresses; len(x) != 1 || x[0].IP != "172.0.1.2" {
+		t.Errorf("Expected only %s to be terminating, got %v", "172.0.1.2", x)
+	}
+	if strings.Join(e.IndexIP, ",") != "172.0.1.1" {
+		t.Errorf("Expected only ready addresses to be indexed, got %v", e.IndexIP)
+	}
+}
+
+func TestTerminatingFallback(t *testing.T) {
+	tests := []struct {
+		qname    string
+		fallback bool
+		expected []string
+	}{
+		{"drain.testns.svc.cluster.local.", false, nil},
+		{"drain.testns.svc.cluster.local.", true, []string{"172.0.1.2"}},
+		{"mixed.testns.svc.cluster.local.", true, []string{"172.0.2.1"}},
+		{"pod-1.mixed.testns.svc.cluster.local.", false, nil},
+		{"pod-1.mixed.testns.svc.cluster.local.", true, []string{"172.0.2.2"}},
+	}
+
+	for i, tc := range tests {
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnTerminatingTest{}
+		k.opts.terminatingFallback = tc.fallback
+
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, dns.TypeA)
+		state := request.Request{Zone: "cluster.local.", Req: m, W: &test.ResponseWriter{}}
+
+		services, _ := k.Records(context.TODO(), state, false)
+		var hosts []string
+		for _, s := range services {
+			hosts = append(hosts, s.Host)
+		}
+		if strings.Join(hosts, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("Test %d: expected %v for %s, got %v", i, tc.expected, tc.qname, hosts)
+		}
+	}
+}
+
+func TestMetadataTerminating(t *testing.T) {
+	k := New([]string{"cluster.local."})
+	k.APIConn = &APIConnTerminatingTest{}
+
+	ctx := metadata.ContextWithMetadata(context.Background())
+	state := request.Request{
+		Req:  &dns.Msg{Question: []dns.Question{{Name: "drain.testns.svc.cluster.local.", Qtype: dns.TypeA}}},
+		Zone: ".",
+		W:    &test.ResponseWriter{},
+	}
+	k.Metadata(ctx, state)
+
+	expect := map[string]string{
+		"kubernetes/endpoint-ready":       "false",
+		"kubernetes/endpoint-serving":     "true",
+		"kubernetes/endpoint-terminating": "true",
+	}
+	for l, v := range expect {
+		if got := metadata.ValueFunc(ctx, l)(); got != v {
+			t.Errorf("Expected %q for %s, got %q", v, l, got)
+		}
+	}
+}
+
+type APIConnTerminatingTest struct {
+	APIConnServeTest
+}
+
+func (APIConnTerminatingTest) SvcIndex(s string) []*object.Service {
+	name, ns, _ := strings.Cut(s, ".")
+	return []*object.Service{{Name: name, Namespace: ns, Type: api.ServiceTypeClusterIP, ClusterIPs: []string{api.ClusterIPNone}}}
+}
+
+func (APIConnTerminatingTest) EpIndex(s string) []*object.Endpoints {
+	eps := map[string][]*object.Endpoints{
+		"drain.testns": {{
+			Subsets: []object.EndpointSubset{{
+				TerminatingAddresses: []object.EndpointAddress{{IP: "172.0.1.2", Hostname: "pod-0"}},
+				Ports:                []object.EndpointPort{{Port: 80, Protocol: "tcp", Name: "http"}},
+			}},
+			Name: "drain-1", Namespace: "testns", Index: object.EndpointsKey("drain", "testns"),
+		}},
+		"mixed.testns": {{
+			Subsets: []object.EndpointSubset{{
+				Addresses:            []object.EndpointAddress{{IP: "172.0.2.1", Hostname: "pod-0"}},
+				TerminatingAddresses: []object.EndpointAddress{{IP: "172.0.2.2", Hostname: "pod-1"}},
+				Ports:                []object.EndpointPort{{Port: 80, Protocol: "tcp", Name: "http"}},
+			}},
+			Name: "mixed-1", Namespace: "testns", Index: object.EndpointsKey("mixed", "testns"),
+		}},
+	}
+	return eps[s]
+}
@@ -0,0 +1,161 @@
+package kubernetes
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/singleflight"
+	"github.com/coredns/coredns/request"
+
+	api "k8s.io/api/core/v1"
+)
+
+const (
+	// topologyPrefer orders the endpoints so the ones closest to the client come first.
+	topologyPrefer = "prefer"
+	// topologyFilter only returns the endpoints closest to the client.
+	topologyFilter = "filter"
+
+	// nodeZoneTTL is how long we cache the topology zone of a node.
+	nodeZoneTTL = 5 * time.Minute
+	// nodeZoneTimeout is the timeout of a background refresh of the topology zone of a node.
+	nodeZoneTimeout = 5 * time.Second
+)
+
+// Ranks of an endpoint address as seen from the client, lower is closer.
+const (
+	rankSameNode = iota
+	rankSameZone
+	rankOther
+)
+
+// clientTopology is the location of the pod that sent a query.
+type clientTopology struct {
+	node string
+	zone string
+}
+
+// rank returns how close addr is to t. An address with topology hints is in the same zone if the hints contain
+// the zone of the client, the zone of the address itself is then ignored.
+func (t *clientTopology) rank(addr object.EndpointAddress) int {
+	if t.node != "" && addr.NodeName == t.node {
+		return rankSameNode
+	}
+	if t.zone == "" {
+		return rankOther
+	}
+	if len(addr.ForZones) > 0 {
+		for _, z := range addr.ForZones {
+			if z == t.zone {
+				return rankSameZone
+			}
+		}
+		return rankOther
+	}
+	if addr.Zone == t.zone {
+		return rankSameZone
+	}
+	return rankOther
+}
+
+// nodeZones caches the topology zones of the nodes, so we don't need to ask the API for every query.
+type nodeZones struct {
+	sync.Mutex
+	m map[string]nodeZone
+
+	inflight singleflight.Group // deduplicates the API lookups of a node
+}
+
+type nodeZone struct {
+	zone       string
+	expires    time.Time
+	refreshing bool // a background refresh has been started
+}
+
+// clientTopology returns the topology of the client pod of state, or nil if topology aware answers are not
+// enabled or the client is not a known pod.
+func (k *Kubernetes) clientTopology(ctx context.Context, state request.Request) *clientTopology {
+	if k.topologyMode == "" {
+		return nil
+	}
+	ip := state.IP()
+	for _, p := range k.APIConn.PodIndex(ip) {
+		if p.PodIP != ip || p.NodeName == "" {
+			continue
+		}
+		return &clientTopology{node: p.NodeName, zone: k.nodeZone(ctx, p.NodeName)}
+	}
+	return nil
+}
+
+// nodeZone returns the topology zone of the node named name, or the empty string if it can't be found. Only the
+// first query from a node waits for the API, an expired zone is returned while it is refreshed in the background.
+// Only one refresh of a node is started at a time.
+func (k *Kubernetes) nodeZone(ctx context.Context, name string) string {
+	k.nodeZones.Lock()
+	nz, ok := k.nodeZones.m[name]
+	refresh := ok && !nz.refreshing && time.Now().After(nz.expires)
+	if refresh {
+		nz.refreshing = true
+		k.nodeZones.m[name] = nz
+	}
+	k.nodeZones.Unlock()
+
+	if !ok {
+		return k.lookupNodeZone(ctx, name)
+	}
+	if refresh {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), nodeZoneTimeout)
+			defer cancel()
+			k.lookupNodeZone(ctx, name)
+		}()
+	}
+	return nz.zone
+}
+
+// lookupNodeZone gets the topology zone of the node named name from the API and caches it. Concurrent lookups of
+// the same node share a single API call.
+func (k *Kubernetes) lookupNodeZone(ctx context.Context, name string) string {
+	h := fnv.New64()
+	h.Write([]byte(name))
+	zone, _ := k.nodeZones.inflight.Do(h.Sum64(), func() (interface{}, error) {
+		nz := nodeZone{expires: time.Now().Add(nodeZoneTTL)}
+		node, err := k.APIConn.GetNodeByName(ctx, name)
+		if err != nil {
+			log.Warningf("Failed to get the topology zone of node %q: %s", name, err)
+		} else {
+			nz.zone = node.Labels[api.LabelTopologyZone]
+		}
+
+		k.nodeZones.Lock()
+		k.nodeZones.m[name] = nz
+		k.nodeZones.Unlock()
+		return nz.zone, nil
+	})
+	return zone.(string)
+}
+
+// byTopology orders services by their ranks, the services of the closest endpoints come first. With the filter
+// topology mode only those closest services are returned. The SRV priority of a service is set to its rank.
+func (k *Kubernetes) byTopology(services []msg.Service, ranks []int) []msg.Service {
+	for i := range services {
+		services[i].Priority = ranks[i]
+	}
+	sort.SliceStable(services, func(i, j int) bool { return services[i].Priority < services[j].Priority })
+	if k.topologyMode != topologyFilter || len(services) == 0 {
+		return services
+	}
+	closest := services[0].Priority
+	for i := range services {
+		if services[i].Priority != closest {
+			return services[:i]
+		}
+	}
+	return services
+}
@@ -0,0 +1,215 @@
+package kubernetes
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClientTopologyRank(t *testing.T) {
+	ct := &clientTopology{node: "node-a", zone: "zone-1"}
+	tests := []struct {
+		addr     object.EndpointAddress
+		expected int
+	}{
+		{object.EndpointAddress{NodeName: "node-a", Zone: "zone-2"}, rankSameNode},
+		{object.EndpointAddress{NodeName: "node-b", Zone: "zone-1"}, rankSameZone},
+		{object.EndpointAddress{NodeName: "node-b", Zone: "zone-2"}, rankOther},
+		{object.EndpointAddress{NodeName: "node-b", Zone: "zone-2", ForZones: []string{"zone-1"}}, rankSameZone},
+		{object.EndpointAddress{NodeName: "node-b", Zone: "zone-1", ForZones: []string{"zone-2"}}, rankOther},
+		{object.EndpointAddress{}, rankOther},
+	}
+	for i, tc := range tests {
+		if r := ct.rank(tc.addr); r != tc.expected {
+			t.Errorf("Test %d: expected rank %d, got %d", i, tc.expected, r)
+		}
+	}
+}
+
+func TestTopologyRecords(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected []string
+	}{
+		{topologyPrefer, []string{"172.0.0.3", "172.0.0.4", "172.0.0.5", "172.0.0.2"}},
+		{topologyFilter, []string{"172.0.0.3"}},
+	}
+
+	for i, tc := range tests {
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnTopologyTest{}
+		k.Namespaces = map[string]struct{}{"testns": {}}
+		k.podMode = podModeVerified
+		k.topologyMode = tc.mode
+
+		m := new(dns.Msg)
+		m.SetQuestion("hdls1.testns.svc.cluster.local.", dns.TypeA)
+		state := request.Request{Zone: "cluster.local.", Req: m, W: &test.ResponseWriter{}}
+
+		services, err := k.Records(context.TODO(), state, false)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		if len(services) != len(tc.expected) {
+			t.Fatalf("Test %d: expected %d services, got %d: %v", i, len(tc.expected), len(services), services)
+		}
+		for j, s := range services {
+			if s.Host != tc.expected[j] {
+				t.Errorf("Test %d: expected %s at position %d, got %s", i, tc.expected[j], j, s.Host)
+			}
+		}
+	}
+}
+
+type APIConnTopologyTest struct {
+	APIConnServeTest
+}
+
+func (APIConnTopologyTest) PodIndex(ip string) []*object.Pod {
+	if ip != "10.240.0.1" { // Remote IP set in test.ResponseWriter
+		return nil
+	}
+	return []*object.Pod{{Namespace: "podns", Name: "client", PodIP: ip, NodeName: "node-a"}}
+}
+
+func (APIConnTopologyTest) GetNodeByName(ctx context.Context, name string) (*api.Node, error) {
+	return &api.Node{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{api.LabelTopologyZone: "zone-1"},
+		},
+	}, nil
+}
+
+func (APIConnTopologyTest) EpIndex(s string) []*object.Endpoints {
+	if s != "hdls1.testns" {
+		return nil
+	}
+	return []*object.Endpoints{{
+		Subsets: []object.EndpointSubset{
+			{
+				Addresses: []object.EndpointAddress{
+					{IP: "172.0.0.2", NodeName: "node-c", Zone: "zone-2"},
+					{IP: "172.0.0.3", NodeName: "node-a", Zone: "zone-1"},
+					{IP: "172.0.0.4", NodeName: "node-b", Zone: "zone-1"},
+					{IP: "172.0.0.5", NodeName: "node-c", Zone: "zone-2", ForZones: []string{"zone-1"}},
+				},
+				Ports: []object.EndpointPort{
+					{Port: 80, Protocol: "tcp", Name: "http"},
+				},
+			},
+		},
+		Name:      "hdls1-slice1",
+		Namespace: "testns",
+		Index:     object.EndpointsKey("hdls1", "testns"),
+	}}
+}
+
+func TestNodeZone(t *testing.T) {
+	conn := &APIConnNodeZoneTest{release: make(chan struct{})}
+	k := New([]string{"cluster.local."})
+	k.APIConn = conn
+
+	// A slow lookup of one node doesn't block the lookups of other nodes.
+	done := make(chan string)
+	for i := 0; i < 3; i++ {
+		go func() { done <- k.nodeZone(context.TODO(), "slow") }()
+	}
+	if z := k.nodeZone(context.TODO(), "node-a"); z != "zone-1" {
+		t.Errorf("Expected zone-1 for node-a, got %q", z)
+	}
+	close(conn.release)
+	for i := 0; i < 3; i++ {
+		if z := <-done; z != "zone-1" {
+			t.Errorf("Expected zone-1 for the slow node, got %q", z)
+		}
+	}
+	if n := atomic.LoadInt32(&conn.calls); n > 3 {
+		t.Errorf("Expected concurrent lookups to share API calls, got %d calls", n)
+	}
+
+	// An expired zone is returned while it is refreshed.
+	k.nodeZones.Lock()
+	k.nodeZones.m["node-a"] = nodeZone{zone: "zone-old", expires: time.Now().Add(-time.Second)}
+	k.nodeZones.Unlock()
+	if z := k.nodeZone(context.TODO(), "node-a"); z != "zone-old" {
+		t.Errorf("Expected the expired zone-old for node-a, got %q", z)
+	}
+	for i := 0; i < 100; i++ {
+		k.nodeZones.Lock()
+		z := k.nodeZones.m["node-a"].zone
+		k.nodeZones.Unlock()
+		if z == "zone-1" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected the zone of node-a to be refreshed")
+}
+
+func TestNodeZoneSingleRefresh(t *testing.T) {
+	conn := &APIConnNodeZoneTest{release: make(chan struct{})}
+	k := New([]string{"cluster.local."})
+	k.APIConn = conn
+
+	k.nodeZones.Lock()
+	k.nodeZones.m["slow"] = nodeZone{zone: "zone-old", expires: time.Now().Add(-time.Second)}
+	k.nodeZones.Unlock()
+	for i := 0; i < 10; i++ {
+		if z := k.nodeZone(context.TODO(), "slow"); z != "zone-old" {
+			t.Errorf("Expected the expired zone-old for the slow node, got %q", z)
+		}
+	}
+	k.nodeZones.Lock()
+	refreshing := k.nodeZones.m["slow"].refreshing
+	k.nodeZones.Unlock()
+	if !refreshing {
+		t.Error("Expected a refresh of the slow node to be in flight")
+	}
+
+	close(conn.release)
+	for i := 0; i < 100; i++ {
+		k.nodeZones.Lock()
+		nz := k.nodeZones.m["slow"]
+		k.nodeZones.Unlock()
+		if nz.zone == "zone-1" {
+			if nz.refreshing {
+				t.Error("Expected the refreshed zone not to be marked as refreshing")
+			}
+			if n := atomic.LoadInt32(&conn.calls); n != 1 {
+				t.Errorf("Expected a single refresh, got %d calls", n)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected the zone of the slow node to be refreshed")
+}
+
+type APIConnNodeZoneTest struct {
+	APIConnServeTest
+	calls   int32
+	release chan struct{}
+}
+
+func (a *APIConnNodeZoneTest) GetNodeByName(ctx context.Context, name string) (*api.Node, error) {
+	atomic.AddInt32(&a.calls, 1)
+	if name == "slow" {
+		<-a.release
+	}
+	return &api.Node{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{api.LabelTopologyZone: "zone-1"},
+		},
+	}, nil
+}
@@ -19,7 +19,7 @@ import (
 // Transfer implements the transfer.Transfer interface.
 func (k *Kubernetes) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
 	match := plugin.Zones(k.Zones).Matches(zone)
-	if match == "" {
+	if match == "" || k.isMultiClusterZone(zone) {
 		return nil, transfer.ErrNotAuthoritative
 	}
 	// state is not used here, hence the empty request.Request{]
@@ -0,0 +1,136 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+)
+
+// rotateLayout is the time format appended to the name of rotated files.
+const rotateLayout = "2006-01-02T15-04-05.000"
+
+// rotateFile is an output that appends entries to a file. The file is rotated when it grows
+// beyond maxSize and rotated files older than maxAge are removed.
+type rotateFile struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	json    bool // entries are JSON objects and written without a timestamp
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func newRotateFile(path string, maxSize int64, maxAge time.Duration, json bool) *rotateFile {
+	return &rotateFile{path: path, maxSize: maxSize, maxAge: maxAge, json: json}
+}
+
+// open opens the file for appending and removes expired rotated files.
+func (r *rotateFile) open() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.openFile(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+func (r *rotateFile) openFile() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+// close closes the file.
+func (r *rotateFile) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+func (r *rotateFile) write(entry string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return
+	}
+
+	line := entry + "\n"
+	if !r.json {
+		line = time.Now().UTC().Format(time.RFC3339Nano) + " " + line
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			clog.Warningf("Failed to rotate log file %q: %s", r.path, err)
+			if r.f == nil {
+				return
+			}
+		}
+	}
+
+	n, err := r.f.WriteString(line)
+	r.size += int64(n)
+	if err != nil {
+		clog.Warningf("Failed to write to log file %q: %s", r.path, err)
+	}
+}
+
+// rotate renames the current file by appending the current time and opens a new one.
+func (r *rotateFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+	rotated := r.path + "." + time.Now().UTC().Format(rotateLayout)
+	if err := os.Rename(r.path, rotated); err != nil {
+		// Keep appending to the existing file.
+		if err1 := r.openFile(); err1 != nil {
+			return err1
+		}
+		return err
+	}
+	if err := r.openFile(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// prune removes rotated files older than maxAge.
+func (r *rotateFile) prune() {
+	if r.maxAge <= 0 {
+		return
+	}
+	matches, _ := filepath.Glob(r.path + ".*")
+	for _, m := range matches {
+		t, err := time.Parse(rotateLayout, strings.TrimPrefix(m, r.path+"."))
+		if err != nil {
+			continue
+		}
+		if time.Since(t) > r.maxAge {
+			if err := os.Remove(m); err != nil {
+				clog.Warningf("Failed to remove rotated log file %q: %s", m, err)
+			}
+		}
+	}
+}
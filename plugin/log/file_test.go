@@ -0,0 +1,77 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.log")
+
+	// An expired and a recent rotated file, only the first must be removed.
+	expired := path + "." + time.Now().Add(-48*time.Hour).UTC().Format(rotateLayout)
+	recent := path + "." + time.Now().Add(-time.Hour).UTC().Format(rotateLayout)
+	for _, p := range []string{expired, recent} {
+		if err := os.WriteFile(p, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := newRotateFile(path, 100, 24*time.Hour, false)
+	if err := f.open(); err != nil {
+		t.Fatalf("Failed to open log file: %s", err)
+	}
+	defer f.close()
+
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", expired)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("Expected %s to be kept: %s", recent, err)
+	}
+
+	// A format may start with a placeholder, that doesn't make it JSON.
+	f.write("{x} example.org.")
+	f.write("A IN example.org.")
+	buf, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf)
+	}
+	if !strings.HasSuffix(lines[0], "Z {x} example.org.") {
+		t.Errorf("Expected timestamped entry, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "Z A IN example.org.") {
+		t.Errorf("Expected timestamped entry, got %q", lines[1])
+	}
+
+	// Exceeding the maximum size rotates the file.
+	f.write(strings.Repeat("x", 50))
+	buf, _ = os.ReadFile(path)
+	if !strings.Contains(string(buf), "xxx") || strings.Contains(string(buf), "example.org.") {
+		t.Errorf("Expected log file to be rotated, got %q", buf)
+	}
+	matches, _ := filepath.Glob(path + ".*")
+	if len(matches) != 2 {
+		t.Errorf("Expected 2 rotated files, got %v", matches)
+	}
+}
+
+func TestRotateFileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	f := newRotateFile(path, 0, 0, true)
+	if err := f.open(); err != nil {
+		t.Fatalf("Failed to open log file: %s", err)
+	}
+	defer f.close()
+
+	f.write(`{"name":"example.org."}`)
+	buf, _ := os.ReadFile(path)
+	if string(buf) != `{"name":"example.org."}`+"\n" {
+		t.Errorf("Expected JSON entry to be written as is, got %q", buf)
+	}
+}
@@ -3,9 +3,12 @@ package log
 
 import (
 	"context"
+	"encoding/json"
+	"math/rand"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metadata"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 	"github.com/coredns/coredns/plugin/pkg/replacer"
@@ -37,16 +40,15 @@ func (l Logger) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 
 		// If we don't set up a class in config, the default "all" will be added
 		// and we shouldn't have an empty rule.Class.
-		_, ok := rule.Class[response.All]
-		var ok1 bool
-		if !ok {
+		_, all := rule.Class[response.All]
+		class := response.All
+		if !all || rule.Sample != nil {
 			tpe, _ := response.Typify(rrw.Msg, time.Now().UTC())
-			class := response.Classify(tpe)
-			_, ok1 = rule.Class[class]
+			class = response.Classify(tpe)
 		}
-		if ok || ok1 {
-			logstr := l.repl.Replace(ctx, state, rrw, rule.Format)
-			clog.Info(logstr)
+		_, ok := rule.Class[class]
+		if (all || ok) && rule.sampled(class) {
+			rule.log(ctx, l.repl, state, rrw)
 		}
 
 		return rc, err
@@ -62,6 +64,65 @@ type Rule struct {
 	NameScope string
 	Class     map[response.Class]struct{}
 	Format    string
+	// JSON logs all replacer fields and metadata as a JSON object instead of Format.
+	JSON bool
+	// Sample holds the fraction of responses logged per class, response.All applies to
+	// classes without their own rate. A nil map logs everything.
+	Sample map[response.Class]float64
+
+	out output // nil logs to standard output
+}
+
+// sampled reports whether a response of class should be logged.
+func (r Rule) sampled(class response.Class) bool {
+	if r.Sample == nil {
+		return true
+	}
+	rate, ok := r.Sample[class]
+	if !ok {
+		if rate, ok = r.Sample[response.All]; !ok {
+			return true
+		}
+	}
+	return rand.Float64() < rate
+}
+
+// log writes the entry for the recorded response to the rule's output.
+func (r Rule) log(ctx context.Context, repl replacer.Replacer, state request.Request, rr *dnstest.Recorder) {
+	var entry string
+	if r.JSON {
+		entry = jsonEntry(ctx, repl, state, rr)
+	} else {
+		entry = repl.Replace(ctx, state, rr, r.Format)
+	}
+	if r.out == nil {
+		clog.Info(entry)
+		return
+	}
+	r.out.write(entry)
+}
+
+// jsonEntry returns all replacer fields, the time and any metadata as a JSON object.
+func jsonEntry(ctx context.Context, repl replacer.Replacer, state request.Request, rr *dnstest.Recorder) string {
+	entry := make(map[string]interface{})
+	for k, v := range repl.Fields(state, rr) {
+		entry[k] = v
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if funcs := metadata.ValueFuncs(ctx); len(funcs) > 0 {
+		md := make(map[string]string, len(funcs))
+		for label, f := range funcs {
+			md[label] = f()
+		}
+		entry["metadata"] = md
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
 }
 
 const (
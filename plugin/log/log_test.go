@@ -3,15 +3,20 @@ package log
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"log"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/coredns/coredns/plugin/metadata"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 	"github.com/coredns/coredns/plugin/pkg/replacer"
 	"github.com/coredns/coredns/plugin/pkg/response"
+	"github.com/coredns/coredns/plugin/pkg/syslog"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
@@ -278,3 +283,139 @@ func BenchmarkLogged(b *testing.B) {
 		logger.ServeDNS(ctx, rec, r)
 	}
 }
+
+func TestLoggedJSON(t *testing.T) {
+	rule := Rule{
+		NameScope: ".",
+		Class:     map[response.Class]struct{}{response.All: {}},
+		JSON:      true,
+	}
+
+	var f bytes.Buffer
+	log.SetOutput(&f)
+
+	logger := Logger{
+		Rules: []Rule{rule},
+		Next:  rcodeHandler(dns.RcodeNameError),
+		repl:  replacer.New(),
+	}
+
+	ctx := metadata.ContextWithMetadata(context.TODO())
+	metadata.SetValueFunc(ctx, "test/label", func() string { return "value" })
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	logger.ServeDNS(ctx, rec, r)
+
+	logged := strings.TrimSpace(f.String())
+	logged = logged[strings.Index(logged, "{"):]
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(logged), &entry); err != nil {
+		t.Fatalf("Expected a JSON object, got %q: %s", logged, err)
+	}
+	for k, v := range map[string]string{"name": "example.org.", "type": "A", "rcode": "NXDOMAIN", "remote": "10.240.0.1"} {
+		if entry[k] != v {
+			t.Errorf("Expected %q to be %q, got %v", k, v, entry[k])
+		}
+	}
+	md, _ := entry["metadata"].(map[string]interface{})
+	if md["test/label"] != "value" {
+		t.Errorf("Expected metadata to be logged, got %v", entry["metadata"])
+	}
+}
+
+func TestLoggedSample(t *testing.T) {
+	rule := Rule{
+		NameScope: ".",
+		Format:    "{rcode}",
+		Class:     map[response.Class]struct{}{response.All: {}},
+		Sample:    map[response.Class]float64{response.All: 0, response.Denial: 1},
+	}
+
+	tests := []struct {
+		rcode     int
+		shouldLog bool
+	}{
+		{dns.RcodeSuccess, false},
+		{dns.RcodeServerFailure, false},
+		{dns.RcodeNameError, true},
+	}
+
+	for i, tc := range tests {
+		var f bytes.Buffer
+		log.SetOutput(&f)
+
+		logger := Logger{
+			Rules: []Rule{rule},
+			Next:  rcodeHandler(tc.rcode),
+			repl:  replacer.New(),
+		}
+
+		r := new(dns.Msg)
+		r.SetQuestion("example.org.", dns.TypeA)
+		logger.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), r)
+
+		if logged := f.Len() > 0; logged != tc.shouldLog {
+			t.Errorf("Test %d: expected logged to be %t, got %t: %q", i, tc.shouldLog, logged, f.String())
+		}
+	}
+}
+
+// rcodeHandler returns a handler that replies with rcode, and an answer for successful replies.
+func rcodeHandler(rcode int) test.Handler {
+	return test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetRcode(r, rcode)
+		switch rcode {
+		case dns.RcodeSuccess:
+			m.Answer = []dns.RR{test.A("example.org. 3600 IN A 127.0.0.1")}
+		case dns.RcodeNameError:
+			m.Ns = []dns.RR{test.SOA("org. 3600 IN SOA ns.org. hostmaster.org. 1 7200 3600 1209600 3600")}
+		}
+		w.WriteMsg(m)
+		return rcode, nil
+	})
+}
+
+func TestLoggedSyslog(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w, err := syslog.New("log", pc.LocalAddr().String(), syslog.DefaultFacility, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := syslogOutput{w: w}
+	out.open()
+	defer out.close()
+
+	logger := Logger{
+		Rules: []Rule{{
+			NameScope: ".",
+			Format:    DefaultLogFormat,
+			Class:     map[response.Class]struct{}{response.All: {}},
+			out:       out,
+		}},
+		Next: rcodeHandler(dns.RcodeSuccess),
+		repl: replacer.New(),
+	}
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+	logger.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), r)
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to receive syslog message: %s", err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<30>1 ") || !strings.Contains(msg, " log - 10.240.0.1:40212 - ") {
+		t.Errorf("Unexpected syslog message %q", msg)
+	}
+}
@@ -0,0 +1,21 @@
+package log
+
+import (
+	"github.com/coredns/coredns/plugin/pkg/syslog"
+)
+
+// output receives formatted log entries. It is opened on startup and closed on shutdown.
+type output interface {
+	write(entry string)
+	open() error
+	close() error
+}
+
+// syslogOutput sends log entries to a syslog collector with Info severity.
+type syslogOutput struct {
+	w *syslog.Writer
+}
+
+func (s syslogOutput) write(entry string) { s.w.Write(syslog.Info, entry) }
+func (s syslogOutput) open() error        { return s.w.Start() }
+func (s syslogOutput) close() error       { return s.w.Stop() }
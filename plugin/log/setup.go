@@ -1,25 +1,42 @@
 package log
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/replacer"
 	"github.com/coredns/coredns/plugin/pkg/response"
+	"github.com/coredns/coredns/plugin/pkg/syslog"
 
 	"github.com/miekg/dns"
 )
 
 func init() { plugin.Register("log", setup) }
 
+// defaultMaxSize is the size in bytes at which a log file is rotated.
+const defaultMaxSize = 100 * 1024 * 1024
+
 func setup(c *caddy.Controller) error {
 	rules, err := logParse(c)
 	if err != nil {
 		return plugin.Error("log", err)
 	}
 
+	outputs := make(map[output]struct{})
+	for _, r := range rules {
+		if r.out != nil {
+			outputs[r.out] = struct{}{}
+		}
+	}
+	for o := range outputs {
+		c.OnStartup(o.open)
+		c.OnShutdown(o.close)
+	}
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		return Logger{Next: next, Rules: rules, repl: replacer.New()}
 	})
@@ -68,8 +85,18 @@ func logParse(c *caddy.Controller) ([]Rule, error) {
 			}
 		}
 
-		// Class refinements in an extra block.
+		// Class refinements and output options in an extra block.
 		classes := make(map[response.Class]struct{})
+		var (
+			sample     map[response.Class]float64
+			jsonFormat bool
+			path       string
+			maxSize    int64 = defaultMaxSize
+			maxAge     time.Duration
+			rotateOpts bool
+			syslogArgs []string
+			tlsArgs    []string
+		)
 		for c.NextBlock() {
 			switch c.Val() {
 			// class followed by combinations of all, denial, error and success.
@@ -85,6 +112,73 @@ func logParse(c *caddy.Controller) ([]Rule, error) {
 					}
 					classes[cls] = struct{}{}
 				}
+			case "json":
+				if c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				jsonFormat = true
+			// sample followed by a rate and optionally the classes it applies to.
+			case "sample":
+				sampleArgs := c.RemainingArgs()
+				if len(sampleArgs) == 0 {
+					return nil, c.ArgErr()
+				}
+				rate, err := strconv.ParseFloat(sampleArgs[0], 64)
+				if err != nil || rate < 0 || rate > 1 {
+					return nil, c.Errf("invalid sample rate %q, must be between 0 and 1", sampleArgs[0])
+				}
+				if sample == nil {
+					sample = make(map[response.Class]float64)
+				}
+				if len(sampleArgs) == 1 {
+					sample[response.All] = rate
+				}
+				for _, c := range sampleArgs[1:] {
+					cls, err := response.ClassFromString(c)
+					if err != nil {
+						return nil, err
+					}
+					sample[cls] = rate
+				}
+			case "file":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				path = c.Val()
+				if c.NextArg() {
+					return nil, c.ArgErr()
+				}
+			case "max_size":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				mb, err := strconv.Atoi(c.Val())
+				if err != nil || mb <= 0 {
+					return nil, c.Errf("invalid max_size %q, must be a positive number of megabytes", c.Val())
+				}
+				maxSize = int64(mb) * 1024 * 1024
+				rotateOpts = true
+			case "max_age":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil || d <= 0 {
+					return nil, c.Errf("invalid max_age %q", c.Val())
+				}
+				maxAge = d
+				rotateOpts = true
+			case "syslog":
+				syslogArgs = c.RemainingArgs()
+				if len(syslogArgs) == 0 || len(syslogArgs) > 2 {
+					return nil, c.ArgErr()
+				}
+			case "syslog_tls":
+				// A non-nil slice marks the option as present.
+				tlsArgs = append([]string{}, c.RemainingArgs()...)
+				if len(tlsArgs) > 3 {
+					return nil, c.ArgErr()
+				}
 			default:
 				return nil, c.ArgErr()
 			}
@@ -92,9 +186,32 @@ func logParse(c *caddy.Controller) ([]Rule, error) {
 		if len(classes) == 0 {
 			classes[response.All] = struct{}{}
 		}
+		if rotateOpts && path == "" {
+			return nil, c.Err("max_size and max_age require a file")
+		}
+		if path != "" && syslogArgs != nil {
+			return nil, c.Err("file and syslog are mutually exclusive")
+		}
+		if tlsArgs != nil && syslogArgs == nil {
+			return nil, c.Err("syslog_tls requires syslog")
+		}
+		var out output
+		switch {
+		case path != "":
+			out = newRotateFile(path, maxSize, maxAge, jsonFormat)
+		case syslogArgs != nil:
+			w, err := syslog.NewFromArgs("log", syslogArgs, tlsArgs)
+			if err != nil {
+				return nil, c.Err(err.Error())
+			}
+			out = syslogOutput{w: w}
+		}
 
 		for i := len(rules) - 1; i >= length; i-- {
 			rules[i].Class = classes
+			rules[i].JSON = jsonFormat
+			rules[i].Sample = sample
+			rules[i].out = out
 		}
 	}
 
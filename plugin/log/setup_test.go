@@ -3,6 +3,7 @@ package log
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/plugin/pkg/response"
@@ -129,6 +130,57 @@ func TestLogParse(t *testing.T) {
 		{`log {
 			unknown
 		}`, true, []Rule{}},
+		{`log {
+			json
+			sample 0.1
+			sample 1 error denial
+		}`, false, []Rule{{
+			NameScope: ".",
+			Format:    CommonLogFormat,
+			Class:     map[response.Class]struct{}{response.All: {}},
+			JSON:      true,
+			Sample:    map[response.Class]float64{response.All: 0.1, response.Error: 1, response.Denial: 1},
+		}}},
+		{`log {
+			json yes
+		}`, true, []Rule{}},
+		{`log {
+			sample
+		}`, true, []Rule{}},
+		{`log {
+			sample 1.5
+		}`, true, []Rule{}},
+		{`log {
+			sample 0.5 abracadabra
+		}`, true, []Rule{}},
+		{`log {
+			max_size 10
+		}`, true, []Rule{}},
+		{`log {
+			file /tmp/query.log
+			max_size -1
+		}`, true, []Rule{}},
+		{`log {
+			file /tmp/query.log
+			max_age never
+		}`, true, []Rule{}},
+		{`log {
+			syslog
+		}`, true, []Rule{}},
+		{`log {
+			syslog tcp://10.0.0.1 nofacility
+		}`, true, []Rule{}},
+		{`log {
+			syslog 10.0.0.1
+			file /tmp/query.log
+		}`, true, []Rule{}},
+		{`log {
+			syslog_tls
+		}`, true, []Rule{}},
+		{`log {
+			syslog tcp://10.0.0.1
+			syslog_tls
+		}`, true, []Rule{}},
 		{`log example.org "{combined} {/forward/upstream}"`, false, []Rule{{
 			NameScope: "example.org.",
 			Format:    CombinedLogFormat + " {/forward/upstream}",
@@ -182,3 +234,47 @@ func TestLogParse(t *testing.T) {
 		}
 	}
 }
+
+func TestLogParseFile(t *testing.T) {
+	c := caddy.NewTestController("dns", `log example.org example.net {
+		file /var/log/coredns/query.log
+		max_size 10
+		max_age 24h
+	}`)
+	rules, err := logParse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(rules))
+	}
+	f, ok := rules[0].out.(*rotateFile)
+	if !ok {
+		t.Fatalf("Expected rule to log to a file, got %T", rules[0].out)
+	}
+	if rules[1].out != f {
+		t.Errorf("Expected rules to share the file output")
+	}
+	if f.path != "/var/log/coredns/query.log" || f.maxSize != 10*1024*1024 || f.maxAge != 24*time.Hour {
+		t.Errorf("Unexpected file output: %q %d %s", f.path, f.maxSize, f.maxAge)
+	}
+}
+
+func TestLogParseSyslog(t *testing.T) {
+	c := caddy.NewTestController("dns", `log {
+		json
+		syslog tls://syslog.example.org local0
+		syslog_tls
+	}`)
+	rules, err := logParse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	s, ok := rules[0].out.(syslogOutput)
+	if !ok {
+		t.Fatalf("Expected rule to log to syslog, got %T", rules[0].out)
+	}
+	if s.w.String() != "tls://syslog.example.org:6514" {
+		t.Errorf("Expected syslog collector tls://syslog.example.org:6514, got %s", s.w)
+	}
+}
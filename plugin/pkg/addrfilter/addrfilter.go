@@ -0,0 +1,62 @@
+// Package addrfilter is used by plugins that select which addresses of the A and AAAA RRsets in a response are
+// returned, such as gslb and geosteer.
+package addrfilter
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Key identifies the RRset of a record.
+type Key struct {
+	name  string
+	rtype uint16
+}
+
+// KeyOf returns the key of the RRset of rr.
+func KeyOf(rr dns.RR) Key { return Key{strings.ToLower(rr.Header().Name), rr.Header().Rrtype} }
+
+// Address returns the address of an A or AAAA record, or nil for other records.
+func Address(rr dns.RR) net.IP {
+	switch x := rr.(type) {
+	case *dns.A:
+		return x.A
+	case *dns.AAAA:
+		return x.AAAA
+	}
+	return nil
+}
+
+// Filter returns the records of rrs, without the A and AAAA records keep returns false for. The other records
+// are always returned.
+func Filter(rrs []dns.RR, keep func(rr dns.RR) bool) []dns.RR {
+	filtered := make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		if Address(rr) == nil || keep(rr) {
+			filtered = append(filtered, rr)
+		}
+	}
+	return filtered
+}
+
+// ResponseWriter is a response writer that filters the answer of successful responses with Filter. Zone
+// transfers are written as is.
+type ResponseWriter struct {
+	dns.ResponseWriter
+	Filter func(answer []dns.RR) []dns.RR
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (r *ResponseWriter) WriteMsg(res *dns.Msg) error {
+	if res.Rcode != dns.RcodeSuccess || len(res.Question) == 0 {
+		return r.ResponseWriter.WriteMsg(res)
+	}
+	if res.Question[0].Qtype == dns.TypeAXFR || res.Question[0].Qtype == dns.TypeIXFR {
+		return r.ResponseWriter.WriteMsg(res)
+	}
+
+	res.Answer = r.Filter(res.Answer)
+	return r.ResponseWriter.WriteMsg(res)
+}
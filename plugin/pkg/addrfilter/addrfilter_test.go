@@ -0,0 +1,59 @@
+package addrfilter
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestFilter(t *testing.T) {
+	answer := []dns.RR{
+		test.CNAME("www.example.org. 300 IN CNAME a.example.org."),
+		test.A("a.example.org. 300 IN A 192.0.2.1"),
+		test.A("A.example.org. 300 IN A 192.0.2.2"),
+		test.AAAA("a.example.org. 300 IN AAAA 2001:db8::1"),
+	}
+	keys := make(map[Key]int)
+	rrs := Filter(answer, func(rr dns.RR) bool {
+		keys[KeyOf(rr)]++
+		return Address(rr).String() != "192.0.2.2"
+	})
+	if len(rrs) != 3 || rrs[0] != answer[0] || rrs[1] != answer[1] || rrs[2] != answer[3] {
+		t.Errorf("Expected the CNAME and all but the filtered address, got %v", rrs)
+	}
+	if len(keys) != 2 || keys[KeyOf(answer[1])] != 2 {
+		t.Errorf("Expected the A records to share an RRset, got %v", keys)
+	}
+}
+
+func TestResponseWriter(t *testing.T) {
+	tests := []struct {
+		qtype    uint16
+		rcode    int
+		expected int
+	}{
+		{dns.TypeA, dns.RcodeSuccess, 0},
+		{dns.TypeA, dns.RcodeServerFailure, 1},
+		{dns.TypeAXFR, dns.RcodeSuccess, 1},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("a.example.org.", tc.qtype)
+		m.Rcode = tc.rcode
+		m.Answer = []dns.RR{test.A("a.example.org. 300 IN A 192.0.2.1")}
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		w := &ResponseWriter{ResponseWriter: rec, Filter: func(answer []dns.RR) []dns.RR {
+			return Filter(answer, func(dns.RR) bool { return false })
+		}}
+		if err := w.WriteMsg(m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if len(rec.Msg.Answer) != tc.expected {
+			t.Errorf("Test %d: expected %d answers, got %d", i, tc.expected, len(rec.Msg.Answer))
+		}
+	}
+}
@@ -28,6 +28,20 @@ func (r Replacer) Replace(ctx context.Context, state request.Request, rr *dnstes
 	return loadFormat(s).Replace(ctx, state, rr)
 }
 
+// Fields returns the values of all supported labels, keyed by the label name without the
+// surrounding braces and header prefix, i.e. "{>rflags}" is returned as "rflags".
+func (r Replacer) Fields(state request.Request, rr *dnstest.Recorder) map[string]string {
+	fields := make(map[string]string, len(labels))
+	b := bufPool.Get().([]byte)
+	for label := range labels {
+		b = appendValue(b[:0], state, rr, label)
+		fields[strings.TrimPrefix(strings.Trim(label, "{}"), ">")] = string(b)
+	}
+	//nolint:staticcheck
+	bufPool.Put(b[:0])
+	return fields
+}
+
 const (
 	headerReplacer = "{>"
 	// EmptyValue is the default empty value.
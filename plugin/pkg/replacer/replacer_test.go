@@ -446,3 +446,29 @@ func TestNoResponseWasWritten(t *testing.T) {
 		}
 	}
 }
+
+func TestFields(t *testing.T) {
+	w := dnstest.NewRecorder(&test.ResponseWriter{})
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeHINFO)
+	r.Id = 1053
+	state := request.Request{W: w, Req: r}
+
+	fields := New().Fields(state, w)
+	if len(fields) != len(labels) {
+		t.Fatalf("Expected %d fields, got %d", len(labels), len(fields))
+	}
+
+	expect := map[string]string{
+		"type":   "HINFO",
+		"name":   "example.org.",
+		"remote": "10.240.0.1",
+		"id":     "1053",
+		"rflags": "-",
+	}
+	for k, v := range expect {
+		if fields[k] != v {
+			t.Errorf("Expected field %q to be %q, got %q", k, v, fields[k])
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package syslog
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Variables declared for monitoring.
+var (
+	sentCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "syslog",
+		Name:      "sent_total",
+		Help:      "Counter of messages sent to a syslog collector.",
+	}, []string{"plugin", "to"})
+
+	droppedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "syslog",
+		Name:      "dropped_total",
+		Help:      "Counter of messages dropped because the queue was full or the syslog collector could not be reached.",
+	}, []string{"plugin", "to"})
+)
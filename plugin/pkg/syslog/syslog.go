@@ -0,0 +1,341 @@
+// Package syslog implements a non-blocking client that sends RFC 5424 messages to a syslog
+// collector over UDP, TCP or TLS.
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	pkgtls "github.com/coredns/coredns/plugin/pkg/tls"
+)
+
+// Severity is the syslog severity of a message.
+type Severity int
+
+// Severities as defined in RFC 5424, section 6.2.1.
+const (
+	Emergency Severity = iota
+	Alert
+	Critical
+	Error
+	Warning
+	Notice
+	Info
+	Debug
+)
+
+// facilities maps the facility names to their code.
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+const (
+	// DefaultFacility is used when no facility is configured.
+	DefaultFacility = "daemon"
+
+	appName     = "coredns"
+	queueSize   = 10000
+	dialTimeout = 5 * time.Second
+	sendTimeout = 5 * time.Second
+)
+
+var (
+	// redialInterval is the time during which messages are dropped after the collector
+	// could not be reached.
+	redialInterval = 1 * time.Second
+	// drainTimeout is how long Stop keeps sending the queued messages, the rest is dropped.
+	drainTimeout = 5 * time.Second
+)
+
+// Writer sends messages to a syslog collector. Messages are queued and sent by a separate
+// goroutine, when the queue is full or the collector is unreachable they are dropped and
+// counted. A Writer is safe for concurrent use.
+type Writer struct {
+	plugin    string
+	network   string // udp, tcp or tls
+	addr      string
+	tlsConfig *tls.Config
+	facility  int
+	hostname  string
+	pid       string
+
+	queue   chan []byte
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+	running bool
+
+	conn     net.Conn
+	redial   time.Time
+	deadline time.Time // when draining, the time until which messages are sent
+}
+
+// New returns a Writer that sends the messages of plugin to address. The address is of the
+// form [udp://|tcp://|tls://]HOST[:PORT]; without a scheme udp is used and the port defaults
+// to 514, or 6514 for tls. The tlsConfig is only used for tls and may be nil to verify the
+// collector against the system roots.
+func New(plugin, address, facility string, tlsConfig *tls.Config) (*Writer, error) {
+	network, addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	fac, ok := facilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility: %s", facility)
+	}
+
+	if network == "tls" {
+		if tlsConfig == nil {
+			tlsConfig = new(tls.Config)
+		} else {
+			tlsConfig = tlsConfig.Clone()
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &Writer{
+		plugin:    plugin,
+		network:   network,
+		addr:      addr,
+		tlsConfig: tlsConfig,
+		facility:  fac,
+		hostname:  hostname,
+		pid:       strconv.Itoa(os.Getpid()),
+		queue:     make(chan []byte, queueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// parseAddress splits address into the network and a host:port.
+func parseAddress(address string) (network, addr string, err error) {
+	network, addr = "udp", address
+	if i := strings.Index(address, "://"); i >= 0 {
+		network, addr = address[:i], address[i+3:]
+	}
+	port := "514"
+	switch network {
+	case "udp", "tcp":
+	case "tls":
+		port = "6514"
+	default:
+		return "", "", fmt.Errorf("unsupported syslog transport: %s", network)
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), port)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return "", "", fmt.Errorf("invalid syslog address: %s", address)
+	}
+	return network, addr, nil
+}
+
+// String returns the address of the collector.
+func (w *Writer) String() string { return w.network + "://" + w.addr }
+
+// Start starts sending the queued messages.
+func (w *Writer) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.running {
+		w.running = true
+		go w.run()
+	}
+	return nil
+}
+
+// Stop sends the remaining queued messages, and stops the writer. Messages that can't be sent
+// within drainTimeout are dropped. A stopped writer can not be started again.
+func (w *Writer) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		w.running = false
+		close(w.stop)
+		<-w.done
+	}
+	return nil
+}
+
+// Write queues msg with the severity sev. If the queue is full the message is dropped.
+func (w *Writer) Write(sev Severity, msg string) {
+	select {
+	case w.queue <- w.format(sev, time.Now(), msg):
+	default:
+		droppedCount.WithLabelValues(w.plugin, w.String()).Inc()
+	}
+}
+
+// Errorf writes a message with Error severity.
+func (w *Writer) Errorf(format string, v ...interface{}) { w.Write(Error, fmt.Sprintf(format, v...)) }
+
+// Warningf writes a message with Warning severity.
+func (w *Writer) Warningf(format string, v ...interface{}) {
+	w.Write(Warning, fmt.Sprintf(format, v...))
+}
+
+// Infof writes a message with Info severity.
+func (w *Writer) Infof(format string, v ...interface{}) { w.Write(Info, fmt.Sprintf(format, v...)) }
+
+// Debugf writes a message with Debug severity, when debug logging is enabled.
+func (w *Writer) Debugf(format string, v ...interface{}) {
+	if !clog.D.Value() {
+		return
+	}
+	w.Write(Debug, fmt.Sprintf(format, v...))
+}
+
+// format returns msg as a RFC 5424 message without structured data, the plugin is used as
+// the message ID.
+func (w *Writer) format(sev Severity, t time.Time, msg string) []byte {
+	b := make([]byte, 0, 64+len(w.hostname)+len(w.plugin)+len(msg))
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(w.facility*8+int(sev)), 10)
+	b = append(b, ">1 "...)
+	b = t.UTC().AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	b = append(b, ' ')
+	b = append(b, w.hostname...)
+	b = append(b, ' ')
+	b = append(b, appName...)
+	b = append(b, ' ')
+	b = append(b, w.pid...)
+	b = append(b, ' ')
+	b = append(b, w.plugin...)
+	b = append(b, " - "...)
+	return append(b, msg...)
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	defer w.close()
+	for {
+		select {
+		case msg := <-w.queue:
+			w.send(msg)
+		case <-w.stop:
+			w.deadline = time.Now().Add(drainTimeout)
+			for {
+				select {
+				case msg := <-w.queue:
+					if time.Now().After(w.deadline) {
+						droppedCount.WithLabelValues(w.plugin, w.String()).Inc()
+						continue
+					}
+					w.send(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// timeout returns d, or the time left until the deadline if that is shorter.
+func (w *Writer) timeout(d time.Duration) time.Duration {
+	if w.deadline.IsZero() {
+		return d
+	}
+	if left := time.Until(w.deadline); left < d {
+		// A zero timeout means no timeout at all.
+		return max(left, time.Nanosecond)
+	}
+	return d
+}
+
+// send sends msg to the collector, reconnecting once if the connection was lost.
+func (w *Writer) send(msg []byte) {
+	if w.network != "udp" {
+		// Octet counting framing, RFC 6587 section 3.4.1.
+		msg = append(strconv.AppendInt(nil, int64(len(msg)), 10), append([]byte{' '}, msg...)...)
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+			break
+		}
+		if w.conn == nil {
+			if time.Now().Before(w.redial) {
+				break
+			}
+			if err := w.dial(); err != nil {
+				w.redial = time.Now().Add(redialInterval)
+				clog.Warningf("Failed to connect to syslog collector %s: %s", w, err)
+				break
+			}
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout(sendTimeout)))
+		if _, err := w.conn.Write(msg); err != nil {
+			w.close()
+			continue
+		}
+		sentCount.WithLabelValues(w.plugin, w.String()).Inc()
+		return
+	}
+	droppedCount.WithLabelValues(w.plugin, w.String()).Inc()
+}
+
+func (w *Writer) dial() error {
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: w.timeout(dialTimeout)}
+	if w.network == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", w.addr, w.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(w.network, w.addr)
+	}
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *Writer) close() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// NewFromArgs returns a Writer for plugin from the arguments of the "syslog ADDRESS [FACILITY]"
+// option and, if not nil, the arguments of the "syslog_tls [CERT KEY] [CA]" option.
+func NewFromArgs(plugin string, args, tlsArgs []string) (*Writer, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("syslog takes an address and an optional facility, found %d arguments", len(args))
+	}
+	facility := DefaultFacility
+	if len(args) == 2 {
+		facility = args[1]
+	}
+	var tlsConfig *tls.Config
+	if tlsArgs != nil {
+		if !strings.HasPrefix(args[0], "tls://") {
+			return nil, fmt.Errorf("syslog_tls requires a tls:// syslog address")
+		}
+		var err error
+		if tlsConfig, err = pkgtls.NewTLSConfigFromArgs(tlsArgs...); err != nil {
+			return nil, err
+		}
+	}
+	return New(plugin, args[0], facility, tlsConfig)
+}
@@ -0,0 +1,229 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		addr    string
+		err     bool
+	}{
+		{"10.0.0.1", "udp", "10.0.0.1:514", false},
+		{"udp://10.0.0.1:1514", "udp", "10.0.0.1:1514", false},
+		{"tcp://syslog.example.org", "tcp", "syslog.example.org:514", false},
+		{"tls://syslog.example.org", "tls", "syslog.example.org:6514", false},
+		{"tcp://[::1]", "tcp", "[::1]:514", false},
+		{"tcp://[::1]:601", "tcp", "[::1]:601", false},
+		{"http://10.0.0.1", "", "", true},
+		{"tcp://", "", "", true},
+	}
+	for i, tc := range tests {
+		network, addr, err := parseAddress(tc.address)
+		if (err != nil) != tc.err {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.err, err)
+			continue
+		}
+		if network != tc.network || addr != tc.addr {
+			t.Errorf("Test %d: expected %s %s, got %s %s", i, tc.network, tc.addr, network, addr)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	w, err := New("log", "127.0.0.1", "local0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.hostname, w.pid = "ns1", "42"
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	got := string(w.format(Warning, ts, "hello"))
+	expect := "<132>1 2024-01-02T03:04:05.000006Z ns1 coredns 42 log - hello"
+	if got != expect {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+
+	if _, err := New("log", "127.0.0.1", "nofacility", nil); err == nil {
+		t.Errorf("Expected error for unknown facility")
+	}
+}
+
+func TestWriterUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w, err := New("errors", "udp://"+pc.LocalAddr().String(), DefaultFacility, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop()
+
+	w.Errorf("%d %s", 2, "example.org. A: timeout")
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to receive message: %s", err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<27>1 ") || !strings.HasSuffix(msg, " coredns "+w.pid+" errors - 2 example.org. A: timeout") {
+		t.Errorf("Unexpected message %q", msg)
+	}
+}
+
+func TestWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	w, err := New("log", "tcp://"+l.Addr().String(), DefaultFacility, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	w.Write(Info, "first")
+	w.Write(Info, "second")
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	w.Stop()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, expect := range []string{"first", "second"} {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("Failed to read frame length: %s", err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			t.Fatalf("Invalid frame length %q", length)
+		}
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			t.Fatalf("Failed to read frame: %s", err)
+		}
+		if !strings.HasPrefix(string(frame), "<30>1 ") || !strings.HasSuffix(string(frame), " log - "+expect) {
+			t.Errorf("Unexpected message %q", frame)
+		}
+	}
+}
+
+func TestWriterDrops(t *testing.T) {
+	// Reserve a port and close it again, so nothing is listening.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	w, err := New("drops", "tcp://"+addr, DefaultFacility, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.queue = make(chan []byte, 10)
+
+	// Not started: the queue fills up and the rest is dropped without blocking.
+	for i := 0; i < 15; i++ {
+		w.Write(Info, "message")
+	}
+	if dropped := testutil.ToFloat64(droppedCount.WithLabelValues("drops", w.String())); dropped != 5 {
+		t.Errorf("Expected 5 dropped messages, got %f", dropped)
+	}
+
+	// The queued messages can't be delivered and are dropped as well.
+	w.Start()
+	w.Stop()
+	if dropped := testutil.ToFloat64(droppedCount.WithLabelValues("drops", w.String())); dropped != 15 {
+		t.Errorf("Expected 15 dropped messages, got %f", dropped)
+	}
+}
+
+func TestWriterDrainTimeout(t *testing.T) {
+	defer func(d time.Duration) { drainTimeout = d }(drainTimeout)
+	drainTimeout = 200 * time.Millisecond
+
+	// A collector that accepts the connection, but never reads from it.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	w, err := New("drain", "tcp://"+l.Addr().String(), DefaultFacility, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const messages = 500
+	msg := strings.Repeat("x", 64*1024)
+	for i := 0; i < messages; i++ {
+		w.Write(Info, msg)
+	}
+
+	w.Start()
+	start := time.Now()
+	w.Stop()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Expected Stop to return after the drain timeout, took %s", d)
+	}
+	sent := testutil.ToFloat64(sentCount.WithLabelValues("drain", w.String()))
+	dropped := testutil.ToFloat64(droppedCount.WithLabelValues("drain", w.String()))
+	if dropped == 0 {
+		t.Errorf("Expected dropped messages")
+	}
+	if sent+dropped != messages {
+		t.Errorf("Expected %d sent and dropped messages, got %f and %f", messages, sent, dropped)
+	}
+}
+
+func TestNewFromArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		tlsArgs []string
+		err     bool
+	}{
+		{[]string{"10.0.0.1"}, nil, false},
+		{[]string{"tcp://10.0.0.1", "local3"}, nil, false},
+		{[]string{"tls://syslog.example.org"}, []string{}, false},
+		{[]string{}, nil, true},
+		{[]string{"10.0.0.1", "local3", "extra"}, nil, true},
+		{[]string{"10.0.0.1", "nofacility"}, nil, true},
+		{[]string{"tcp://10.0.0.1"}, []string{}, true},
+		{[]string{"tls://syslog.example.org"}, []string{"/nonexistent/ca.pem"}, true},
+	}
+	for i, tc := range tests {
+		_, err := NewFromArgs("log", tc.args, tc.tlsArgs)
+		if (err != nil) != tc.err {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.err, err)
+		}
+	}
+}
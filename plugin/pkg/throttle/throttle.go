@@ -0,0 +1,23 @@
+// Package throttle is used by the plugins that refresh their zones from a cloud DNS service, to back off while the
+// service throttles the requests.
+package throttle
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxBackoff is the longest delay between two refreshes while the requests are throttled.
+const MaxBackoff = 30 * time.Minute
+
+// ErrThrottled is wrapped by the errors of refreshes that were throttled.
+var ErrThrottled = errors.New("throttled")
+
+// Backoff returns the delay until the next refresh. The delay doubles while the refreshes are throttled, and is
+// reset to refresh when they aren't.
+func Backoff(delay, refresh time.Duration, err error) time.Duration {
+	if !errors.Is(err, ErrThrottled) {
+		return refresh
+	}
+	return max(min(2*delay, MaxBackoff), refresh)
+}
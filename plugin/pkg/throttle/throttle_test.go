@@ -0,0 +1,30 @@
+package throttle
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	throttled := fmt.Errorf("errors updating zones (%w): []", ErrThrottled)
+	tests := []struct {
+		delay    time.Duration
+		refresh  time.Duration
+		err      error
+		expected time.Duration
+	}{
+		{time.Minute, time.Minute, nil, time.Minute},
+		{time.Minute, time.Minute, throttled, 2 * time.Minute},
+		{8 * time.Minute, time.Minute, throttled, 16 * time.Minute},
+		{20 * time.Minute, time.Minute, throttled, MaxBackoff},
+		{MaxBackoff, time.Minute, errors.New("access denied"), time.Minute},
+		{time.Hour, time.Hour, throttled, time.Hour}, // never shorter than the refresh interval
+	}
+	for i, tc := range tests {
+		if got := Backoff(tc.delay, tc.refresh, tc.err); got != tc.expected {
+			t.Errorf("Test %d: expected delay %v, got %v", i, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package route53
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// zoneLastSync is the time a hosted zone was last synced successfully.
+	zoneLastSync = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "route53",
+		Name:      "zone_last_sync_timestamp_seconds",
+		Help:      "Gauge of the time of the last successful sync of each hosted zone.",
+	}, []string{"zone", "hosted_zone"})
+
+	// zoneSyncErrors is the number of failed syncs per hosted zone.
+	zoneSyncErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "route53",
+		Name:      "zone_sync_errors_total",
+		Help:      "Counter of failed syncs of each hosted zone.",
+	}, []string{"zone", "hosted_zone"})
+)
@@ -14,10 +14,12 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/throttle"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/request"
 
 	"github.com/aws/aws-sdk-go/aws"
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/miekg/dns"
@@ -32,15 +34,25 @@ type Route53 struct {
 	client    route53iface.Route53API
 	upstream  *upstream.Upstream
 	refresh   time.Duration
+	// fullRefresh is the longest time between two listings of a hosted zone. If it's longer than refresh, a hosted
+	// zone is only listed when its version changed.
+	fullRefresh time.Duration
 
 	zMu   sync.RWMutex
 	zones zones
+
+	updates bool         // accept dynamic updates
+	updater file.Updater // its TSIG secrets are set on startup
 }
 
 type zone struct {
 	id  string
 	z   *file.Zone
 	dns string
+
+	version string    // the version of the hosted zone when it was last listed
+	synced  time.Time // the time the hosted zone was last listed
+	pages   int       // the number of pages of the last listing
 }
 
 type zones map[string][]*zone
@@ -69,11 +81,12 @@ func New(ctx context.Context, c route53iface.Route53API, keys map[string][]strin
 		}
 	}
 	return &Route53{
-		client:    c,
-		zoneNames: zoneNames,
-		zones:     zones,
-		upstream:  upstream.New(),
-		refresh:   refresh,
+		client:      c,
+		zoneNames:   zoneNames,
+		zones:       zones,
+		upstream:    upstream.New(),
+		refresh:     refresh,
+		fullRefresh: refresh,
 	}, nil
 }
 
@@ -84,16 +97,19 @@ func (h *Route53) Run(ctx context.Context) error {
 		return err
 	}
 	go func() {
-		timer := time.NewTimer(h.refresh)
+		delay := h.refresh
+		timer := time.NewTimer(delay)
 		defer timer.Stop()
 		for {
-			timer.Reset(h.refresh)
+			timer.Reset(delay)
 			select {
 			case <-ctx.Done():
 				log.Debugf("Breaking out of Route53 update loop for %v: %v", h.zoneNames, ctx.Err())
 				return
 			case <-timer.C:
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
+				err := h.updateZones(ctx)
+				delay = throttle.Backoff(delay, h.refresh, err)
+				if err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
 					log.Errorf("Failed to update zones %v: %v", h.zoneNames, err)
 				}
 			}
@@ -115,6 +131,9 @@ func (h *Route53) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 	if !ok || z == nil {
 		return dns.RcodeServerFailure, nil
 	}
+	if r.Opcode == dns.OpcodeUpdate && h.updates {
+		return h.serveUpdate(ctx, w, r, z)
+	}
 
 	m := new(dns.Msg)
 	m.SetReply(r)
@@ -237,7 +256,8 @@ func updateZoneFromRRS(rrs *route53.ResourceRecordSet, z *file.Zone) error {
 // updateZones re-queries resource record sets for each zone and updates the
 // zone object.
 // Returns error if any zones error'ed out, but waits for other zones to
-// complete first.
+// complete first. The error wraps throttle.ErrThrottled if route53 throttled any of
+// the requests.
 func (h *Route53) updateZones(ctx context.Context) error {
 	errc := make(chan error)
 	defer close(errc)
@@ -249,26 +269,21 @@ func (h *Route53) updateZones(ctx context.Context) error {
 			}()
 
 			for i, hostedZone := range z {
-				newZ := file.NewZone(zName, "")
-				newZ.Upstream = h.upstream
-				in := &route53.ListResourceRecordSetsInput{
-					HostedZoneId: aws.String(hostedZone.id),
-					MaxItems:     aws.String("1000"),
-				}
-				err = h.client.ListResourceRecordSetsPagesWithContext(ctx, in,
-					func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
-						for _, rrs := range out.ResourceRecordSets {
-							if err := updateZoneFromRRS(rrs, newZ); err != nil {
-								// Maybe unsupported record type. Log and carry on.
-								log.Warningf("Failed to process resource record set: %v", err)
-							}
-						}
-						return true
-					})
+				var newZ *file.Zone
+				newZ, err = h.syncZone(ctx, hostedZone)
 				if err != nil {
+					zoneSyncErrors.WithLabelValues(zName, hostedZone.id).Inc()
+					if awsrequest.IsErrorThrottle(err) {
+						err = fmt.Errorf("failed to list resource records for %v:%v from route53: %w: %v", zName, hostedZone.id, throttle.ErrThrottled, err)
+						return
+					}
 					err = fmt.Errorf("failed to list resource records for %v:%v from route53: %v", zName, hostedZone.id, err)
 					return
 				}
+				zoneLastSync.WithLabelValues(zName, hostedZone.id).SetToCurrentTime()
+				if newZ == nil {
+					continue
+				}
 				h.zMu.Lock()
 				(*z[i]).z = newZ
 				h.zMu.Unlock()
@@ -278,17 +293,62 @@ func (h *Route53) updateZones(ctx context.Context) error {
 	// Collect errors (if any). This will also sync on all zones updates
 	// completion.
 	var errs []string
+	throttled := false
 	for i := 0; i < len(h.zones); i++ {
 		err := <-errc
 		if err != nil {
 			errs = append(errs, err.Error())
+			throttled = throttled || errors.Is(err, throttle.ErrThrottled)
 		}
 	}
+	if throttled {
+		return fmt.Errorf("errors updating zones (%w): %v", throttle.ErrThrottled, errs)
+	}
 	if len(errs) != 0 {
 		return fmt.Errorf("errors updating zones: %v", errs)
 	}
 	return nil
 }
 
+// syncZone lists the resource record sets of hostedZone and returns them as a new zone. It returns nil if the
+// version of hostedZone didn't change since it was last listed, and it isn't due for a full refresh. When the last
+// listing fit in a single page the version isn't checked, as listing the hosted zone takes fewer API calls.
+func (h *Route53) syncZone(ctx context.Context, hostedZone *zone) (*file.Zone, error) {
+	var version string
+	if h.fullRefresh > h.refresh && hostedZone.pages != 1 {
+		var err error
+		if version, err = h.zoneVersion(ctx, hostedZone); err != nil {
+			return nil, err
+		}
+		if version == hostedZone.version && time.Since(hostedZone.synced) < h.fullRefresh {
+			return nil, nil
+		}
+	}
+
+	newZ := file.NewZone(hostedZone.dns, "")
+	newZ.Upstream = h.upstream
+	in := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZone.id),
+		MaxItems:     aws.String("1000"),
+	}
+	pages := 0
+	err := h.client.ListResourceRecordSetsPagesWithContext(ctx, in,
+		func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
+			pages++
+			for _, rrs := range out.ResourceRecordSets {
+				if err := updateZoneFromRRS(rrs, newZ); err != nil {
+					// Maybe unsupported record type. Log and carry on.
+					log.Warningf("Failed to process resource record set: %v", err)
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+	hostedZone.version, hostedZone.synced, hostedZone.pages = version, time.Now(), pages
+	return newZ, nil
+}
+
 // Name implements plugin.Handler.Name.
 func (h *Route53) Name() string { return "route53" }
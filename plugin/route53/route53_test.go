@@ -9,10 +9,12 @@ import (
 
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/throttle"
 	"github.com/coredns/coredns/plugin/test"
 	crequest "github.com/coredns/coredns/request"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
@@ -76,6 +78,19 @@ func (fakeRoute53) ListResourceRecordSetsPagesWithContext(_ aws.Context, in *rou
 	return nil
 }
 
+func (fakeRoute53) GetHostedZoneWithContext(_ aws.Context, in *route53.GetHostedZoneInput, _ ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	return &route53.GetHostedZoneOutput{HostedZone: &route53.HostedZone{Id: in.Id, ResourceRecordSetCount: aws.Int64(10)}}, nil
+}
+
+func (fakeRoute53) ListResourceRecordSetsWithContext(_ aws.Context, in *route53.ListResourceRecordSetsInput, _ ...request.Option) (*route53.ListResourceRecordSetsOutput, error) {
+	return &route53.ListResourceRecordSetsOutput{ResourceRecordSets: []*route53.ResourceRecordSet{{
+		Name:            aws.String("org."),
+		Type:            aws.String("SOA"),
+		TTL:             aws.Int64(300),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("ns-1536.awsdns-00.co.uk. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400")}},
+	}}}, nil
+}
+
 func TestRoute53(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -265,6 +280,85 @@ func TestRoute53(t *testing.T) {
 	}
 }
 
+// syncRoute53 counts the listings and version checks of the hosted zones, and returns count as their number of
+// resource record sets. The listings have pages pages.
+type syncRoute53 struct {
+	fakeRoute53
+	lists    int
+	versions int
+	count    int64
+	pages    int
+	err      error
+}
+
+func (f *syncRoute53) GetHostedZoneWithContext(_ aws.Context, in *route53.GetHostedZoneInput, _ ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.versions++
+	return &route53.GetHostedZoneOutput{HostedZone: &route53.HostedZone{Id: in.Id, ResourceRecordSetCount: aws.Int64(f.count)}}, nil
+}
+
+func (f *syncRoute53) ListResourceRecordSetsPagesWithContext(ctx aws.Context, in *route53.ListResourceRecordSetsInput, fn func(*route53.ListResourceRecordSetsOutput, bool) bool, opts ...request.Option) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.lists++
+	for i := 1; i < f.pages; i++ {
+		fn(&route53.ListResourceRecordSetsOutput{}, false)
+	}
+	return f.fakeRoute53.ListResourceRecordSetsPagesWithContext(ctx, in, fn, opts...)
+}
+
+func TestRoute53FullRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &syncRoute53{count: 10, pages: 2}
+	r, err := New(ctx, client, map[string][]string{"org.": {"1234567890"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create route53: %v", err)
+	}
+	r.fullRefresh = time.Hour
+
+	hostedZone := r.zones["org."][0]
+	for i, tc := range []struct {
+		prepare          func()
+		expectedLists    int
+		expectedVersions int
+	}{
+		{func() {}, 1, 1}, // The first sync always lists the hosted zone.
+		{func() {}, 1, 2},
+		{func() { client.count = 11 }, 2, 3},
+		{func() {}, 2, 4},
+		{func() { hostedZone.synced = time.Now().Add(-2 * time.Hour) }, 3, 5},
+		// A listing of a single page is cheaper than checking the version.
+		{func() { client.count, client.pages = 12, 1 }, 4, 6},
+		{func() {}, 5, 6},
+		{func() {}, 6, 6},
+	} {
+		tc.prepare()
+		if err := r.updateZones(ctx); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if client.lists != tc.expectedLists {
+			t.Errorf("Test %d: expected %d listings, got %d", i, tc.expectedLists, client.lists)
+		}
+		if client.versions != tc.expectedVersions {
+			t.Errorf("Test %d: expected %d version checks, got %d", i, tc.expectedVersions, client.versions)
+		}
+	}
+
+	client.err = awserr.New("Throttling", "Rate exceeded", nil)
+	if err := r.updateZones(ctx); !errors.Is(err, throttle.ErrThrottled) {
+		t.Errorf("Expected throttled error, got %v", err)
+	}
+	client.err = errors.New("access denied")
+	if err := r.updateZones(ctx); err == nil || errors.Is(err, throttle.ErrThrottled) {
+		t.Errorf("Expected error that isn't throttled, got %v", err)
+	}
+}
+
 func TestMaybeUnescape(t *testing.T) {
 	for ti, tc := range []struct {
 		escaped, want string
@@ -47,6 +47,9 @@ func setup(c *caddy.Controller) error {
 		var fall fall.F
 
 		refresh := time.Duration(1) * time.Minute // default update frequency to 1 minute
+		var fullRefresh time.Duration             // defaults to refresh
+		var updates bool
+		var updateKeys []string
 
 		args := c.RemainingArgs()
 
@@ -117,11 +120,39 @@ func setup(c *caddy.Controller) error {
 				} else {
 					return plugin.Error("route53", c.ArgErr())
 				}
+			case "full_refresh":
+				if !c.NextArg() {
+					return plugin.Error("route53", c.ArgErr())
+				}
+				fullRefreshStr := c.Val()
+				if _, err := strconv.Atoi(fullRefreshStr); err == nil {
+					fullRefreshStr += "s"
+				}
+				var err error
+				fullRefresh, err = time.ParseDuration(fullRefreshStr)
+				if err != nil {
+					return plugin.Error("route53", c.Errf("Unable to parse duration: %v", err))
+				}
+				if fullRefresh <= 0 {
+					return plugin.Error("route53", c.Errf("full refresh interval must be greater than 0: %q", fullRefreshStr))
+				}
+			case "update":
+				updates = true
+				for _, k := range c.RemainingArgs() {
+					updateKeys = append(updateKeys, plugin.Name(k).Normalize())
+				}
 			default:
 				return plugin.Error("route53", c.Errf("unknown property %q", c.Val()))
 			}
 		}
 
+		if fullRefresh == 0 {
+			fullRefresh = refresh
+		}
+		if fullRefresh < refresh {
+			return plugin.Error("route53", c.Errf("full refresh interval %v must not be shorter than the refresh interval %v", fullRefresh, refresh))
+		}
+
 		client := f(opts)
 		ctx, cancel := context.WithCancel(context.Background())
 		h, err := New(ctx, client, keys, refresh)
@@ -130,6 +161,8 @@ func setup(c *caddy.Controller) error {
 			return plugin.Error("route53", c.Errf("failed to create route53 plugin: %v", err))
 		}
 		h.Fall = fall
+		h.fullRefresh = fullRefresh
+		h.updates, h.updater.Keys = updates, updateKeys
 		if err := h.Run(ctx); err != nil {
 			cancel()
 			return plugin.Error("route53", c.Errf("failed to initialize route53 plugin: %v", err))
@@ -139,6 +172,7 @@ func setup(c *caddy.Controller) error {
 			return h
 		})
 		c.OnShutdown(func() error { cancel(); return nil })
+		c.OnStartup(func() error { h.updater.Secrets = dnsserver.GetConfig(c).TsigSecret; return nil })
 	}
 	return nil
 }
@@ -67,6 +67,23 @@ func TestSetupRoute53(t *testing.T) {
 		{`route53 example.org:12345678 {
 	refresh -1m
 }`, true},
+		{`route53 example.org:12345678 {
+	refresh 5m
+	full_refresh 1h
+}`, false},
+		{`route53 example.org:12345678 {
+	full_refresh 3600
+}`, false},
+		{`route53 example.org:12345678 {
+	full_refresh
+}`, true},
+		{`route53 example.org:12345678 {
+	full_refresh -1h
+}`, true},
+		{`route53 example.org:12345678 {
+	refresh 5m
+	full_refresh 1m
+}`, true},
 
 		{`route53 example.org {
 	}`, true},
@@ -75,6 +92,12 @@ func TestSetupRoute53(t *testing.T) {
 }`, true},
 		{`route53 example.org:12345678 {
     aws_endpoint https://localhost
+}`, false},
+		{`route53 example.org:12345678 {
+    update
+}`, false},
+		{`route53 example.org:12345678 {
+    update update.key. other.key
 }`, false},
 	}
 
@@ -0,0 +1,36 @@
+package route53
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// zoneVersion returns the version of hostedZone, made up of its number of resource record sets and its SOA
+// record. Route 53 doesn't change the SOA serial by itself, so changes that neither add nor remove resource
+// record sets don't change the version.
+func (h *Route53) zoneVersion(ctx context.Context, hostedZone *zone) (string, error) {
+	out, err := h.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: aws.String(hostedZone.id)})
+	if err != nil {
+		return "", err
+	}
+	soa, err := h.client.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZone.id),
+		StartRecordName: aws.String(hostedZone.dns),
+		StartRecordType: aws.String(route53.RRTypeSoa),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	version := strconv.FormatInt(aws.Int64Value(out.HostedZone.ResourceRecordSetCount), 10)
+	for _, rrs := range soa.ResourceRecordSets {
+		for _, rr := range rrs.ResourceRecords {
+			version += " " + aws.StringValue(rr.Value)
+		}
+	}
+	return version, nil
+}
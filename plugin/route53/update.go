@@ -0,0 +1,59 @@
+package route53
+
+import (
+	"context"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/file"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/miekg/dns"
+)
+
+// serveUpdate handles the dynamic update r of a zone, see RFC 2136. The update is applied to the first hosted zone
+// in z, the hosted zones of the zone.
+func (h *Route53) serveUpdate(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, z []*zone) (int, error) {
+	hostedZone := z[0]
+	m := new(dns.Msg)
+	m.SetRcode(r, h.updater.Update(w, r, hostedZone.z, &h.zMu, func(changes []file.Change) (int, int) {
+		return h.commit(ctx, hostedZone, changes)
+	}))
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// commit writes changes to hostedZone in a single change batch, so either all of them are written or none are.
+func (h *Route53) commit(ctx context.Context, hostedZone *zone, changes []file.Change) (int, int) {
+	batch := &route53.ChangeBatch{Comment: aws.String("CoreDNS dynamic update")}
+	for _, c := range changes {
+		if len(c.New) == 0 {
+			batch.Changes = append(batch.Changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: toRRS(c.Name, c.Type, c.Old)})
+			continue
+		}
+		batch.Changes = append(batch.Changes, &route53.Change{Action: aws.String(route53.ChangeActionUpsert), ResourceRecordSet: toRRS(c.Name, c.Type, c.New)})
+	}
+	_, err := h.client.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZone.id),
+		ChangeBatch:  batch,
+	})
+	if err != nil {
+		log.Errorf("Failed to update %v:%v in route53: %v", hostedZone.dns, hostedZone.id, err)
+		return 0, dns.RcodeServerFailure
+	}
+	return len(changes), dns.RcodeSuccess
+}
+
+// toRRS returns the resource record set for the RRset rrs of name and type t.
+func toRRS(name string, t uint16, rrs []dns.RR) *route53.ResourceRecordSet {
+	rrs53 := &route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: aws.String(dns.TypeToString[t]),
+		TTL:  aws.Int64(int64(rrs[0].Header().Ttl)),
+	}
+	for _, rr := range rrs {
+		value := strings.TrimPrefix(rr.String(), rr.Header().String())
+		rrs53.ResourceRecords = append(rrs53.ResourceRecords, &route53.ResourceRecord{Value: aws.String(value)})
+	}
+	return rrs53
+}
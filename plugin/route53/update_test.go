@@ -0,0 +1,195 @@
+package route53
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/miekg/dns"
+)
+
+type fakeUpdateRoute53 struct {
+	fakeRoute53
+	inputs []*route53.ChangeResourceRecordSetsInput
+	err    error
+}
+
+func (f *fakeUpdateRoute53) ChangeResourceRecordSetsWithContext(_ aws.Context, in *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.inputs = append(f.inputs, in)
+	return &route53.ChangeResourceRecordSetsOutput{}, f.err
+}
+
+func TestUpdate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		key           string
+		keys          []string
+		update        func(m *dns.Msg)
+		err           error
+		expectedRcode int
+		expected      []*route53.Change
+	}{
+		// Unsigned updates are refused.
+		{
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeRefused,
+		},
+		// Signed with a key that isn't allowed.
+		{
+			key:           "other.key.",
+			keys:          []string{"update.key."},
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeRefused,
+		},
+		{
+			key:           "update.key.",
+			keys:          []string{"update.key."},
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected: []*route53.Change{{
+				Action: aws.String("UPSERT"),
+				ResourceRecordSet: &route53.ResourceRecordSet{
+					Name:            aws.String("www.example.org."),
+					Type:            aws.String("A"),
+					TTL:             aws.Int64(300),
+					ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("1.2.3.4")}, {Value: aws.String("1.2.3.5")}},
+				},
+			}},
+		},
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.RemoveRRset([]dns.RR{test.CNAME("sample.example.org. 0 IN CNAME .")}) },
+			expectedRcode: dns.RcodeSuccess,
+			expected: []*route53.Change{{
+				Action: aws.String("DELETE"),
+				ResourceRecordSet: &route53.ResourceRecordSet{
+					Name:            aws.String("sample.example.org."),
+					Type:            aws.String("CNAME"),
+					TTL:             aws.Int64(300),
+					ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("example.org.")}},
+				},
+			}},
+		},
+		// Failed prerequisite.
+		{
+			key: "update.key.",
+			update: func(m *dns.Msg) {
+				m.NameNotUsed([]dns.RR{test.A("www.example.org. 0 IN A 0.0.0.0")})
+				m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")})
+			},
+			expectedRcode: dns.RcodeYXDomain,
+		},
+		// Failed change.
+		{
+			key:           "update.key.",
+			update:        func(m *dns.Msg) { m.Insert([]dns.RR{test.A("www.example.org. 300 IN A 1.2.3.5")}) },
+			err:           errors.New("throttled"),
+			expectedRcode: dns.RcodeServerFailure,
+		},
+	}
+
+	for i, tc := range tests {
+		client := &fakeUpdateRoute53{err: tc.err}
+		h, err := New(ctx, client, map[string][]string{"org.": {"1234567890"}}, time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to create route53: %v", err)
+		}
+		if err := h.Run(ctx); err != nil {
+			t.Fatalf("Failed to initialize route53: %v", err)
+		}
+		h.updates, h.updater.Keys, h.updater.Secrets = true, tc.keys, testSecrets
+
+		m := new(dns.Msg)
+		m.SetUpdate("org.")
+		tc.update(m)
+		if tc.key != "" {
+			m = signed(t, m, tc.key)
+		}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := h.ServeDNS(ctx, rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if rec.Msg.Rcode != tc.expectedRcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.expectedRcode], dns.RcodeToString[rec.Msg.Rcode])
+		}
+
+		if tc.expected == nil {
+			if tc.err == nil && len(client.inputs) != 0 {
+				t.Errorf("Test %d: expected no changes, got %v", i, client.inputs)
+			}
+			continue
+		}
+		if len(client.inputs) != 1 {
+			t.Fatalf("Test %d: expected a single change batch, got %d", i, len(client.inputs))
+		}
+		if id := aws.StringValue(client.inputs[0].HostedZoneId); id != "1234567890" {
+			t.Errorf("Test %d: expected hosted zone 1234567890, got %s", i, id)
+		}
+		if got := client.inputs[0].ChangeBatch.Changes; awsString(got) != awsString(tc.expected) {
+			t.Errorf("Test %d: expected changes %s, got %s", i, awsString(tc.expected), awsString(got))
+		}
+	}
+}
+
+func TestUpdateVisible(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h, err := New(ctx, &fakeUpdateRoute53{}, map[string][]string{"org.": {"1234567890"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create route53: %v", err)
+	}
+	if err := h.Run(ctx); err != nil {
+		t.Fatalf("Failed to initialize route53: %v", err)
+	}
+	h.updates, h.updater.Secrets = true, testSecrets
+
+	m := new(dns.Msg)
+	m.SetUpdate("org.")
+	m.Insert([]dns.RR{test.AAAA("new.example.org. 60 IN AAAA 2001:db8::1")})
+	m = signed(t, m, "update.key.")
+	if _, err := h.ServeDNS(ctx, dnstest.NewRecorder(&test.ResponseWriter{}), m); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("new.example.org.", dns.TypeAAAA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	if _, err := h.ServeDNS(ctx, rec, req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].String() != "new.example.org.\t60\tIN\tAAAA\t2001:db8::1" {
+		t.Errorf("Expected the updated record, got %v", rec.Msg.Answer)
+	}
+}
+
+func awsString(changes []*route53.Change) string {
+	return (&route53.ChangeBatch{Changes: changes}).String()
+}
+
+// testSecrets are the TSIG secrets of the keys used by the tests.
+var testSecrets = map[string]string{"update.key.": "c2VjcmV0", "other.key.": "c2VjcmV0"}
+
+// signed returns m signed with key.
+func signed(t *testing.T, m *dns.Msg, key string) *dns.Msg {
+	t.Helper()
+	m.SetTsig(key, dns.HmacSHA256, 300, time.Now().Unix())
+	buf, _, err := dns.TsigGenerate(m, testSecrets[key], "", false)
+	if err != nil {
+		t.Fatalf("Failed to sign update: %v", err)
+	}
+	r := new(dns.Msg)
+	if err := r.Unpack(buf); err != nil {
+		t.Fatalf("Failed to unpack update: %v", err)
+	}
+	return r
+}
@@ -1,6 +1,9 @@
 package secondary
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/coredns/caddy"
@@ -29,6 +32,14 @@ func setup(c *caddy.Controller) error {
 		if len(z.TransferFrom) > 0 {
 			c.OnStartup(func() error {
 				z.StartupOnce.Do(func() {
+					if z.CacheFile != "" {
+						// Serve the cached zone until the transfer below succeeds, or the zone expires.
+						if err := z.ReadCache(); err == nil {
+							log.Infof("Loaded zone %q from %q with %d SOA serial", n, z.CacheFile, z.SOASerialIfDefined())
+						} else if !os.IsNotExist(err) {
+							log.Warningf("Failed to load zone %q from %q: %s", n, z.CacheFile, err)
+						}
+					}
 					go func() {
 						dur := time.Millisecond * 250
 						step := time.Duration(2)
@@ -61,6 +72,7 @@ func setup(c *caddy.Controller) error {
 }
 
 func secondaryParse(c *caddy.Controller) (file.Zones, error) {
+	config := dnsserver.GetConfig(c)
 	z := make(map[string]*file.Zone)
 	names := []string{}
 	for c.Next() {
@@ -82,6 +94,32 @@ func secondaryParse(c *caddy.Controller) (file.Zones, error) {
 					if err != nil {
 						return file.Zones{}, err
 					}
+				case "zonemd":
+					policy := file.ZONEMDVerify
+					args := c.RemainingArgs()
+					switch {
+					case len(args) == 1 && args[0] == "required":
+						policy = file.ZONEMDRequire
+					case len(args) > 0:
+						return file.Zones{}, c.ArgErr()
+					}
+					for _, origin := range origins {
+						z[origin].ZONEMD = policy
+					}
+				case "cache":
+					if !c.NextArg() {
+						return file.Zones{}, c.ArgErr()
+					}
+					dir := c.Val()
+					if c.NextArg() {
+						return file.Zones{}, c.ArgErr()
+					}
+					if !filepath.IsAbs(dir) && config.Root != "" {
+						dir = filepath.Join(config.Root, dir)
+					}
+					for _, origin := range origins {
+						z[origin].CacheFile = filepath.Join(dir, fmt.Sprintf("db.%scache", origin)) // origin is a fqdn, hence %scache.
+					}
 				default:
 					return file.Zones{}, c.Errf("unknown property '%s'", c.Val())
 				}
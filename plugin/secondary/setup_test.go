@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/file"
 )
 
 func TestSecondaryParse(t *testing.T) {
@@ -61,3 +62,61 @@ func TestSecondaryParse(t *testing.T) {
 		}
 	}
 }
+
+func TestSecondaryParseZONEMD(t *testing.T) {
+	tests := []struct {
+		inputFileRules string
+		shouldErr      bool
+		policy         file.ZONEMDPolicy
+	}{
+		{"secondary example.org {\n transfer from 127.0.0.1\n}", false, file.ZONEMDIgnore},
+		{"secondary example.org {\n transfer from 127.0.0.1\n zonemd\n}", false, file.ZONEMDVerify},
+		{"secondary example.org {\n transfer from 127.0.0.1\n zonemd required\n}", false, file.ZONEMDRequire},
+		{"secondary example.org {\n transfer from 127.0.0.1\n zonemd optional\n}", true, file.ZONEMDIgnore},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.inputFileRules)
+		s, err := secondaryParse(c)
+		if err == nil && test.shouldErr {
+			t.Fatalf("Test %d expected errors, but got no error", i)
+		} else if err != nil && !test.shouldErr {
+			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
+		}
+		if test.shouldErr {
+			continue
+		}
+		if x := s.Z["example.org."].ZONEMD; x != test.policy {
+			t.Errorf("Test %d expected ZONEMD policy %d, got %d", i, test.policy, x)
+		}
+	}
+}
+
+func TestSecondaryParseCache(t *testing.T) {
+	tests := []struct {
+		inputFileRules string
+		shouldErr      bool
+		cacheFile      string
+	}{
+		{"secondary example.org {\n transfer from 127.0.0.1\n}", false, ""},
+		{"secondary example.org {\n transfer from 127.0.0.1\n cache /var/lib/coredns\n}", false, "/var/lib/coredns/db.example.org.cache"},
+		{"secondary example.org {\n transfer from 127.0.0.1\n cache\n}", true, ""},
+		{"secondary example.org {\n transfer from 127.0.0.1\n cache /tmp /var/tmp\n}", true, ""},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.inputFileRules)
+		s, err := secondaryParse(c)
+		if err == nil && test.shouldErr {
+			t.Fatalf("Test %d expected errors, but got no error", i)
+		} else if err != nil && !test.shouldErr {
+			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
+		}
+		if test.shouldErr {
+			continue
+		}
+		if x := s.Z["example.org."].CacheFile; x != test.cacheFile {
+			t.Errorf("Test %d expected cache file %q, got %q", i, test.cacheFile, x)
+		}
+	}
+}
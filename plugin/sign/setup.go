@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"math/rand"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
 )
 
 func init() { plugin.Register("sign", setup) }
@@ -89,6 +92,24 @@ func parse(c *caddy.Controller) (*Sign, error) {
 					signers[i].directory = dir[0]
 					signers[i].signedfile = fmt.Sprintf("db.%ssigned", signers[i].origin)
 				}
+			case "zonemd":
+				hashes := []uint8{}
+				for _, h := range c.RemainingArgs() {
+					switch strings.ToLower(h) {
+					case "sha384":
+						hashes = append(hashes, dns.ZoneMDHashAlgSHA384)
+					case "sha512":
+						hashes = append(hashes, dns.ZoneMDHashAlgSHA512)
+					default:
+						return nil, c.Errf("unknown ZONEMD hash algorithm '%s'", h)
+					}
+				}
+				if len(hashes) == 0 {
+					hashes = append(hashes, dns.ZoneMDHashAlgSHA384)
+				}
+				for i := range signers {
+					signers[i].zonemd = hashes
+				}
 			default:
 				return nil, c.Errf("unknown property '%s'", c.Val())
 			}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/coredns/caddy"
+
+	"github.com/miekg/dns"
 )
 
 func TestParse(t *testing.T) {
@@ -37,6 +39,20 @@ func TestParse(t *testing.T) {
 				signedfile: "db.example.org.signed",
 			},
 		},
+		{`sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			zonemd
+		 }`,
+			false,
+			&Signer{
+				keys:       []Pair{},
+				origin:     "miek.nl.",
+				dbfile:     "testdata/db.miek.nl",
+				directory:  "/var/lib/coredns",
+				zonemd:     []uint8{dns.ZoneMDHashAlgSHA384},
+				signedfile: "db.miek.nl.signed",
+			},
+		},
 		// errors
 		{`sign db.example.org {
 			key file /etc/coredns/keys/Kexample.org
@@ -44,6 +60,13 @@ func TestParse(t *testing.T) {
 			true,
 			nil,
 		},
+		{`sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			zonemd sha1
+		 }`,
+			true,
+			nil,
+		},
 	}
 	for i, tc := range tests {
 		c := caddy.NewTestController("dns", tc.input)
@@ -68,6 +91,9 @@ func TestParse(t *testing.T) {
 		if x := signer.directory; x != tc.exp.directory {
 			t.Errorf("Test %d expected %s as directory, got %s", i, tc.exp.directory, x)
 		}
+		if x := signer.zonemd; len(x) != len(tc.exp.zonemd) {
+			t.Errorf("Test %d expected %v as zonemd, got %v", i, tc.exp.zonemd, x)
+		}
 		if x := signer.signedfile; x != tc.exp.signedfile {
 			t.Errorf("Test %d expected %s as signedfile, got %s", i, tc.exp.signedfile, x)
 		}
@@ -24,6 +24,7 @@ type Signer struct {
 	directory   string
 	jitterIncep time.Duration
 	jitterExpir time.Duration
+	zonemd      []uint8 // hash algorithms for the ZONEMD records, none if empty
 
 	signedfile string
 	stop       chan struct{}
@@ -54,6 +55,19 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 		z.Insert(pair.Public.ToCDNSKEY())
 	}
 
+	// The ZONEMD records are inserted here so they end up in the NSEC type bitmap, the digest is filled in
+	// once the rest of the zone has been signed.
+	zonemd := make([]dns.RR, len(s.zonemd))
+	for i, h := range s.zonemd {
+		zonemd[i] = &dns.ZONEMD{
+			Hdr:    dns.RR_Header{Name: s.origin, Rrtype: dns.TypeZONEMD, Class: dns.ClassINET, Ttl: ttl},
+			Serial: z.Apex.SOA.Serial,
+			Scheme: dns.ZoneMDSchemeSimple,
+			Hash:   h,
+		}
+		z.Insert(zonemd[i])
+	}
+
 	names := names(s.origin, z)
 	ln := len(names)
 
@@ -91,7 +105,8 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 		for t, rrs := range zrrs {
 			// RRSIGs are not signed and NS records are not signed because we are never authoratiative for them.
 			// The zone's apex nameservers records are not kept in this tree and are signed separately.
-			if t == dns.TypeRRSIG || t == dns.TypeNS {
+			// ZONEMD records are signed after their digest has been calculated.
+			if t == dns.TypeRRSIG || t == dns.TypeNS || t == dns.TypeZONEMD {
 				continue
 			}
 			for _, pair := range s.keys {
@@ -105,7 +120,24 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 		i++
 		return nil
 	})
-	return z, err
+	if err != nil || len(zonemd) == 0 {
+		return z, err
+	}
+
+	for _, rr := range zonemd {
+		md := rr.(*dns.ZONEMD)
+		if md.Digest, err = z.Digest(md.Hash); err != nil {
+			return nil, err
+		}
+	}
+	for _, pair := range s.keys {
+		rrsig, err := pair.signRRs(zonemd, s.origin, ttl, inception, expiration)
+		if err != nil {
+			return nil, err
+		}
+		z.Insert(rrsig)
+	}
+	return z, nil
 }
 
 // resign checks if the signed zone exists, or needs resigning.
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/file"
 
 	"github.com/miekg/dns"
 )
@@ -175,3 +176,50 @@ func TestSignDS(t *testing.T) {
 		t.Errorf("Expected no NSEC TTL to be %d for %s, got %d", minttl, "www.miek.nl.", x)
 	}
 }
+
+func TestSignZONEMD(t *testing.T) {
+	input := `sign testdata/db.miek.nl miek.nl {
+		key file testdata/Kmiek.nl.+013+59725
+		directory testdata
+		zonemd sha384 sha512
+	}`
+	c := caddy.NewTestController("dns", input)
+	sign, err := parse(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := sign.signers[0].Sign(time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apex, _ := z.Search("miek.nl.")
+	if x := apex.Type(dns.TypeZONEMD); len(x) != 2 {
+		t.Fatalf("Expected %d ZONEMD records, got %d", 2, len(x))
+	}
+	if x := apex.Type(dns.TypeZONEMD)[0].(*dns.ZONEMD).Serial; x != z.Apex.SOA.Serial {
+		t.Errorf("Expected ZONEMD serial %d, got %d", z.Apex.SOA.Serial, x)
+	}
+	signed := false
+	for _, s := range apex.Type(dns.TypeRRSIG) {
+		if s.(*dns.RRSIG).TypeCovered == dns.TypeZONEMD {
+			signed = true
+		}
+	}
+	if !signed {
+		t.Error("Expected RRSIG for the ZONEMD records")
+	}
+	nsec := apex.Type(dns.TypeNSEC)[0].(*dns.NSEC)
+	found := false
+	for _, typ := range nsec.TypeBitMap {
+		if typ == dns.TypeZONEMD {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected ZONEMD in the NSEC bitmap, got %v", nsec.TypeBitMap)
+	}
+	if err := z.VerifyZONEMD(file.ZONEMDRequire); err != nil {
+		t.Errorf("Expected ZONEMD to verify, got %s", err)
+	}
+}
@@ -0,0 +1,45 @@
+package transfer
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	notifySentCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "transfer",
+		Name:      "notify_sent_total",
+		Help:      "Counter of NOTIFY messages sent to secondaries, including retries.",
+	}, []string{"zone", "secondary"})
+
+	notifyFailedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "transfer",
+		Name:      "notify_failed_total",
+		Help:      "Counter of NOTIFYs that were never acknowledged by a secondary.",
+	}, []string{"zone", "secondary"})
+
+	notifySerial = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "transfer",
+		Name:      "notify_serial",
+		Help:      "SOA serial of the last NOTIFY sent to a secondary.",
+	}, []string{"zone", "secondary"})
+
+	notifyAckedSerial = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "transfer",
+		Name:      "notify_acked_serial",
+		Help:      "SOA serial of the last NOTIFY acknowledged by a secondary.",
+	}, []string{"zone", "secondary"})
+
+	transferSerial = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "transfer",
+		Name:      "secondary_serial",
+		Help:      "SOA serial of the last zone transfer to a secondary.",
+	}, []string{"zone", "secondary"})
+)
@@ -2,57 +2,191 @@ package transfer
 
 import (
 	"fmt"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/coredns/coredns/plugin/pkg/rcode"
 
 	"github.com/miekg/dns"
 )
 
+const (
+	notifyBackoffMax   = 1 * time.Minute // maximum time between NOTIFY retries
+	defaultNotifyRetry = 5 * time.Minute // how long we retry an unacknowledged NOTIFY
+)
+
+// notifyBackoff is the time we wait before the first retry of a NOTIFY, it doubles for every following retry.
+var notifyBackoff = 1 * time.Second
+
+// pending holds the cancel channels of the NOTIFYs that are being retried, keyed on zone and address.
+type pending struct {
+	sync.Mutex
+	m map[[2]string]chan struct{}
+}
+
+func newPending() *pending { return &pending{m: make(map[[2]string]chan struct{})} }
+
 // Notify will send notifies to all configured to hosts IP addresses. The string zone must be lowercased.
+// Secondaries that don't acknowledge the NOTIFY are retried in the background with an exponential backoff.
 func (t *Transfer) Notify(zone string) error {
 	if t == nil { // t might be nil, mostly expected in tests, so intercept and to a noop in that case
 		return nil
 	}
 
-	m := new(dns.Msg)
-	m.SetNotify(zone)
-	c := new(dns.Client)
-
 	x := longestMatch(t.xfrs, zone)
 	if x == nil {
 		// return without error if there is no matching zone
 		return nil
 	}
 
+	m := new(dns.Msg)
+	m.SetNotify(zone)
+	serial, soa := t.soa(zone)
+	if soa != nil {
+		// RFC 1996, Section 3.7: the NOTIFY may contain the new SOA.
+		m.Answer = []dns.RR{soa}
+	}
+	c := new(dns.Client)
+
 	var err1 error
-	for _, t := range x.to {
-		if t == "*" {
+	for _, to := range x.to {
+		if to == "*" {
+			continue
+		}
+		cancel := t.notified(zone, to, serial)
+		err := sendNotify(c, m, to)
+		if err == nil {
+			t.acked(zone, to, serial)
 			continue
 		}
-		if err := sendNotify(c, m, t); err != nil {
-			err1 = err
+		err1 = err
+		if x.notifyRetry > 0 {
+			go t.retryNotify(c, m, to, serial, x.notifyRetry, cancel)
+		} else {
+			notifyFailedCount.WithLabelValues(zone, to).Inc()
 		}
 	}
 	log.Debugf("Sent notifies for zone %q to %v", zone, x.to)
 	return err1 // this only captures the last error
 }
 
-func sendNotify(c *dns.Client, m *dns.Msg, s string) error {
-	var err error
+// retryNotify resends m to addr until it is acknowledged, timeout has passed, a newer NOTIFY is sent or the plugin
+// is shut down.
+func (t *Transfer) retryNotify(c *dns.Client, m *dns.Msg, addr string, serial uint32, timeout time.Duration, cancel <-chan struct{}) {
+	zone := m.Question[0].Name
+	deadline := time.Now().Add(timeout)
+	backoff := notifyBackoff
+	for {
+		if time.Now().Add(backoff).After(deadline) {
+			log.Warningf("Giving up on notify for zone %q to %q with %d SOA serial after %s", zone, addr, serial, timeout)
+			notifyFailedCount.WithLabelValues(zone, addr).Inc()
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-cancel:
+			return
+		case <-t.stop:
+			return
+		}
+		if err := sendNotify(c, m, addr); err == nil {
+			t.acked(zone, addr, serial)
+			return
+		}
+		backoff *= 2
+		if backoff > notifyBackoffMax {
+			backoff = notifyBackoffMax
+		}
+	}
+}
 
-	code := dns.RcodeServerFailure
-	for i := 0; i < 3; i++ {
-		ret, _, err := c.Exchange(m, s)
-		if err != nil {
+// notified records that a NOTIFY with serial is sent to addr, it cancels the retries of an older NOTIFY.
+func (t *Transfer) notified(zone, addr string, serial uint32) <-chan struct{} {
+	if serial != 0 {
+		notifySerial.WithLabelValues(zone, addr).Set(float64(serial))
+	}
+	if t.pending == nil {
+		return nil
+	}
+	t.pending.Lock()
+	defer t.pending.Unlock()
+	k := [2]string{zone, addr}
+	if cancel, ok := t.pending.m[k]; ok {
+		close(cancel)
+	}
+	cancel := make(chan struct{})
+	t.pending.m[k] = cancel
+	return cancel
+}
+
+// acked records that addr acknowledged the NOTIFY for serial.
+func (t *Transfer) acked(zone, addr string, serial uint32) {
+	if serial != 0 {
+		notifyAckedSerial.WithLabelValues(zone, addr).Set(float64(serial))
+	}
+}
+
+// transferred records the serial of zone transferred to ip, if transfers are tracked for x.
+func (x *xfr) transferred(zone, ip string, serial uint32) {
+	if !x.track {
+		return
+	}
+	for _, to := range x.to {
+		if host, _, err := net.SplitHostPort(to); err == nil && host == ip {
+			transferSerial.WithLabelValues(zone, to).Set(float64(serial))
+		}
+	}
+}
+
+// soa returns the current SOA of zone, as seen by the first Transferer that is authoritative for it.
+func (t *Transfer) soa(zone string) (uint32, *dns.SOA) {
+	for _, p := range t.Transferers {
+		if s, ok := p.(SOAer); ok {
+			soa, err := s.SOA(zone)
+			if err == ErrNotAuthoritative {
+				continue
+			}
+			if err != nil || soa == nil {
+				return 0, nil
+			}
+			return soa.Serial, soa
+		}
+
+		ch, err := p.Transfer(zone, 0)
+		if err == ErrNotAuthoritative {
 			continue
 		}
-		code = ret.Rcode
-		if code == dns.RcodeSuccess {
-			return nil
+		if err != nil {
+			return 0, nil
+		}
+		var soa *dns.SOA
+		if rrs, ok := <-ch; ok && len(rrs) > 0 {
+			soa, _ = rrs[0].(*dns.SOA)
 		}
+		// Drain the channel so the Transferer can finish.
+		go func() {
+			for range ch {
+			}
+		}()
+		if soa == nil {
+			return 0, nil
+		}
+		return soa.Serial, soa
 	}
+	return 0, nil
+}
+
+func sendNotify(c *dns.Client, m *dns.Msg, s string) error {
+	zone := m.Question[0].Name
+	notifySentCount.WithLabelValues(zone, s).Inc()
+
+	ret, _, err := c.Exchange(m, s)
 	if err != nil {
-		return fmt.Errorf("notify for zone %q was not accepted by %q: %q", m.Question[0].Name, s, err)
+		return fmt.Errorf("notify for zone %q was not accepted by %q: %q", zone, s, err)
+	}
+	if ret.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("notify for zone %q was not accepted by %q: rcode was %q", zone, s, rcode.ToString(ret.Rcode))
 	}
-	return fmt.Errorf("notify for zone %q was not accepted by %q: rcode was %q", m.Question[0].Name, s, rcode.ToString(code))
+	return nil
 }
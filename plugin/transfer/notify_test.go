@@ -0,0 +1,83 @@
+package transfer
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestNotifyRetry(t *testing.T) {
+	notifyBackoff = 10 * time.Millisecond
+
+	var count int32
+	acked := make(chan *dns.Msg, 1)
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		// Fail the first NOTIFY, so it needs to be retried.
+		if atomic.AddInt32(&count, 1) == 1 {
+			m.Rcode = dns.RcodeServerFailure
+		} else {
+			acked <- r
+		}
+		w.WriteMsg(m)
+	})
+	defer s.Close()
+
+	tr := newTestTransfer()
+	tr.pending = newPending()
+	tr.stop = make(chan struct{})
+	defer close(tr.stop)
+	tr.xfrs[1].to = []string{s.Addr}
+	tr.xfrs[1].notifyRetry = time.Minute
+
+	if err := tr.Notify("example.com."); err == nil {
+		t.Fatal("Expected an error for the first NOTIFY")
+	}
+
+	select {
+	case r := <-acked:
+		if len(r.Answer) != 1 {
+			t.Fatalf("Expected the SOA in the NOTIFY, got %d records", len(r.Answer))
+		}
+		if soa, ok := r.Answer[0].(*dns.SOA); !ok || soa.Serial != 12345 {
+			t.Errorf("Expected SOA with serial %d in the NOTIFY, got %s", 12345, r.Answer[0])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the NOTIFY to be retried")
+	}
+}
+
+// soaPlugin is a Transferer that returns its SOA without transferring the zone.
+type soaPlugin struct {
+	zone string
+}
+
+func (p soaPlugin) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	return nil, errors.New("transfer should not be used to get the SOA")
+}
+
+func (p soaPlugin) SOA(zone string) (*dns.SOA, error) {
+	if zone != p.zone {
+		return nil, ErrNotAuthoritative
+	}
+	return test.SOA(zone + " 3600 IN SOA ns." + zone + " hostmaster." + zone + " 42 7200 3600 1209600 3600"), nil
+}
+
+func TestNotifySOAer(t *testing.T) {
+	tr := &Transfer{Transferers: []Transferer{soaPlugin{zone: "example.net."}, soaPlugin{zone: "example.org."}}}
+
+	serial, soa := tr.soa("example.org.")
+	if serial != 42 || soa == nil || soa.Header().Name != "example.org." {
+		t.Errorf("Expected SOA of example.org. with serial 42, got %d %v", serial, soa)
+	}
+	if serial, soa := tr.soa("example.com."); serial != 0 || soa != nil {
+		t.Errorf("Expected no SOA for example.com., got %d %v", serial, soa)
+	}
+}
@@ -1,6 +1,8 @@
 package transfer
 
 import (
+	"time"
+
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -22,6 +24,11 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("transfer", err)
 	}
 
+	c.OnShutdown(func() error {
+		close(t.stop)
+		return nil
+	})
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		t.Next = next
 		return t
@@ -46,9 +53,9 @@ func setup(c *caddy.Controller) error {
 }
 
 func parseTransfer(c *caddy.Controller) (*Transfer, error) {
-	t := &Transfer{}
+	t := &Transfer{pending: newPending(), stop: make(chan struct{})}
 	for c.Next() {
-		x := &xfr{}
+		x := &xfr{notifyRetry: defaultNotifyRetry}
 		x.Zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
 		for c.NextBlock() {
 			switch c.Val() {
@@ -68,6 +75,23 @@ func parseTransfer(c *caddy.Controller) (*Transfer, error) {
 					}
 					x.to = append(x.to, normalized)
 				}
+			case "notify_retry":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil {
+					return nil, plugin.Error("transfer", c.Errf("invalid duration %q", c.Val()))
+				}
+				if d < 0 {
+					return nil, plugin.Error("transfer", c.Errf("notify_retry can not be negative: %s", d))
+				}
+				x.notifyRetry = d
+			case "track_transfers":
+				if c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				x.track = true
 			default:
 				return nil, plugin.Error("transfer", c.Errf("unknown property %q", c.Val()))
 			}
@@ -2,6 +2,7 @@ package transfer
 
 import (
 	"testing"
+	"time"
 
 	"github.com/coredns/caddy"
 )
@@ -23,11 +24,29 @@ func TestParse(t *testing.T) {
 			false,
 			&Transfer{
 				xfrs: []*xfr{{
-					Zones: []string{"example.net.", "example.org."},
-					to:    []string{"1.2.3.4:53", "5.6.7.8:1053", "[1::2]:34"},
+					Zones:       []string{"example.net.", "example.org."},
+					to:          []string{"1.2.3.4:53", "5.6.7.8:1053", "[1::2]:34"},
+					notifyRetry: defaultNotifyRetry,
 				}, {
-					Zones: []string{"example.com.", "example.edu."},
-					to:    []string{"*", "1.2.3.4:53"},
+					Zones:       []string{"example.com.", "example.edu."},
+					to:          []string{"*", "1.2.3.4:53"},
+					notifyRetry: defaultNotifyRetry,
+				}},
+			},
+		},
+		{`transfer example.org {
+			to 1.2.3.4
+			notify_retry 30s
+			track_transfers
+		 }`,
+			nil,
+			false,
+			&Transfer{
+				xfrs: []*xfr{{
+					Zones:       []string{"example.org."},
+					to:          []string{"1.2.3.4:53"},
+					notifyRetry: 30 * time.Second,
+					track:       true,
 				}},
 			},
 		},
@@ -54,6 +73,22 @@ func TestParse(t *testing.T) {
 			true,
 			nil,
 		},
+		{`transfer example.org {
+			to 1.2.3.4
+			notify_retry -1s
+		 }`,
+			nil,
+			true,
+			nil,
+		},
+		{`transfer example.org {
+			to 1.2.3.4
+			track_transfers yes
+		 }`,
+			nil,
+			true,
+			nil,
+		},
 		{
 			`transfer {
 			to 1.2.3.4 5.6.7.8:1053 [1::2]:34
@@ -62,8 +97,9 @@ func TestParse(t *testing.T) {
 			false,
 			&Transfer{
 				xfrs: []*xfr{{
-					Zones: []string{"."},
-					to:    []string{"1.2.3.4:53", "5.6.7.8:1053", "[1::2]:34"},
+					Zones:       []string{"."},
+					to:          []string{"1.2.3.4:53", "5.6.7.8:1053", "[1::2]:34"},
+					notifyRetry: defaultNotifyRetry,
 				}},
 			},
 		},
@@ -100,6 +136,12 @@ func TestParse(t *testing.T) {
 					t.Errorf("Test %d expected zone %v, got %v", i, tc.exp.xfrs[j].Zones[k], zone)
 				}
 			}
+			if x.notifyRetry != tc.exp.xfrs[j].notifyRetry {
+				t.Errorf("Test %d expected notify_retry %s, got %s", i, tc.exp.xfrs[j].notifyRetry, x.notifyRetry)
+			}
+			if x.track != tc.exp.xfrs[j].track {
+				t.Errorf("Test %d expected track_transfers %t, got %t", i, tc.exp.xfrs[j].track, x.track)
+			}
 			// Check to
 			if len(tc.exp.xfrs[j].to) != len(x.to) {
 				t.Fatalf("Test %d expected %d 'to' values, got %d", i, len(tc.exp.xfrs[i].to), len(x.to))
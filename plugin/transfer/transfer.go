@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net"
+	"time"
 
 	"github.com/coredns/coredns/plugin"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
@@ -20,11 +21,16 @@ type Transfer struct {
 	xfrs        []*xfr
 	tsigSecret  map[string]string
 	Next        plugin.Handler
+
+	pending *pending      // NOTIFYs that are being retried
+	stop    chan struct{} // closed on shutdown, stops the NOTIFY retries
 }
 
 type xfr struct {
-	Zones []string
-	to    []string
+	Zones       []string
+	to          []string
+	notifyRetry time.Duration // how long to retry unacknowledged NOTIFYs
+	track       bool          // track the serials transferred to the secondaries in to
 }
 
 // Transferer may be implemented by plugins to enable zone transfers
@@ -47,6 +53,14 @@ type Transferer interface {
 	Transfer(zone string, serial uint32) (<-chan []dns.RR, error)
 }
 
+// SOAer may be implemented by Transferers that can return the current SOA of a zone without generating a
+// transfer. It is used to get the SOA for outgoing NOTIFYs; for Transferers that don't implement it the SOA is
+// taken from the start of a transfer. SOA returns transfer.ErrNotAuthoritative when the plugin is not
+// authoritative for the zone.
+type SOAer interface {
+	SOA(zone string) (*dns.SOA, error)
+}
+
 var (
 	// ErrNotAuthoritative is returned by Transfer() when the plugin is not authoritative for the zone.
 	ErrNotAuthoritative = errors.New("not authoritative for zone")
@@ -157,6 +171,7 @@ func (t *Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		w.WriteMsg(m)
 
 		log.Infof("Outgoing noop, incremental transfer for up to date zone %q to %s for %d SOA serial", state.QName(), state.IP(), soa.Serial)
+		x.transferred(state.QName(), state.IP(), soa.Serial)
 		return 0, nil
 	}
 
@@ -180,6 +195,9 @@ func (t *Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		logserial = soa.Serial
 	}
 	log.Infof("Outgoing transfer of %d records of zone %q to %s for %d SOA serial", l, state.QName(), state.IP(), logserial)
+	if soa != nil {
+		x.transferred(state.QName(), state.IP(), soa.Serial)
+	}
 	return 0, nil
 }
 
@@ -226,6 +226,36 @@ func TestServeDNSTsigErrors(t *testing.T) {
 	}
 }
 
+func TestServeDNSUpdateKeepsTsig(t *testing.T) {
+	for _, opcode := range []int{dns.OpcodeQuery, dns.OpcodeUpdate} {
+		var seen bool
+		tsig := TSIGServer{
+			Zones: []string{"."},
+			all:   true,
+			Next: test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				seen = r.IsTsig() != nil
+				m := new(dns.Msg)
+				m.SetReply(r)
+				w.WriteMsg(m)
+				return dns.RcodeSuccess, nil
+			}),
+		}
+
+		r := new(dns.Msg)
+		r.SetQuestion("example.", dns.TypeSOA)
+		r.Opcode = opcode
+		r.SetTsig("test.key.", dns.HmacSHA256, 300, time.Now().Unix())
+
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := tsig.ServeDNS(context.TODO(), w, r); err != nil {
+			t.Fatal(err)
+		}
+		if expected := opcode == dns.OpcodeUpdate; seen != expected {
+			t.Errorf("Opcode %s: expected TSIG to be seen by the next plugin to be %t", dns.OpcodeToString[opcode], expected)
+		}
+	}
+}
+
 func testHandler() test.HandlerFunc {
 	return func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 		state := request.Request{W: w, Req: r}
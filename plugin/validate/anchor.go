@@ -0,0 +1,362 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// keyState is the RFC 5011 state of a trust anchor key.
+type keyState int
+
+const (
+	stateValid keyState = iota
+	stateAddPend
+	stateMissing
+	stateRevoked
+)
+
+var stateToString = map[keyState]string{
+	stateValid:   "valid",
+	stateAddPend: "addpend",
+	stateMissing: "missing",
+	stateRevoked: "revoked",
+}
+
+func (s keyState) String() string { return stateToString[s] }
+
+// trackedKey is a DNSKEY trust anchor together with its RFC 5011 state.
+type trackedKey struct {
+	key     *dns.DNSKEY
+	state   keyState
+	changed time.Time
+}
+
+// anchor holds the trust anchors of a single zone.
+type anchor struct {
+	ds   []*dns.DS
+	keys []*trackedKey
+	auto bool // when true the keys are updated following RFC 5011
+}
+
+// trustAnchors holds all configured trust anchors.
+type trustAnchors struct {
+	sync.RWMutex
+	zones    map[string]*anchor
+	file     string        // file RFC 5011 updates are written to, empty if there is none
+	holdDown time.Duration // add hold-down time, RFC 5011, Section 2.4.1
+}
+
+const (
+	defaultHoldDown = 30 * 24 * time.Hour
+	minRefresh      = 1 * time.Hour
+	maxRefresh      = 15 * 24 * time.Hour
+)
+
+// rootAnchors are the DS records for the root KSKs published by IANA (KSK-2017 and KSK-2024).
+var rootAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+func newTrustAnchors() *trustAnchors {
+	return &trustAnchors{zones: make(map[string]*anchor), holdDown: defaultHoldDown}
+}
+
+// add adds a DS or DNSKEY record as a trust anchor.
+func (t *trustAnchors) add(rr dns.RR, state keyState, changed time.Time, auto bool) error {
+	t.Lock()
+	defer t.Unlock()
+
+	zone := strings.ToLower(rr.Header().Name)
+	a, ok := t.zones[zone]
+	if !ok {
+		a = &anchor{}
+		t.zones[zone] = a
+	}
+	a.auto = a.auto || auto
+
+	switch x := rr.(type) {
+	case *dns.DS:
+		a.ds = append(a.ds, x)
+	case *dns.DNSKEY:
+		if x.Flags&dns.ZONE == 0 {
+			return fmt.Errorf("key %d for %s has no zone key bit set", x.KeyTag(), x.Hdr.Name)
+		}
+		a.keys = append(a.keys, &trackedKey{key: x, state: state, changed: changed})
+	default:
+		return fmt.Errorf("trust anchor must be DS or DNSKEY, got %s", dns.TypeToString[rr.Header().Rrtype])
+	}
+	return nil
+}
+
+// closest returns the closest trust anchor at or above name, or the empty string if there is none.
+func (t *trustAnchors) closest(name string) string {
+	t.RLock()
+	defer t.RUnlock()
+
+	for {
+		if _, ok := t.zones[name]; ok {
+			return name
+		}
+		i, end := dns.NextLabel(name, 0)
+		if end {
+			return ""
+		}
+		name = name[i:]
+	}
+}
+
+// trusted returns true if k is a trust anchor for zone.
+func (t *trustAnchors) trusted(zone string, k *dns.DNSKEY) bool {
+	t.RLock()
+	defer t.RUnlock()
+
+	a, ok := t.zones[zone]
+	if !ok || k.Flags&dns.REVOKE != 0 {
+		return false
+	}
+	valid := false
+	for _, tk := range a.keys {
+		if tk.state != stateValid && tk.state != stateMissing {
+			continue
+		}
+		valid = true
+		if sameKey(tk.key, k) {
+			return true
+		}
+	}
+	// Once we have valid keys, the DS records have served their purpose and are no longer used. This makes sure
+	// a revocation can't be undone by the DS.
+	if valid && a.auto {
+		return false
+	}
+	for _, d := range a.ds {
+		if matchDS(k, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// update runs the RFC 5011 state machine for zone with the validated DNSKEY set and writes the new state to disk
+// when something changed.
+func (t *trustAnchors) update(zone string, set []dns.RR, sigs []*dns.RRSIG, now time.Time) {
+	t.Lock()
+	a, ok := t.zones[zone]
+	if !ok || !a.auto {
+		t.Unlock()
+		return
+	}
+
+	changed := false
+	seen := map[*trackedKey]bool{}
+	for _, k := range dnskeys(set) {
+		if k.Flags&dns.SEP == 0 {
+			continue
+		}
+		tk := a.find(k)
+		if k.Flags&dns.REVOKE != 0 {
+			if tk == nil {
+				continue
+			}
+			seen[tk] = true
+			// RFC 5011, Section 2.1: a revoked key must have signed the DNSKEY RRset itself.
+			if tk.state != stateRevoked && verifyRRset(set, sigs, []*dns.DNSKEY{k}, now) == nil {
+				log.Infof("Trust anchor %d for %s has been revoked", tk.key.KeyTag(), zone)
+				tk.key, tk.state, tk.changed = k, stateRevoked, now
+				changed = true
+			}
+			continue
+		}
+
+		if tk == nil {
+			tk = &trackedKey{key: k, state: stateAddPend, changed: now}
+			// A key matching a configured DS record is trusted right away, this is how we bootstrap.
+			for _, d := range a.ds {
+				if matchDS(k, d) {
+					tk.state = stateValid
+				}
+			}
+			log.Infof("New key %d for %s, state %s", k.KeyTag(), zone, tk.state)
+			a.keys = append(a.keys, tk)
+			seen[tk] = true
+			changed = true
+			continue
+		}
+
+		seen[tk] = true
+		switch tk.state {
+		case stateAddPend:
+			if now.Sub(tk.changed) >= t.holdDown {
+				log.Infof("Key %d for %s is now a trust anchor", k.KeyTag(), zone)
+				tk.state, tk.changed = stateValid, now
+				changed = true
+			}
+		case stateMissing:
+			tk.state, tk.changed = stateValid, now
+			changed = true
+		}
+	}
+
+	keys := a.keys[:0]
+	for _, tk := range a.keys {
+		if !seen[tk] {
+			switch tk.state {
+			case stateValid:
+				tk.state, tk.changed = stateMissing, now
+				changed = true
+			case stateAddPend:
+				changed = true
+				continue
+			case stateRevoked:
+				if now.Sub(tk.changed) >= t.holdDown {
+					changed = true
+					continue
+				}
+			}
+		}
+		keys = append(keys, tk)
+	}
+	a.keys = keys
+	t.Unlock()
+
+	if !changed || t.file == "" {
+		return
+	}
+	if err := t.save(); err != nil {
+		log.Errorf("Failed to write trust anchors to %q: %s", t.file, err)
+	}
+}
+
+// find returns the tracked key that is the same key as k, ignoring the revoke bit.
+func (a *anchor) find(k *dns.DNSKEY) *trackedKey {
+	for _, tk := range a.keys {
+		if sameKey(tk.key, k) {
+			return tk
+		}
+	}
+	return nil
+}
+
+// sameKey returns true if a and b are the same key, ignoring the revoke bit.
+func sameKey(a, b *dns.DNSKEY) bool {
+	return a.Algorithm == b.Algorithm && a.Protocol == b.Protocol &&
+		a.Flags|dns.REVOKE == b.Flags|dns.REVOKE && a.PublicKey == b.PublicKey
+}
+
+// save writes the automatically updated trust anchors to t.file.
+func (t *trustAnchors) save() error {
+	t.RLock()
+	zones := make([]string, 0, len(t.zones))
+	for z, a := range t.zones {
+		if a.auto {
+			zones = append(zones, z)
+		}
+	}
+	sort.Strings(zones)
+
+	b := &strings.Builder{}
+	b.WriteString("; Trust anchors maintained following RFC 5011, do not edit while CoreDNS is running.\n")
+	for _, z := range zones {
+		a := t.zones[z]
+		if len(a.keys) == 0 {
+			for _, d := range a.ds {
+				fmt.Fprintln(b, d.String())
+			}
+			continue
+		}
+		for _, tk := range a.keys {
+			fmt.Fprintf(b, "%s ;state=%s;changed=%s\n", tk.key.String(), tk.state, tk.changed.UTC().Format(time.RFC3339))
+		}
+	}
+	t.RUnlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(t.file), filepath.Base(t.file)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), t.file)
+}
+
+// load reads the trust anchors from file. DNSKEY records may carry their RFC 5011 state in a comment, keys without
+// one are considered valid.
+func (t *trustAnchors) load(file string, auto bool) error {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zp := dns.NewZoneParser(f, ".", file)
+	zp.SetIncludeAllowed(false)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		state, changed, err := parseState(zp.Comment())
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+		if err := t.add(rr, state, changed, auto); err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+	}
+	return zp.Err()
+}
+
+// parseState parses a comment of the form ";state=valid;changed=2006-01-02T15:04:05Z".
+func parseState(comment string) (keyState, time.Time, error) {
+	state, changed := stateValid, time.Time{}
+	for _, f := range strings.Split(comment, ";") {
+		kv := strings.SplitN(strings.TrimSpace(f), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "state":
+			found := false
+			for s, str := range stateToString {
+				if str == kv[1] {
+					state, found = s, true
+				}
+			}
+			if !found {
+				return state, changed, fmt.Errorf("unknown trust anchor state %q", kv[1])
+			}
+		case "changed":
+			t, err := time.Parse(time.RFC3339, kv[1])
+			if err != nil {
+				return state, changed, err
+			}
+			changed = t
+		}
+	}
+	return state, changed, nil
+}
+
+// autoZones returns the zones with automatically updated trust anchors.
+func (t *trustAnchors) autoZones() []string {
+	t.RLock()
+	defer t.RUnlock()
+	zones := []string{}
+	for z, a := range t.zones {
+		if a.auto {
+			zones = append(zones, z)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
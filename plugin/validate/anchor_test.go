@@ -0,0 +1,112 @@
+package validate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestRFC5011(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "org.keys")
+	org := newTestZone(t, "org.")
+	next := newTestZone(t, "org.")
+
+	ta := newTrustAnchors()
+	ta.file = file
+	ta.holdDown = 2 * time.Hour
+	ta.add(org.key.ToDS(dns.SHA256), stateValid, time.Time{}, true)
+
+	now := time.Now()
+	set := []dns.RR{org.key, next.key}
+	sigs := rrsigs(org.sign(t, set...))
+
+	// The key from the DS is trusted right away, the new one is pending.
+	ta.update("org.", set, sigs, now)
+	if !ta.trusted("org.", org.key) {
+		t.Error("Expected the key matching the DS to be trusted")
+	}
+	if ta.trusted("org.", next.key) {
+		t.Error("Expected the new key to not be trusted before the hold-down time")
+	}
+
+	ta.update("org.", set, sigs, now.Add(3*time.Hour))
+	if !ta.trusted("org.", next.key) {
+		t.Error("Expected the new key to be trusted after the hold-down time")
+	}
+
+	// Revoke the old key, this needs to be self-signed.
+	revoked := dns.Copy(org.key).(*dns.DNSKEY)
+	revoked.Flags |= dns.REVOKE
+	org.key = revoked
+	set = []dns.RR{revoked, next.key}
+	sigs = rrsigs(org.sign(t, set...))
+	ta.update("org.", set, sigs, now.Add(4*time.Hour))
+
+	unrevoked := dns.Copy(revoked).(*dns.DNSKEY)
+	unrevoked.Flags &^= dns.REVOKE
+	if ta.trusted("org.", unrevoked) {
+		t.Error("Expected the revoked key to not be trusted")
+	}
+
+	// Load the saved state and check it survived.
+	loaded := newTrustAnchors()
+	if err := loaded.load(file, true); err != nil {
+		t.Fatalf("Failed to load trust anchors: %s", err)
+	}
+	if loaded.trusted("org.", unrevoked) {
+		t.Error("Expected the revoked key to not be trusted after loading")
+	}
+	if !loaded.trusted("org.", next.key) {
+		t.Error("Expected the new key to be trusted after loading")
+	}
+	tk := loaded.zones["org."].find(unrevoked)
+	if tk == nil || tk.state != stateRevoked {
+		t.Errorf("Expected the revoked key to be loaded in state %s", stateRevoked)
+	}
+
+	// The new key goes missing, it is still trusted.
+	set = []dns.RR{revoked}
+	ta.update("org.", set, rrsigs(org.sign(t, set...)), now.Add(5*time.Hour))
+	if tk := ta.zones["org."].find(next.key); tk == nil || tk.state != stateMissing {
+		t.Errorf("Expected the new key to be in state %s", stateMissing)
+	}
+	if !ta.trusted("org.", next.key) {
+		t.Error("Expected the missing key to be trusted")
+	}
+}
+
+func TestParseState(t *testing.T) {
+	tests := []struct {
+		comment   string
+		state     keyState
+		shouldErr bool
+	}{
+		{"", stateValid, false},
+		{";state=addpend;changed=2024-01-02T03:04:05Z", stateAddPend, false},
+		{"; state=revoked", stateRevoked, false},
+		{";state=bogus", stateValid, true},
+		{";changed=yesterday", stateValid, true},
+	}
+	for i, tc := range tests {
+		state, _, err := parseState(tc.comment)
+		if tc.shouldErr != (err != nil) {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.shouldErr, err)
+			continue
+		}
+		if state != tc.state {
+			t.Errorf("Test %d: expected state %s, got %s", i, tc.state, state)
+		}
+	}
+}
+
+func rrsigs(rrs []dns.RR) []*dns.RRSIG {
+	sigs := []*dns.RRSIG{}
+	for _, rr := range rrs {
+		if s, ok := rr.(*dns.RRSIG); ok {
+			sigs = append(sigs, s)
+		}
+	}
+	return sigs
+}
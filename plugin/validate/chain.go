@@ -0,0 +1,305 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// security is the outcome of validating (part of) a response.
+type security int
+
+const (
+	indeterminate security = iota
+	secure
+	insecure
+	bogus
+)
+
+func (s security) String() string {
+	switch s {
+	case secure:
+		return "secure"
+	case insecure:
+		return "insecure"
+	case bogus:
+		return "bogus"
+	}
+	return "indeterminate"
+}
+
+// bogusError is returned when validation fails, code is the extended DNS error code (RFC 8914) we return to the
+// client.
+type bogusError struct {
+	code uint16
+	msg  string
+}
+
+func (b *bogusError) Error() string { return b.msg }
+
+func bogusf(code uint16, format string, a ...interface{}) error {
+	return &bogusError{code: code, msg: fmt.Sprintf(format, a...)}
+}
+
+// step records what we learned about a single name while walking down from a trust anchor.
+type step struct {
+	kind   stepKind
+	keys   []*dns.DNSKEY // validated keys, only set for a secure zone cut
+	expire time.Time
+}
+
+type stepKind int
+
+const (
+	stepNoCut    stepKind = iota // name is not a zone cut, continue with the current keys
+	stepSecure                   // name is a secure zone cut, keys holds its validated DNSKEYs
+	stepInsecure                 // name is a provably insecure delegation
+	stepNoName                   // name does not exist
+)
+
+const (
+	maxStepTTL = 1 * time.Hour
+	maxDepth   = 32
+	maxSteps   = 10000
+)
+
+// chase walks the chain of trust from the closest trust anchor down to name. It returns the deepest secure zone at
+// or above name and its validated keys. When an insecure delegation is found on the way, insecure is returned. If
+// there is no trust anchor for name, the result is indeterminate.
+func (v *Validate) chase(ctx context.Context, w dns.ResponseWriter, name string) (string, []*dns.DNSKEY, security, error) {
+	name = strings.ToLower(dns.Fqdn(name))
+	anchor := v.anchors.closest(name)
+	if anchor == "" {
+		return "", nil, indeterminate, nil
+	}
+
+	keys, err := v.anchorKeys(ctx, w, anchor)
+	if err != nil {
+		return "", nil, bogus, err
+	}
+
+	zone := anchor
+	labels := dns.Split(name)
+	start := len(labels) - dns.CountLabel(anchor)
+	if start > maxDepth {
+		return "", nil, bogus, bogusf(dns.ExtendedErrorCodeDNSBogus, "too many labels between %s and %s", anchor, name)
+	}
+	for i := start - 1; i >= 0; i-- {
+		child := name[labels[i]:]
+		s, err := v.step(ctx, w, zone, keys, child)
+		if err != nil {
+			return "", nil, bogus, err
+		}
+		switch s.kind {
+		case stepInsecure:
+			return "", nil, insecure, nil
+		case stepNoName:
+			return zone, keys, secure, nil
+		case stepSecure:
+			zone, keys = child, s.keys
+		}
+	}
+	return zone, keys, secure, nil
+}
+
+// step looks up the DS records for child, which lives in zone that is signed with keys.
+func (v *Validate) step(ctx context.Context, w dns.ResponseWriter, zone string, keys []*dns.DNSKEY, child string) (step, error) {
+	now := v.now()
+	v.mu.Lock()
+	s, ok := v.steps[child]
+	v.mu.Unlock()
+	if ok && now.Before(s.expire) {
+		return s, nil
+	}
+
+	s, err := v.fetchStep(ctx, w, zone, keys, child)
+	if err != nil {
+		return s, err
+	}
+
+	v.mu.Lock()
+	if len(v.steps) >= maxSteps {
+		v.steps = make(map[string]step)
+	}
+	v.steps[child] = s
+	v.mu.Unlock()
+	return s, nil
+}
+
+func (v *Validate) fetchStep(ctx context.Context, w dns.ResponseWriter, zone string, keys []*dns.DNSKEY, child string) (step, error) {
+	now := v.now()
+	m, err := v.lookup(ctx, w, child, dns.TypeDS)
+	if err != nil {
+		return step{}, bogusf(dns.ExtendedErrorCodeNetworkError, "failed to lookup DS for %s: %s", child, err)
+	}
+
+	ds, dsSigs := rrset(m.Answer, child, dns.TypeDS)
+	if len(ds) > 0 {
+		if err := verifyRRset(ds, dsSigs, keys, now); err != nil {
+			return step{}, err
+		}
+		ttl := ttlOf(ds, dsSigs, now)
+		return v.fetchKeys(ctx, w, child, ds, ttl)
+	}
+
+	// No DS records, we need a proof of their absence, signed by zone.
+	if m.Rcode != dns.RcodeSuccess && m.Rcode != dns.RcodeNameError {
+		return step{}, bogusf(dns.ExtendedErrorCodeNetworkError, "failed to lookup DS for %s: %s", child, dns.RcodeToString[m.Rcode])
+	}
+	if err := verifySection(m.Ns, keys, now); err != nil {
+		return step{}, err
+	}
+	ttl := ttlOf(m.Ns, nil, now)
+
+	nsec, nsec3 := denials(m.Ns)
+	if m.Rcode == dns.RcodeNameError {
+		if !provesNameError(child, nsec, nsec3) {
+			return step{}, bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof that %s does not exist", child)
+		}
+		return step{kind: stepNoName, expire: now.Add(ttl)}, nil
+	}
+
+	kind, ok := dsAbsence(child, nsec, nsec3)
+	if !ok {
+		return step{}, bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof that %s has no DS records", child)
+	}
+	return step{kind: kind, expire: now.Add(ttl)}, nil
+}
+
+// fetchKeys retrieves the DNSKEYs for zone and checks them against the already validated DS records.
+func (v *Validate) fetchKeys(ctx context.Context, w dns.ResponseWriter, zone string, ds []dns.RR, ttl time.Duration) (step, error) {
+	now := v.now()
+
+	supported := false
+	for _, rr := range ds {
+		d := rr.(*dns.DS)
+		if algorithmSupported(d.Algorithm) && digestSupported(d.DigestType) {
+			supported = true
+			break
+		}
+	}
+	// RFC 4035, Section 5.2: if none of the DS records can be used, the zone is treated as insecure.
+	if !supported {
+		return step{kind: stepInsecure, expire: now.Add(ttl)}, nil
+	}
+
+	m, err := v.lookup(ctx, w, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return step{}, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "failed to lookup DNSKEY for %s: %s", zone, err)
+	}
+	set, sigs := rrset(m.Answer, zone, dns.TypeDNSKEY)
+	if len(set) == 0 {
+		return step{}, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY records for %s", zone)
+	}
+
+	trusted := []*dns.DNSKEY{}
+	for _, rr := range set {
+		k := rr.(*dns.DNSKEY)
+		for _, r := range ds {
+			if matchDS(k, r.(*dns.DS)) {
+				trusted = append(trusted, k)
+				break
+			}
+		}
+	}
+	if len(trusted) == 0 {
+		return step{}, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY for %s matches its DS records", zone)
+	}
+	if err := verifyRRset(set, sigs, trusted, now); err != nil {
+		return step{}, err
+	}
+
+	if t := ttlOf(set, sigs, now); t < ttl {
+		ttl = t
+	}
+	return step{kind: stepSecure, keys: dnskeys(set), expire: now.Add(ttl)}, nil
+}
+
+// anchorKeys returns the validated DNSKEYs of the trust anchor zone.
+func (v *Validate) anchorKeys(ctx context.Context, w dns.ResponseWriter, zone string) ([]*dns.DNSKEY, error) {
+	now := v.now()
+	v.mu.Lock()
+	s, ok := v.steps[zone]
+	v.mu.Unlock()
+	if ok && now.Before(s.expire) {
+		return s.keys, nil
+	}
+
+	m, err := v.lookup(ctx, w, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "failed to lookup DNSKEY for trust anchor %s: %s", zone, err)
+	}
+	set, sigs := rrset(m.Answer, zone, dns.TypeDNSKEY)
+	if len(set) == 0 {
+		return nil, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY records for trust anchor %s", zone)
+	}
+
+	trusted := []*dns.DNSKEY{}
+	for _, rr := range set {
+		if k := rr.(*dns.DNSKEY); v.anchors.trusted(zone, k) {
+			trusted = append(trusted, k)
+		}
+	}
+	if len(trusted) == 0 {
+		return nil, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY for %s matches the trust anchor", zone)
+	}
+	if err := verifyRRset(set, sigs, trusted, now); err != nil {
+		return nil, err
+	}
+
+	v.anchors.update(zone, set, sigs, now)
+
+	keys := dnskeys(set)
+	v.mu.Lock()
+	v.steps[zone] = step{kind: stepSecure, keys: keys, expire: now.Add(ttlOf(set, sigs, now))}
+	v.mu.Unlock()
+	return keys, nil
+}
+
+// rrset returns the records of type qtype owned by name from rrs, and the signatures covering them.
+func rrset(rrs []dns.RR, name string, qtype uint16) ([]dns.RR, []*dns.RRSIG) {
+	set := []dns.RR{}
+	sigs := []*dns.RRSIG{}
+	for _, rr := range rrs {
+		if !strings.EqualFold(rr.Header().Name, name) {
+			continue
+		}
+		if rr.Header().Rrtype == qtype {
+			set = append(set, rr)
+			continue
+		}
+		if s, ok := rr.(*dns.RRSIG); ok && s.TypeCovered == qtype {
+			sigs = append(sigs, s)
+		}
+	}
+	return set, sigs
+}
+
+func dnskeys(rrs []dns.RR) []*dns.DNSKEY {
+	keys := make([]*dns.DNSKEY, 0, len(rrs))
+	for _, rr := range rrs {
+		if k, ok := rr.(*dns.DNSKEY); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// ttlOf returns the smallest TTL in rrs, capped to the expiration of the signatures and to maxStepTTL.
+func ttlOf(rrs []dns.RR, sigs []*dns.RRSIG, now time.Time) time.Duration {
+	ttl := maxStepTTL
+	for _, rr := range rrs {
+		if t := time.Duration(rr.Header().Ttl) * time.Second; t < ttl {
+			ttl = t
+		}
+	}
+	for _, s := range sigs {
+		if t := time.Unix(int64(s.Expiration), 0).Sub(now); t < ttl {
+			ttl = t
+		}
+	}
+	return ttl
+}
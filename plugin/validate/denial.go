@@ -0,0 +1,214 @@
+package validate
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// denials returns the NSEC and NSEC3 records from rrs.
+func denials(rrs []dns.RR) ([]*dns.NSEC, []*dns.NSEC3) {
+	nsec := []*dns.NSEC{}
+	nsec3 := []*dns.NSEC3{}
+	for _, rr := range rrs {
+		switch x := rr.(type) {
+		case *dns.NSEC:
+			nsec = append(nsec, x)
+		case *dns.NSEC3:
+			nsec3 = append(nsec3, x)
+		}
+	}
+	return nsec, nsec3
+}
+
+// provesNameError returns true if the NSEC or NSEC3 records prove that name and a wildcard that could have matched
+// it do not exist.
+func provesNameError(name string, nsec []*dns.NSEC, nsec3 []*dns.NSEC3) bool {
+	for _, n := range nsec {
+		if !nsecCovers(n, name) {
+			continue
+		}
+		ce := closestEncloser(name, n)
+		wildcard := "*." + ce
+		for _, w := range nsec {
+			if nsecCovers(w, wildcard) {
+				return true
+			}
+		}
+	}
+
+	ce, next := nsec3ClosestEncloser(name, nsec3)
+	if ce == "" || next == "" {
+		return false
+	}
+	return nsec3Covers(next, nsec3) != nil && nsec3Covers("*."+ce, nsec3) != nil
+}
+
+// provesNoData returns true if the NSEC or NSEC3 records prove that name exists, but has no records of type qtype.
+func provesNoData(name string, qtype uint16, nsec []*dns.NSEC, nsec3 []*dns.NSEC3) bool {
+	for _, n := range nsec {
+		if strings.EqualFold(n.Hdr.Name, name) {
+			return !hasType(n.TypeBitMap, qtype) && !hasType(n.TypeBitMap, dns.TypeCNAME)
+		}
+		// Empty non-terminal, the NSEC's next name is below name.
+		if nsecCovers(n, name) && dns.IsSubDomain(name, n.NextDomain) {
+			return true
+		}
+	}
+
+	for _, n := range nsec3 {
+		if n.Match(name) {
+			return !hasType(n.TypeBitMap, qtype) && !hasType(n.TypeBitMap, dns.TypeCNAME)
+		}
+	}
+	// RFC 5155, Section 8.6: a DS query may be answered with an opt-out NSEC3 covering the next closer name.
+	if qtype == dns.TypeDS {
+		_, next := nsec3ClosestEncloser(name, nsec3)
+		if n := nsec3Covers(next, nsec3); n != nil && next != "" && n.Flags&optOut != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dsAbsence checks the proof that name has no DS records. It returns stepInsecure when name is a delegation
+// without DS records (or falls in an opt-out span) and stepNoCut when name is not a delegation at all.
+func dsAbsence(name string, nsec []*dns.NSEC, nsec3 []*dns.NSEC3) (stepKind, bool) {
+	for _, n := range nsec {
+		if strings.EqualFold(n.Hdr.Name, name) {
+			if hasType(n.TypeBitMap, dns.TypeDS) {
+				return stepNoCut, false
+			}
+			if hasType(n.TypeBitMap, dns.TypeNS) && !hasType(n.TypeBitMap, dns.TypeSOA) {
+				return stepInsecure, true
+			}
+			return stepNoCut, true
+		}
+		if nsecCovers(n, name) && dns.IsSubDomain(name, n.NextDomain) {
+			return stepNoCut, true
+		}
+	}
+
+	for _, n := range nsec3 {
+		if n.Match(name) {
+			if hasType(n.TypeBitMap, dns.TypeDS) {
+				return stepNoCut, false
+			}
+			if hasType(n.TypeBitMap, dns.TypeNS) && !hasType(n.TypeBitMap, dns.TypeSOA) {
+				return stepInsecure, true
+			}
+			return stepNoCut, true
+		}
+	}
+	_, next := nsec3ClosestEncloser(name, nsec3)
+	if n := nsec3Covers(next, nsec3); n != nil && next != "" && n.Flags&optOut != 0 {
+		return stepInsecure, true
+	}
+	return stepNoCut, false
+}
+
+// coversName returns true if the NSEC or NSEC3 records prove name does not exist. For NSEC3 the next closer name
+// next must be covered.
+func coversName(name, next string, nsec []*dns.NSEC, nsec3 []*dns.NSEC3) bool {
+	for _, n := range nsec {
+		if nsecCovers(n, name) {
+			return true
+		}
+	}
+	return nsec3Covers(next, nsec3) != nil
+}
+
+// nsecCovers returns true if name falls strictly between the owner and next name of n.
+func nsecCovers(n *dns.NSEC, name string) bool {
+	owner, next := n.Hdr.Name, n.NextDomain
+	if compareNames(owner, name) >= 0 {
+		return false
+	}
+	// The last NSEC in a zone wraps around to the apex.
+	if compareNames(next, owner) <= 0 {
+		return dns.IsSubDomain(next, name)
+	}
+	return compareNames(name, next) < 0
+}
+
+// closestEncloser returns the closest encloser of name, based on NSEC n that covers name.
+func closestEncloser(name string, n *dns.NSEC) string {
+	a := dns.CompareDomainName(name, n.Hdr.Name)
+	b := dns.CompareDomainName(name, n.NextDomain)
+	if b > a {
+		a = b
+	}
+	idx := dns.Split(name)
+	if a >= len(idx) {
+		return dns.Fqdn(name)
+	}
+	if a == 0 {
+		return "."
+	}
+	return name[idx[len(idx)-a]:]
+}
+
+// nsec3ClosestEncloser finds the closest encloser of name (RFC 5155, Section 8.3) and returns it together with the
+// next closer name.
+func nsec3ClosestEncloser(name string, nsec3 []*dns.NSEC3) (string, string) {
+	if len(nsec3) == 0 {
+		return "", ""
+	}
+	idx := dns.Split(name)
+	next := ""
+	for i := range idx {
+		candidate := name[idx[i]:]
+		for _, n := range nsec3 {
+			if n.Match(candidate) {
+				return candidate, next
+			}
+		}
+		next = candidate
+	}
+	return "", ""
+}
+
+// nsec3Covers returns the NSEC3 record that covers name, or nil if there is none.
+func nsec3Covers(name string, nsec3 []*dns.NSEC3) *dns.NSEC3 {
+	if name == "" {
+		return nil
+	}
+	for _, n := range nsec3 {
+		if n.Cover(name) {
+			return n
+		}
+	}
+	return nil
+}
+
+func hasType(bitmap []uint16, t uint16) bool {
+	for _, b := range bitmap {
+		if b == t {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNames compares a and b in canonical DNS name order (RFC 4034, Section 6.1).
+func compareNames(a, b string) int {
+	la := dns.SplitDomainName(strings.ToLower(a))
+	lb := dns.SplitDomainName(strings.ToLower(b))
+	i, j := len(la)-1, len(lb)-1
+	for i >= 0 && j >= 0 {
+		if c := strings.Compare(la[i], lb[j]); c != 0 {
+			return c
+		}
+		i--
+		j--
+	}
+	switch {
+	case i < 0 && j < 0:
+		return 0
+	case i < 0:
+		return -1
+	}
+	return 1
+}
+
+const optOut = 1
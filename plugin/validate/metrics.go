@@ -0,0 +1,25 @@
+package validate
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// responseCount is the number of validated responses per security status.
+	responseCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "validate",
+		Name:      "responses_total",
+		Help:      "Counter of validated responses per result.",
+	}, []string{"server", "result"})
+	// refreshFailureCount is the number of failed trust anchor refreshes.
+	refreshFailureCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "validate",
+		Name:      "trust_anchor_refresh_failures_total",
+		Help:      "Counter of failed trust anchor refreshes.",
+	}, []string{"zone"})
+)
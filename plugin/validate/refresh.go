@@ -0,0 +1,64 @@
+package validate
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// refresh periodically fetches the DNSKEYs of the trust anchors that are automatically updated, this is the
+// active refresh from RFC 5011, Section 2.3.
+func (v *Validate) refresh(stop <-chan struct{}) {
+	interval := minRefresh
+	for {
+		for _, z := range v.anchors.autoZones() {
+			v.mu.Lock()
+			delete(v.steps, z)
+			v.mu.Unlock()
+
+			if _, err := v.anchorKeys(context.Background(), &refreshWriter{}, z); err != nil {
+				log.Warningf("Failed to refresh trust anchor for %s: %s", z, err)
+				refreshFailureCount.WithLabelValues(z).Inc()
+				continue
+			}
+
+			v.mu.Lock()
+			s := v.steps[z]
+			v.mu.Unlock()
+			interval = refreshInterval(s.expire.Sub(v.now()))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// refreshInterval returns the active refresh interval for a DNSKEY RRset with the given TTL.
+func refreshInterval(ttl time.Duration) time.Duration {
+	d := ttl / 2
+	if d > maxRefresh {
+		d = maxRefresh
+	}
+	if d < minRefresh {
+		d = minRefresh
+	}
+	return d
+}
+
+// refreshWriter is the dns.ResponseWriter used for the queries we send on our own accord.
+type refreshWriter struct {
+	dns.ResponseWriter
+}
+
+func (r *refreshWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+}
+func (r *refreshWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+}
+func (r *refreshWriter) WriteMsg(m *dns.Msg) error { return nil }
@@ -0,0 +1,125 @@
+package validate
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/miekg/dns"
+)
+
+var log = clog.NewWithPlugin("validate")
+
+func init() { plugin.Register("validate", setup) }
+
+func setup(c *caddy.Controller) error {
+	v, err := parse(c)
+	if err != nil {
+		return plugin.Error("validate", err)
+	}
+
+	stop := make(chan struct{})
+	c.OnStartup(func() error {
+		if len(v.anchors.autoZones()) > 0 {
+			go v.refresh(stop)
+		}
+		return nil
+	})
+	c.OnShutdown(func() error {
+		close(stop)
+		return nil
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		v.Next = next
+		return v
+	})
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (*Validate, error) {
+	config := dnsserver.GetConfig(c)
+	ta := newTrustAnchors()
+	zones := []string{}
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+
+		for c.NextBlock() {
+			switch x := c.Val(); x {
+			case "trust_anchor":
+				args := c.RemainingArgs()
+				if len(args) < 3 {
+					return nil, c.ArgErr()
+				}
+				rr, err := dns.NewRR(strings.Join(args, " "))
+				if err != nil {
+					return nil, err
+				}
+				if rr == nil {
+					return nil, c.ArgErr()
+				}
+				if err := ta.add(rr, stateValid, time.Time{}, false); err != nil {
+					return nil, err
+				}
+			case "trust_anchor_file":
+				args := c.RemainingArgs()
+				if len(args) == 0 || len(args) > 2 {
+					return nil, c.ArgErr()
+				}
+				file := args[0]
+				if !filepath.IsAbs(file) && config.Root != "" {
+					file = filepath.Join(config.Root, file)
+				}
+				auto := false
+				if len(args) == 2 {
+					if args[1] != "auto" {
+						return nil, c.Errf("unknown argument '%s'", args[1])
+					}
+					if ta.file != "" {
+						return nil, c.Err("only one trust anchor file can be automatically updated")
+					}
+					auto = true
+					ta.file = file
+				}
+				if err := ta.load(file, auto); err != nil {
+					return nil, err
+				}
+			case "hold_down":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if d < 0 {
+					return nil, c.Errf("hold_down can not be negative: %s", d)
+				}
+				ta.holdDown = d
+			default:
+				return nil, c.Errf("unknown property '%s'", x)
+			}
+		}
+	}
+
+	if len(ta.zones) == 0 {
+		for _, s := range rootAnchors {
+			rr, _ := dns.NewRR(s)
+			ta.add(rr, stateValid, time.Time{}, false)
+		}
+	}
+	return New(zones, ta), nil
+}
@@ -0,0 +1,99 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "root.keys")
+	if err := os.WriteFile(file, []byte(". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D\n"), 0644); err != nil {
+		t.Fatalf("Failed to write trust anchor file: %s", err)
+	}
+
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedZones      []string
+		expectedAnchors    []string
+		expectedAuto       []string
+		expectedErrContent string
+	}{
+		{`validate`, false, nil, []string{"."}, []string{}, ""},
+		{`validate example.org`, false, []string{"example.org."}, []string{"."}, []string{}, ""},
+		{`validate {
+			trust_anchor example.org. DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D
+		}`, false, nil, []string{"example.org."}, []string{}, ""},
+		{`validate {
+			trust_anchor_file ` + file + `
+		}`, false, nil, []string{"."}, []string{}, ""},
+		{`validate {
+			trust_anchor_file ` + file + ` auto
+			hold_down 1h
+		}`, false, nil, []string{"."}, []string{"."}, ""},
+		// fails
+		{`validate {
+			trust_anchor example.org. A 127.0.0.1
+		}`, true, nil, nil, nil, "must be DS or DNSKEY"},
+		{`validate {
+			trust_anchor example.org.
+		}`, true, nil, nil, nil, "Wrong argument count"},
+		{`validate {
+			trust_anchor_file ` + file + ` manual
+		}`, true, nil, nil, nil, "unknown argument"},
+		{`validate {
+			trust_anchor_file ` + file + ` auto
+			trust_anchor_file ` + file + ` auto
+		}`, true, nil, nil, nil, "only one trust anchor file"},
+		{`validate {
+			trust_anchor_file /does/not/exist
+		}`, true, nil, nil, nil, "no such file"},
+		{`validate {
+			hold_down -1h
+		}`, true, nil, nil, nil, "can not be negative"},
+		{`validate {
+			foo
+		}`, true, nil, nil, nil, "unknown property"},
+		{`validate
+		  validate`, true, nil, nil, nil, ""},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		v, err := parse(c)
+
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			} else if !strings.Contains(err.Error(), tc.expectedErrContent) {
+				t.Errorf("Test %d: expected error to contain %q, got %q", i, tc.expectedErrContent, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+			continue
+		}
+
+		if len(v.zones) != len(tc.expectedZones) {
+			t.Errorf("Test %d: expected zones %v, got %v", i, tc.expectedZones, v.zones)
+		}
+		for j, z := range tc.expectedZones {
+			if j < len(v.zones) && v.zones[j] != z {
+				t.Errorf("Test %d: expected zone %s, got %s", i, z, v.zones[j])
+			}
+		}
+		for _, a := range tc.expectedAnchors {
+			if _, ok := v.anchors.zones[a]; !ok {
+				t.Errorf("Test %d: expected trust anchor for %s", i, a)
+			}
+		}
+		if auto := v.anchors.autoZones(); len(auto) != len(tc.expectedAuto) {
+			t.Errorf("Test %d: expected automatically updated trust anchors %v, got %v", i, tc.expectedAuto, auto)
+		}
+	}
+}
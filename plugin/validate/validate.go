@@ -0,0 +1,169 @@
+// Package validate implements a plugin that performs DNSSEC validation of the responses it sees.
+package validate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/nonwriter"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Validate performs DNSSEC validation of the replies coming from the next plugin.
+type Validate struct {
+	Next plugin.Handler
+
+	zones   []string
+	anchors *trustAnchors
+
+	mu    sync.Mutex
+	steps map[string]step // cached results of walking the chain of trust, keyed by name.
+
+	now func() time.Time
+}
+
+// New returns a new Validate that validates answers for names in zones using the trust anchors ta.
+func New(zones []string, ta *trustAnchors) *Validate {
+	return &Validate{
+		zones:   zones,
+		anchors: ta,
+		steps:   make(map[string]step),
+		now:     time.Now,
+	}
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (v *Validate) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(v.zones).Matches(state.Name())
+	if zone == "" || r.CheckingDisabled {
+		return plugin.NextOrFailure(v.Name(), v.Next, ctx, w, r)
+	}
+
+	do := state.Do()
+	opt := r.IsEdns0()
+
+	// We always need the signatures, so set the DO bit on the query we send onwards.
+	req := r.Copy()
+	if o := req.IsEdns0(); o != nil {
+		o.SetDo()
+	} else {
+		req.SetEdns0(4096, true)
+	}
+
+	nw := nonwriter.New(w)
+	rcode, err := plugin.NextOrFailure(v.Name(), v.Next, ctx, nw, req)
+	if nw.Msg == nil {
+		return rcode, err
+	}
+	res := nw.Msg
+
+	if res.Rcode != dns.RcodeSuccess && res.Rcode != dns.RcodeNameError {
+		w.WriteMsg(restore(res, opt, do))
+		return rcode, err
+	}
+
+	server := metrics.WithServer(ctx)
+	sec, verr := v.validate(ctx, w, res)
+	responseCount.WithLabelValues(server, sec.String()).Inc()
+
+	if sec == bogus {
+		log.Debugf("Bogus answer for %s/%s: %s", state.Name(), state.Type(), verr)
+
+		m := new(dns.Msg).SetRcode(r, dns.RcodeServerFailure)
+		// Extended DNS errors can only be returned to clients that use EDNS0.
+		if opt != nil {
+			m.SetEdns0(4096, do)
+			code := dns.ExtendedErrorCodeDNSBogus
+			var be *bogusError
+			if errors.As(verr, &be) {
+				code = be.code
+			}
+			ede := &dns.EDNS0_EDE{InfoCode: code, ExtraText: verr.Error()}
+			m.IsEdns0().Option = append(m.IsEdns0().Option, ede)
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	}
+
+	// RFC 6840, Section 5.7: only set the AD bit when the client signalled it understands it.
+	res.AuthenticatedData = sec == secure && (do || r.AuthenticatedData)
+	w.WriteMsg(restore(res, opt, do))
+	return rcode, err
+}
+
+// restore undoes the changes we made to the query in the reply: if the client didn't set the DO bit all DNSSEC
+// records are removed and if the client didn't use EDNS0 the OPT record is removed.
+func restore(res *dns.Msg, opt *dns.OPT, do bool) *dns.Msg {
+	if do {
+		return res
+	}
+	qtype := uint16(0)
+	if len(res.Question) > 0 {
+		qtype = res.Question[0].Qtype
+	}
+	res.Answer = stripDNSSEC(res.Answer, qtype)
+	res.Ns = stripDNSSEC(res.Ns, qtype)
+	res.Extra = stripDNSSEC(res.Extra, qtype)
+
+	extra := res.Extra[:0]
+	for _, rr := range res.Extra {
+		o, ok := rr.(*dns.OPT)
+		if !ok {
+			extra = append(extra, rr)
+			continue
+		}
+		if opt == nil {
+			continue
+		}
+		o.SetDo(false)
+		extra = append(extra, o)
+	}
+	res.Extra = extra
+	return res
+}
+
+// stripDNSSEC removes the DNSSEC records from rrs, unless they were explicitly asked for with qtype.
+func stripDNSSEC(rrs []dns.RR, qtype uint16) []dns.RR {
+	j := 0
+	for _, rr := range rrs {
+		switch t := rr.Header().Rrtype; t {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+			if t != qtype {
+				continue
+			}
+		}
+		rrs[j] = rr
+		j++
+	}
+	return rrs[:j]
+}
+
+// lookup sends a query for name and qtype with the DO and CD bits set down the plugin chain.
+func (v *Validate) lookup(ctx context.Context, w dns.ResponseWriter, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.SetEdns0(4096, true)
+	m.CheckingDisabled = true
+
+	nw := nonwriter.New(w)
+	if _, err := plugin.NextOrFailure(v.Name(), v.Next, ctx, nw, m); err != nil {
+		return nil, err
+	}
+	if nw.Msg == nil {
+		return nil, errNoReply
+	}
+	return nw.Msg, nil
+}
+
+// Name implements the Handler interface.
+func (v *Validate) Name() string { return "validate" }
+
+var errNoReply = errors.New("no reply received")
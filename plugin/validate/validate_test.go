@@ -0,0 +1,273 @@
+package validate
+
+import (
+	"context"
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+type testZone struct {
+	name string
+	key  *dns.DNSKEY
+	priv crypto.Signer
+}
+
+func newTestZone(t *testing.T, name string) *testZone {
+	t.Helper()
+	k := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: name, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := k.Generate(256)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	return &testZone{name: name, key: k, priv: priv.(crypto.Signer)}
+}
+
+func (z *testZone) signAt(t *testing.T, incep, expir time.Time, rrs ...dns.RR) []dns.RR {
+	t.Helper()
+	sig := &dns.RRSIG{
+		Inception:  uint32(incep.Unix()),
+		Expiration: uint32(expir.Unix()),
+		KeyTag:     z.key.KeyTag(),
+		SignerName: z.name,
+		Algorithm:  z.key.Algorithm,
+	}
+	if err := sig.Sign(z.priv, rrs); err != nil {
+		t.Fatalf("Failed to sign: %s", err)
+	}
+	return append(rrs, sig)
+}
+
+func (z *testZone) sign(t *testing.T, rrs ...dns.RR) []dns.RR {
+	return z.signAt(t, time.Now().Add(-1*time.Hour), time.Now().Add(24*time.Hour), rrs...)
+}
+
+func (z *testZone) soa(t *testing.T) []dns.RR {
+	return z.sign(t, test.SOA(z.name+" 3600 IN SOA ns."+z.name+" hostmaster."+z.name+" 1 7200 3600 1209600 3600"))
+}
+
+type reply struct {
+	rcode  int
+	answer []dns.RR
+	ns     []dns.RR
+}
+
+// upstream returns a handler that serves the canned replies, keyed on "qname/qtype".
+func upstream(replies map[string]reply) test.HandlerFunc {
+	return func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		rep, ok := replies[r.Question[0].Name+"/"+dns.TypeToString[r.Question[0].Qtype]]
+		if !ok {
+			m.Rcode = dns.RcodeServerFailure
+			w.WriteMsg(m)
+			return dns.RcodeSuccess, nil
+		}
+		m.Rcode = rep.rcode
+		m.Answer = rep.answer
+		m.Ns = rep.ns
+		if o := r.IsEdns0(); o != nil {
+			m.SetEdns0(4096, o.Do())
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	}
+}
+
+func testValidate(t *testing.T) *Validate {
+	t.Helper()
+	org := newTestZone(t, "org.")
+	ex := newTestZone(t, "example.org.")
+
+	tampered := ex.sign(t, test.A("bad.example.org. 3600 IN A 127.0.0.1"))
+	tampered[0].(*dns.A).A = []byte{127, 0, 0, 2}
+
+	replies := map[string]reply{
+		"org./DNSKEY":         {answer: org.sign(t, org.key)},
+		"example.org./DS":     {answer: org.sign(t, ex.key.ToDS(dns.SHA256))},
+		"example.org./DNSKEY": {answer: ex.sign(t, ex.key)},
+		"www.example.org./A":  {answer: ex.sign(t, test.A("www.example.org. 3600 IN A 127.0.0.1"))},
+		"bad.example.org./A":  {answer: tampered},
+		"expired.example.org./A": {answer: ex.signAt(t, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour),
+			test.A("expired.example.org. 3600 IN A 127.0.0.1"))},
+		"nosig.example.org./A": {answer: []dns.RR{test.A("nosig.example.org. 3600 IN A 127.0.0.1")}},
+		"nosig.example.org./DS": {ns: append(ex.soa(t),
+			ex.sign(t, test.NSEC("nosig.example.org. 3600 IN NSEC www.example.org. A RRSIG NSEC"))...)},
+		"nx.example.org./A": {rcode: dns.RcodeNameError, ns: append(ex.soa(t),
+			append(ex.sign(t, test.NSEC("example.org. 3600 IN NSEC nosig.example.org. NS SOA RRSIG NSEC DNSKEY")),
+				ex.sign(t, test.NSEC("nosig.example.org. 3600 IN NSEC www.example.org. A RRSIG NSEC"))...)...)},
+		"nodata.example.org./A": {ns: ex.soa(t)},
+		"insecure.org./DS": {ns: append(org.soa(t),
+			org.sign(t, test.NSEC("insecure.org. 3600 IN NSEC org. NS RRSIG NSEC"))...)},
+		"www.insecure.org./A": {answer: []dns.RR{test.A("www.insecure.org. 3600 IN A 127.0.0.1")}},
+		"www.example.com./A":  {answer: []dns.RR{test.A("www.example.com. 3600 IN A 127.0.0.1")}},
+	}
+
+	ta := newTrustAnchors()
+	ta.add(org.key.ToDS(dns.SHA256), stateValid, time.Time{}, false)
+
+	v := New([]string{"org."}, ta)
+	v.Next = upstream(replies)
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	v := testValidate(t)
+
+	tests := []struct {
+		qname   string
+		do      bool
+		cd      bool
+		rcode   int
+		ad      bool
+		answers int
+		ede     uint16
+	}{
+		{qname: "www.example.org.", do: true, rcode: dns.RcodeSuccess, ad: true, answers: 2},
+		{qname: "www.example.org.", rcode: dns.RcodeSuccess, answers: 1},
+		{qname: "nx.example.org.", do: true, rcode: dns.RcodeNameError, ad: true},
+		{qname: "bad.example.org.", do: true, rcode: dns.RcodeServerFailure, ede: dns.ExtendedErrorCodeDNSBogus},
+		{qname: "bad.example.org.", do: true, cd: true, rcode: dns.RcodeSuccess, answers: 2},
+		{qname: "expired.example.org.", do: true, rcode: dns.RcodeServerFailure, ede: dns.ExtendedErrorCodeSignatureExpired},
+		{qname: "nosig.example.org.", do: true, rcode: dns.RcodeServerFailure, ede: dns.ExtendedErrorCodeRRSIGsMissing},
+		{qname: "nodata.example.org.", do: true, rcode: dns.RcodeServerFailure, ede: dns.ExtendedErrorCodeNSECMissing},
+		{qname: "www.insecure.org.", do: true, rcode: dns.RcodeSuccess, answers: 1},
+		{qname: "www.example.com.", do: true, rcode: dns.RcodeSuccess, answers: 1},
+	}
+
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, dns.TypeA)
+		if tc.do {
+			m.SetEdns0(4096, true)
+		}
+		m.CheckingDisabled = tc.cd
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := v.ServeDNS(context.TODO(), rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		if rec.Msg.Rcode != tc.rcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.rcode], dns.RcodeToString[rec.Msg.Rcode])
+		}
+		if rec.Msg.AuthenticatedData != tc.ad {
+			t.Errorf("Test %d: expected AD bit to be %t", i, tc.ad)
+		}
+		if len(rec.Msg.Answer) != tc.answers {
+			t.Errorf("Test %d: expected %d answers, got %d", i, tc.answers, len(rec.Msg.Answer))
+		}
+		if tc.ede == 0 {
+			continue
+		}
+		opt := rec.Msg.IsEdns0()
+		if opt == nil || len(opt.Option) != 1 {
+			t.Errorf("Test %d: expected an EDE option", i)
+			continue
+		}
+		if ede, ok := opt.Option[0].(*dns.EDNS0_EDE); !ok || ede.InfoCode != tc.ede {
+			t.Errorf("Test %d: expected EDE %s, got %v", i, dns.ExtendedErrorCodeToString[tc.ede], opt.Option[0])
+		}
+	}
+}
+
+func TestValidateADWithoutDO(t *testing.T) {
+	v := testValidate(t)
+
+	m := new(dns.Msg)
+	m.SetQuestion("www.example.org.", dns.TypeA)
+	m.AuthenticatedData = true
+
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	v.ServeDNS(context.TODO(), rec, m)
+	if !rec.Msg.AuthenticatedData {
+		t.Error("Expected AD bit to be set")
+	}
+	if rec.Msg.IsEdns0() != nil {
+		t.Error("Expected no OPT record in the reply")
+	}
+	for _, rr := range rec.Msg.Answer {
+		if rr.Header().Rrtype == dns.TypeRRSIG {
+			t.Errorf("Expected no signatures in the reply, got %s", rr)
+		}
+	}
+}
+
+func TestValidateBogusWithoutEDNS(t *testing.T) {
+	v := testValidate(t)
+
+	m := new(dns.Msg)
+	m.SetQuestion("bad.example.org.", dns.TypeA)
+
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	v.ServeDNS(context.TODO(), rec, m)
+	if rec.Msg.Rcode != dns.RcodeServerFailure {
+		t.Errorf("Expected SERVFAIL, got %s", dns.RcodeToString[rec.Msg.Rcode])
+	}
+	if rec.Msg.IsEdns0() != nil {
+		t.Error("Expected no OPT record in the reply")
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	// Names in canonical order, RFC 4034, Section 6.1.
+	names := []string{"example.", "a.example.", "yljkjljk.a.example.", "Z.a.example.", "zABC.a.EXAMPLE.", "z.example.", "*.z.example."}
+	for i := 0; i < len(names)-1; i++ {
+		if compareNames(names[i], names[i+1]) >= 0 {
+			t.Errorf("Expected %s to sort before %s", names[i], names[i+1])
+		}
+	}
+	if compareNames("a.example.", "A.EXAMPLE.") != 0 {
+		t.Error("Expected names to be equal")
+	}
+}
+
+func TestNSEC3Denial(t *testing.T) {
+	apex := dns.HashName("example.org.", dns.SHA1, 0, "")
+	www := dns.HashName("www.example.org.", dns.SHA1, 0, "")
+	first, second := apex, www
+	if first > second {
+		first, second = second, first
+	}
+	nsec3 := func(owner, next string, flags uint8, types ...uint16) *dns.NSEC3 {
+		return &dns.NSEC3{
+			Hdr:        dns.RR_Header{Name: owner + ".example.org.", Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: 3600},
+			Hash:       dns.SHA1,
+			Flags:      flags,
+			Salt:       "",
+			NextDomain: next,
+			TypeBitMap: types,
+		}
+	}
+
+	denial := []*dns.NSEC3{nsec3(first, second, 0, dns.TypeA), nsec3(second, first, 0, dns.TypeA)}
+	if !provesNameError("nx.example.org.", nil, denial) {
+		t.Error("Expected NSEC3 records to prove nx.example.org does not exist")
+	}
+	if provesNameError("www.example.org.", nil, denial) {
+		t.Error("Expected NSEC3 records to not prove www.example.org does not exist")
+	}
+	if !provesNoData("www.example.org.", dns.TypeTXT, nil, denial) {
+		t.Error("Expected NSEC3 records to prove www.example.org has no TXT records")
+	}
+	if provesNoData("www.example.org.", dns.TypeA, nil, denial) {
+		t.Error("Expected NSEC3 records to not prove www.example.org has no A records")
+	}
+	if kind, ok := dsAbsence("deleg.example.org.", nil, denial); ok {
+		t.Errorf("Expected no proof for the absence of DS records, got %d", kind)
+	}
+
+	optout := []*dns.NSEC3{nsec3(first, second, optOut, dns.TypeA), nsec3(second, first, optOut, dns.TypeA)}
+	if kind, ok := dsAbsence("deleg.example.org.", nil, optout); !ok || kind != stepInsecure {
+		t.Errorf("Expected opt-out NSEC3 records to make deleg.example.org insecure, got %d", kind)
+	}
+}
@@ -0,0 +1,280 @@
+package validate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// validate validates the response res and returns its security status. For bogus responses the returned error
+// tells what went wrong.
+func (v *Validate) validate(ctx context.Context, w dns.ResponseWriter, res *dns.Msg) (security, error) {
+	if len(res.Question) == 0 {
+		return indeterminate, nil
+	}
+	now := v.now()
+
+	sets := rrsets(res.Answer)
+	if len(sets) == 0 {
+		return v.validateDenial(ctx, w, res)
+	}
+
+	result := secure
+	for _, set := range sets {
+		owner := set[0].Header().Name
+		typ := set[0].Header().Rrtype
+		_, sigs := rrset(res.Answer, owner, typ)
+
+		if len(sigs) == 0 {
+			// A CNAME synthesized from a signed DNAME is never signed itself.
+			if typ == dns.TypeCNAME && synthesized(res.Answer, owner) {
+				continue
+			}
+			sec, err := v.unsigned(ctx, w, owner)
+			if sec == bogus {
+				return bogus, err
+			}
+			result = worst(result, sec)
+			continue
+		}
+
+		signer := sigs[0].SignerName
+		if !dns.IsSubDomain(signer, owner) {
+			return bogus, bogusf(dns.ExtendedErrorCodeDNSBogus, "signer %s is not an ancestor of %s", signer, owner)
+		}
+		zone, keys, sec, err := v.chase(ctx, w, signer)
+		if sec != secure {
+			if sec == bogus {
+				return bogus, err
+			}
+			result = worst(result, sec)
+			continue
+		}
+		if !strings.EqualFold(zone, signer) {
+			return bogus, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "%s is signed by %s, but that is not a zone", owner, signer)
+		}
+		if err := verifyRRset(set, sigs, keys, now); err != nil {
+			return bogus, err
+		}
+
+		// If the answer was synthesized from a wildcard, we need proof the qname itself does not exist.
+		if wildcard, next := expanded(sigs, owner); wildcard {
+			if err := verifySection(res.Ns, keys, now); err != nil {
+				return bogus, err
+			}
+			nsec, nsec3 := denials(res.Ns)
+			if !coversName(owner, next, nsec, nsec3) {
+				return bogus, bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof that %s does not exist for wildcard answer", owner)
+			}
+		}
+	}
+	return result, nil
+}
+
+// validateDenial validates a negative response.
+func (v *Validate) validateDenial(ctx context.Context, w dns.ResponseWriter, res *dns.Msg) (security, error) {
+	q := res.Question[0]
+	now := v.now()
+
+	var signer string
+	for _, rr := range res.Ns {
+		if s, ok := rr.(*dns.RRSIG); ok && s.TypeCovered == dns.TypeSOA {
+			signer = s.SignerName
+			break
+		}
+	}
+	if signer == "" {
+		return v.unsigned(ctx, w, q.Name)
+	}
+	if !dns.IsSubDomain(signer, q.Name) {
+		return bogus, bogusf(dns.ExtendedErrorCodeDNSBogus, "signer %s is not an ancestor of %s", signer, q.Name)
+	}
+
+	zone, keys, sec, err := v.chase(ctx, w, signer)
+	if sec != secure {
+		return sec, err
+	}
+	if !strings.EqualFold(zone, signer) {
+		return bogus, bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "%s is signed by %s, but that is not a zone", q.Name, signer)
+	}
+	if err := verifySection(res.Ns, keys, now); err != nil {
+		return bogus, err
+	}
+
+	nsec, nsec3 := denials(res.Ns)
+	if res.Rcode == dns.RcodeNameError {
+		if !provesNameError(q.Name, nsec, nsec3) {
+			return bogus, bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof that %s does not exist", q.Name)
+		}
+		return secure, nil
+	}
+	if !provesNoData(q.Name, q.Qtype, nsec, nsec3) {
+		return bogus, bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof that %s has no %s records", q.Name, dns.TypeToString[q.Qtype])
+	}
+	return secure, nil
+}
+
+// unsigned checks if unsigned data for name is allowed, i.e. if name lives in an insecure zone.
+func (v *Validate) unsigned(ctx context.Context, w dns.ResponseWriter, name string) (security, error) {
+	zone, _, sec, err := v.chase(ctx, w, name)
+	if sec == secure {
+		return bogus, bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for %s in secure zone %s", name, zone)
+	}
+	return sec, err
+}
+
+// worst returns the least secure of a and b.
+func worst(a, b security) security {
+	if a == bogus || b == bogus {
+		return bogus
+	}
+	if a == indeterminate || b == indeterminate {
+		return indeterminate
+	}
+	if a == insecure || b == insecure {
+		return insecure
+	}
+	return secure
+}
+
+// rrsets groups rrs (excluding signatures) into RRsets.
+func rrsets(rrs []dns.RR) [][]dns.RR {
+	sets := [][]dns.RR{}
+	index := map[string]int{}
+	for _, rr := range rrs {
+		h := rr.Header()
+		if h.Rrtype == dns.TypeRRSIG || h.Rrtype == dns.TypeOPT {
+			continue
+		}
+		key := strings.ToLower(h.Name) + "/" + dns.TypeToString[h.Rrtype]
+		if i, ok := index[key]; ok {
+			sets[i] = append(sets[i], rr)
+			continue
+		}
+		index[key] = len(sets)
+		sets = append(sets, []dns.RR{rr})
+	}
+	return sets
+}
+
+// synthesized returns true when there is a DNAME in rrs that could have been used to synthesize a CNAME for name.
+func synthesized(rrs []dns.RR, name string) bool {
+	for _, rr := range rrs {
+		if d, ok := rr.(*dns.DNAME); ok && dns.IsSubDomain(d.Hdr.Name, name) && !strings.EqualFold(d.Hdr.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// expanded returns true when the signatures show owner was synthesized from a wildcard. It also returns the
+// "next closer" name, the name one label longer than the wildcard's parent.
+func expanded(sigs []*dns.RRSIG, owner string) (bool, string) {
+	labels := dns.CountLabel(owner)
+	if strings.HasPrefix(owner, "*.") {
+		labels--
+	}
+	l := int(sigs[0].Labels)
+	if l >= labels {
+		return false, ""
+	}
+	idx := dns.Split(owner)
+	return true, owner[idx[len(idx)-l-1]:]
+}
+
+// verifySection verifies the signatures of all signed RRsets in rrs with keys. The SOA, NSEC and NSEC3 RRsets
+// must be signed.
+func verifySection(rrs []dns.RR, keys []*dns.DNSKEY, now time.Time) error {
+	for _, set := range rrsets(rrs) {
+		h := set[0].Header()
+		_, sigs := rrset(rrs, h.Name, h.Rrtype)
+		if len(sigs) == 0 {
+			switch h.Rrtype {
+			case dns.TypeSOA, dns.TypeNSEC, dns.TypeNSEC3:
+				return bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for %s/%s", h.Name, dns.TypeToString[h.Rrtype])
+			}
+			continue
+		}
+		if err := verifyRRset(set, sigs, keys, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyRRset checks that one of sigs is a valid signature over set made with one of keys.
+func verifyRRset(set []dns.RR, sigs []*dns.RRSIG, keys []*dns.DNSKEY, now time.Time) error {
+	h := set[0].Header()
+	if len(sigs) == 0 {
+		return bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for %s/%s", h.Name, dns.TypeToString[h.Rrtype])
+	}
+
+	err := bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no key to verify %s/%s", h.Name, dns.TypeToString[h.Rrtype])
+	for _, s := range sigs {
+		if !algorithmSupported(s.Algorithm) {
+			err = bogusf(dns.ExtendedErrorCodeUnsupportedDNSKEYAlgorithm, "unsupported algorithm %d for %s/%s", s.Algorithm, h.Name, dns.TypeToString[h.Rrtype])
+			continue
+		}
+		for _, k := range keys {
+			if k.KeyTag() != s.KeyTag || k.Algorithm != s.Algorithm || !strings.EqualFold(k.Hdr.Name, s.SignerName) {
+				continue
+			}
+			if k.Flags&dns.ZONE == 0 {
+				err = bogusf(dns.ExtendedErrorCodeNoZoneKeyBitSet, "key %d for %s has no zone key bit set", k.KeyTag(), k.Hdr.Name)
+				continue
+			}
+			if k.Flags&dns.REVOKE != 0 && h.Rrtype != dns.TypeDNSKEY {
+				continue
+			}
+			if e := s.Verify(k, set); e != nil {
+				err = bogusf(dns.ExtendedErrorCodeDNSBogus, "signature for %s/%s does not verify: %s", h.Name, dns.TypeToString[h.Rrtype], e)
+				continue
+			}
+			if !s.ValidityPeriod(now) {
+				err = validityError(s, h, now)
+				continue
+			}
+			return nil
+		}
+	}
+	return err
+}
+
+const year68 = 1 << 31
+
+func validityError(s *dns.RRSIG, h *dns.RR_Header, now time.Time) error {
+	utc := now.UTC().Unix()
+	modi := (int64(s.Inception) - utc) / year68
+	ti := int64(s.Inception) + modi*year68
+	if ti > utc {
+		return bogusf(dns.ExtendedErrorCodeSignatureNotYetValid, "signature for %s/%s is not yet valid", h.Name, dns.TypeToString[h.Rrtype])
+	}
+	return bogusf(dns.ExtendedErrorCodeSignatureExpired, "signature for %s/%s has expired", h.Name, dns.TypeToString[h.Rrtype])
+}
+
+// matchDS returns true if k is the key referenced by d.
+func matchDS(k *dns.DNSKEY, d *dns.DS) bool {
+	if k.KeyTag() != d.KeyTag || k.Algorithm != d.Algorithm || !digestSupported(d.DigestType) {
+		return false
+	}
+	kd := k.ToDS(d.DigestType)
+	return kd != nil && strings.EqualFold(kd.Digest, d.Digest)
+}
+
+func algorithmSupported(alg uint8) bool {
+	switch alg {
+	case dns.RSASHA1, dns.RSASHA1NSEC3SHA1, dns.RSASHA256, dns.RSASHA512, dns.ECDSAP256SHA256, dns.ECDSAP384SHA384, dns.ED25519:
+		return true
+	}
+	return false
+}
+
+func digestSupported(digest uint8) bool {
+	switch digest {
+	case dns.SHA1, dns.SHA256, dns.SHA384:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// ecsUpstream starts an upstream that answers with the client subnet option it received, or a fixed one if there
+// was none. The received options are sent on the returned channel.
+func ecsUpstream(t *testing.T) (string, <-chan *dns.EDNS0_SUBNET) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	received := make(chan *dns.EDNS0_SUBNET, 10)
+	s := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		var got *dns.EDNS0_SUBNET
+		if o := r.IsEdns0(); o != nil {
+			for _, opt := range o.Option {
+				if e, ok := opt.(*dns.EDNS0_SUBNET); ok {
+					got = e
+				}
+			}
+		}
+		received <- got
+
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Answer = []dns.RR{&dns.A{Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("192.0.2.1")}}
+		e := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, Address: net.ParseIP("0.0.0.0").To4()}
+		if got != nil {
+			e = got
+			e.SourceScope = e.SourceNetmask
+		}
+		m.SetEdns0(4096, false)
+		o := m.IsEdns0()
+		o.Option = append(o.Option, e)
+		w.WriteMsg(m)
+	})}
+	go s.ActivateAndServe()
+	t.Cleanup(func() { s.Shutdown() })
+	return pc.LocalAddr().String(), received
+}
+
+func ecsQuery(t *testing.T, addr string, subnet *dns.EDNS0_SUBNET) *dns.EDNS0_SUBNET {
+	t.Helper()
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	m.SetEdns0(4096, false)
+	if subnet != nil {
+		o := m.IsEdns0()
+		o.Option = append(o.Option, subnet)
+	}
+	resp, err := dns.Exchange(m, addr)
+	if err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %v", err)
+	}
+	if o := resp.IsEdns0(); o != nil {
+		for _, opt := range o.Option {
+			if e, ok := opt.(*dns.EDNS0_SUBNET); ok {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func TestECS(t *testing.T) {
+	upstream, received := ecsUpstream(t)
+
+	corefile := `.:0 {
+		ecs {
+			trust 127.0.0.0/8 ::1/128
+			upstreams ` + upstream + `
+		}
+		forward . ` + upstream + `
+	}`
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	// The source prefix is limited to /24, the client gets its own subnet back.
+	resp := ecsQuery(t, udp, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 32, Address: net.ParseIP("192.0.2.55").To4()})
+	if got := <-received; got == nil || got.SourceNetmask != 24 || got.Address.String() != "192.0.2.0" {
+		t.Errorf("Expected upstream to receive 192.0.2.0/24, got %v", got)
+	}
+	if resp == nil || resp.SourceNetmask != 32 || resp.SourceScope != 24 || resp.Address.String() != "192.0.2.55" {
+		t.Errorf("Expected 192.0.2.55/32/24 in the response, got %v", resp)
+	}
+
+	// A client that didn't send the option doesn't get one.
+	if resp := ecsQuery(t, udp, nil); resp != nil {
+		t.Errorf("Expected no client subnet in the response, got %v", resp)
+	}
+	<-received
+}
+
+func TestECSUpstreamNotAllowed(t *testing.T) {
+	upstream, received := ecsUpstream(t)
+
+	corefile := `.:0 {
+		ecs {
+			trust 127.0.0.0/8 ::1/128
+		}
+		forward . ` + upstream + `
+	}`
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	ecsQuery(t, udp, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: net.ParseIP("192.0.2.0").To4()})
+	if got := <-received; got != nil {
+		t.Errorf("Expected upstream to receive no client subnet, got %v", got)
+	}
+}
+
+func TestECSUpstreamsWithCache(t *testing.T) {
+	corefile := `example.org:0 {
+		ecs {
+			add
+			upstreams 127.0.0.1:53
+		}
+		cache
+		forward . 127.0.0.1:53
+	}`
+	i, _, _, err := CoreDNSServerAndPorts(corefile)
+	if err == nil {
+		defer i.Stop()
+		t.Fatalf("Expected an error for ecs upstreams together with cache, got none")
+	}
+	if !strings.Contains(err.Error(), "can not be used together with the cache plugin") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}